@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Store keeps archive objects under slash-separated keys such as
+// "audit_logs/2026/01/31/<id>.ndjson.gz". Objects are written once and never
+// changed.
+type Store interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+var validKey = regexp.MustCompile(`^[a-z0-9_]+(/[a-z0-9_][a-z0-9_.-]*)*$`)
+
+func checkKey(key string) error {
+	if !validKey.MatchString(key) {
+		return fmt.Errorf("invalid archive key %q", key)
+	}
+	return nil
+}
+
+// DirStore keeps objects as files under a local directory, e.g. a mounted volume
+type DirStore struct {
+	root string
+}
+
+func NewDirStore(root string) *DirStore {
+	return &DirStore{root: root}
+}
+
+func (s *DirStore) Put(ctx context.Context, key string, body []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves half an archive
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DirStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("archive object not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive file: %w", err)
+	}
+
+	return body, nil
+}
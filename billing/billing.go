@@ -0,0 +1,136 @@
+// Package billing maps paid plans to feature limits and reads subscription
+// changes from payment providers. Enforcing the limits is left to the
+// services that own the limited records.
+package billing
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider reads the webhook events a payment provider sends when a
+// subscription is created, changed or cancelled.
+type Provider interface {
+	// Name is the provider's path segment, e.g. "stripe"
+	Name() string
+	// ParseEvent verifies the request's signature and returns the
+	// subscription change it reports, or nil for events about anything else
+	ParseEvent(header http.Header, body []byte) (*Event, error)
+}
+
+// Subscription statuses that grant the subscribed plan; any other status,
+// such as canceled or unpaid, falls back to the default plan
+const (
+	StatusActive   = "active"
+	StatusTrialing = "trialing"
+	StatusPastDue  = "past_due"
+	StatusCanceled = "canceled"
+)
+
+// Event is a subscription as a provider reported it at one moment
+type Event struct {
+	ID             string
+	CreatedAt      time.Time
+	SubscriptionID string
+	CustomerID     string
+	// UserID is the account ID the checkout put in the subscription's
+	// metadata; it is empty for subscriptions created outside the API
+	UserID string
+	// Price is the provider's price ID, and LookupKey its lookup key if set
+	Price            string
+	LookupKey        string
+	Status           string
+	CurrentPeriodEnd *time.Time
+}
+
+// GrantsPlan reports whether a subscription with the status is in effect.
+// Past-due subscriptions keep their plan while the provider retries the payment.
+func GrantsPlan(status string) bool {
+	return status == StatusActive || status == StatusTrialing || status == StatusPastDue
+}
+
+// Premium features a plan can include; routes using one answer 402 for
+// accounts whose plan lacks it
+const (
+	FeatureWebhooks     = "webhooks"
+	FeatureIntegrations = "integrations"
+)
+
+// Features lists every feature a plan can include
+var Features = []string{FeatureWebhooks, FeatureIntegrations}
+
+// Limits caps what an account on a plan may keep, 0 being unlimited, and
+// names the premium features it may use
+type Limits struct {
+	Tasks    int
+	Webhooks int
+	Features []string
+}
+
+// Includes reports whether the plan includes the feature
+func (l Limits) Includes(feature string) bool {
+	return slices.Contains(l.Features, feature)
+}
+
+// DefaultPlans apply when BILLING_PLANS is not set
+var DefaultPlans = map[string]Limits{
+	"free": {Tasks: 500},
+	"pro":  {Tasks: 0, Webhooks: 10, Features: Features},
+}
+
+// ParsePlans reads plans written as name:tasks:webhooks[:features], e.g.
+// "free:500:1:webhooks", with 0 for no limit and features joined by +. A plan
+// without the features part includes all of them; an empty one includes none.
+func ParsePlans(entries []string) (map[string]Limits, error) {
+	plans := make(map[string]Limits, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid plan %q, use name:tasks:webhooks[:features]", entry)
+		}
+		features := Features
+		if len(parts) == 4 {
+			features = []string{}
+			for _, feature := range strings.Split(parts[3], "+") {
+				if feature == "" {
+					continue
+				}
+				if !slices.Contains(Features, feature) {
+					return nil, fmt.Errorf("invalid plan %q, features must be among: %s", entry, strings.Join(Features, ", "))
+				}
+				features = append(features, feature)
+			}
+			parts = parts[:3]
+		}
+		var counts [2]int
+		for i, part := range parts[1:] {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid plan %q, limits must be whole numbers, 0 for no limit", entry)
+			}
+			counts[i] = n
+		}
+		plans[parts[0]] = Limits{Tasks: counts[0], Webhooks: counts[1], Features: features}
+	}
+	return plans, nil
+}
+
+// ParsePrices reads price to plan mappings written as price=plan
+func ParsePrices(entries []string, plans map[string]Limits) (map[string]string, error) {
+	prices := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		price, plan, ok := strings.Cut(entry, "=")
+		if !ok || price == "" {
+			return nil, fmt.Errorf("invalid price mapping %q, use price=plan", entry)
+		}
+		if _, ok := plans[plan]; !ok {
+			return nil, fmt.Errorf("price %s maps to unknown plan %q", price, plan)
+		}
+		prices[price] = plan
+	}
+	return prices, nil
+}
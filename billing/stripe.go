@@ -0,0 +1,138 @@
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stripeTolerance is how old a signed event may be, against replays
+const stripeTolerance = 5 * time.Minute
+
+// Stripe reads customer.subscription.* webhook events. Checkouts should put
+// the account's ID in the subscription metadata as user_id.
+type Stripe struct {
+	webhookSecret string
+	now           func() time.Time
+}
+
+func NewStripe(webhookSecret string) (*Stripe, error) {
+	if webhookSecret == "" {
+		return nil, fmt.Errorf("Stripe webhook secret is required")
+	}
+	return &Stripe{webhookSecret: webhookSecret, now: time.Now}, nil
+}
+
+func (s *Stripe) Name() string {
+	return "stripe"
+}
+
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object stripeSubscription `json:"object"`
+	} `json:"data"`
+}
+
+type stripeSubscription struct {
+	ID               string            `json:"id"`
+	Customer         string            `json:"customer"`
+	Status           string            `json:"status"`
+	Metadata         map[string]string `json:"metadata"`
+	CurrentPeriodEnd int64             `json:"current_period_end"`
+	Items            struct {
+		Data []struct {
+			CurrentPeriodEnd int64 `json:"current_period_end"`
+			Price            struct {
+				ID        string `json:"id"`
+				LookupKey string `json:"lookup_key"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+func (s *Stripe) ParseEvent(header http.Header, body []byte) (*Event, error) {
+	if err := s.verify(header.Get("Stripe-Signature"), body); err != nil {
+		return nil, err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid event body")
+	}
+	switch event.Type {
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+	default:
+		return nil, nil
+	}
+
+	sub := event.Data.Object
+	parsed := &Event{
+		ID:             event.ID,
+		CreatedAt:      time.Unix(event.Created, 0).UTC(),
+		SubscriptionID: sub.ID,
+		CustomerID:     sub.Customer,
+		UserID:         sub.Metadata["user_id"],
+		Status:         sub.Status,
+	}
+	// Newer API versions moved the billing period onto the items
+	periodEnd := sub.CurrentPeriodEnd
+	if len(sub.Items.Data) > 0 {
+		item := sub.Items.Data[0]
+		parsed.Price, parsed.LookupKey = item.Price.ID, item.Price.LookupKey
+		if periodEnd == 0 {
+			periodEnd = item.CurrentPeriodEnd
+		}
+	}
+	if periodEnd > 0 {
+		end := time.Unix(periodEnd, 0).UTC()
+		parsed.CurrentPeriodEnd = &end
+	}
+	if event.Type == "customer.subscription.deleted" {
+		parsed.Status = StatusCanceled
+	}
+
+	return parsed, nil
+}
+
+// verify checks a Stripe-Signature header of the form t=<unix>,v1=<hex>,...
+// against an HMAC-SHA256 of "<t>.<body>"
+func (s *Stripe) verify(signature string, body []byte) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("invalid signature")
+	}
+	if age := s.now().Sub(time.Unix(signedAt, 0)); age > stripeTolerance || age < -stripeTolerance {
+		return fmt.Errorf("invalid signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, candidate := range signatures {
+		if decoded, err := hex.DecodeString(candidate); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid signature")
+}
@@ -0,0 +1,86 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the single source of "now" for services, repositories and the worker.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func New() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Controllable is a clock that can be frozen at an instant or shifted by an
+// offset from real time. It backs tests and the sandbox mode.
+type Controllable struct {
+	mu       sync.RWMutex
+	frozenAt *time.Time
+	offset   time.Duration
+}
+
+func NewControllable() *Controllable {
+	return &Controllable{}
+}
+
+func (c *Controllable) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.frozenAt != nil {
+		return *c.frozenAt
+	}
+	return time.Now().Add(c.offset)
+}
+
+// Freeze stops the clock at t until Unfreeze is called.
+func (c *Controllable) Freeze(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.frozenAt = &t
+}
+
+func (c *Controllable) Unfreeze() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.frozenAt = nil
+}
+
+// SetOffset shifts a running clock relative to real time.
+func (c *Controllable) SetOffset(offset time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.offset = offset
+}
+
+// Advance moves the clock forward, whether frozen or running.
+func (c *Controllable) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.frozenAt != nil {
+		advanced := c.frozenAt.Add(d)
+		c.frozenAt = &advanced
+		return
+	}
+	c.offset += d
+}
+
+func (c *Controllable) State() (frozenAt *time.Time, offset time.Duration) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.frozenAt, c.offset
+}
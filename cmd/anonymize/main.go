@@ -0,0 +1,68 @@
+// Command anonymize refreshes a staging database with production data in which
+// personal data is replaced by fakes. It reads the same configuration as the
+// server: MONGODB_URI and MONGODB_DATABASE name the source, and
+// STAGING_MONGODB_URI and STAGING_MONGODB_DATABASE the staging copy.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"task-management-api/config"
+	"task-management-api/database"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	sourceConfig := config.LoadConfig()
+	if sourceConfig.StagingMongoDBDatabase == "" {
+		log.Fatal("STAGING_MONGODB_DATABASE is required")
+	}
+	targetConfig := *sourceConfig
+	if sourceConfig.StagingMongoDBURI != "" {
+		targetConfig.MongoDBURI = sourceConfig.StagingMongoDBURI
+	}
+	targetConfig.MongoDBDatabase = sourceConfig.StagingMongoDBDatabase
+	if targetConfig.MongoDBURI == sourceConfig.MongoDBURI && targetConfig.MongoDBDatabase == sourceConfig.MongoDBDatabase {
+		log.Fatal("Refusing to refresh: the staging database is the source database")
+	}
+
+	source, err := database.InitDB(sourceConfig)
+	if err != nil {
+		log.Fatal("Failed to connect to the source database:", err)
+	}
+	target, err := database.InitDB(&targetConfig)
+	if err != nil {
+		log.Fatal("Failed to connect to the staging database:", err)
+	}
+
+	// Stop between batches on Ctrl+C; staging is left partly refreshed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	stagingService := service.NewStagingService(repository.NewStagingRepository(source, target), sourceConfig.StagingUserPassword)
+	log.Printf("Refreshing %s from %s", targetConfig.MongoDBDatabase, sourceConfig.MongoDBDatabase)
+	refreshErr := stagingService.Refresh(ctx)
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, db := range []*database.MongoDB{source, target} {
+		if err := db.Close(shutdownCtx); err != nil {
+			log.Printf("Error closing database connection: %v", err)
+		}
+	}
+
+	if refreshErr != nil {
+		log.Fatal("Staging refresh failed: ", refreshErr)
+	}
+	log.Println("Staging refresh complete")
+}
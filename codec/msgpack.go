@@ -0,0 +1,124 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// MessagePack encodes responses as MessagePack. JSON integers become
+// MessagePack integers and other numbers 64-bit floats.
+type MessagePack struct{}
+
+func (MessagePack) ContentType() string {
+	return "application/msgpack"
+}
+
+func (MessagePack) Encode(w io.Writer, data interface{}) error {
+	tree, err := jsonTree(data)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if body, err = appendMsgpack(body, tree); err != nil {
+		return err
+	}
+	return writeAll(w, body)
+}
+
+func appendMsgpack(b []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		return appendMsgpackNumber(b, v)
+	case string:
+		return appendMsgpackString(b, v), nil
+	case []interface{}:
+		b = appendMsgpackHeader(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, item := range v {
+			var err error
+			if b, err = appendMsgpack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case object:
+		b = appendMsgpackHeader(b, len(v), 0x80, 0xde, 0xdf)
+		for _, m := range v {
+			b = appendMsgpackString(b, m.key)
+			var err error
+			if b, err = appendMsgpack(b, m.value); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unexpected %T", value)
+	}
+}
+
+// appendMsgpackNumber writes integers in the smallest form that holds them
+func appendMsgpackNumber(b []byte, n json.Number) ([]byte, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		switch {
+		case i >= 0 && i <= math.MaxInt8:
+			return append(b, byte(i)), nil
+		case i < 0 && i >= -32:
+			return append(b, byte(int8(i))), nil
+		case i >= math.MinInt8 && i <= math.MaxInt8:
+			return append(b, 0xd0, byte(int8(i))), nil
+		case i >= math.MinInt16 && i <= math.MaxInt16:
+			return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(int16(i))), nil
+		case i >= math.MinInt32 && i <= math.MaxInt32:
+			return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(int32(i))), nil
+		default:
+			return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i)), nil
+		}
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: invalid number %s", n)
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendMsgpackHeader writes an array or map header: the fix form holds up
+// to 15 entries, then 16 and 32-bit lengths follow the marker
+func appendMsgpackHeader(b []byte, n int, fix, marker16, marker32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, marker16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, marker32), uint32(n))
+	}
+}
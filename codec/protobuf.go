@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ProtobufValue encodes responses as a google.protobuf.Value, the well-known
+// type for JSON-shaped data, so clients decode them with the types their
+// protobuf runtime already ships and no .proto files are published. As in
+// JSON read by JavaScript, numbers are doubles.
+type ProtobufValue struct{}
+
+func (ProtobufValue) ContentType() string {
+	return "application/x-protobuf; messageType=google.protobuf.Value"
+}
+
+func (ProtobufValue) Encode(w io.Writer, data interface{}) error {
+	tree, err := jsonTree(data)
+	if err != nil {
+		return err
+	}
+
+	body, err := appendProtoValue(nil, tree)
+	if err != nil {
+		return err
+	}
+	return writeAll(w, body)
+}
+
+// Wire types, and the fields of Value, Struct (with its map entries) and
+// ListValue in google/protobuf/struct.proto
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+
+	valueNull   = 1
+	valueNumber = 2
+	valueString = 3
+	valueBool   = 4
+	valueStruct = 5
+	valueList   = 6
+
+	structFields = 1
+	entryKey     = 1
+	entryValue   = 2
+	listValues   = 1
+)
+
+func appendProtoValue(b []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return binary.AppendUvarint(appendProtoTag(b, valueNull, protoVarint), 0), nil
+	case bool:
+		var bit uint64
+		if v {
+			bit = 1
+		}
+		return binary.AppendUvarint(appendProtoTag(b, valueBool, protoVarint), bit), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("protobuf: invalid number %s", v)
+		}
+		return binary.LittleEndian.AppendUint64(appendProtoTag(b, valueNumber, protoFixed64), math.Float64bits(f)), nil
+	case string:
+		return appendProtoBytes(b, valueString, []byte(v)), nil
+	case []interface{}:
+		var list []byte
+		for _, item := range v {
+			encoded, err := appendProtoValue(nil, item)
+			if err != nil {
+				return nil, err
+			}
+			list = appendProtoBytes(list, listValues, encoded)
+		}
+		return appendProtoBytes(b, valueList, list), nil
+	case object:
+		var fields []byte
+		for _, m := range v {
+			encoded, err := appendProtoValue(nil, m.value)
+			if err != nil {
+				return nil, err
+			}
+			entry := appendProtoBytes(appendProtoBytes(nil, entryKey, []byte(m.key)), entryValue, encoded)
+			fields = appendProtoBytes(fields, structFields, entry)
+		}
+		return appendProtoBytes(b, valueStruct, fields), nil
+	default:
+		return nil, fmt.Errorf("protobuf: unexpected %T", value)
+	}
+}
+
+func appendProtoTag(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+// appendProtoBytes writes a length-delimited field: a string or an embedded message
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(appendProtoTag(b, field, protoBytes), uint64(len(data)))
+	return append(b, data...)
+}
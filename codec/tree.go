@@ -0,0 +1,82 @@
+// Package codec encodes API responses in binary formats for clients that
+// prefer them to JSON. Every format starts from the response's JSON encoding,
+// so field names, omitted fields, timestamps and nulls are the same in all of
+// them and no model needs a second set of tags.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// object is a JSON object with its keys in their encoded order
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+// jsonTree encodes data as JSON and reads it back as a tree of nil, bool,
+// json.Number, string, []interface{} and object values
+func jsonTree(data interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	value, err := readValue(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response JSON: %w", err)
+	}
+	return value, nil
+}
+
+func readValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+		_, err := decoder.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := readValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token()
+		return list, err
+	default:
+		return token, nil
+	}
+}
+
+// writeAll writes an encoded body; encoders build the whole body first so a
+// failure never leaves a partial response
+func writeAll(w io.Writer, body []byte) error {
+	_, err := w.Write(body)
+	return err
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// Setting sources
+const (
+	SourceDefault = "default"
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceRuntime = "runtime"
+)
+
+const redacted = "[redacted]"
+
+// Keys whose values are never shown
+var secretKeys = map[string]bool{
+	"JWT_SECRET":                         true,
+	"GOOGLE_CLIENT_SECRET":               true,
+	"GITHUB_CLIENT_SECRET":               true,
+	"OIDC_CLIENT_SECRET":                 true,
+	"STAGING_USER_PASSWORD":              true,
+	"AUDIT_ARCHIVE_S3_SECRET_ACCESS_KEY": true,
+	"STRIPE_WEBHOOK_SECRET":              true,
+	"SMTP_PASSWORD":                      true,
+	"TELEGRAM_BOT_TOKEN":                 true,
+	"TELEGRAM_WEBHOOK_SECRET":            true,
+	"VAPID_PRIVATE_KEY":                  true,
+}
+
+// Setting is one configuration value and where it came from
+type Setting struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+	Source  string `json:"source"`
+}
+
+// loader records every value LoadConfig reads, and its source
+type loader struct {
+	fileValues map[string]string
+	settings   []Setting
+}
+
+func newLoader() *loader {
+	// .env has already been applied to the environment by main; reading it
+	// again tells values from the file apart from ones set in the environment
+	fileValues, err := godotenv.Read()
+	if err != nil {
+		fileValues = map[string]string{}
+	}
+	return &loader{fileValues: fileValues}
+}
+
+// record notes a loaded value; fromEnv is false when the default was used,
+// including when the variable was set but could not be parsed
+func (l *loader) record(key, value, defaultValue string, fromEnv bool) {
+	source := SourceDefault
+	if fromEnv {
+		source = SourceEnv
+		if fileValue, ok := l.fileValues[key]; ok && fileValue == os.Getenv(key) {
+			source = SourceFile
+		}
+	}
+
+	l.settings = append(l.settings, Setting{
+		Key:     key,
+		Value:   redact(key, value),
+		Default: redact(key, defaultValue),
+		Source:  source,
+	})
+}
+
+// Override records a value that the process changed after loading, so the
+// effective configuration reflects what is actually in use.
+func (c *Config) Override(key, value string) {
+	for i := range c.settings {
+		if c.settings[i].Key == key {
+			c.settings[i].Value = redact(key, value)
+			c.settings[i].Source = SourceRuntime
+			return
+		}
+	}
+	c.settings = append(c.settings, Setting{Key: key, Value: redact(key, value), Source: SourceRuntime})
+}
+
+// Effective returns every setting in load order, with secrets redacted.
+func (c *Config) Effective() []Setting {
+	settings := make([]Setting, len(c.settings))
+	copy(settings, c.settings)
+	return settings
+}
+
+func redact(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if secretKeys[key] {
+		return redacted
+	}
+	// Connection strings keep their host but lose the password
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			return u.Redacted()
+		}
+	}
+	return value
+}
@@ -0,0 +1,180 @@
+// Package cron parses cron expressions and finds the times they match. It
+// understands the five standard fields (minute, hour, day of month, month,
+// day of week) with lists, ranges, steps and English month and day names, the
+// @hourly, @daily, @weekly, @monthly and @yearly shorthands, and @every
+// followed by a Go duration. Times are matched in UTC.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a day field starting with *: when both day
+	// fields are restricted, a day matching either of them matches, as in crontab
+	domAny, dowAny bool
+	// every is set for @every schedules, which ignore the fields
+	every time.Duration
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Sunday is 0 or 7
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse reads a cron expression such as "*/15 9-17 * * mon-fri"
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q, @every takes a duration of at least 1s", spec)
+		}
+		return &Schedule{every: every}, nil
+	}
+	if expanded, ok := shorthands[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q, use five fields: minute hour day-of-month month day-of-week", spec)
+	}
+
+	s := &Schedule{domAny: strings.HasPrefix(fields[2], "*"), dowAny: strings.HasPrefix(fields[4], "*")}
+	targets := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, f := range []field{minuteField, hourField, domField, monthField, dowField} {
+		bits, err := f.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		*targets[i] = bits
+	}
+	// Fold Sunday as 7 into 0
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	return s, nil
+}
+
+// parse reads a comma-separated list of values, ranges and steps into a bit set
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepExpr, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rangeExpr != "*" {
+			loExpr, hiExpr, isRange := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = f.value(loExpr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiExpr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" runs from 5 to the end of the range
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q in %s", rangeExpr, f.name)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(expr string) (int, error) {
+	if n, ok := f.names[strings.ToLower(expr)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(expr)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s %q, must be %d-%d", f.name, expr, f.min, f.max)
+	}
+	return n, nil
+}
+
+// maxSearchYears bounds Next for expressions that never match, such as 30 February
+const maxSearchYears = 5
+
+// Next returns the first matching time after t, or the zero time when there
+// is none within five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every).Truncate(time.Second)
+	}
+
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
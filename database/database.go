@@ -6,21 +6,34 @@ import (
 	"task-management-api/config"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type MongoDB struct {
 	Client   *mongo.Client
 	Database *mongo.Database
+	// ListDatabase reads with MONGODB_LIST_READ_PREFERENCE and is only used by
+	// list endpoints that tolerate stale data; everything else reads the primary.
+	ListDatabase *mongo.Database
+	ReadMetrics  *ReadMetrics
 }
 
 func InitDB(config *config.Config) (*MongoDB, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(config.MongoDBURI)
+	listReadPref, err := listReadPreference(config)
+	if err != nil {
+		return nil, err
+	}
+
+	readMetrics := NewReadMetrics(config.MongoDBRegionTag)
+	clientOptions := options.Client().
+		ApplyURI(config.MongoDBURI).
+		SetServerMonitor(readMetrics.ServerMonitor()).
+		SetMonitor(readMetrics.CommandMonitor())
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
@@ -33,48 +46,35 @@ func InitDB(config *config.Config) (*MongoDB, error) {
 
 	database := client.Database(config.MongoDBDatabase)
 
-	// Create indexes
-	if err := createIndexes(ctx, database); err != nil {
-		return nil, fmt.Errorf("failed to create indexes: %w", err)
-	}
-
+	// Indexes are built in the background by the index manager, see IndexSpecs
 	return &MongoDB{
-		Client:   client,
-		Database: database,
+		Client:       client,
+		Database:     database,
+		ListDatabase: client.Database(config.MongoDBDatabase, options.Database().SetReadPreference(listReadPref)),
+		ReadMetrics:  readMetrics,
 	}, nil
 }
 
-func createIndexes(ctx context.Context, db *mongo.Database) error {
-	// Users collection indexes
-	usersCollection := db.Collection("users")
-	_, err := usersCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "email", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-	})
+func listReadPreference(config *config.Config) (*readpref.ReadPref, error) {
+	mode, err := readpref.ModeFromString(config.MongoDBListReadPref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MONGODB_LIST_READ_PREFERENCE: %w", err)
+	}
+	maxStaleness, err := staleness(config.MongoDBMaxStalenessSecs)
 	if err != nil {
-		return fmt.Errorf("failed to create users indexes: %w", err)
+		return nil, fmt.Errorf("invalid MONGODB_MAX_STALENESS_SECONDS: %w", err)
 	}
 
-	// Tasks collection indexes
-	tasksCollection := db.Collection("tasks")
-	_, err = tasksCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys: bson.D{{Key: "user_id", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "status", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "created_at", Value: -1}},
-		},
-	})
+	var opts []readpref.Option
+	if maxStaleness > 0 {
+		opts = append(opts, readpref.WithMaxStaleness(maxStaleness))
+	}
+	listReadPref, err := readpref.New(mode, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to create tasks indexes: %w", err)
+		return nil, fmt.Errorf("invalid list read preference: %w", err)
 	}
 
-	return nil
+	return listReadPref, nil
 }
 
 func (m *MongoDB) Close(ctx context.Context) error {
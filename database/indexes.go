@@ -0,0 +1,167 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexSpec describes an index the application expects to exist
+type IndexSpec struct {
+	Collection         string
+	Keys               bson.D
+	Unique             bool
+	Sparse             bool
+	ExpireAfterSeconds *int32
+}
+
+// IndexSpecs is the desired set of indexes, grouped by collection in the order they are built
+var IndexSpecs = []IndexSpec{
+	// Users collection indexes
+	{Collection: "users", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+	{Collection: "users", Keys: bson.D{{Key: "password_reset_token_hash", Value: 1}}, Sparse: true},
+	{Collection: "users", Keys: bson.D{{Key: "email_verification_token_hash", Value: 1}}, Sparse: true},
+	{Collection: "users", Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}}, Unique: true, Sparse: true},
+	{Collection: "users", Keys: bson.D{{Key: "settings.auto_complete_disabled", Value: 1}}, Sparse: true},
+	{Collection: "users", Keys: bson.D{{Key: "settings.auto_complete_minutes", Value: 1}}, Sparse: true},
+
+	// Tasks collection indexes
+	{Collection: "tasks", Keys: bson.D{{Key: "user_id", Value: 1}}},
+	{Collection: "tasks", Keys: bson.D{{Key: "status", Value: 1}}},
+	{Collection: "tasks", Keys: bson.D{{Key: "created_at", Value: -1}}},
+	{Collection: "tasks", Keys: bson.D{{Key: "parent_id", Value: 1}}},
+	{Collection: "tasks", Keys: bson.D{{Key: "purge_at", Value: 1}}, Sparse: true},
+	{Collection: "tasks", Keys: bson.D{{Key: "due_date", Value: 1}}, Sparse: true},
+	{Collection: "tasks", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "due_date", Value: 1}}},
+
+	// My day items are unique per user and task; the rollover job scans by day
+	{Collection: "my_day_items", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "task_id", Value: 1}}, Unique: true},
+	{Collection: "my_day_items", Keys: bson.D{{Key: "day", Value: 1}}},
+
+	// Focus sessions are read per task for history and per user for stats
+	{Collection: "focus_sessions", Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "started_at", Value: -1}}},
+	{Collection: "focus_sessions", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: 1}}},
+
+	// One achievements record per user; the unique index also settles racing first completions
+	{Collection: "user_achievements", Keys: bson.D{{Key: "user_id", Value: 1}}, Unique: true},
+
+	// Task summaries are keyed by user; a rebuild drops those it did not refresh
+	{Collection: "task_summaries", Keys: bson.D{{Key: "updated_at", Value: 1}}},
+
+	// Comments and task history collection indexes
+	{Collection: "comments", Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	{Collection: "task_history", Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: -1}}},
+
+	// Refresh tokens collection indexes; expired tokens are removed by the TTL index
+	{Collection: "refresh_tokens", Keys: bson.D{{Key: "token_hash", Value: 1}}, Unique: true},
+	{Collection: "refresh_tokens", Keys: bson.D{{Key: "user_id", Value: 1}}},
+	{Collection: "refresh_tokens", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: ttl(0)},
+	{Collection: "refresh_tokens", Keys: bson.D{{Key: "session_id", Value: 1}}, Sparse: true},
+
+	// Sessions expire with their latest refresh token
+	{Collection: "sessions", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_used_at", Value: -1}}},
+	{Collection: "sessions", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: ttl(0)},
+
+	// Login attempts expire when their window ends
+	{Collection: "login_attempts", Keys: bson.D{{Key: "reset_at", Value: 1}}, ExpireAfterSeconds: ttl(0)},
+
+	// API keys collection indexes
+	{Collection: "api_keys", Keys: bson.D{{Key: "key_hash", Value: 1}}, Unique: true},
+	{Collection: "api_keys", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+
+	// Webhooks are listed and matched per user; deliveries are claimed when due,
+	// listed per webhook and expire after 30 days
+	{Collection: "webhooks", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "webhook_deliveries", Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+	{Collection: "webhook_deliveries", Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "webhook_deliveries", Keys: bson.D{{Key: "created_at", Value: 1}}, ExpireAfterSeconds: ttl(30 * 24 * 60 * 60)},
+
+	// Notification channels are listed and matched per user; Telegram chats
+	// are found by link token and deleted when the link expires unused
+	{Collection: "notification_channels", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "notification_channels", Keys: bson.D{{Key: "telegram.link_token_hash", Value: 1}}, Unique: true, Sparse: true},
+	{Collection: "notification_channels", Keys: bson.D{{Key: "telegram.link_expires_at", Value: 1}}, ExpireAfterSeconds: ttl(0)},
+	{Collection: "push_subscriptions", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "endpoint", Value: 1}}, Unique: true},
+	{Collection: "push_subscriptions", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: ttl(0)},
+
+	// Audit logs collection indexes
+	{Collection: "audit_logs", Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "audit_logs", Keys: bson.D{{Key: "created_at", Value: 1}}},
+	{Collection: "audit_logs", Keys: bson.D{{Key: "purge_at", Value: 1}}, Sparse: true},
+
+	// Audit archives are listed newest day first
+	{Collection: "audit_archives", Keys: bson.D{{Key: "day", Value: -1}}},
+
+	// A user's running import is looked up before starting another; jobs expire after 30 days
+	{Collection: "import_jobs", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "import_jobs", Keys: bson.D{{Key: "created_at", Value: 1}}, ExpireAfterSeconds: ttl(30 * 24 * 60 * 60)},
+	{Collection: "usage_buckets", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "hour", Value: 1}, {Key: "api_key_id", Value: 1}, {Key: "route", Value: 1}}, Unique: true},
+	{Collection: "usage_buckets", Keys: bson.D{{Key: "hour", Value: 1}}, ExpireAfterSeconds: ttl(400 * 24 * 60 * 60)},
+	{Collection: "subscriptions", Keys: bson.D{{Key: "user_id", Value: 1}}, Unique: true},
+	{Collection: "subscriptions", Keys: bson.D{{Key: "provider", Value: 1}, {Key: "customer_id", Value: 1}}},
+	{Collection: "export_jobs", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "export_jobs", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: ttl(0)},
+	{Collection: "export_chunks", Keys: bson.D{{Key: "export_id", Value: 1}, {Key: "n", Value: 1}}, Unique: true},
+	{Collection: "export_chunks", Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: ttl(0)},
+	{Collection: "deprecation_usage", Keys: bson.D{{Key: "deprecation", Value: 1}, {Key: "route", Value: 1}, {Key: "user_id", Value: 1}, {Key: "api_key_id", Value: 1}, {Key: "user_agent", Value: 1}}, Unique: true},
+	{Collection: "deprecation_usage", Keys: bson.D{{Key: "last_seen", Value: 1}}, ExpireAfterSeconds: ttl(180 * 24 * 60 * 60)},
+	{Collection: "queue_jobs", Keys: bson.D{{Key: "queue", Value: 1}, {Key: "key", Value: 1}}, Unique: true},
+	{Collection: "queue_jobs", Keys: bson.D{{Key: "queue", Value: 1}, {Key: "available_at", Value: 1}}},
+	{Collection: "dead_letter", Keys: bson.D{{Key: "queue", Value: 1}, {Key: "failed_at", Value: -1}}},
+	{Collection: "dead_letter", Keys: bson.D{{Key: "failed_at", Value: 1}}, ExpireAfterSeconds: ttl(90 * 24 * 60 * 60)},
+
+	// Traces of failed requests are looked up by request ID and expire after 7 days
+	{Collection: "request_traces", Keys: bson.D{{Key: "started_at", Value: 1}}, ExpireAfterSeconds: ttl(7 * 24 * 60 * 60)},
+}
+
+func ttl(seconds int32) *int32 {
+	return &seconds
+}
+
+// Name is the key signature MongoDB uses as the default index name, e.g. "user_id_1_created_at_-1".
+// Indexes are matched on it regardless of the name they were actually created with.
+func (s IndexSpec) Name() string {
+	return IndexKeySignature(s.Keys)
+}
+
+// Model builds the driver model used to create the index
+func (s IndexSpec) Model() mongo.IndexModel {
+	opts := options.Index()
+	if s.Unique {
+		opts.SetUnique(true)
+	}
+	if s.Sparse {
+		opts.SetSparse(true)
+	}
+	if s.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*s.ExpireAfterSeconds)
+	}
+
+	return mongo.IndexModel{Keys: s.Keys, Options: opts}
+}
+
+// IndexKeySignature renders a key document the way MongoDB names indexes by default
+func IndexKeySignature(keys bson.D) string {
+	parts := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		var direction string
+		switch v := key.Value.(type) {
+		case int:
+			direction = fmt.Sprint(v)
+		case int32:
+			direction = fmt.Sprint(v)
+		case int64:
+			direction = fmt.Sprint(v)
+		case float64:
+			direction = fmt.Sprint(int64(v))
+		default:
+			direction = fmt.Sprint(v)
+		}
+		parts = append(parts, key.Key, direction)
+	}
+
+	return strings.Join(parts, "_")
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/description"
+)
+
+// readCommands are the commands counted as reads
+var readCommands = map[string]bool{
+	"find":      true,
+	"aggregate": true,
+	"count":     true,
+	"distinct":  true,
+	"getMore":   true,
+}
+
+// ReadMetrics counts reads per replica set member, labelled with the member's
+// region tag and the read preference the read was sent with, so operators can
+// see where list reads are actually routed.
+type ReadMetrics struct {
+	regionTag string
+
+	mu      sync.Mutex
+	members map[string]memberInfo
+	pending map[int64]string
+	stats   map[readKey]*readStats
+}
+
+type memberInfo struct {
+	region string
+	role   string
+}
+
+type readKey struct {
+	host, region, role, readPreference string
+}
+
+type readStats struct {
+	reads   int64
+	errors  int64
+	seconds float64
+}
+
+func NewReadMetrics(regionTag string) *ReadMetrics {
+	return &ReadMetrics{
+		regionTag: regionTag,
+		members:   make(map[string]memberInfo),
+		pending:   make(map[int64]string),
+		stats:     make(map[readKey]*readStats),
+	}
+}
+
+// ServerMonitor learns each member's region and role from topology updates
+func (m *ReadMetrics) ServerMonitor() *event.ServerMonitor {
+	return &event.ServerMonitor{
+		ServerDescriptionChanged: func(e *event.ServerDescriptionChangedEvent) {
+			info := memberInfo{region: "unknown", role: memberRole(e.NewDescription.Kind)}
+			for _, t := range e.NewDescription.Tags {
+				if t.Name == m.regionTag {
+					info.region = t.Value
+				}
+			}
+
+			m.mu.Lock()
+			m.members[e.Address.String()] = info
+			m.mu.Unlock()
+		},
+	}
+}
+
+// CommandMonitor times read commands per member
+func (m *ReadMetrics) CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			if !readCommands[e.CommandName] {
+				return
+			}
+			// Primary reads carry no $readPreference
+			readPreference := "primary"
+			if mode, err := e.Command.LookupErr("$readPreference", "mode"); err == nil {
+				readPreference = mode.StringValue()
+			}
+
+			m.mu.Lock()
+			m.pending[e.RequestID] = readPreference
+			m.mu.Unlock()
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			m.finish(&e.CommandFinishedEvent, false)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			m.finish(&e.CommandFinishedEvent, true)
+		},
+	}
+}
+
+func (m *ReadMetrics) finish(e *event.CommandFinishedEvent, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	readPreference, ok := m.pending[e.RequestID]
+	if !ok {
+		return
+	}
+	delete(m.pending, e.RequestID)
+
+	// Connection IDs look like "host:port[-12]"
+	host := e.ConnectionID
+	if i := strings.Index(host, "[-"); i >= 0 {
+		host = host[:i]
+	}
+	member, ok := m.members[host]
+	if !ok {
+		member = memberInfo{region: "unknown", role: "unknown"}
+	}
+
+	key := readKey{host: host, region: member.region, role: member.role, readPreference: readPreference}
+	stats, ok := m.stats[key]
+	if !ok {
+		stats = &readStats{}
+		m.stats[key] = stats
+	}
+	stats.reads++
+	stats.seconds += e.Duration.Seconds()
+	if failed {
+		stats.errors++
+	}
+}
+
+func memberRole(kind description.ServerKind) string {
+	switch kind {
+	case description.RSPrimary:
+		return "primary"
+	case description.RSSecondary:
+		return "secondary"
+	case description.Standalone:
+		return "standalone"
+	case description.Mongos:
+		return "mongos"
+	}
+	return "unknown"
+}
+
+// PrometheusMetrics renders per-member read counters in the Prometheus text exposition format.
+func (m *ReadMetrics) PrometheusMetrics() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]readKey, 0, len(m.stats))
+	for key := range m.stats {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		if keys[i].role != keys[j].role {
+			return keys[i].role < keys[j].role
+		}
+		return keys[i].readPreference < keys[j].readPreference
+	})
+
+	var b strings.Builder
+	metrics := []struct {
+		name, help string
+		value      func(*readStats) string
+	}{
+		{"mongodb_reads_total", "MongoDB read commands by member, region, role and read preference.", func(s *readStats) string { return fmt.Sprint(s.reads) }},
+		{"mongodb_read_errors_total", "MongoDB read commands that failed, by member.", func(s *readStats) string { return fmt.Sprint(s.errors) }},
+		{"mongodb_read_duration_seconds_sum", "Total time spent in MongoDB read commands, by member.", func(s *readStats) string { return fmt.Sprintf("%g", s.seconds) }},
+	}
+	for _, metric := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s counter\n", metric.name)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s{host=%q,region=%q,role=%q,read_preference=%q} %s\n",
+				metric.name, key.host, key.region, key.role, key.readPreference, metric.value(m.stats[key]))
+		}
+	}
+
+	return b.String()
+}
+
+// staleness converts MONGODB_MAX_STALENESS_SECONDS; MongoDB rejects bounds below 90 seconds
+func staleness(seconds int) (time.Duration, error) {
+	if seconds != 0 && seconds < 90 {
+		return 0, fmt.Errorf("max staleness must be 0 or at least 90 seconds, got %d", seconds)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
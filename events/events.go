@@ -0,0 +1,119 @@
+// Package events carries what happened in one service to the parts of the
+// API that react to it. Services publish typed events to a Bus once a change
+// is saved; webhooks, notifications, audit logging and the rest subscribe to
+// the events they need during startup, so a new integration is a new
+// subscriber rather than another call in the service.
+package events
+
+import (
+	"context"
+	"task-management-api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event is something that happened. Name identifies the kind of event and
+// must be unique to its type.
+type Event interface {
+	Name() string
+}
+
+// TaskCreated is published for each task created, imported or scheduled as
+// the next occurrence of a recurring task
+type TaskCreated struct {
+	Task *models.Task
+}
+
+func (TaskCreated) Name() string { return models.TaskEventCreated }
+
+// TaskCompleted is published for each task a user marks completed and each
+// task the auto-complete worker completes
+type TaskCompleted struct {
+	Task *models.Task
+	// By is the user who completed the task, nil when the worker did
+	By *models.User
+	// AutoCompleteMinutes is how long the worker waited before completing the task
+	AutoCompleteMinutes int
+}
+
+func (TaskCompleted) Name() string { return models.TaskEventCompleted }
+
+// AutoCompleted reports whether the auto-complete worker completed the task
+func (e TaskCompleted) AutoCompleted() bool {
+	return e.By == nil
+}
+
+// TaskDeleted is published for each deleted task, including those removed by
+// a cascade or a bulk delete, with the task as it was just before deletion.
+type TaskDeleted struct {
+	Task *models.Task
+}
+
+func (TaskDeleted) Name() string { return models.TaskEventDeleted }
+
+// TaskDueSoon is published by the reminders job for each task about to be due
+type TaskDueSoon struct {
+	Task *models.Task
+}
+
+func (TaskDueSoon) Name() string { return models.TaskEventDueSoon }
+
+// TasksChanged is published for each user whose tasks were created, changed
+// or deleted.
+type TasksChanged struct {
+	UserID primitive.ObjectID
+}
+
+func (TasksChanged) Name() string { return "tasks.changed" }
+
+// TasksAssigned is published once an admin's reassignment of open tasks to
+// a user finishes
+type TasksAssigned struct {
+	UserID primitive.ObjectID
+	Count  int64
+}
+
+func (TasksAssigned) Name() string { return models.NotificationEventAssigned }
+
+// UserRegistered is published for each new account, whether it signed up
+// with a password or through a login provider
+type UserRegistered struct {
+	User *models.User
+	// Provider names the login provider, empty for a password sign-up
+	Provider string
+}
+
+func (UserRegistered) Name() string { return "user.registered" }
+
+// Bus delivers each published event to the handlers subscribed to its type,
+// in the order they subscribed. Handlers run in the publisher's goroutine
+// after its change is saved, so they cannot fail the change; they log their
+// own failures and hand slow work to a queue.
+type Bus struct {
+	handlers map[string][]func(ctx context.Context, event Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]func(ctx context.Context, event Event))}
+}
+
+// Subscribe registers handler for every event of type E. Subscribe during
+// startup, before anything is published.
+func Subscribe[E Event](bus *Bus, handler func(ctx context.Context, event E)) {
+	var zero E
+	name := zero.Name()
+	bus.handlers[name] = append(bus.handlers[name], func(ctx context.Context, event Event) {
+		handler(ctx, event.(E))
+	})
+}
+
+// Publish runs the handlers subscribed to the event's type. A nil Bus
+// publishes nowhere.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	for _, handler := range b.handlers[event.Name()] {
+		handler(ctx, event)
+	}
+}
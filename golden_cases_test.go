@@ -0,0 +1,152 @@
+package main
+
+// Sandbox fixture IDs, see service/sandbox_service.go
+const (
+	adminTaskID   = "650000000000000000000104"
+	pendingTaskID = "650000000000000000000101"
+	activeTaskID  = "650000000000000000000102"
+	doneTaskID    = "650000000000000000000103"
+	userID        = "650000000000000000000002"
+)
+
+// goldenCase is one request whose response is pinned by a golden file. Every
+// case starts from a fresh sandbox, so cases that write do not affect others.
+type goldenCase struct {
+	name string
+	// as is "user", "admin" or "" for an anonymous request
+	as     string
+	method string
+	path   string
+	body   string
+	// contentType of body, application/json when empty
+	contentType string
+}
+
+// goldenCases covers every v1 route at least once, plus the common error
+// shapes. Add a case with each new route and run make golden-update.
+var goldenCases = []goldenCase{
+	// Public
+	{name: "meta_errors", method: "GET", path: "/meta/errors"},
+	{name: "register", method: "POST", path: "/api/v1/register", body: `{"email":"new@example.com","username":"newuser","password":"password123"}`},
+	{name: "register_missing_fields", method: "POST", path: "/api/v1/register", body: `{"email":"new@example.com"}`},
+	{name: "login_invalid_credentials", method: "POST", path: "/api/v1/login", body: `{"email":"user@example.com","password":"wrong-password"}`},
+	{name: "webhook_schemas", method: "GET", path: "/api/v1/webhooks/schemas"},
+	{name: "billing_webhook_unsigned", method: "POST", path: "/api/v1/billing/stripe/webhook", body: `{"type":"customer.subscription.updated"}`},
+	{name: "refresh_invalid", method: "POST", path: "/api/v1/auth/refresh", body: `{"refresh_token":"unknown"}`},
+	{name: "route_not_found", method: "GET", path: "/api/v1/nothing-here"},
+	{name: "method_not_allowed", method: "PUT", path: "/api/v1/tasks"},
+	{name: "legacy_alias", as: "user", method: "GET", path: "/me"},
+
+	// Own account
+	{name: "me", as: "user", method: "GET", path: "/api/v1/me"},
+	{name: "me_unauthenticated", method: "GET", path: "/api/v1/me"},
+	{name: "me_update", as: "user", method: "PUT", path: "/api/v1/me", body: `{"username":"renamed","preferences":{"achievements_opt_out":true}}`},
+	{name: "me_update_empty", as: "user", method: "PUT", path: "/api/v1/me", body: `{}`},
+	{name: "me_delete", as: "user", method: "DELETE", path: "/api/v1/me", body: `{"password":"password123","tasks":"delete"}`},
+	{name: "me_settings", as: "user", method: "GET", path: "/api/v1/me/settings"},
+	{name: "me_settings_update", as: "user", method: "PATCH", path: "/api/v1/me/settings", body: `{"auto_complete":true,"auto_complete_minutes":30}`},
+	{name: "me_settings_invalid_minutes", as: "user", method: "PATCH", path: "/api/v1/me/settings", body: `{"auto_complete_minutes":5000}`},
+	{name: "me_private_passphrase", as: "user", method: "PUT", path: "/api/v1/me/private-passphrase", body: `{"passphrase":"correct horse battery staple"}`},
+	{name: "me_private_passphrase_short", as: "user", method: "PUT", path: "/api/v1/me/private-passphrase", body: `{"passphrase":"short"}`},
+	{name: "api_keys_create", as: "user", method: "POST", path: "/api/v1/me/api-keys", body: `{"name":"nightly sync","scopes":["tasks:read"]}`},
+	{name: "api_keys_list", as: "user", method: "GET", path: "/api/v1/me/api-keys"},
+	{name: "api_keys_revoke_unknown", as: "user", method: "DELETE", path: "/api/v1/me/api-keys/" + adminTaskID},
+	{name: "sessions_list", as: "user", method: "GET", path: "/api/v1/me/sessions"},
+	{name: "scoped_token", as: "user", method: "POST", path: "/api/v1/me/tokens", body: `{"scopes":["tasks:read"],"expires_in_hours":1}`},
+	{name: "achievements", as: "user", method: "GET", path: "/api/v1/me/achievements"},
+	{name: "usage_range_too_long", as: "user", method: "GET", path: "/api/v1/me/usage?from=2024-01-01&to=2024-06-30"},
+	{name: "subscription_billing_disabled", as: "user", method: "GET", path: "/api/v1/me/subscription"},
+	{name: "webhooks_create", as: "user", method: "POST", path: "/api/v1/me/webhooks", body: `{"url":"https://example.com/hooks/tasks","secret":"0123456789abcdef"}`},
+	{name: "webhooks_create_invalid_url", as: "user", method: "POST", path: "/api/v1/me/webhooks", body: `{"url":"ftp://example.com"}`},
+	{name: "webhooks_create_invalid_schema_version", as: "user", method: "POST", path: "/api/v1/me/webhooks", body: `{"url":"https://example.com/hooks/tasks","schema_version":99}`},
+	{name: "webhooks_list", as: "user", method: "GET", path: "/api/v1/me/webhooks"},
+	{name: "webhooks_update_unknown", as: "user", method: "PATCH", path: "/api/v1/me/webhooks/" + adminTaskID, body: `{"schema_version":1}`},
+	{name: "webhooks_update_empty", as: "user", method: "PATCH", path: "/api/v1/me/webhooks/" + adminTaskID, body: `{}`},
+	{name: "webhooks_enable_unknown", as: "user", method: "PATCH", path: "/api/v1/me/webhooks/" + adminTaskID, body: `{"enabled":true}`},
+	{name: "webhooks_deliveries_unknown", as: "user", method: "GET", path: "/api/v1/me/webhooks/" + adminTaskID + "/deliveries"},
+	{name: "notification_channels_create_slack", as: "user", method: "POST", path: "/api/v1/me/notification-channels", body: `{"type":"slack","slack":{"webhook_url":"https://hooks.slack.com/services/T000/B000/XXXX"}}`},
+	{name: "notification_channels_create_invalid_url", as: "user", method: "POST", path: "/api/v1/me/notification-channels", body: `{"type":"slack","slack":{"webhook_url":"https://example.com/hook"}}`},
+	{name: "notification_channels_create_telegram_unconfigured", as: "user", method: "POST", path: "/api/v1/me/notification-channels", body: `{"type":"telegram"}`},
+	{name: "telegram_webhook_unconfigured", method: "POST", path: "/api/v1/telegram/webhook", body: `{"update_id":1}`},
+	{name: "notification_channels_list", as: "user", method: "GET", path: "/api/v1/me/notification-channels"},
+	{name: "notification_channels_test_unknown", as: "user", method: "POST", path: "/api/v1/me/notification-channels/" + adminTaskID + "/test"},
+	{name: "notification_preferences_get", as: "user", method: "GET", path: "/api/v1/me/notification-preferences"},
+	{name: "notification_preferences_update", as: "user", method: "PATCH", path: "/api/v1/me/notification-preferences", body: `{"channels":{"email":["task.due_soon"],"push":[]}}`},
+	{name: "notification_preferences_update_invalid_channel", as: "user", method: "PATCH", path: "/api/v1/me/notification-preferences", body: `{"channels":{"sms":["task.due_soon"]}}`},
+	{name: "push_subscriptions_public_key_unconfigured", as: "user", method: "GET", path: "/api/v1/me/push-subscriptions/public-key"},
+	{name: "push_subscriptions_create_unconfigured", as: "user", method: "POST", path: "/api/v1/me/push-subscriptions", body: `{"endpoint":"https://push.example.com/send/abc","keys":{"p256dh":"x","auth":"y"}}`},
+	{name: "push_subscriptions_list", as: "user", method: "GET", path: "/api/v1/me/push-subscriptions"},
+	{name: "push_subscriptions_delete_unknown", as: "user", method: "DELETE", path: "/api/v1/me/push-subscriptions/" + adminTaskID},
+
+	// Tasks
+	{name: "tasks_list", as: "user", method: "GET", path: "/api/v1/tasks"},
+	{name: "tasks_list_filtered", as: "user", method: "GET", path: "/api/v1/tasks?status=completed&limit=1"},
+	{name: "tasks_list_invalid_status", as: "user", method: "GET", path: "/api/v1/tasks?status=done"},
+	{name: "tasks_create", as: "user", method: "POST", path: "/api/v1/tasks", body: `{"title":"Golden task","description":"Pinned response","due_date":"2024-01-05T17:00:00Z"}`},
+	{name: "tasks_create_missing_title", as: "user", method: "POST", path: "/api/v1/tasks", body: `{"description":"no title"}`},
+	{name: "tasks_get", as: "user", method: "GET", path: "/api/v1/tasks/" + pendingTaskID},
+	{name: "tasks_get_invalid_id", as: "user", method: "GET", path: "/api/v1/tasks/not-an-id"},
+	{name: "tasks_get_other_user", as: "user", method: "GET", path: "/api/v1/tasks/" + adminTaskID},
+	{name: "tasks_update", as: "user", method: "PATCH", path: "/api/v1/tasks/" + pendingTaskID, body: `{"status":"completed"}`},
+	{name: "tasks_delete", as: "user", method: "DELETE", path: "/api/v1/tasks/" + activeTaskID},
+	{name: "tasks_bulk_delete", as: "user", method: "DELETE", path: "/api/v1/tasks", body: `{"status":"completed"}`},
+	{name: "tasks_summary", as: "user", method: "GET", path: "/api/v1/tasks/summary"},
+	{name: "tasks_subtasks", as: "user", method: "GET", path: "/api/v1/tasks/" + pendingTaskID + "/subtasks"},
+	{name: "tasks_history", as: "user", method: "GET", path: "/api/v1/tasks/" + pendingTaskID + "/history"},
+	{name: "tasks_archive", as: "user", method: "POST", path: "/api/v1/tasks/" + doneTaskID + "/archive"},
+	{name: "tasks_unarchive", as: "user", method: "POST", path: "/api/v1/tasks/" + doneTaskID + "/unarchive"},
+	{name: "tasks_schedule", as: "user", method: "POST", path: "/api/v1/tasks/" + pendingTaskID + "/schedule", body: `{"date":"2024-01-03"}`},
+	{name: "tasks_list_fields", as: "user", method: "GET", path: "/api/v1/tasks?fields=title,status"},
+	{name: "tasks_list_fields_unknown", as: "user", method: "GET", path: "/api/v1/tasks?fields=title,password"},
+	{name: "tasks_plan", as: "user", method: "GET", path: "/api/v1/tasks/plan?week=2024-W01"},
+	{name: "tasks_export", as: "user", method: "GET", path: "/api/v1/tasks/export?format=markdown"},
+	{name: "reports_tasks_pdf_invalid_range", as: "user", method: "GET", path: "/api/v1/reports/tasks.pdf?from=2024-02-01&to=2024-01-31"},
+	{name: "exports_invalid_format", as: "user", method: "POST", path: "/api/v1/exports", body: `{"format":"xml"}`},
+	{name: "exports_job_unknown", as: "user", method: "GET", path: "/api/v1/exports/000000000000000000000000"},
+	{name: "reports_tasks_pdf_other_user", as: "user", method: "GET", path: "/api/v1/reports/tasks.pdf?user_id=000000000000000000000000"},
+	{name: "tasks_import", as: "user", method: "POST", path: "/api/v1/tasks/import", contentType: "text/markdown", body: "- [ ] Imported task (due 2024-01-08)\n- [x] Imported and done\n"},
+	{name: "tasks_import_json", as: "user", method: "POST", path: "/api/v1/tasks/import", body: `[{"title":"Imported task","due_date":"2024-01-08T00:00:00Z"},{"title":""},{"title":"Done","status":"done"},"not a task"]`},
+	{name: "tasks_import_todoist_no_token", as: "user", method: "POST", path: "/api/v1/tasks/import/todoist", body: `{}`},
+	{name: "tasks_import_job_unknown", as: "user", method: "GET", path: "/api/v1/tasks/import/jobs/000000000000000000000000"},
+	{name: "tasks_import_ndjson", as: "user", method: "POST", path: "/api/v1/tasks/import", contentType: "application/x-ndjson", body: "{\"title\":\"First\"}\n\n{\"title\":\"Second\",\"status\":\"in_progress\"}\n{\"title\":\"Bad\",\"recurrence\":\"sometimes\"}\n"},
+	{name: "my_day_add", as: "user", method: "POST", path: "/api/v1/tasks/" + pendingTaskID + "/my-day"},
+	{name: "my_day_list", as: "user", method: "GET", path: "/api/v1/tasks/my-day"},
+	{name: "my_day_remove_missing", as: "user", method: "DELETE", path: "/api/v1/tasks/" + pendingTaskID + "/my-day"},
+	{name: "pomodoro_start", as: "user", method: "POST", path: "/api/v1/tasks/" + pendingTaskID + "/pomodoros", body: `{"minutes":25}`},
+	{name: "pomodoro_list", as: "user", method: "GET", path: "/api/v1/tasks/" + pendingTaskID + "/pomodoros"},
+	{name: "pomodoro_stop_missing", as: "user", method: "POST", path: "/api/v1/tasks/" + pendingTaskID + "/pomodoros/stop"},
+	{name: "focus_stats", as: "user", method: "GET", path: "/api/v1/tasks/focus-stats?period=week"},
+	{name: "comments_create", as: "user", method: "POST", path: "/api/v1/tasks/" + pendingTaskID + "/comments", body: `{"body":"Looks good"}`},
+	{name: "comments_list", as: "user", method: "GET", path: "/api/v1/tasks/" + pendingTaskID + "/comments"},
+	{name: "comments_delete_missing", as: "user", method: "DELETE", path: "/api/v1/tasks/" + pendingTaskID + "/comments/" + adminTaskID},
+
+	// Admin
+	{name: "admin_forbidden", as: "user", method: "GET", path: "/api/v1/admin/retention"},
+	{name: "admin_retention", as: "admin", method: "GET", path: "/api/v1/admin/retention"},
+	{name: "admin_retention_update", as: "admin", method: "PUT", path: "/api/v1/admin/retention", body: `{"completed_task_days":90,"grace_days":7}`},
+	{name: "admin_retention_run", as: "admin", method: "POST", path: "/api/v1/admin/retention/run"},
+	{name: "admin_retention_reports", as: "admin", method: "GET", path: "/api/v1/admin/retention/reports"},
+	{name: "admin_request_trace_unknown", as: "admin", method: "GET", path: "/api/v1/admin/requests/unknown-request"},
+	{name: "admin_user_usage_invalid_id", as: "admin", method: "GET", path: "/api/v1/admin/users/not-an-id/usage"},
+	{name: "admin_deprecations", as: "admin", method: "GET", path: "/api/v1/admin/deprecations"},
+	{name: "admin_audit_archives", as: "admin", method: "GET", path: "/api/v1/admin/audit-archives"},
+	{name: "admin_audit_archives_run_invalid_days", as: "admin", method: "POST", path: "/api/v1/admin/audit-archives/run", body: `{"older_than_days":0}`},
+	{name: "admin_audit_archive_download_invalid_id", as: "admin", method: "GET", path: "/api/v1/admin/audit-archives/not-an-id/download"},
+	{name: "admin_worker_simulate", as: "admin", method: "POST", path: "/api/v1/admin/worker/simulate", body: `{"task_id":"` + pendingTaskID + `"}`},
+	{name: "admin_worker_status", as: "admin", method: "GET", path: "/api/v1/admin/worker"},
+	{name: "admin_dead_letters", as: "admin", method: "GET", path: "/api/v1/admin/dead-letters"},
+	{name: "admin_projection_consistency", as: "admin", method: "GET", path: "/api/v1/admin/projections/consistency"},
+	{name: "admin_force_logout", as: "admin", method: "POST", path: "/api/v1/admin/users/" + userID + "/force-logout"},
+	{name: "admin_unlock", as: "admin", method: "POST", path: "/api/v1/admin/users/" + userID + "/unlock"},
+	{name: "admin_reassign_tasks", as: "admin", method: "POST", path: "/api/v1/admin/users/" + userID + "/reassign-tasks", body: `{"to":"unassigned"}`},
+	{name: "admin_set_permissions", as: "admin", method: "PUT", path: "/api/v1/admin/users/" + userID + "/permissions", body: `{"permissions":["tasks:read_all"]}`},
+	{name: "admin_field_policy", as: "admin", method: "GET", path: "/api/v1/admin/field-policy/tasks"},
+	{name: "admin_field_policy_update", as: "admin", method: "PUT", path: "/api/v1/admin/field-policy/tasks", body: `{"fields":{"due_date":["admin"]}}`},
+	{name: "admin_notification_templates", as: "admin", method: "GET", path: "/api/v1/admin/notification-templates"},
+	{name: "admin_notification_template_unknown", as: "admin", method: "GET", path: "/api/v1/admin/notification-templates/newsletter"},
+	{name: "admin_notification_template_preview", as: "admin", method: "POST", path: "/api/v1/admin/notification-templates/welcome/preview", body: `{"subject":"Welcome, {{.Username}}","body":"Hi {{.Username}}, your account is ready."}`},
+	{name: "admin_notification_template_invalid", as: "admin", method: "PUT", path: "/api/v1/admin/notification-templates/welcome", body: `{"subject":"Welcome, {{.Name}}","body":"Hi"}`},
+	{name: "admin_user_legal_hold", as: "admin", method: "PUT", path: "/api/v1/admin/users/" + userID + "/legal-hold", body: `{"enabled":true}`},
+	{name: "admin_task_legal_hold", as: "admin", method: "PUT", path: "/api/v1/admin/tasks/" + pendingTaskID + "/legal-hold", body: `{"enabled":true}`},
+	{name: "admin_login_attempts", as: "admin", method: "GET", path: "/api/v1/admin/login-attempts"},
+}
@@ -0,0 +1,290 @@
+package main
+
+// The golden tests pin the JSON responses of the API in golden files, so a
+// change to a response shape fails the tests instead of reaching clients. The
+// API runs in process in sandbox mode: every case resets the sandbox first, so
+// IDs, timestamps and data are the same on every run.
+//
+// Goldens live in testdata/golden/<api version>. Run with -update to rewrite
+// them after an intended change, and review the diff like any other code.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/database"
+	"testing"
+	"time"
+)
+
+var updateGoldens = flag.Bool("update", false, "rewrite the golden files instead of comparing against them")
+
+const goldenDir = "testdata/golden/v1"
+
+// sandboxIDPrefix starts every ID the sandbox seeds, see service/sandbox_service.go
+const sandboxIDPrefix = "65000000000000000000"
+
+// volatileKeys hold values that differ on every run even in the sandbox
+var volatileKeys = map[string]bool{
+	"token":         true,
+	"refresh_token": true,
+	"key":           true,
+	"prefix":        true,
+	"secret":        true,
+	"reset_token":   true,
+	"request_id":    true,
+	"timestamp":     true,
+}
+
+var objectIDPattern = regexp.MustCompile(`\b[0-9a-f]{24}\b`)
+
+// golden is the stored form of one response
+type golden struct {
+	Request string      `json:"request"`
+	Status  int         `json:"status"`
+	Body    interface{} `json:"body"`
+}
+
+type goldenRunner struct {
+	baseURL string
+	client  *http.Client
+}
+
+// TestGoldenResponses runs against the golden database of the MongoDB at
+// GOLDEN_MONGODB_URI, which every sandbox reset empties. It is skipped when the
+// variable is not set, so it never touches a database nobody pointed it at.
+func TestGoldenResponses(t *testing.T) {
+	uri := os.Getenv("GOLDEN_MONGODB_URI")
+	if uri == "" {
+		t.Skip("GOLDEN_MONGODB_URI is not set; point it at a disposable MongoDB to check the golden responses")
+	}
+
+	cfg := config.LoadConfig()
+	cfg.MongoDBURI = uri
+	cfg.MongoDBDatabase = "golden"
+	cfg.SandboxMode = true
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		t.Fatalf("Failed to connect to %s: %v", uri, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer closeCancel()
+		db.Close(closeCtx)
+	})
+
+	api, _ := newServer(ctx, cfg, db)
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	r := &goldenRunner{baseURL: server.URL, client: &http.Client{Timeout: 30 * time.Second}}
+	if *updateGoldens {
+		if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", goldenDir, err)
+		}
+	}
+
+	for _, c := range goldenCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			got, err := r.run(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(goldenDir, c.name+".json")
+			if *updateGoldens {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("Failed to write %s: %v", path, err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				t.Fatalf("no golden file %s, run make golden-update", path)
+			}
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", path, err)
+			}
+			if !bytes.Equal(want, got) {
+				t.Errorf("response differs from %s\n%s", path, diffLines(string(want), string(got)))
+			}
+		})
+	}
+}
+
+// run resets the sandbox, signs in as the case requires and returns the
+// canonical golden for the response
+func (r *goldenRunner) run(c goldenCase) ([]byte, error) {
+	status, _, err := r.do("POST", "/sandbox/reset", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("sandbox reset returned %d", status)
+	}
+
+	token := ""
+	if c.as != "" {
+		if token, err = r.login(c.as); err != nil {
+			return nil, err
+		}
+	}
+
+	status, body, err := r.do(c.method, c.path, c.contentType, c.body, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(golden{
+		Request: c.method + " " + c.path,
+		Status:  status,
+		Body:    normalize(body),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode golden: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func (r *goldenRunner) login(as string) (string, error) {
+	credentials, _ := json.Marshal(map[string]string{
+		"email":    as + "@example.com",
+		"password": "password123",
+	})
+	status, body, err := r.do("POST", "/api/v1/login", "", string(credentials), "")
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("login as %s returned %d: %s", as, status, body)
+	}
+
+	var response struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to decode login response: %w", err)
+	}
+	return response.Token, nil
+}
+
+// do sends a request; bodies are sent as JSON unless contentType says otherwise
+func (r *goldenRunner) do(method, path, contentType, body, token string) (int, []byte, error) {
+	req, err := http.NewRequest(method, r.baseURL+path, strings.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != "" {
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// normalize parses a JSON body and masks the values that change between runs.
+// Bodies that are not JSON, such as the markdown export, are kept as text.
+func normalize(body []byte) interface{} {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return maskIDs(string(body))
+	}
+	return mask(value)
+}
+
+func mask(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if volatileKeys[key] {
+				if s, ok := field.(string); ok && s != "" {
+					v[key] = "<redacted>"
+				}
+				continue
+			}
+			v[key] = mask(field)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = mask(item)
+		}
+		return v
+	case string:
+		return maskIDs(v)
+	default:
+		return v
+	}
+}
+
+// maskIDs replaces IDs of records created during the case. Seeded IDs are
+// fixed and kept, so goldens still show which fixture a response refers to.
+func maskIDs(s string) string {
+	return objectIDPattern.ReplaceAllStringFunc(s, func(id string) string {
+		if strings.HasPrefix(id, sandboxIDPrefix) {
+			return id
+		}
+		return "<id>"
+	})
+}
+
+// diffLines lists the lines of want and got that differ, position by position
+func diffLines(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var b strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		fmt.Fprintf(&b, "  line %d\n    - %s\n    + %s\n", i+1, w, g)
+	}
+	return b.String()
+}
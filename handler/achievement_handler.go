@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type AchievementHandler struct {
+	achievementService *service.AchievementService
+}
+
+func NewAchievementHandler(achievementService *service.AchievementService) *AchievementHandler {
+	return &AchievementHandler{
+		achievementService: achievementService,
+	}
+}
+
+func (h *AchievementHandler) GetAchievements(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	response, err := h.achievementService.Get(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to load achievements")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
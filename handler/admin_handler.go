@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminHandler struct {
+	adminService  *service.AdminService
+	schemaService *service.SchemaService
+	indexService  *service.IndexService
+	cfg           *config.Config
+}
+
+func NewAdminHandler(adminService *service.AdminService, schemaService *service.SchemaService, indexService *service.IndexService, cfg *config.Config) *AdminHandler {
+	return &AdminHandler{
+		adminService:  adminService,
+		schemaService: schemaService,
+		indexService:  indexService,
+		cfg:           cfg,
+	}
+}
+
+func (h *AdminHandler) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.adminService.ForceLogout(r.Context(), actor, userID); err != nil {
+		if err.Error() == "user not found" {
+			utils.RespondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "all sessions revoked"})
+}
+
+func (h *AdminHandler) ResetCredentials(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	response, err := h.adminService.ResetCredentials(r.Context(), actor, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.RespondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		if err.Error() == "user email address is not verified" {
+			utils.RespondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to reset credentials")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *AdminHandler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.adminService.UnlockAccount(r.Context(), actor, userID); err != nil {
+		if err.Error() == "user not found" {
+			utils.RespondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to unlock account")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "account unlocked"})
+}
+
+func (h *AdminHandler) ReassignTasks(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req models.ReassignTasksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.adminService.ReassignTasks(r.Context(), actor, userID, &req)
+	if err != nil {
+		switch err.Error() {
+		case "user not found", "target user not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		case "to is required", "invalid target user ID", "target user must differ from the source user":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to reassign tasks")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *AdminHandler) SetPermissions(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req models.SetPermissionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.adminService.SetPermissions(r.Context(), actor, userID, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "user not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		case err.Error() == "permissions is required", strings.HasPrefix(err.Error(), "invalid permission"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case strings.HasPrefix(err.Error(), "cannot "):
+			utils.RespondError(w, http.StatusForbidden, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to update permissions")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, user)
+}
+
+func (h *AdminHandler) SetUserLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, "user")
+}
+
+func (h *AdminHandler) SetTaskLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, "task")
+}
+
+func (h *AdminHandler) setLegalHold(w http.ResponseWriter, r *http.Request, targetType string) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	targetID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid "+targetType+" ID")
+		return
+	}
+
+	var req models.SetLegalHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var response *models.LegalHoldResponse
+	if targetType == "user" {
+		response, err = h.adminService.SetUserLegalHold(r.Context(), actor, targetID, &req)
+	} else {
+		response, err = h.adminService.SetTaskLegalHold(r.Context(), actor, targetID, &req)
+	}
+	if err != nil {
+		switch err.Error() {
+		case "enabled is required":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case "user not found", "task not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to update legal hold")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *AdminHandler) SchemaStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.schemaService.Status(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to read schema version")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, status)
+}
+
+// Indexes compares the desired indexes with the database and reports the background build
+func (h *AdminHandler) Indexes(w http.ResponseWriter, r *http.Request) {
+	report, err := h.indexService.Report(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to read indexes")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+// Config shows the effective configuration with secrets redacted
+func (h *AdminHandler) Config(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"settings": h.cfg.Effective()})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.apiKeyService.CreateKey(r.Context(), user, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "name is required",
+			strings.HasPrefix(err.Error(), "name must be at most"),
+			strings.HasPrefix(err.Error(), "invalid scope"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case strings.HasPrefix(err.Error(), "at most"):
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to create api key")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, response)
+}
+
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list api keys")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	keyID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid api key ID")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(r.Context(), user, keyID); err != nil {
+		if err.Error() == "api key not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to revoke api key")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "api key revoked"})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditArchiveHandler struct {
+	auditArchiveService *service.AuditArchiveService
+}
+
+func NewAuditArchiveHandler(auditArchiveService *service.AuditArchiveService) *AuditArchiveHandler {
+	return &AuditArchiveHandler{
+		auditArchiveService: auditArchiveService,
+	}
+}
+
+func (h *AuditArchiveHandler) Run(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// The body is optional
+	var req models.RunAuditArchiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	olderThanDays := 0
+	if req.OlderThanDays != nil {
+		if *req.OlderThanDays < 1 {
+			utils.RespondError(w, http.StatusBadRequest, "older_than_days must be at least 1")
+			return
+		}
+		olderThanDays = *req.OlderThanDays
+	}
+
+	report, err := h.auditArchiveService.Run(r.Context(), actor, olderThanDays)
+	if err != nil {
+		switch err.Error() {
+		case "audit archiving is not configured":
+			utils.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		case "older_than_days must be at least 1":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case "audit archive run already in progress":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to archive audit logs")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+func (h *AuditArchiveHandler) ListArchives(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	filter := repository.AuditArchiveFilter{Page: page, Limit: limit}
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s, use YYYY-MM-DD", param.name))
+			return
+		}
+		*param.target = &day
+	}
+
+	response, err := h.auditArchiveService.ListArchives(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list audit archives")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// Download streams the archive as stored: gzipped NDJSON, one audit log per line
+func (h *AuditArchiveHandler) Download(w http.ResponseWriter, r *http.Request) {
+	archiveID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid audit archive ID")
+		return
+	}
+
+	archived, body, err := h.auditArchiveService.Download(r.Context(), archiveID)
+	if err != nil {
+		switch err.Error() {
+		case "audit archiving is not configured":
+			utils.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		case "audit archive not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to download audit archive")
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s-%s.ndjson.gz", archived.Day.UTC().Format("2006-01-02"), archived.ID.Hex())
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
@@ -2,19 +2,25 @@ package handler
 
 import (
 	"encoding/json"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"task-management-api/models"
 	"task-management-api/service"
 	"task-management-api/utils"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
+	authService  *service.AuthService
+	loginLimiter *service.LoginLimiter
 }
 
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, loginLimiter *service.LoginLimiter) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:  authService,
+		loginLimiter: loginLimiter,
 	}
 }
 
@@ -41,7 +47,135 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.authService.Login(r.Context(), &req)
+	ip := clientIP(r)
+	if retryAfter := h.loginLimiter.Check(r.Context(), req.Email, ip); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		utils.RespondError(w, http.StatusTooManyRequests, "too many failed login attempts, try again later")
+		return
+	}
+
+	response, err := h.authService.Login(r.Context(), &req, clientInfo(r))
+	if err != nil {
+		if err.Error() == "email not verified" || err.Error() == "account is temporarily locked" {
+			utils.RespondError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "invalid credentials" {
+			h.loginLimiter.RecordFailure(r.Context(), req.Email, ip)
+		}
+		utils.RespondError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	h.loginLimiter.RecordSuccess(r.Context(), req.Email)
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.VerifyEmail(r.Context(), r.URL.Query().Get("token")); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "email verified"})
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req models.ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.authService.ResendVerification(r.Context(), &req); err != nil {
+		if err.Error() == "email is required" {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to send verification email")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "if the account exists and is unverified, a verification email has been sent"})
+}
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.authService.ChangePassword(r.Context(), user, &req); err != nil {
+		if err.Error() == "current password is incorrect" {
+			utils.RespondError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "password has been changed, please log in again"})
+}
+
+func (h *AuthHandler) IssueScopedToken(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateScopedTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.authService.IssueScopedToken(r.Context(), user, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "scopes is required",
+			strings.HasPrefix(err.Error(), "invalid scope"),
+			strings.HasPrefix(err.Error(), "expires_in_hours must be"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to issue token")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, response)
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "password has been reset"})
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.authService.Refresh(r.Context(), &req, clientInfo(r))
 	if err != nil {
 		utils.RespondError(w, http.StatusUnauthorized, err.Error())
 		return
@@ -49,3 +183,41 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	utils.RespondJSON(w, http.StatusOK, response)
 }
+
+func (h *AuthHandler) ListLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	attempts, err := h.loginLimiter.List(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list login attempts")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, attempts)
+}
+
+// ClearLoginAttempts lifts the lockout for ?email= and/or ?ip=
+func (h *AuthHandler) ClearLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if err := h.loginLimiter.Clear(r.Context(), query.Get("email"), query.Get("ip")); err != nil {
+		if err.Error() == "email or ip is required" {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to clear login attempts")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "login attempts cleared"})
+}
+
+func clientInfo(r *http.Request) models.ClientInfo {
+	return models.ClientInfo{IP: clientIP(r), UserAgent: r.UserAgent()}
+}
+
+// clientIP is the connection's remote address; proxies are not trusted
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBillingEventBytes bounds webhook event bodies; subscription events are a few KB
+const maxBillingEventBytes = 256 << 10
+
+type BillingHandler struct {
+	billingService *service.BillingService
+}
+
+func NewBillingHandler(billingService *service.BillingService) *BillingHandler {
+	return &BillingHandler{
+		billingService: billingService,
+	}
+}
+
+// Webhook receives subscription events from a payment provider; they are
+// authenticated by the provider's signature rather than a user's credentials
+func (h *BillingHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBillingEventBytes))
+	if err != nil {
+		utils.RespondError(w, http.StatusRequestEntityTooLarge, "billing event is too large")
+		return
+	}
+
+	if err := h.billingService.HandleEvent(r.Context(), mux.Vars(r)["provider"], r.Header, body); err != nil {
+		switch err.Error() {
+		case "billing provider not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		case "invalid signature", "invalid event body":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to process billing event")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "event received"})
+}
+
+// respondPlanLimitError names the plan, what it lacks and where to upgrade it
+func respondPlanLimitError(w http.ResponseWriter, err *service.PlanLimitError) {
+	utils.RespondErrorDetails(w, http.StatusPaymentRequired, err.Error(), err.Details())
+}
+
+func (h *BillingHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	subscription, err := h.billingService.GetSubscription(r.Context(), user)
+	if err != nil {
+		if err.Error() == "billing is not enabled" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get subscription")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, subscription)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentHandler struct {
+	commentService *service.CommentService
+}
+
+func NewCommentHandler(commentService *service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(r.Context(), taskID, user, &req)
+	if err != nil {
+		if respondTaskAccessError(w, err) {
+			return
+		}
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, comment)
+}
+
+func (h *CommentHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	page, limit := parsePagination(r)
+	response, err := h.commentService.ListComments(r.Context(), taskID, user, repository.CommentFilter{Page: page, Limit: limit})
+	if err != nil {
+		if respondTaskAccessError(w, err) {
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list comments")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	commentID, err := primitive.ObjectIDFromHex(vars["commentId"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	if err := h.commentService.DeleteComment(r.Context(), taskID, commentID, user); err != nil {
+		if respondTaskAccessError(w, err) {
+			return
+		}
+		if err.Error() == "comment not found" {
+			utils.RespondError(w, http.StatusNotFound, "comment not found")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to delete comment")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "comment deleted successfully"})
+}
+
+// respondTaskAccessError writes the 404/403 response for task lookup failures and reports whether it did.
+func respondTaskAccessError(w http.ResponseWriter, err error) bool {
+	switch err.Error() {
+	case "task not found":
+		utils.RespondError(w, http.StatusNotFound, "task not found")
+		return true
+	case "unauthorized access to task":
+		utils.RespondError(w, http.StatusForbidden, "you don't have permission to access this task")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService *service.DeadLetterService
+}
+
+func NewDeadLetterHandler(deadLetterService *service.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// List pages through the dead letters, optionally of one queue
+func (h *DeadLetterHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	filter := repository.DeadLetterFilter{Queue: r.URL.Query().Get("queue"), Page: page, Limit: limit}
+
+	response, err := h.deadLetterService.List(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list dead letters")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *DeadLetterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid dead letter ID")
+		return
+	}
+
+	deadLetter, err := h.deadLetterService.Get(r.Context(), id)
+	if err != nil {
+		if err.Error() == "dead letter not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to find dead letter")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, deadLetter)
+}
+
+// Requeue puts the job back on its queue and removes the dead letter
+func (h *DeadLetterHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid dead letter ID")
+		return
+	}
+
+	result, err := h.deadLetterService.Requeue(r.Context(), actor, id)
+	if err != nil {
+		switch {
+		case err.Error() == "dead letter not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		case strings.HasPrefix(err.Error(), "queue "):
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to requeue dead letter")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
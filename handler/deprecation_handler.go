@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type DeprecationHandler struct {
+	deprecationTracker *service.DeprecationTracker
+}
+
+func NewDeprecationHandler(deprecationTracker *service.DeprecationTracker) *DeprecationHandler {
+	return &DeprecationHandler{
+		deprecationTracker: deprecationTracker,
+	}
+}
+
+// Report lists the deprecated parts of the API and the clients still using them
+func (h *DeprecationHandler) Report(w http.ResponseWriter, r *http.Request) {
+	report, err := h.deprecationTracker.Report(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to build deprecation report")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"task-management-api/models"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+var pathVariablePattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Routes that are reachable without a bearer token
+var publicRoutes = map[string]bool{
+	"/register":                      true,
+	"/login":                         true,
+	"/auth/reset-password":           true,
+	"/auth/refresh":                  true,
+	"/auth/verify":                   true,
+	"/auth/google":                   true,
+	"/auth/google/callback":          true,
+	"/auth/github":                   true,
+	"/auth/github/callback":          true,
+	"/auth/oidc":                     true,
+	"/auth/oidc/callback":            true,
+	"/auth/verify/resend":            true,
+	"/health":                        true,
+	"/version":                       true,
+	"/.well-known/jwks.json":         true,
+	"/docs/postman.json":             true,
+	"/docs/postman-environment.json": true,
+	"/openapi.json":                  true,
+	"/docs":                          true,
+	"/meta/errors":                   true,
+	"/webhooks/schemas":              true,
+	"/sandbox/reset":                 true,
+	"/sandbox/time":                  true,
+}
+
+// currentAPIPrefix is where the documented version of the API is mounted
+const currentAPIPrefix = "/api/v1"
+
+// Example request bodies keyed by "METHOD path-template"
+var exampleBodies = map[string]interface{}{
+	"POST /register": map[string]string{
+		"email":    "user@example.com",
+		"username": "johndoe",
+		"password": "password123",
+	},
+	"POST /login": map[string]string{
+		"email":    "user@example.com",
+		"password": "password123",
+	},
+	"POST /auth/refresh": map[string]string{
+		"refresh_token": "<refresh-token>",
+	},
+	"POST /auth/reset-password": map[string]string{
+		"token":        "<reset-token>",
+		"new_password": "newpassword123",
+	},
+	"POST /auth/change-password": map[string]string{
+		"current_password": "password123",
+		"new_password":     "newpassword123",
+	},
+	"POST /auth/verify/resend": map[string]string{
+		"email": "user@example.com",
+	},
+	"PUT /me": map[string]interface{}{
+		"username":    "johnny",
+		"email":       "johnny@example.com",
+		"preferences": map[string]bool{"achievements_opt_out": false},
+	},
+	"PUT /admin/field-policy/tasks": map[string]interface{}{
+		"fields": map[string][]string{"due_date": {"admin"}, "recurrence": {"admin"}},
+	},
+	"PUT /admin/users/{id}/permissions": map[string]interface{}{
+		"permissions": []string{"tasks:read_all", "system:read"},
+	},
+	"POST /admin/worker/simulate": map[string]string{
+		"task_id": "507f1f77bcf86cd799439011",
+	},
+	"POST /me/tokens": map[string]interface{}{
+		"scopes":           []string{"tasks:read"},
+		"expires_in_hours": 24,
+	},
+	"POST /me/api-keys": map[string]interface{}{
+		"name":   "nightly sync",
+		"scopes": []string{"tasks:read", "tasks:write"},
+	},
+	"POST /me/webhooks": map[string]interface{}{
+		"url":    "https://example.com/hooks/tasks",
+		"events": []string{"task.created", "task.completed", "task.deleted"},
+	},
+	"PATCH /me/webhooks/{id}": map[string]int{
+		"schema_version": 1,
+	},
+	"DELETE /me": map[string]string{
+		"password": "password123",
+		"tasks":    "delete",
+	},
+	"PUT /me/private-passphrase": map[string]string{
+		"passphrase": "correct horse battery staple",
+	},
+	"POST /tasks": map[string]interface{}{
+		"title":       "Complete assignment",
+		"description": "Finish the Go REST API",
+		"status":      "pending",
+	},
+	"POST /tasks/import/todoist": map[string]string{
+		"api_token": "0123456789abcdef0123456789abcdef01234567",
+	},
+	"POST /exports": map[string]string{
+		"format": "csv",
+	},
+	"POST /admin/users/{id}/reassign-tasks": map[string]string{
+		"to": "unassigned",
+	},
+	"PUT /admin/users/{id}/legal-hold": map[string]interface{}{
+		"enabled": true,
+		"reason":  "Litigation 2024-17",
+	},
+	"PUT /admin/tasks/{id}/legal-hold": map[string]interface{}{
+		"enabled": true,
+		"reason":  "Litigation 2024-17",
+	},
+	"PUT /admin/retention": map[string]int{
+		"completed_task_days": 180,
+		"audit_log_days":      365,
+		"grace_days":          7,
+	},
+	"POST /admin/audit-archives/run": map[string]int{
+		"older_than_days": 90,
+	},
+	"PATCH /tasks/{id}": map[string]interface{}{
+		"status": "completed",
+	},
+	"DELETE /tasks": map[string]interface{}{
+		"status": "completed",
+	},
+	"POST /tasks/{id}/schedule": map[string]string{
+		"date": "2024-05-21",
+	},
+	"POST /tasks/{id}/pomodoros": map[string]int{
+		"minutes": 25,
+	},
+	"POST /tasks/{id}/comments": map[string]string{
+		"body": "Waiting on review",
+	},
+}
+
+type DocsHandler struct {
+	router *mux.Router
+}
+
+func NewDocsHandler(router *mux.Router) *DocsHandler {
+	return &DocsHandler{
+		router: router,
+	}
+}
+
+func (h *DocsHandler) PostmanCollection(w http.ResponseWriter, r *http.Request) {
+	routes, err := h.documentedRoutes()
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to build collection")
+		return
+	}
+
+	var items []map[string]interface{}
+	for _, route := range routes {
+		items = append(items, postmanItem(route.method, route.path))
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"info": map[string]string{
+			"name":   "Task Management API",
+			"schema": postmanSchema,
+		},
+		"auth": map[string]interface{}{
+			"type": "bearer",
+			"bearer": []map[string]string{
+				{"key": "token", "value": "{{token}}", "type": "string"},
+			},
+		},
+		"variable": []map[string]string{
+			{"key": "baseUrl", "value": requestBaseURL(r)},
+			{"key": "token", "value": ""},
+		},
+		"item": items,
+	})
+}
+
+func (h *DocsHandler) PostmanEnvironment(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"name": "Task Management API",
+		"values": []map[string]interface{}{
+			{"key": "baseUrl", "value": requestBaseURL(r), "type": "default", "enabled": true},
+			{"key": "token", "value": "", "type": "secret", "enabled": true},
+		},
+		"_postman_variable_scope": "environment",
+	})
+}
+
+func postmanItem(method, path string) map[string]interface{} {
+	// Postman uses :name for path variables
+	postmanPath := pathVariablePattern.ReplaceAllString(path, ":$1")
+
+	variables := []map[string]string{}
+	for _, match := range pathVariablePattern.FindAllStringSubmatch(path, -1) {
+		variables = append(variables, map[string]string{"key": match[1], "value": ""})
+	}
+
+	request := map[string]interface{}{
+		"method": method,
+		"url": map[string]interface{}{
+			"raw":      "{{baseUrl}}" + postmanPath,
+			"host":     []string{"{{baseUrl}}"},
+			"path":     strings.Split(strings.TrimPrefix(postmanPath, "/"), "/"),
+			"variable": variables,
+		},
+	}
+
+	if publicRoutes[routeKey(path)] {
+		request["auth"] = map[string]string{"type": "noauth"}
+	}
+
+	if body, ok := exampleBodies[method+" "+routeKey(path)]; ok {
+		raw, _ := json.MarshalIndent(body, "", "  ")
+		request["header"] = []map[string]string{{"key": "Content-Type", "value": "application/json"}}
+		request["body"] = map[string]interface{}{
+			"mode": "raw",
+			"raw":  string(raw),
+		}
+	}
+
+	return map[string]interface{}{
+		"name":    method + " " + path,
+		"request": request,
+	}
+}
+
+type documentedRoute struct {
+	method string
+	path   string
+}
+
+// documentedRoutes lists the registered routes, leaving out the deprecated
+// unprefixed aliases of versioned routes.
+func (h *DocsHandler) documentedRoutes() ([]documentedRoute, error) {
+	var routes []documentedRoute
+	registered := make(map[string]bool)
+
+	err := h.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		// Subrouter prefixes have no methods of their own
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			routes = append(routes, documentedRoute{method: method, path: path})
+			registered[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	documented := routes[:0]
+	for _, route := range routes {
+		if !registered[route.method+" "+currentAPIPrefix+route.path] {
+			documented = append(documented, route)
+		}
+	}
+	return documented, nil
+}
+
+// routeKey strips the version prefix; the docs tables are keyed by unversioned path
+func routeKey(path string) string {
+	return strings.TrimPrefix(path, currentAPIPrefix)
+}
+
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// ErrorCatalog lists every error code the API can return
+func (h *DocsHandler) ErrorCatalog(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, models.ErrorCatalogResponse{Errors: models.ErrorCatalog})
+}
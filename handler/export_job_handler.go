@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var exportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json",
+}
+
+type ExportJobHandler struct {
+	exportJobService *service.ExportJobService
+}
+
+func NewExportJobHandler(exportJobService *service.ExportJobService) *ExportJobHandler {
+	return &ExportJobHandler{
+		exportJobService: exportJobService,
+	}
+}
+
+// StartExport answers 202 Accepted with the queued job; its Location header
+// is where the job's status, and then its download link, are read
+func (h *ExportJobHandler) StartExport(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	job, err := h.exportJobService.StartExport(r.Context(), user, &req)
+	if err != nil {
+		switch err.Error() {
+		case "invalid format, must be one of: csv, json":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case "invalid private passphrase", "private passphrase is not set up":
+			respondPrivatePassphraseError(w, err)
+		case "an export is already running":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to start export")
+		}
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+job.ID.Hex())
+	utils.RespondJSON(w, http.StatusAccepted, job)
+}
+
+func (h *ExportJobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	user, jobID, ok := h.jobRequest(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.exportJobService.GetJob(r.Context(), user, jobID)
+	if err != nil {
+		respondExportJobError(w, err)
+		return
+	}
+	if job.Status == models.ExportJobStatusCompleted {
+		job.DownloadURL = r.URL.Path + "/download"
+	}
+
+	utils.RespondJSON(w, http.StatusOK, job)
+}
+
+func (h *ExportJobHandler) Download(w http.ResponseWriter, r *http.Request) {
+	user, jobID, ok := h.jobRequest(w, r)
+	if !ok {
+		return
+	}
+
+	job, data, err := h.exportJobService.Download(r.Context(), user, jobID)
+	if err != nil {
+		respondExportJobError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[job.Format])
+	w.Header().Set("Content-Disposition", `attachment; filename="tasks-`+job.CreatedAt.UTC().Format("2006-01-02")+`.`+job.Format+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h *ExportJobHandler) jobRequest(w http.ResponseWriter, r *http.Request) (*models.User, primitive.ObjectID, bool) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, primitive.NilObjectID, false
+	}
+
+	jobID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid export job ID")
+		return nil, primitive.NilObjectID, false
+	}
+	return user, jobID, true
+}
+
+func respondExportJobError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "export job not found", "export file not found":
+		utils.RespondError(w, http.StatusNotFound, "export job not found")
+	case "export is not ready":
+		utils.RespondError(w, http.StatusConflict, err.Error())
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get export job")
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type FieldPolicyHandler struct {
+	fieldPolicyService *service.FieldPolicyService
+}
+
+func NewFieldPolicyHandler(fieldPolicyService *service.FieldPolicyService) *FieldPolicyHandler {
+	return &FieldPolicyHandler{
+		fieldPolicyService: fieldPolicyService,
+	}
+}
+
+func (h *FieldPolicyHandler) GetTaskPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.fieldPolicyService.GetTaskPolicy(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get field policy")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, policy)
+}
+
+func (h *FieldPolicyHandler) UpdateTaskPolicy(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateTaskFieldPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	policy, err := h.fieldPolicyService.UpdateTaskPolicy(r.Context(), actor, &req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid field") || strings.HasPrefix(err.Error(), "invalid role") {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to update field policy")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, policy)
+}
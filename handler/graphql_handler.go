@@ -0,0 +1,592 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxGraphQLBytes bounds a GraphQL request body; queries are small
+const maxGraphQLBytes = 64 << 10
+
+// GraphQLHandler serves the tasks over GraphQL. Resolvers call the same
+// services as the REST routes, so the same rules, events and history apply,
+// and their errors carry the REST error code and status in extensions.
+type GraphQLHandler struct {
+	taskService *service.TaskService
+	taskQueries *service.TaskQueryService
+	authService *service.AuthService
+	schema      graphql.Schema
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLResponse documents the body Serve writes, which is a graphql.Result
+type graphQLResponse struct {
+	Data   map[string]interface{}   `json:"data"`
+	Errors []map[string]interface{} `json:"errors,omitempty"`
+}
+
+// graphQLError is a resolver error, reported with the code and status the
+// REST route would have answered with
+type graphQLError struct {
+	status  int
+	message string
+	details map[string]interface{}
+}
+
+func (e *graphQLError) Error() string {
+	return e.message
+}
+
+func (e *graphQLError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"code":   models.ErrorCode(e.status, e.message),
+		"status": e.status,
+	}
+	for key, value := range e.details {
+		extensions[key] = value
+	}
+	return extensions
+}
+
+func newGraphQLError(status int, message string) *graphQLError {
+	return &graphQLError{status: status, message: message}
+}
+
+func NewGraphQLHandler(taskService *service.TaskService, taskQueries *service.TaskQueryService, authService *service.AuthService) (*GraphQLHandler, error) {
+	h := &GraphQLHandler{
+		taskService: taskService,
+		taskQueries: taskQueries,
+		authService: authService,
+	}
+	schema, err := h.newSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GraphQL schema: %w", err)
+	}
+	h.schema = schema
+	return h, nil
+}
+
+// Serve runs a GraphQL query or mutation. Errors of single fields are
+// returned next to the data with status 200, as GraphQL clients expect.
+func (h *GraphQLHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	if _, err := service.GetUserFromContext(r.Context()); err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req graphQLRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBytes)).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Query == "" {
+		utils.RespondError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        r.Context(),
+	})
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+func (h *GraphQLHandler) newSchema() (graphql.Schema, error) {
+	taskStatus := graphql.NewEnum(graphql.EnumConfig{
+		Name: "TaskStatus",
+		Values: graphql.EnumValueConfigMap{
+			"pending":     {Value: string(models.TaskStatusPending)},
+			"in_progress": {Value: string(models.TaskStatusInProgress)},
+			"completed":   {Value: string(models.TaskStatusCompleted)},
+		},
+	})
+	taskPriority := graphql.NewEnum(graphql.EnumConfig{
+		Name: "TaskPriority",
+		Values: graphql.EnumValueConfigMap{
+			"low":    {Value: string(models.TaskPriorityLow)},
+			"medium": {Value: string(models.TaskPriorityMedium)},
+			"high":   {Value: string(models.TaskPriorityHigh)},
+		},
+	})
+	subtaskDeleteMode := graphql.NewEnum(graphql.EnumConfig{
+		Name: "SubtaskDeleteMode",
+		Values: graphql.EnumValueConfigMap{
+			"cascade": {Value: string(models.SubtaskDeleteCascade)},
+			"orphan":  {Value: string(models.SubtaskDeleteOrphan)},
+		},
+	})
+
+	// Fields are named as in the REST responses; times are RFC 3339 strings in UTC
+	task := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Task",
+		Fields: graphql.Fields{
+			"id":                 taskField(graphql.NewNonNull(graphql.ID), func(t *models.Task) interface{} { return t.ID.Hex() }),
+			"user_id":            taskField(graphql.NewNonNull(graphql.ID), func(t *models.Task) interface{} { return t.UserID.Hex() }),
+			"parent_id":          taskField(graphql.ID, func(t *models.Task) interface{} { return hexOrNil(t.ParentID) }),
+			"blocked_by":         taskField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID))), func(t *models.Task) interface{} { return hexes(t.BlockedBy) }),
+			"title":              taskField(graphql.NewNonNull(graphql.String), func(t *models.Task) interface{} { return t.Title }),
+			"description":        taskField(graphql.NewNonNull(graphql.String), func(t *models.Task) interface{} { return t.Description }),
+			"status":             taskField(graphql.NewNonNull(taskStatus), func(t *models.Task) interface{} { return string(t.Status) }),
+			"priority":           taskField(taskPriority, func(t *models.Task) interface{} { return stringOrNil(string(t.Priority)) }),
+			"due_date":           taskField(graphql.String, func(t *models.Task) interface{} { return timeOrNil(t.DueDate) }),
+			"recurrence":         taskField(graphql.String, func(t *models.Task) interface{} { return stringOrNil(t.Recurrence) }),
+			"next_occurrence_id": taskField(graphql.ID, func(t *models.Task) interface{} { return hexOrNil(t.NextOccurrenceID) }),
+			"archived":           taskField(graphql.NewNonNull(graphql.Boolean), func(t *models.Task) interface{} { return t.Archived }),
+			"purge_at":           taskField(graphql.String, func(t *models.Task) interface{} { return timeOrNil(t.PurgeAt) }),
+			"created_at":         taskField(graphql.NewNonNull(graphql.String), func(t *models.Task) interface{} { return models.FormatTime(t.CreatedAt) }),
+			"updated_at":         taskField(graphql.NewNonNull(graphql.String), func(t *models.Task) interface{} { return models.FormatTime(t.UpdatedAt) }),
+			"private":            taskField(graphql.NewNonNull(graphql.Boolean), func(t *models.Task) interface{} { return t.Private }),
+		},
+	})
+
+	taskPage := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaskPage",
+		Fields: graphql.Fields{
+			"tasks":       pageField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(task))), func(p *models.TaskListResponse) interface{} { return p.Tasks }),
+			"page":        pageField(graphql.NewNonNull(graphql.Int), func(p *models.TaskListResponse) interface{} { return p.Page }),
+			"limit":       pageField(graphql.NewNonNull(graphql.Int), func(p *models.TaskListResponse) interface{} { return p.Limit }),
+			"total_count": pageField(graphql.NewNonNull(graphql.Int), func(p *models.TaskListResponse) interface{} { return int64(p.TotalCount) }),
+			"total_pages": pageField(graphql.NewNonNull(graphql.Int), func(p *models.TaskListResponse) interface{} { return p.TotalPages }),
+		},
+	})
+
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":                userField(graphql.NewNonNull(graphql.ID), func(u *models.User) interface{} { return u.ID.Hex() }),
+			"email":             userField(graphql.NewNonNull(graphql.String), func(u *models.User) interface{} { return u.Email }),
+			"username":          userField(graphql.NewNonNull(graphql.String), func(u *models.User) interface{} { return u.Username }),
+			"role":              userField(graphql.NewNonNull(graphql.String), func(u *models.User) interface{} { return string(u.Role) }),
+			"email_verified_at": userField(graphql.String, func(u *models.User) interface{} { return timeOrNil(u.EmailVerifiedAt) }),
+			"created_at":        userField(graphql.NewNonNull(graphql.String), func(u *models.User) interface{} { return models.FormatTime(u.CreatedAt) }),
+		},
+	})
+
+	// The filter and pagination arguments of GET /tasks
+	listArgs := graphql.FieldConfigArgument{
+		"status":           {Type: taskStatus},
+		"include_archived": {Type: graphql.Boolean, DefaultValue: false},
+		"page":             {Type: graphql.Int, DefaultValue: 1},
+		"limit":            {Type: graphql.Int, DefaultValue: 10},
+	}
+	subtaskArgs := graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}}
+	for name, arg := range listArgs {
+		subtaskArgs[name] = arg
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type:    graphql.NewNonNull(user),
+				Resolve: h.me,
+			},
+			"task": &graphql.Field{
+				Type:    graphql.NewNonNull(task),
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: h.task,
+			},
+			"tasks": &graphql.Field{
+				Type:    graphql.NewNonNull(taskPage),
+				Args:    listArgs,
+				Resolve: h.tasks,
+			},
+			"subtasks": &graphql.Field{
+				Type:    graphql.NewNonNull(taskPage),
+				Args:    subtaskArgs,
+				Resolve: h.subtasks,
+			},
+		},
+	})
+
+	// Inputs take the members of the REST request bodies
+	createInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateTaskInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       {Type: graphql.NewNonNull(graphql.String)},
+			"description": {Type: graphql.String},
+			"status":      {Type: taskStatus},
+			"priority":    {Type: taskPriority},
+			"parent_id":   {Type: graphql.ID},
+			"blocked_by":  {Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+			"due_date":    {Type: graphql.String},
+			"recurrence":  {Type: graphql.String},
+			"private":     {Type: graphql.Boolean},
+		},
+	})
+	updateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdateTaskInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       {Type: graphql.String},
+			"description": {Type: graphql.String},
+			"status":      {Type: taskStatus},
+			"priority":    {Type: taskPriority},
+			"blocked_by":  {Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+			"due_date":    {Type: graphql.String},
+			// GraphQL inputs cannot tell an explicit null from an omitted field
+			"clear_due_date": {Type: graphql.Boolean},
+			"recurrence":     {Type: graphql.String},
+			"private":        {Type: graphql.Boolean},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTask": &graphql.Field{
+				Type:    graphql.NewNonNull(task),
+				Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(createInput)}},
+				Resolve: h.createTask,
+			},
+			"updateTask": &graphql.Field{
+				Type: graphql.NewNonNull(task),
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: graphql.NewNonNull(graphql.ID)},
+					"input": {Type: graphql.NewNonNull(updateInput)},
+				},
+				Resolve: h.updateTask,
+			},
+			"deleteTask": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id":       {Type: graphql.NewNonNull(graphql.ID)},
+					"subtasks": {Type: subtaskDeleteMode},
+				},
+				Resolve: h.deleteTask,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// me returns the signed-in user. Like GET /me, it is not available to API
+// keys and scoped tokens.
+func (h *GraphQLHandler) me(p graphql.ResolveParams) (interface{}, error) {
+	user, err := service.GetUserFromContext(p.Context)
+	if err != nil {
+		return nil, newGraphQLError(http.StatusUnauthorized, "unauthorized")
+	}
+	if _, scoped := service.GetScopesFromContext(p.Context); scoped {
+		return nil, newGraphQLError(http.StatusForbidden, "me is not available to API keys or scoped tokens")
+	}
+	return user, nil
+}
+
+func (h *GraphQLHandler) task(p graphql.ResolveParams) (interface{}, error) {
+	user, err := graphQLUser(p, service.ScopeTasksRead)
+	if err != nil {
+		return nil, err
+	}
+	taskID, err := graphQLTaskID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := h.taskQueries.GetTask(p.Context, taskID, user)
+	if err != nil {
+		return nil, taskAccessError(err, "failed to get task")
+	}
+	if err := h.taskQueries.RevealPrivate(p.Context, user, task); err != nil {
+		return nil, privatePassphraseError(err)
+	}
+	return task, nil
+}
+
+func (h *GraphQLHandler) tasks(p graphql.ResolveParams) (interface{}, error) {
+	user, err := graphQLUser(p, service.ScopeTasksRead)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.taskQueries.ListTasks(p.Context, user, graphQLTaskFilter(p.Args))
+	if err != nil {
+		return nil, newGraphQLError(http.StatusInternalServerError, "failed to list tasks")
+	}
+	if err := h.taskQueries.RevealPrivate(p.Context, user, response.Tasks...); err != nil {
+		return nil, privatePassphraseError(err)
+	}
+	return response, nil
+}
+
+func (h *GraphQLHandler) subtasks(p graphql.ResolveParams) (interface{}, error) {
+	user, err := graphQLUser(p, service.ScopeTasksRead)
+	if err != nil {
+		return nil, err
+	}
+	taskID, err := graphQLTaskID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.taskQueries.ListSubtasks(p.Context, taskID, user, graphQLTaskFilter(p.Args))
+	if err != nil {
+		return nil, taskAccessError(err, "failed to list subtasks")
+	}
+	if err := h.taskQueries.RevealPrivate(p.Context, user, response.Tasks...); err != nil {
+		return nil, privatePassphraseError(err)
+	}
+	return response, nil
+}
+
+func (h *GraphQLHandler) createTask(p graphql.ResolveParams) (interface{}, error) {
+	user, err := graphQLUser(p, service.ScopeTasksWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.authService.CheckVerifiedEmail(user); err != nil {
+		return nil, newGraphQLError(http.StatusForbidden, err.Error())
+	}
+
+	var req models.CreateTaskRequest
+	if err := decodeGraphQLInput(p.Args["input"], &req); err != nil {
+		return nil, err
+	}
+
+	task, err := h.taskService.CreateTask(p.Context, user, &req)
+	if err != nil {
+		var limitErr *service.PlanLimitError
+		switch {
+		case err.Error() == "invalid private passphrase", err.Error() == "only the owner can change a private task":
+			return nil, newGraphQLError(http.StatusForbidden, err.Error())
+		case errors.As(err, &limitErr):
+			return nil, &graphQLError{status: http.StatusPaymentRequired, message: err.Error(), details: limitErr.Details()}
+		default:
+			return nil, newGraphQLError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if err := h.taskQueries.RevealPrivate(p.Context, user, task); err != nil {
+		return nil, privatePassphraseError(err)
+	}
+	return task, nil
+}
+
+func (h *GraphQLHandler) updateTask(p graphql.ResolveParams) (interface{}, error) {
+	user, err := graphQLUser(p, service.ScopeTasksWrite)
+	if err != nil {
+		return nil, err
+	}
+	taskID, err := graphQLTaskID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	input, _ := p.Args["input"].(map[string]interface{})
+	clearDueDate, _ := input["clear_due_date"].(bool)
+	if clearDueDate && input["due_date"] != nil {
+		return nil, newGraphQLError(http.StatusBadRequest, "set either due_date or clear_due_date")
+	}
+	var req models.UpdateTaskRequest
+	if err := decodeGraphQLInput(input, &req); err != nil {
+		return nil, err
+	}
+	if clearDueDate {
+		req.DueDate = models.Nullable[time.Time]{Set: true}
+	}
+
+	task, err := h.taskService.UpdateTask(p.Context, taskID, user, &req)
+	if err != nil {
+		var policyErr *service.FieldPolicyError
+		if errors.As(err, &policyErr) {
+			return nil, &graphQLError{
+				status:  http.StatusForbidden,
+				message: "you don't have permission to change some fields of this task",
+				details: map[string]interface{}{"blocked_fields": policyErr.Fields},
+			}
+		}
+		switch err.Error() {
+		case "task not found":
+			return nil, newGraphQLError(http.StatusNotFound, "task not found")
+		case "unauthorized to update this task":
+			return nil, newGraphQLError(http.StatusForbidden, "you don't have permission to update this task")
+		case "invalid private passphrase", "only the owner can change a private task":
+			return nil, newGraphQLError(http.StatusForbidden, err.Error())
+		case "task has been modified":
+			return nil, newGraphQLError(http.StatusConflict, "task was modified by another request, retry")
+		case "task has open subtasks":
+			return nil, newGraphQLError(http.StatusConflict, "task cannot be completed while it has open subtasks")
+		case "task is blocked by open tasks":
+			return nil, newGraphQLError(http.StatusConflict, "task cannot be started or completed while its blockers are open")
+		default:
+			return nil, newGraphQLError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if err := h.taskQueries.RevealPrivate(p.Context, user, task); err != nil {
+		return nil, privatePassphraseError(err)
+	}
+	return task, nil
+}
+
+func (h *GraphQLHandler) deleteTask(p graphql.ResolveParams) (interface{}, error) {
+	user, err := graphQLUser(p, service.ScopeTasksWrite)
+	if err != nil {
+		return nil, err
+	}
+	taskID, err := graphQLTaskID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	subtaskMode, _ := p.Args["subtasks"].(string)
+	if err := h.taskService.DeleteTask(p.Context, taskID, user, models.SubtaskDeleteMode(subtaskMode)); err != nil {
+		switch err.Error() {
+		case "task not found":
+			return nil, newGraphQLError(http.StatusNotFound, "task not found")
+		case "unauthorized to delete this task":
+			return nil, newGraphQLError(http.StatusForbidden, "you don't have permission to delete this task")
+		case "task has subtasks, specify subtasks=cascade or subtasks=orphan", "task is under legal hold":
+			return nil, newGraphQLError(http.StatusConflict, err.Error())
+		default:
+			return nil, newGraphQLError(http.StatusInternalServerError, "failed to delete task")
+		}
+	}
+	return true, nil
+}
+
+// graphQLUser returns the signed-in user once the request's credential is
+// found to carry scope
+func graphQLUser(p graphql.ResolveParams, scope string) (*models.User, error) {
+	user, err := service.GetUserFromContext(p.Context)
+	if err != nil {
+		return nil, newGraphQLError(http.StatusUnauthorized, "unauthorized")
+	}
+	if err := service.CheckScope(p.Context, scope); err != nil {
+		return nil, newGraphQLError(http.StatusForbidden, err.Error())
+	}
+	return user, nil
+}
+
+func graphQLTaskID(arg interface{}) (primitive.ObjectID, error) {
+	id, _ := arg.(string)
+	taskID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, newGraphQLError(http.StatusBadRequest, "invalid task ID")
+	}
+	return taskID, nil
+}
+
+// graphQLTaskFilter reads the list arguments; like the query parameters of
+// GET /tasks, a page below 1 or a limit outside 1 to 100 falls back to the default
+func graphQLTaskFilter(args map[string]interface{}) repository.TaskFilter {
+	filter := repository.TaskFilter{Page: 1, Limit: 10}
+	if page, ok := args["page"].(int); ok && page > 0 {
+		filter.Page = page
+	}
+	if limit, ok := args["limit"].(int); ok && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+	filter.IncludeArchived, _ = args["include_archived"].(bool)
+	if status, ok := args["status"].(string); ok {
+		taskStatus := models.TaskStatus(status)
+		filter.Status = &taskStatus
+	}
+	return filter
+}
+
+// decodeGraphQLInput fills a REST request body from an input object, so both
+// APIs decode and validate fields alike
+func decodeGraphQLInput(input interface{}, req interface{}) error {
+	fields, _ := input.(map[string]interface{})
+	body := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if name != "clear_due_date" {
+			body[name] = value
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err == nil {
+		err = json.Unmarshal(data, req)
+	}
+	if err != nil {
+		return newGraphQLError(http.StatusBadRequest, "invalid input, due_date must be an RFC 3339 time")
+	}
+	return nil
+}
+
+func taskAccessError(err error, fallback string) error {
+	switch err.Error() {
+	case "task not found":
+		return newGraphQLError(http.StatusNotFound, "task not found")
+	case "unauthorized access to task":
+		return newGraphQLError(http.StatusForbidden, "you don't have permission to access this task")
+	default:
+		return newGraphQLError(http.StatusInternalServerError, fallback)
+	}
+}
+
+func privatePassphraseError(err error) error {
+	switch err.Error() {
+	case "invalid private passphrase":
+		return newGraphQLError(http.StatusForbidden, err.Error())
+	case "private passphrase is not set up":
+		return newGraphQLError(http.StatusBadRequest, err.Error())
+	default:
+		return newGraphQLError(http.StatusInternalServerError, "failed to decrypt private tasks")
+	}
+}
+
+func taskField(typ graphql.Output, value func(*models.Task) interface{}) *graphql.Field {
+	return &graphql.Field{Type: typ, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return value(p.Source.(*models.Task)), nil
+	}}
+}
+
+func pageField(typ graphql.Output, value func(*models.TaskListResponse) interface{}) *graphql.Field {
+	return &graphql.Field{Type: typ, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return value(p.Source.(*models.TaskListResponse)), nil
+	}}
+}
+
+func userField(typ graphql.Output, value func(*models.User) interface{}) *graphql.Field {
+	return &graphql.Field{Type: typ, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return value(p.Source.(*models.User)), nil
+	}}
+}
+
+func hexOrNil(id *primitive.ObjectID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return id.Hex()
+}
+
+func hexes(ids []primitive.ObjectID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.Hex()
+	}
+	return out
+}
+
+func stringOrNil(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func timeOrNil(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return models.FormatTime(*t)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestGraphQLSchemaRejectsUnknownFields(t *testing.T) {
+	h, err := NewGraphQLHandler(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewGraphQLHandler: %v", err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        h.schema,
+		RequestString: `{ tasks(status: done) { tasks { id } } }`,
+		Context:       context.Background(),
+	})
+	if len(result.Errors) == 0 {
+		t.Fatalf("query with an unknown status succeeded: %+v", result.Data)
+	}
+}
+
+func TestGraphQLErrorsCarryRESTCodes(t *testing.T) {
+	h, err := NewGraphQLHandler(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewGraphQLHandler: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "query", query: `{ tasks(status: pending, limit: 5) { total_count tasks { id title due_date } } }`},
+		{name: "mutation", query: `mutation { deleteTask(id: "65000000000000000000000a", subtasks: cascade) }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without a signed-in user every resolver answers 401
+			result := graphql.Do(graphql.Params{Schema: h.schema, RequestString: tt.query, Context: context.Background()})
+			if len(result.Errors) != 1 {
+				t.Fatalf("errors = %+v, want one", result.Errors)
+			}
+
+			body, err := json.Marshal(result.Errors[0])
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var got struct {
+				Message    string `json:"message"`
+				Extensions struct {
+					Code   string `json:"code"`
+					Status int    `json:"status"`
+				} `json:"extensions"`
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("unmarshal %s: %v", body, err)
+			}
+			if got.Message != "unauthorized" || got.Extensions.Status != 401 || got.Extensions.Code == "" {
+				t.Errorf("error = %s, want unauthorized with status 401 and a code", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ImportJobHandler struct {
+	importJobService *service.ImportJobService
+}
+
+func NewImportJobHandler(importJobService *service.ImportJobService) *ImportJobHandler {
+	return &ImportJobHandler{
+		importJobService: importJobService,
+	}
+}
+
+// StartTodoistImport answers 202 Accepted with the queued job; its Location
+// header is where the job's progress is read
+func (h *ImportJobHandler) StartTodoistImport(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.StartTodoistImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	job, err := h.importJobService.StartTodoistImport(r.Context(), user, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "api_token is required", err.Error() == "invalid Todoist API token":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case err.Error() == "an import is already running":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case strings.HasPrefix(err.Error(), "failed to reach Todoist"), strings.HasPrefix(err.Error(), "failed to read Todoist"):
+			utils.RespondError(w, http.StatusBadGateway, "failed to reach Todoist")
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to start import")
+		}
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/todoist")+"/jobs/"+job.ID.Hex())
+	utils.RespondJSON(w, http.StatusAccepted, job)
+}
+
+func (h *ImportJobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	jobID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid import job ID")
+		return
+	}
+
+	job, err := h.importJobService.GetJob(r.Context(), user, jobID)
+	if err != nil {
+		if err.Error() == "import job not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get import job")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, job)
+}
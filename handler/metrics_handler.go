@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+	"task-management-api/database"
+	"task-management-api/outbound"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type MetricsHandler struct {
+	sloTracker *service.SLOTracker
+	outbound   *outbound.Client
+	reads      *database.ReadMetrics
+}
+
+func NewMetricsHandler(sloTracker *service.SLOTracker, outboundClient *outbound.Client, readMetrics *database.ReadMetrics) *MetricsHandler {
+	return &MetricsHandler{
+		sloTracker: sloTracker,
+		outbound:   outboundClient,
+		reads:      readMetrics,
+	}
+}
+
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(h.sloTracker.PrometheusMetrics()))
+	w.Write([]byte(h.outbound.PrometheusMetrics()))
+	w.Write([]byte(h.reads.PrometheusMetrics()))
+}
+
+func (h *MetricsHandler) SLOReport(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, h.sloTracker.Report())
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MyDayHandler struct {
+	myDayService *service.MyDayService
+}
+
+func NewMyDayHandler(myDayService *service.MyDayService) *MyDayHandler {
+	return &MyDayHandler{
+		myDayService: myDayService,
+	}
+}
+
+func (h *MyDayHandler) ListMyDay(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	response, err := h.myDayService.List(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list my day")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *MyDayHandler) AddToMyDay(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	entry, err := h.myDayService.Add(r.Context(), user, taskID)
+	if err != nil {
+		switch err.Error() {
+		case "task not found":
+			utils.RespondError(w, http.StatusNotFound, "task not found")
+		case "unauthorized access to task":
+			utils.RespondError(w, http.StatusForbidden, "you don't have permission to access this task")
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to add task to my day")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, entry)
+}
+
+func (h *MyDayHandler) RemoveFromMyDay(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	if err := h.myDayService.Remove(r.Context(), user, taskID); err != nil {
+		if err.Error() == "task is not in my day" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to remove task from my day")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "task removed from my day"})
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxTelegramUpdateBytes bounds bot updates; a /start message is well under 1KB
+const maxTelegramUpdateBytes = 64 << 10
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+func (h *NotificationHandler) CreateChannel(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateNotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.notificationService.CreateChannel(r.Context(), user, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "type is required",
+			err.Error() == "slack is required",
+			strings.HasPrefix(err.Error(), "invalid type"),
+			strings.HasPrefix(err.Error(), "invalid notification event"),
+			strings.HasPrefix(err.Error(), "invalid slack"),
+			strings.HasPrefix(err.Error(), "slack needs"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case strings.HasPrefix(err.Error(), "at most"):
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case err.Error() == "telegram notifications are not configured":
+			utils.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to create notification channel")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, response)
+}
+
+func (h *NotificationHandler) ListChannels(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	channels, err := h.notificationService.ListChannels(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list notification channels")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, channels)
+}
+
+func (h *NotificationHandler) DeleteChannel(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	channelID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid notification channel ID")
+		return
+	}
+
+	if err := h.notificationService.DeleteChannel(r.Context(), user, channelID); err != nil {
+		if err.Error() == "notification channel not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to delete notification channel")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "notification channel deleted"})
+}
+
+// TestChannel sends a test message and reports the chat service's answer
+func (h *NotificationHandler) TestChannel(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	channelID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid notification channel ID")
+		return
+	}
+
+	if err := h.notificationService.TestChannel(r.Context(), user, channelID); err != nil {
+		switch {
+		case err.Error() == "notification channel not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		case err.Error() == "notification channel is not linked yet":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case err.Error() == "telegram notifications are not configured":
+			utils.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		case strings.HasPrefix(err.Error(), "test message failed"):
+			utils.RespondError(w, http.StatusBadGateway, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to test notification channel")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "test message sent"})
+}
+
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get notification preferences")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, preferences)
+}
+
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(r.Context(), user, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "channels is required",
+			strings.HasPrefix(err.Error(), "invalid notification channel"),
+			strings.HasPrefix(err.Error(), "invalid notification event"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to update notification preferences")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, preferences)
+}
+
+// PushPublicKey returns the key browsers pass to pushManager.subscribe
+func (h *NotificationHandler) PushPublicKey(w http.ResponseWriter, r *http.Request) {
+	response, err := h.notificationService.PushPublicKey()
+	if err != nil {
+		utils.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *NotificationHandler) CreatePushSubscription(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreatePushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	subscription, err := h.notificationService.SubscribePush(r.Context(), user, &req, r.UserAgent())
+	if err != nil {
+		switch {
+		case err.Error() == "endpoint is required",
+			err.Error() == "subscription has already expired",
+			strings.HasPrefix(err.Error(), "invalid endpoint"),
+			strings.HasPrefix(err.Error(), "invalid keys"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case strings.HasPrefix(err.Error(), "at most"):
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case err.Error() == "push notifications are not configured":
+			utils.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to save push subscription")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, subscription)
+}
+
+func (h *NotificationHandler) ListPushSubscriptions(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	subscriptions, err := h.notificationService.ListPushSubscriptions(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list push subscriptions")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *NotificationHandler) DeletePushSubscription(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	subscriptionID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid push subscription ID")
+		return
+	}
+
+	if err := h.notificationService.DeletePushSubscription(r.Context(), user, subscriptionID); err != nil {
+		if err.Error() == "push subscription not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to delete push subscription")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "push subscription deleted"})
+}
+
+// TelegramWebhook receives updates for the bot. Telegram authenticates them
+// with the secret token the webhook was registered with, not a user's
+// credentials.
+func (h *NotificationHandler) TelegramWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTelegramUpdateBytes))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid update body")
+		return
+	}
+
+	if err := h.notificationService.HandleTelegramUpdate(r.Context(), r.Header.Get("X-Telegram-Bot-Api-Secret-Token"), body); err != nil {
+		switch err.Error() {
+		case "telegram notifications are not configured":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		case "invalid secret token":
+			utils.RespondError(w, http.StatusUnauthorized, err.Error())
+		case "invalid update body":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to process telegram update")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "update received"})
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type NotificationTemplateHandler struct {
+	templateService *service.NotificationTemplateService
+}
+
+func NewNotificationTemplateHandler(templateService *service.NotificationTemplateService) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+func (h *NotificationTemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templateService.List(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list notification templates")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, templates)
+}
+
+func (h *NotificationTemplateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	template, err := h.templateService.Get(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		if err.Error() == "notification template not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get notification template")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, template)
+}
+
+func (h *NotificationTemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateNotificationTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	template, err := h.templateService.Update(r.Context(), actor, mux.Vars(r)["name"], &req)
+	if err != nil {
+		if status, ok := templateErrorStatus(err); ok {
+			utils.RespondError(w, status, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to update notification template")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, template)
+}
+
+func (h *NotificationTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.templateService.Delete(r.Context(), actor, mux.Vars(r)["name"]); err != nil {
+		if err.Error() == "notification template not found" || err.Error() == "custom notification template not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to delete notification template")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "notification template reset to default"})
+}
+
+func (h *NotificationTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateNotificationTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	preview, err := h.templateService.Preview(mux.Vars(r)["name"], &req)
+	if err != nil {
+		if status, ok := templateErrorStatus(err); ok {
+			utils.RespondError(w, status, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to preview notification template")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, preview)
+}
+
+// templateErrorStatus maps the errors a submitted template can cause
+func templateErrorStatus(err error) (int, bool) {
+	switch {
+	case err.Error() == "notification template not found":
+		return http.StatusNotFound, true
+	case err.Error() == "subject and body are required", strings.HasPrefix(err.Error(), "invalid template:"):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
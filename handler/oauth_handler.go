@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+const oauthStateCookie = "oauth_state"
+
+type OAuthHandler struct {
+	authService *service.AuthService
+}
+
+func NewOAuthHandler(authService *service.AuthService) *OAuthHandler {
+	return &OAuthHandler{
+		authService: authService,
+	}
+}
+
+// Login returns the handler that redirects to the provider's consent screen,
+// binding the flow to the browser with a state cookie.
+func (h *OAuthHandler) Login(provider service.OAuthProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.login(w, r, provider)
+	}
+}
+
+// Callback returns the handler for the provider's redirect back with an authorization code.
+func (h *OAuthHandler) Callback(provider service.OAuthProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.callback(w, r, provider)
+	}
+}
+
+func (h *OAuthHandler) login(w http.ResponseWriter, r *http.Request, provider service.OAuthProvider) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+	state := base64.RawURLEncoding.EncodeToString(buf)
+
+	// Scoped to the login path, which prefixes the callback under every API version
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     r.URL.Path,
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, provider.AuthCodeURL(state), http.StatusFound)
+}
+
+func (h *OAuthHandler) callback(w http.ResponseWriter, r *http.Request, provider service.OAuthProvider) {
+	query := r.URL.Query()
+	if errParam := query.Get("error"); errParam != "" {
+		utils.RespondError(w, http.StatusUnauthorized, "login was not completed: "+errParam)
+		return
+	}
+
+	cookie, err := r.Cookie(oauthStateCookie)
+	state := query.Get("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		utils.RespondError(w, http.StatusBadRequest, "invalid oauth state")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: strings.TrimSuffix(r.URL.Path, "/callback"), MaxAge: -1})
+
+	code := query.Get("code")
+	if code == "" {
+		utils.RespondError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	profile, err := provider.Exchange(r.Context(), code)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadGateway, "failed to verify "+provider.Name()+" login")
+		return
+	}
+
+	response, err := h.authService.LoginWithOAuth(r.Context(), profile, clientInfo(r))
+	if err != nil {
+		switch err.Error() {
+		case "user with this email already exists":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case "email not verified":
+			utils.RespondError(w, http.StatusForbidden, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to log in")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
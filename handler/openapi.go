@@ -0,0 +1,455 @@
+package handler
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-management-api/config"
+	"task-management-api/models"
+	"task-management-api/utils"
+	"task-management-api/version"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiOperation documents one route. Request and response are zero values of
+// the Go types the handler decodes and encodes, so schemas follow the models.
+type apiOperation struct {
+	summary     string
+	request     interface{}
+	response    interface{}
+	status      int
+	contentType string
+}
+
+// message is the {"message": "..."} body of simple confirmations
+type message struct {
+	Message string `json:"message"`
+}
+
+// Operations keyed by "METHOD path-template" without the version prefix.
+// Routes missing here are still listed, generated from the router, just
+// without schemas.
+var apiOperations = map[string]apiOperation{
+	"POST /register":                     {summary: "Register a user", request: models.RegisterRequest{}, response: models.User{}, status: http.StatusCreated},
+	"POST /login":                        {summary: "Log in with email and password", request: models.LoginRequest{}, response: models.LoginResponse{}},
+	"POST /auth/refresh":                 {summary: "Rotate a refresh token", request: models.RefreshRequest{}, response: models.LoginResponse{}},
+	"POST /auth/reset-password":          {summary: "Set a new password with a reset token", request: models.ResetPasswordRequest{}, response: message{}},
+	"POST /auth/change-password":         {summary: "Change the password", request: models.ChangePasswordRequest{}, response: message{}},
+	"GET /auth/verify":                   {summary: "Verify an email address", response: message{}},
+	"POST /auth/verify/resend":           {summary: "Resend the verification email", request: models.ResendVerificationRequest{}, response: message{}},
+	"GET /docs/postman.json":             {summary: "Postman collection", response: map[string]interface{}{}},
+	"GET /docs/postman-environment.json": {summary: "Postman environment", response: map[string]interface{}{}},
+	"GET /openapi.json":                  {summary: "This OpenAPI document", response: map[string]interface{}{}},
+	"GET /docs":                          {summary: "Swagger UI", response: "", contentType: "text/html"},
+	"GET /meta/errors":                   {summary: "Error code catalog", response: models.ErrorCatalogResponse{}},
+	"GET /webhooks/schemas":              {summary: "JSON Schemas of webhook payloads, by version", response: models.WebhookSchemasResponse{}},
+	"POST /billing/{provider}/webhook":   {summary: "Receive a signed subscription event from a payment provider", response: message{}},
+	"POST /telegram/webhook":             {summary: "Receive an update for the Telegram bot", response: message{}},
+	"GET /.well-known/jwks.json":         {summary: "Public keys for verifying access tokens", response: models.JWKSet{}},
+	"GET /version":                       {summary: "Build information", response: version.Info{}},
+	"GET /health":                        {summary: "Health check", response: map[string]string{}},
+
+	"GET /me":                          {summary: "Get the current user", response: models.User{}},
+	"PUT /me":                          {summary: "Update the current user", request: models.UpdateProfileRequest{}, response: models.User{}},
+	"DELETE /me":                       {summary: "Delete the current account", request: models.DeleteAccountRequest{}, response: models.DeleteAccountResponse{}},
+	"GET /me/settings":                 {summary: "Your auto-completion settings", response: models.UserSettingsResponse{}},
+	"PATCH /me/settings":               {summary: "Change your auto-completion settings", request: models.UpdateUserSettingsRequest{}, response: models.UserSettingsResponse{}},
+	"PUT /me/private-passphrase":       {summary: "Set the passphrase private tasks are encrypted with", request: models.SetPrivatePassphraseRequest{}, response: models.User{}},
+	"POST /me/api-keys":                {summary: "Create an API key", request: models.CreateAPIKeyRequest{}, response: models.CreateAPIKeyResponse{}, status: http.StatusCreated},
+	"GET /me/api-keys":                 {summary: "List API keys", response: []*models.APIKey{}},
+	"DELETE /me/api-keys/{id}":         {summary: "Revoke an API key", response: message{}},
+	"GET /me/sessions":                 {summary: "List active sessions", response: []*models.Session{}},
+	"DELETE /me/sessions/{id}":         {summary: "Revoke a session", response: message{}},
+	"GET /me/achievements":             {summary: "Completion streaks and badges", response: models.AchievementsResponse{}},
+	"GET /me/usage":                    {summary: "Your API usage by endpoint, API key and day", response: models.UsageReport{}},
+	"GET /me/subscription":             {summary: "Your plan, its limits and how much of them you use", response: models.SubscriptionResponse{}},
+	"POST /me/tokens":                  {summary: "Issue a scoped access token", request: models.CreateScopedTokenRequest{}, response: models.ScopedTokenResponse{}, status: http.StatusCreated},
+	"POST /me/webhooks":                {summary: "Register a webhook", request: models.CreateWebhookRequest{}, response: models.CreateWebhookResponse{}, status: http.StatusCreated},
+	"GET /me/webhooks":                 {summary: "List webhooks", response: []*models.Webhook{}},
+	"PATCH /me/webhooks/{id}":          {summary: "Pin a webhook to another payload schema version, or turn it off or on", request: models.UpdateWebhookRequest{}, response: models.Webhook{}},
+	"DELETE /me/webhooks/{id}":         {summary: "Delete a webhook", response: message{}},
+	"GET /me/webhooks/{id}/deliveries": {summary: "Webhook delivery log", response: models.WebhookDeliveryListResponse{}},
+
+	"POST /me/notification-channels":           {summary: "Connect a chat channel for task notifications", request: models.CreateNotificationChannelRequest{}, response: models.CreateNotificationChannelResponse{}, status: http.StatusCreated},
+	"GET /me/notification-channels":            {summary: "List notification channels", response: []*models.NotificationChannel{}},
+	"DELETE /me/notification-channels/{id}":    {summary: "Disconnect a notification channel", response: message{}},
+	"POST /me/notification-channels/{id}/test": {summary: "Send a test message to a notification channel", response: message{}},
+
+	"GET /me/notification-preferences":   {summary: "Which events reach you by email, chat and push", response: models.NotificationPreferences{}},
+	"PATCH /me/notification-preferences": {summary: "Choose which events reach you by email, chat and push", request: models.UpdateNotificationPreferencesRequest{}, response: models.NotificationPreferences{}},
+
+	"POST /me/push-subscriptions":           {summary: "Subscribe a browser to push notifications", request: models.CreatePushSubscriptionRequest{}, response: models.PushSubscription{}, status: http.StatusCreated},
+	"GET /me/push-subscriptions":            {summary: "List push subscriptions", response: []*models.PushSubscription{}},
+	"GET /me/push-subscriptions/public-key": {summary: "Get the VAPID public key browsers subscribe with", response: models.PushPublicKeyResponse{}},
+	"DELETE /me/push-subscriptions/{id}":    {summary: "Unsubscribe a browser from push notifications", response: message{}},
+
+	"POST /tasks":                             {summary: "Create a task", request: models.CreateTaskRequest{}, response: models.Task{}, status: http.StatusCreated},
+	"GET /tasks":                              {summary: "List tasks", response: models.TaskListResponse{}},
+	"DELETE /tasks":                           {summary: "Bulk delete tasks", request: models.BulkDeleteTasksRequest{}, response: models.BulkDeleteTasksResponse{}},
+	"GET /tasks/summary":                      {summary: "Task counts for dashboards", response: models.TaskSummary{}},
+	"GET /tasks/my-day":                       {summary: "Get today's my day list", response: models.MyDayResponse{}},
+	"GET /tasks/focus-stats":                  {summary: "Daily or weekly focus time", response: models.FocusStats{}},
+	"GET /tasks/plan":                         {summary: "Weekly plan", response: models.WeeklyPlan{}},
+	"GET /tasks/export":                       {summary: "Export tasks as a Markdown checklist or an Excel workbook", response: "", contentType: "text/markdown"},
+	"POST /tasks/import":                      {summary: "Import tasks from a Markdown checklist, or from JSON or NDJSON with a report of skipped records (ImportReport)", request: "", response: models.ImportTasksResponse{}, status: http.StatusCreated},
+	"POST /tasks/import/todoist":              {summary: "Start importing the caller's Todoist tasks in the background", request: models.StartTodoistImportRequest{}, response: models.ImportJob{}, status: http.StatusAccepted},
+	"GET /tasks/import/jobs/{id}":             {summary: "Get the progress of an import job", response: models.ImportJob{}},
+	"GET /reports/tasks.pdf":                  {summary: "Download a PDF report of a user's tasks with a status summary chart", response: "", contentType: "application/pdf"},
+	"POST /exports":                           {summary: "Start a background export of all your tasks as CSV or JSON", request: models.CreateExportRequest{}, response: models.ExportJob{}, status: http.StatusAccepted},
+	"GET /exports/{id}":                       {summary: "Get the status of an export job, with its download link once done", response: models.ExportJob{}},
+	"GET /exports/{id}/download":              {summary: "Download a finished export", response: "", contentType: "text/csv"},
+	"POST /graphql":                           {summary: "Query and change tasks with GraphQL; field errors come back with status 200", request: graphQLRequest{}, response: graphQLResponse{}},
+	"GET /tasks/{id}":                         {summary: "Get a task; honors If-None-Match", response: models.Task{}},
+	"PATCH /tasks/{id}":                       {summary: "Update a task; honors If-Match", request: models.UpdateTaskRequest{}, response: models.Task{}},
+	"DELETE /tasks/{id}":                      {summary: "Delete a task", response: message{}},
+	"GET /tasks/{id}/subtasks":                {summary: "List subtasks", response: models.TaskListResponse{}},
+	"POST /tasks/{id}/schedule":               {summary: "Place a task on a day", request: models.ScheduleTaskRequest{}, response: models.Task{}},
+	"POST /tasks/{id}/my-day":                 {summary: "Add a task to my day", response: models.MyDayEntry{}},
+	"DELETE /tasks/{id}/my-day":               {summary: "Remove a task from my day", response: message{}},
+	"POST /tasks/{id}/pomodoros":              {summary: "Start a pomodoro session", request: models.StartPomodoroRequest{}, response: models.FocusSession{}, status: http.StatusCreated},
+	"GET /tasks/{id}/pomodoros":               {summary: "List a task's pomodoro sessions", response: models.FocusSessionListResponse{}},
+	"POST /tasks/{id}/pomodoros/stop":         {summary: "Stop the running pomodoro session", response: models.FocusSession{}},
+	"POST /tasks/{id}/archive":                {summary: "Archive a task", response: models.Task{}},
+	"POST /tasks/{id}/unarchive":              {summary: "Unarchive a task", response: models.Task{}},
+	"GET /tasks/{id}/history":                 {summary: "List a task's change history", response: models.TaskHistoryListResponse{}},
+	"POST /tasks/{id}/comments":               {summary: "Add a comment", request: models.CreateCommentRequest{}, response: models.Comment{}, status: http.StatusCreated},
+	"GET /tasks/{id}/comments":                {summary: "List comments", response: models.CommentListResponse{}},
+	"DELETE /tasks/{id}/comments/{commentId}": {summary: "Delete a comment", response: message{}},
+
+	"GET /admin/slo":          {summary: "SLO report", response: models.SLOReport{}},
+	"GET /admin/schema":       {summary: "Schema migration status", response: models.SchemaStatus{}},
+	"GET /admin/indexes":      {summary: "Index build status and drift", response: models.IndexReport{}},
+	"GET /admin/deprecations": {summary: "Clients still using deprecated routes and fields", response: models.DeprecationReport{}},
+	"GET /admin/config": {summary: "Effective configuration", response: struct {
+		Settings []config.Setting `json:"settings"`
+	}{}},
+	"GET /admin/projections/consistency":       {summary: "Compare projections with their source", response: models.ProjectionCheckReport{}},
+	"GET /admin/worker":                        {summary: "Auto-complete worker status, queue depth and counters", response: models.WorkerStatus{}},
+	"POST /admin/worker/pause":                 {summary: "Pause auto-completion on every instance", response: models.WorkerStatus{}},
+	"POST /admin/worker/resume":                {summary: "Resume auto-completion", response: models.WorkerStatus{}},
+	"POST /admin/worker/trigger":               {summary: "Queue the tasks due now", response: models.WorkerTriggerResponse{}},
+	"POST /admin/worker/simulate":              {summary: "Dry-run the auto-complete worker on a task", request: models.SimulateWorkerRequest{}, response: models.WorkerVerdict{}},
+	"GET /admin/dead-letters":                  {summary: "List jobs that failed on every attempt", response: models.DeadLetterListResponse{}},
+	"GET /admin/dead-letters/{id}":             {summary: "Get a dead letter", response: models.DeadLetter{}},
+	"POST /admin/dead-letters/{id}/requeue":    {summary: "Queue a dead letter's job again", response: models.DeadLetterRequeueResult{}},
+	"GET /admin/retention":                     {summary: "Retention policy and status", response: models.RetentionStatusResponse{}},
+	"PUT /admin/retention":                     {summary: "Update the retention policy", request: models.UpdateRetentionPolicyRequest{}, response: models.RetentionPolicy{}},
+	"POST /admin/retention/run":                {summary: "Run retention now", response: models.RetentionReport{}},
+	"GET /admin/retention/reports":             {summary: "List retention reports", response: models.RetentionReportListResponse{}},
+	"GET /admin/requests/{id}":                 {summary: "Look up a failed request by its request ID", response: models.RequestTrace{}},
+	"GET /admin/audit-archives":                {summary: "List audit log archives", response: models.AuditArchiveListResponse{}},
+	"POST /admin/audit-archives/run":           {summary: "Archive old audit logs now", request: models.RunAuditArchiveRequest{}, response: models.AuditArchiveRunReport{}},
+	"GET /admin/audit-archives/{id}/download":  {summary: "Download an audit log archive as gzipped NDJSON", response: "", contentType: "application/gzip"},
+	"POST /admin/users/{id}/force-logout":      {summary: "Revoke all of a user's sessions", response: message{}},
+	"POST /admin/users/{id}/reset-credentials": {summary: "Reset a user's credentials and email them a reset link", response: models.ResetCredentialsResponse{}},
+	"POST /admin/users/{id}/unlock":            {summary: "Unlock a locked account", response: message{}},
+	"GET /admin/users/{id}/usage":              {summary: "A user's API usage by endpoint, API key and day", response: models.UsageReport{}},
+	"POST /admin/users/{id}/reassign-tasks":    {summary: "Reassign a user's open tasks", request: models.ReassignTasksRequest{}, response: models.ReassignTasksResponse{}},
+	"GET /admin/login-attempts":                {summary: "List failed login counters", response: []*models.LoginAttempt{}},
+	"DELETE /admin/login-attempts":             {summary: "Clear failed login counters", response: message{}},
+	"PUT /admin/users/{id}/permissions":        {summary: "Replace a user's permissions", request: models.SetPermissionsRequest{}, response: models.User{}},
+	"GET /admin/field-policy/tasks":            {summary: "Task field policy", response: models.TaskFieldPolicy{}},
+	"PUT /admin/field-policy/tasks":            {summary: "Replace the task field policy", request: models.UpdateTaskFieldPolicyRequest{}, response: models.TaskFieldPolicy{}},
+	"PUT /admin/users/{id}/legal-hold":         {summary: "Place or release a user legal hold", request: models.SetLegalHoldRequest{}, response: models.LegalHoldResponse{}},
+	"PUT /admin/tasks/{id}/legal-hold":         {summary: "Place or release a task legal hold", request: models.SetLegalHoldRequest{}, response: models.LegalHoldResponse{}},
+
+	"GET /admin/notification-templates":                 {summary: "List notification templates", response: []*models.NotificationTemplate{}},
+	"GET /admin/notification-templates/{name}":          {summary: "Get a notification template", response: models.NotificationTemplate{}},
+	"PUT /admin/notification-templates/{name}":          {summary: "Replace a notification template with a custom one", request: models.UpdateNotificationTemplateRequest{}, response: models.NotificationTemplate{}},
+	"DELETE /admin/notification-templates/{name}":       {summary: "Go back to the built-in notification template", response: message{}},
+	"POST /admin/notification-templates/{name}/preview": {summary: "Render a notification template with example data", request: models.UpdateNotificationTemplateRequest{}, response: models.NotificationTemplatePreview{}},
+
+	"POST /sandbox/reset": {summary: "Reset sandbox data", response: models.SandboxResetResponse{}},
+	"GET /sandbox/time":   {summary: "Get the sandbox clock", response: models.SandboxTimeResponse{}},
+	"POST /sandbox/time":  {summary: "Set the sandbox clock", request: models.SandboxTimeRequest{}, response: models.SandboxTimeResponse{}},
+}
+
+// OpenAPI serves an OpenAPI 3.0 document for the routes actually registered
+func (h *DocsHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	spec := newOpenAPISpec(utils.Int64AsString(r.Context()))
+
+	routes, err := h.documentedRoutes()
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to build openapi document")
+		return
+	}
+	for _, route := range routes {
+		spec.addOperation(route.method, route.path)
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "Task Management API",
+			"version": version.Get().Version,
+		},
+		"servers": []map[string]string{{"url": requestBaseURL(r)}},
+		"paths":   spec.paths,
+		"components": map[string]interface{}{
+			"schemas": spec.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey":     map[string]string{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	})
+}
+
+type openAPISpec struct {
+	paths   map[string]map[string]interface{}
+	schemas map[string]interface{}
+	// int64AsString types 64-bit counters as the request will get them
+	int64AsString bool
+}
+
+func newOpenAPISpec(int64AsString bool) *openAPISpec {
+	spec := &openAPISpec{
+		paths:         make(map[string]map[string]interface{}),
+		schemas:       make(map[string]interface{}),
+		int64AsString: int64AsString,
+	}
+	spec.schemaFor(reflect.TypeOf(models.ErrorResponse{}))
+	return spec
+}
+
+func (s *openAPISpec) addOperation(method, path string) {
+	key := routeKey(path)
+	doc := apiOperations[method+" "+key]
+	if doc.summary == "" {
+		doc.summary = method + " " + path
+	}
+
+	status := doc.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	responses := map[string]interface{}{
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content":     jsonContent(map[string]string{"$ref": "#/components/schemas/ErrorResponse"}),
+		},
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if doc.response != nil {
+		success["content"] = s.content(doc.response, doc.contentType)
+	}
+	responses[strconv.Itoa(status)] = success
+
+	operation := map[string]interface{}{
+		"summary":     doc.summary,
+		"operationId": operationID(method, key),
+		"tags":        []string{operationTag(key)},
+		"responses":   responses,
+	}
+
+	var parameters []map[string]interface{}
+	for _, match := range pathVariablePattern.FindAllStringSubmatch(path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]string{"type": "string"},
+		})
+	}
+	if parameters != nil {
+		operation["parameters"] = parameters
+	}
+
+	if doc.request != nil {
+		contentType := ""
+		if _, ok := doc.request.(string); ok {
+			contentType = "text/markdown"
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  s.content(doc.request, contentType),
+		}
+	}
+
+	switch {
+	case publicRoutes[key]:
+		operation["security"] = []interface{}{}
+	case strings.HasPrefix(key, "/tasks"):
+		// Task routes also accept API keys
+		operation["security"] = []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}}
+	default:
+		operation["security"] = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	if s.paths[path] == nil {
+		s.paths[path] = make(map[string]interface{})
+	}
+	s.paths[path][strings.ToLower(method)] = operation
+}
+
+// content describes a body; an empty contentType means JSON with a schema derived from body
+func (s *openAPISpec) content(body interface{}, contentType string) map[string]interface{} {
+	if contentType == "" {
+		return jsonContent(s.schemaFor(reflect.TypeOf(body)))
+	}
+	return map[string]interface{}{contentType: map[string]interface{}{"schema": map[string]string{"type": "string"}}}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	int64Type    = reflect.TypeOf(models.Int64(0))
+)
+
+// schemaFor derives a schema from a Go type's JSON encoding. Named structs
+// become components and are referenced; times are RFC 3339 strings.
+func (s *openAPISpec) schemaFor(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case objectIDType:
+		return map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	case int64Type:
+		if s.int64AsString {
+			return map[string]interface{}{"type": "string", "format": "int64"}
+		}
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.schemaFor(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		// Nullable[T] is T or null in JSON
+		if strings.HasPrefix(t.Name(), "Nullable[") {
+			schema := s.schemaFor(t.Field(1).Type.Elem())
+			schema["nullable"] = true
+			return schema
+		}
+		name := schemaName(t)
+		if name == "" {
+			return s.structSchema(t)
+		}
+		if _, ok := s.schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate
+			s.schemas[name] = map[string]interface{}{}
+			s.schemas[name] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (s *openAPISpec) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	s.addProperties(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (s *openAPISpec) addProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		// Untagged embedded structs are flattened like encoding/json does
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addProperties(embedded, properties)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema := s.schemaFor(field.Type)
+		if field.Type.Kind() == reflect.Ptr {
+			if _, isRef := schema["$ref"]; isRef {
+				schema = map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+			} else {
+				schema["nullable"] = true
+			}
+		}
+		properties[name] = schema
+	}
+}
+
+// schemaName names package-level struct types; the "message" body and
+// anonymous structs are inlined instead.
+func schemaName(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == reflect.TypeOf(message{}).PkgPath() {
+		return ""
+	}
+	return t.Name()
+}
+
+func operationTag(path string) string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	if segment == "" || segment == "register" || segment == "login" {
+		return "auth"
+	}
+	return strings.TrimPrefix(segment, ".")
+}
+
+// operationID turns "GET /tasks/{id}/comments" into "getTasksIdComments"
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Task Management API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUI serves an interactive browser for the OpenAPI document
+func (h *DocsHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUIPage))
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PomodoroHandler struct {
+	pomodoroService *service.PomodoroService
+}
+
+func NewPomodoroHandler(pomodoroService *service.PomodoroService) *PomodoroHandler {
+	return &PomodoroHandler{
+		pomodoroService: pomodoroService,
+	}
+}
+
+func (h *PomodoroHandler) StartSession(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	// The body is optional; an empty one starts a default-length session
+	var req models.StartPomodoroRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	session, err := h.pomodoroService.Start(r.Context(), user, taskID, &req)
+	if err != nil {
+		if respondTaskAccessError(w, err) {
+			return
+		}
+		switch {
+		case err.Error() == "a pomodoro session is already running":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case strings.HasPrefix(err.Error(), "minutes must be"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to start pomodoro session")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, session)
+}
+
+func (h *PomodoroHandler) StopSession(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	session, err := h.pomodoroService.Stop(r.Context(), user, taskID)
+	if err != nil {
+		if err.Error() == "no pomodoro session is running" {
+			utils.RespondError(w, http.StatusNotFound, "no pomodoro session is running for this task")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to stop pomodoro session")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, session)
+}
+
+func (h *PomodoroHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	page, limit := parsePagination(r)
+	response, err := h.pomodoroService.ListSessions(r.Context(), user, taskID, repository.FocusSessionFilter{Page: page, Limit: limit})
+	if err != nil {
+		if respondTaskAccessError(w, err) {
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list pomodoro sessions")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *PomodoroHandler) FocusStats(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	stats, err := h.pomodoroService.Stats(r.Context(), user, r.URL.Query().Get("period"), r.URL.Query().Get("date"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get focus stats")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type ProjectionHandler struct {
+	projectionService *service.ProjectionService
+}
+
+func NewProjectionHandler(projectionService *service.ProjectionService) *ProjectionHandler {
+	return &ProjectionHandler{
+		projectionService: projectionService,
+	}
+}
+
+// CheckConsistency reports drift between the projections and their source
+// without repairing it; the hourly job repairs.
+func (h *ProjectionHandler) CheckConsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := h.projectionService.Check(r.Context(), false)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to check projections")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReportHandler struct {
+	taskQueries *service.TaskQueryService
+}
+
+func NewReportHandler(taskQueries *service.TaskQueryService) *ReportHandler {
+	return &ReportHandler{
+		taskQueries: taskQueries,
+	}
+}
+
+// TasksPDF downloads a PDF report of the caller's tasks, or with user_id of
+// another user's; from and to limit it to tasks created on those days
+func (h *ReportHandler) TasksPDF(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	filter := repository.TaskReportFilter{UserID: user.ID}
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		if filter.UserID, err = primitive.ObjectIDFromHex(value); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "invalid user ID")
+			return
+		}
+	}
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s, use YYYY-MM-DD", param.name))
+			return
+		}
+		*param.target = &day
+	}
+	// to names the last day in the report
+	if filter.To != nil {
+		end := filter.To.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		utils.RespondError(w, http.StatusBadRequest, "from must not be after to")
+		return
+	}
+
+	report, err := h.taskQueries.TaskReportPDF(r.Context(), user, filter)
+	if err != nil {
+		switch err.Error() {
+		case "permission tasks:read_all required":
+			utils.RespondError(w, http.StatusForbidden, err.Error())
+		case "user not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		case "invalid private passphrase", "private passphrase is not set up":
+			respondPrivatePassphraseError(w, err)
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to generate report")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="task-report.pdf"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(report)
+}
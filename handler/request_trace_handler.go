@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type RequestTraceHandler struct {
+	requestTracer *service.RequestTracer
+}
+
+func NewRequestTraceHandler(requestTracer *service.RequestTracer) *RequestTraceHandler {
+	return &RequestTraceHandler{
+		requestTracer: requestTracer,
+	}
+}
+
+// GetTrace looks up a failed request by the request_id of its error response
+func (h *RequestTraceHandler) GetTrace(w http.ResponseWriter, r *http.Request) {
+	trace, err := h.requestTracer.FindTrace(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if err.Error() == "request trace not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to find request trace")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, trace)
+}
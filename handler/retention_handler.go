@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type RetentionHandler struct {
+	retentionService *service.RetentionService
+}
+
+func NewRetentionHandler(retentionService *service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+	}
+}
+
+func (h *RetentionHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.retentionService.Status(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get retention policy")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, status)
+}
+
+func (h *RetentionHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateRetentionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	policy, err := h.retentionService.UpdatePolicy(r.Context(), actor, &req)
+	if err != nil {
+		switch err.Error() {
+		case "completed_task_days must not be negative", "audit_log_days must not be negative", "grace_days must not be negative":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to update retention policy")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, policy)
+}
+
+func (h *RetentionHandler) Run(w http.ResponseWriter, r *http.Request) {
+	report, err := h.retentionService.Run(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to run retention")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+func (h *RetentionHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	response, err := h.retentionService.ListReports(r.Context(), repository.RetentionReportFilter{Page: page, Limit: limit})
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list retention reports")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+type RouteHandler struct {
+	router *mux.Router
+}
+
+func NewRouteHandler(router *mux.Router) *RouteHandler {
+	return &RouteHandler{
+		router: router,
+	}
+}
+
+func (h *RouteHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	utils.RespondError(w, http.StatusNotFound, "resource not found")
+}
+
+func (h *RouteHandler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	methods := h.allowedMethods(r)
+	if len(methods) == 0 {
+		h.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(append(methods, http.MethodOptions), ", "))
+
+	// OPTIONS is answered for every registered path without hitting the route handlers
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	utils.RespondError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed, allowed methods: "+strings.Join(methods, ", "))
+}
+
+func (h *RouteHandler) allowedMethods(r *http.Request) []string {
+	var methods []string
+	for _, method := range routeMethods {
+		req := r.Clone(r.Context())
+		req.Method = method
+
+		var match mux.RouteMatch
+		if h.router.Match(req, &match) && match.MatchErr == nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// DeprecatedAlias marks responses of routes kept for compatibility with their
+// successor under prefix, including the RFC 8594 Sunset date.
+func DeprecatedAlias(prefix string, sunset time.Time) mux.MiddlewareFunc {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Sunset", sunsetHeader)
+			w.Header().Set("Link", "<"+prefix+r.URL.RequestURI()+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type SandboxHandler struct {
+	sandboxService *service.SandboxService
+}
+
+func NewSandboxHandler(sandboxService *service.SandboxService) *SandboxHandler {
+	return &SandboxHandler{
+		sandboxService: sandboxService,
+	}
+}
+
+func (h *SandboxHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	response, err := h.sandboxService.Reset(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to reset sandbox")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *SandboxHandler) GetTime(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, h.sandboxService.TimeState())
+}
+
+func (h *SandboxHandler) SetTime(w http.ResponseWriter, r *http.Request) {
+	var req models.SandboxTimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.sandboxService.SetTime(&req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
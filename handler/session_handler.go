@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionHandler struct {
+	authService *service.AuthService
+}
+
+func NewSessionHandler(authService *service.AuthService) *SessionHandler {
+	return &SessionHandler{
+		authService: authService,
+	}
+}
+
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(r.Context(), user, service.GetSessionIDFromContext(r.Context()))
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, sessions)
+}
+
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid session ID")
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), user, sessionID); err != nil {
+		if err.Error() == "session not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "session revoked"})
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type SettingsHandler struct {
+	settingsService *service.SettingsService
+}
+
+func NewSettingsHandler(settingsService *service.SettingsService) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, h.settingsService.Get(user))
+}
+
+func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateUserSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	settings, err := h.settingsService.Update(r.Context(), user, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "auto_complete or auto_complete_minutes is required",
+			strings.HasPrefix(err.Error(), "auto_complete_minutes must be"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to update settings")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, settings)
+}
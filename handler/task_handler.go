@@ -2,8 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"task-management-api/models"
 	"task-management-api/repository"
@@ -14,14 +20,21 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const maxImportBytes = 1 << 20
+
+// sealedETagSuffix marks the ETag of a private task served without its passphrase
+const sealedETagSuffix = "-sealed"
+
 type TaskHandler struct {
 	taskService *service.TaskService
+	taskQueries *service.TaskQueryService
 	authService *service.AuthService
 }
 
-func NewTaskHandler(taskService *service.TaskService, authService *service.AuthService) *TaskHandler {
+func NewTaskHandler(taskService *service.TaskService, taskQueries *service.TaskQueryService, authService *service.AuthService) *TaskHandler {
 	return &TaskHandler{
 		taskService: taskService,
+		taskQueries: taskQueries,
 		authService: authService,
 	}
 }
@@ -39,9 +52,20 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.taskService.CreateTask(r.Context(), user.ID, &req)
+	task, err := h.taskService.CreateTask(r.Context(), user, &req)
 	if err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		var limitErr *service.PlanLimitError
+		switch {
+		case err.Error() == "invalid private passphrase", err.Error() == "only the owner can change a private task":
+			utils.RespondError(w, http.StatusForbidden, err.Error())
+		case errors.As(err, &limitErr):
+			respondPlanLimitError(w, limitErr)
+		default:
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	if !h.revealPrivate(w, r, user, task) {
 		return
 	}
 
@@ -62,7 +86,7 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.taskService.GetTask(r.Context(), taskID, user)
+	task, err := h.taskQueries.GetTask(r.Context(), taskID, user)
 	if err != nil {
 		if err.Error() == "task not found" {
 			utils.RespondError(w, http.StatusNotFound, "task not found")
@@ -75,6 +99,16 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		utils.RespondError(w, http.StatusInternalServerError, "failed to get task")
 		return
 	}
+	if !h.revealPrivate(w, r, user, task) {
+		return
+	}
+
+	etag := taskETag(task)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
 	utils.RespondJSON(w, http.StatusOK, task)
 }
@@ -86,37 +120,183 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters for pagination and filtering
-	filter := repository.TaskFilter{
-		Page:  1,
-		Limit: 10,
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			filter.Page = page
+	response, err := h.taskQueries.ListTasks(r.Context(), user, filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list tasks")
+		return
+	}
+	if !h.revealPrivate(w, r, user, response.Tasks...) {
+		return
+	}
+
+	respondTaskList(w, response, filter)
+}
+
+func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	var req models.UpdateTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.IfMatch = parseIfMatch(r.Header.Get("If-Match"))
+
+	task, err := h.taskService.UpdateTask(r.Context(), taskID, user, &req)
+	if err != nil {
+		var policyErr *service.FieldPolicyError
+		if errors.As(err, &policyErr) {
+			respondFieldPolicyError(w, policyErr)
+			return
 		}
+		switch err.Error() {
+		case "task not found":
+			utils.RespondError(w, http.StatusNotFound, "task not found")
+		case "unauthorized to update this task":
+			utils.RespondError(w, http.StatusForbidden, "you don't have permission to update this task")
+		case "invalid private passphrase", "only the owner can change a private task":
+			utils.RespondError(w, http.StatusForbidden, err.Error())
+		case "task has been modified":
+			if req.IfMatch != nil {
+				utils.RespondError(w, http.StatusPreconditionFailed, "task has changed since the given ETag, fetch it again")
+				return
+			}
+			utils.RespondError(w, http.StatusConflict, "task was modified by another request, retry")
+		case "task has open subtasks":
+			utils.RespondError(w, http.StatusConflict, "task cannot be completed while it has open subtasks")
+		case "task is blocked by open tasks":
+			utils.RespondError(w, http.StatusConflict, "task cannot be started or completed while its blockers are open")
+		default:
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	if !h.revealPrivate(w, r, user, task) {
+		return
 	}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit <= 100 {
-			filter.Limit = limit
+	w.Header().Set("ETag", taskETag(task))
+	utils.RespondJSON(w, http.StatusOK, task)
+}
+
+func (h *TaskHandler) ListSubtasks(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.taskQueries.ListSubtasks(r.Context(), taskID, user, filter)
+	if err != nil {
+		if err.Error() == "task not found" {
+			utils.RespondError(w, http.StatusNotFound, "task not found")
+			return
+		}
+		if err.Error() == "unauthorized access to task" {
+			utils.RespondError(w, http.StatusForbidden, "you don't have permission to access this task")
+			return
 		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list subtasks")
+		return
+	}
+	if !h.revealPrivate(w, r, user, response.Tasks...) {
+		return
 	}
 
-	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
-		status := models.TaskStatus(statusStr)
-		if service.IsValidStatus(status) {
-			filter.Status = &status
-		} else {
-			utils.RespondError(w, http.StatusBadRequest, "invalid status filter, must be one of: pending, in_progress, completed")
+	respondTaskList(w, response, filter)
+}
+
+func (h *TaskHandler) ArchiveTask(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+func (h *TaskHandler) UnarchiveTask(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *TaskHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	task, err := h.taskService.SetArchived(r.Context(), taskID, user, archived)
+	if err != nil {
+		if err.Error() == "task not found" {
+			utils.RespondError(w, http.StatusNotFound, "task not found")
 			return
 		}
+		if err.Error() == "unauthorized to update this task" {
+			utils.RespondError(w, http.StatusForbidden, "you don't have permission to update this task")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to update task")
+		return
+	}
+	if !h.revealPrivate(w, r, user, task) {
+		return
 	}
 
-	response, err := h.taskService.ListTasks(r.Context(), user, filter)
+	utils.RespondJSON(w, http.StatusOK, task)
+}
+
+func (h *TaskHandler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "failed to list tasks")
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	page, limit := parsePagination(r)
+	response, err := h.taskQueries.ListHistory(r.Context(), taskID, user, repository.HistoryFilter{Page: page, Limit: limit})
+	if err != nil {
+		if respondTaskAccessError(w, err) {
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get task history")
 		return
 	}
 
@@ -137,7 +317,8 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.taskService.DeleteTask(r.Context(), taskID, user); err != nil {
+	subtaskMode := models.SubtaskDeleteMode(r.URL.Query().Get("subtasks"))
+	if err := h.taskService.DeleteTask(r.Context(), taskID, user, subtaskMode); err != nil {
 		if err.Error() == "task not found" {
 			utils.RespondError(w, http.StatusNotFound, "task not found")
 			return
@@ -146,9 +327,394 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 			utils.RespondError(w, http.StatusForbidden, "you don't have permission to delete this task")
 			return
 		}
+		if err.Error() == "task has subtasks, specify subtasks=cascade or subtasks=orphan" || err.Error() == "task is under legal hold" {
+			utils.RespondError(w, http.StatusConflict, err.Error())
+			return
+		}
 		utils.RespondError(w, http.StatusInternalServerError, "failed to delete task")
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "task deleted successfully"})
 }
+
+func (h *TaskHandler) BulkDeleteTasks(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.BulkDeleteTasksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.taskService.BulkDeleteTasks(r.Context(), user, &req)
+	if err != nil {
+		switch err.Error() {
+		case "task has subtasks, specify subtasks=cascade or subtasks=orphan", "task is under legal hold":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case "you don't have permission to delete other users' tasks":
+			utils.RespondError(w, http.StatusForbidden, err.Error())
+		default:
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// TaskSummary returns the user's task counts from the summary projection
+func (h *TaskHandler) TaskSummary(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	summary, err := h.taskQueries.Summary(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to load task summary")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, summary)
+}
+
+// GetWeeklyPlan returns the user's tasks for an ISO week grouped by due day
+func (h *TaskHandler) GetWeeklyPlan(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	plan, err := h.taskQueries.GetWeeklyPlan(r.Context(), user, r.URL.Query().Get("week"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid week") {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get weekly plan")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, plan)
+}
+
+// ScheduleTask places a task on a day of the plan
+func (h *TaskHandler) ScheduleTask(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	var req models.ScheduleTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !req.Date.Set {
+		utils.RespondError(w, http.StatusBadRequest, "date is required")
+		return
+	}
+
+	task, err := h.taskService.ScheduleTask(r.Context(), taskID, user, req.Date.Value)
+	if err != nil {
+		var policyErr *service.FieldPolicyError
+		if errors.As(err, &policyErr) {
+			respondFieldPolicyError(w, policyErr)
+			return
+		}
+		switch err.Error() {
+		case "task not found":
+			utils.RespondError(w, http.StatusNotFound, "task not found")
+		case "unauthorized to update this task":
+			utils.RespondError(w, http.StatusForbidden, "you don't have permission to update this task")
+		case "invalid date, use YYYY-MM-DD":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case "task has been modified":
+			utils.RespondError(w, http.StatusConflict, "task was modified by another request, retry")
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to schedule task")
+		}
+		return
+	}
+	if !h.revealPrivate(w, r, user, task) {
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, task)
+}
+
+// ExportTasks downloads the user's tasks as a Markdown checklist
+func (h *TaskHandler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "markdown":
+		markdown, err := h.taskQueries.ExportTasks(r.Context(), user)
+		if err != nil {
+			respondExportError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.md"`)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, markdown)
+	case "xlsx":
+		workbook, err := h.taskQueries.ExportTasksXLSX(r.Context(), user)
+		if err != nil {
+			respondExportError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.xlsx"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write(workbook)
+	default:
+		utils.RespondError(w, http.StatusBadRequest, "unsupported export format, must be one of: markdown, xlsx")
+	}
+}
+
+// ImportTasks creates tasks from the raw request body: a JSON array or NDJSON
+// stream when the Content-Type says so, and a Markdown checklist otherwise
+func (h *TaskHandler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		utils.RespondError(w, http.StatusRequestEntityTooLarge, "import must be at most 1MB")
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		h.importJSON(w, r, user, body, false)
+		return
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		h.importJSON(w, r, user, body, true)
+		return
+	}
+
+	tasks, err := h.taskService.ImportTasks(r.Context(), user, string(body))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			utils.RespondError(w, http.StatusInternalServerError, "failed to import tasks")
+			return
+		}
+		var limitErr *service.PlanLimitError
+		if errors.As(err, &limitErr) {
+			respondPlanLimitError(w, limitErr)
+			return
+		}
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, models.ImportTasksResponse{ImportedCount: len(tasks), Tasks: tasks})
+}
+
+// importJSON imports what can be imported and reports the rest; it answers
+// 201 Created when at least one task was created and 200 OK otherwise
+func (h *TaskHandler) importJSON(w http.ResponseWriter, r *http.Request, user *models.User, body []byte, ndjson bool) {
+	report, err := h.taskService.ImportTasksJSON(r.Context(), user, body, ndjson)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			utils.RespondError(w, http.StatusInternalServerError, "failed to import tasks")
+			return
+		}
+		var limitErr *service.PlanLimitError
+		if errors.As(err, &limitErr) {
+			respondPlanLimitError(w, limitErr)
+			return
+		}
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.revealPrivate(w, r, user, report.Tasks...) {
+		return
+	}
+
+	status := http.StatusOK
+	if report.ImportedCount > 0 {
+		status = http.StatusCreated
+	}
+	utils.RespondJSON(w, status, report)
+}
+
+// parseTaskFilter reads the pagination, status and fields query parameters shared by task listings.
+func parseTaskFilter(r *http.Request) (repository.TaskFilter, error) {
+	page, limit := parsePagination(r)
+	filter := repository.TaskFilter{
+		Page:  page,
+		Limit: limit,
+	}
+
+	if includeStr := r.URL.Query().Get("include_archived"); includeStr != "" {
+		include, err := strconv.ParseBool(includeStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid include_archived, must be true or false")
+		}
+		filter.IncludeArchived = include
+	}
+
+	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
+		status := models.TaskStatus(statusStr)
+		if !service.IsValidStatus(status) {
+			return filter, fmt.Errorf("invalid status filter, must be one of: pending, in_progress, completed")
+		}
+		filter.Status = &status
+	}
+
+	// Sparse fieldsets; id is always included so tasks can be told apart
+	if fieldsStr := r.URL.Query().Get("fields"); fieldsStr != "" {
+		filter.Fields = []string{"id"}
+		for _, field := range strings.Split(fieldsStr, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if !slices.Contains(models.TaskFields, field) {
+				return filter, fmt.Errorf("unknown field %s in fields, must be one of: %s", field, strings.Join(models.TaskFields, ", "))
+			}
+			if !slices.Contains(filter.Fields, field) {
+				filter.Fields = append(filter.Fields, field)
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// respondTaskList writes a task page, cut down to the filter's fields if it has any
+func respondTaskList(w http.ResponseWriter, response *models.TaskListResponse, filter repository.TaskFilter) {
+	if len(filter.Fields) > 0 {
+		utils.RespondJSON(w, http.StatusOK, models.NewSparseTaskListResponse(response, filter.Fields))
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// parsePagination reads page and limit, falling back to page 1 and 10 items (max 100).
+func parsePagination(r *http.Request) (int, int) {
+	page, limit := 1, 10
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	return page, limit
+}
+
+// taskETag is the strong entity tag of a task's current version. A private
+// task still sealed is a different representation and gets its own tag.
+func taskETag(task *models.Task) string {
+	if task.Private && task.Sealed != nil {
+		return `"` + strconv.FormatInt(task.Version, 10) + sealedETagSuffix + `"`
+	}
+	return `"` + strconv.FormatInt(task.Version, 10) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. The
+// comparison is weak, as RFC 9110 specifies for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIfMatch returns the task versions an If-Match header accepts, or nil when
+// any version is accepted. Weak and foreign tags never match, so a header with
+// only those yields an empty list and the update fails its precondition.
+func parseIfMatch(header string) []int64 {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.ParseInt(strings.TrimSuffix(tag[1:len(tag)-1], sealedETagSuffix), 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// revealPrivate decrypts the user's private tasks for the response when the
+// request carries their passphrase. It responds with an error and returns
+// false when the passphrase is wrong.
+func (h *TaskHandler) revealPrivate(w http.ResponseWriter, r *http.Request, user *models.User, tasks ...*models.Task) bool {
+	if err := h.taskQueries.RevealPrivate(r.Context(), user, tasks...); err != nil {
+		respondPrivatePassphraseError(w, err)
+		return false
+	}
+	return true
+}
+
+func respondPrivatePassphraseError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "invalid private passphrase":
+		utils.RespondError(w, http.StatusForbidden, err.Error())
+	case "private passphrase is not set up":
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, "failed to decrypt private tasks")
+	}
+}
+
+func respondExportError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "invalid private passphrase", "private passphrase is not set up":
+		respondPrivatePassphraseError(w, err)
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, "failed to export tasks")
+	}
+}
+
+// respondFieldPolicyError names the fields the field policy kept the user from changing
+func respondFieldPolicyError(w http.ResponseWriter, err *service.FieldPolicyError) {
+	utils.RespondErrorDetails(w, http.StatusForbidden, "you don't have permission to change some fields of this task", map[string]interface{}{
+		"blocked_fields": err.Fields,
+	})
+}
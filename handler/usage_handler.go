@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"task-management-api/service"
+	"task-management-api/utils"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UsageHandler struct {
+	usageTracker *service.UsageTracker
+}
+
+func NewUsageHandler(usageTracker *service.UsageTracker) *UsageHandler {
+	return &UsageHandler{
+		usageTracker: usageTracker,
+	}
+}
+
+// GetMyUsage reports the caller's own API usage
+func (h *UsageHandler) GetMyUsage(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	h.report(w, r, user.ID)
+}
+
+// GetUserUsage reports any user's API usage
+func (h *UsageHandler) GetUserUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	h.report(w, r, userID)
+}
+
+func (h *UsageHandler) report(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	var from, to *time.Time
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s, use YYYY-MM-DD", param.name))
+			return
+		}
+		*param.target = &day
+	}
+
+	report, err := h.usageTracker.Report(r.Context(), userID, from, to)
+	if err != nil {
+		switch {
+		case err.Error() == "from must not be after to", strings.HasPrefix(err.Error(), "usage reports cover at most"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to get usage")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
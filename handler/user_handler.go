@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+)
+
+type UserHandler struct {
+	authService    *service.AuthService
+	accountService *service.AccountService
+}
+
+func NewUserHandler(authService *service.AuthService, accountService *service.AccountService) *UserHandler {
+	return &UserHandler{
+		authService:    authService,
+		accountService: accountService,
+	}
+}
+
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, user)
+}
+
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	updated, err := h.authService.UpdateProfile(r.Context(), user, &req)
+	if err != nil {
+		switch err.Error() {
+		case "username, email or preferences is required", "username must not be empty", "email must not be empty":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case "user with this email already exists":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to update profile")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, updated)
+}
+
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.accountService.DeleteAccount(r.Context(), user, &req)
+	if err != nil {
+		switch err.Error() {
+		case "password is required", "invalid reassign_to", "tasks must be one of: delete, reassign":
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case "password is incorrect":
+			utils.RespondError(w, http.StatusUnauthorized, err.Error())
+		case "account is under legal hold":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		case "account deletion requires a MongoDB replica set":
+			utils.RespondError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to delete account")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *UserHandler) SetPrivatePassphrase(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.SetPrivatePassphraseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	updated, err := h.accountService.SetPrivatePassphrase(r.Context(), user, &req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "passphrase must be at least"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case err.Error() == "private passphrase is already set":
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to set private passphrase")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, updated)
+}
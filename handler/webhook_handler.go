@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	response, err := h.webhookService.CreateWebhook(r.Context(), user, &req)
+	if err != nil {
+		var limitErr *service.PlanLimitError
+		switch {
+		case errors.As(err, &limitErr):
+			respondPlanLimitError(w, limitErr)
+		case err.Error() == "url is required",
+			strings.HasPrefix(err.Error(), "invalid url"),
+			strings.HasPrefix(err.Error(), "invalid event"),
+			strings.HasPrefix(err.Error(), "invalid schema_version"),
+			strings.HasPrefix(err.Error(), "secret must be at least"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case strings.HasPrefix(err.Error(), "at most"):
+			utils.RespondError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to create webhook")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, response)
+}
+
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(r.Context(), user)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list webhooks")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(r.Context(), user, webhookID, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "schema_version or enabled is required", strings.HasPrefix(err.Error(), "invalid schema_version"):
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+		case err.Error() == "webhook not found":
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, "failed to update webhook")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, webhook)
+}
+
+// ListSchemas publishes the JSON Schema of every webhook payload version
+func (h *WebhookHandler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, service.WebhookSchemas())
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), user, webhookID); err != nil {
+		if err.Error() == "webhook not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to delete webhook")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "webhook deleted"})
+}
+
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	user, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	webhookID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid webhook ID")
+		return
+	}
+
+	page, limit := parsePagination(r)
+	response, err := h.webhookService.ListDeliveries(r.Context(), user, webhookID, repository.WebhookDeliveryFilter{Page: page, Limit: limit})
+	if err != nil {
+		if err.Error() == "webhook not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to list webhook deliveries")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
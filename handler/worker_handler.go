@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"task-management-api/models"
+	"task-management-api/service"
+	"task-management-api/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WorkerHandler struct {
+	taskWorker         *service.TaskWorker
+	workerAdminService *service.WorkerAdminService
+}
+
+func NewWorkerHandler(taskWorker *service.TaskWorker, workerAdminService *service.WorkerAdminService) *WorkerHandler {
+	return &WorkerHandler{
+		taskWorker:         taskWorker,
+		workerAdminService: workerAdminService,
+	}
+}
+
+// Status reports whether the worker is paused, its queue and its counters
+func (h *WorkerHandler) Status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.workerAdminService.Status(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to get worker status")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, status)
+}
+
+func (h *WorkerHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+func (h *WorkerHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+func (h *WorkerHandler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	actor, err := service.GetUserFromContext(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	status, err := h.workerAdminService.SetPaused(r.Context(), actor, paused)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to update worker")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, status)
+}
+
+// Trigger queues the tasks due now without waiting for the next scan
+func (h *WorkerHandler) Trigger(w http.ResponseWriter, r *http.Request) {
+	response, err := h.workerAdminService.Trigger(r.Context())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "failed to scan for due tasks")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// Simulate explains the auto-complete decision for a task without changing it
+func (h *WorkerHandler) Simulate(w http.ResponseWriter, r *http.Request) {
+	var req models.SimulateWorkerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(req.TaskID)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "invalid task ID")
+		return
+	}
+
+	verdict, err := h.taskWorker.Simulate(r.Context(), taskID)
+	if err != nil {
+		if err.Error() == "task not found" {
+			utils.RespondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "failed to simulate worker")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, verdict)
+}
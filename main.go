@@ -4,22 +4,38 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"task-management-api/archive"
+	"task-management-api/billing"
+	"task-management-api/clock"
+	"task-management-api/codec"
 	"task-management-api/config"
 	"task-management-api/database"
+	"task-management-api/events"
 	"task-management-api/handler"
+	"task-management-api/models"
+	"task-management-api/notifications"
+	"task-management-api/outbound"
+	"task-management-api/queue"
 	"task-management-api/repository"
 	"task-management-api/service"
 	"task-management-api/utils"
+	"task-management-api/version"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func main() {
+	info := version.Get()
+	log.Printf("Task Management API %s, commit %s, built %s with %s", info.Version, info.Commit, info.BuildDate, info.GoVersion)
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
@@ -49,57 +65,36 @@ func main() {
 		}
 	}()
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	taskRepo := repository.NewTaskRepository(db)
-
-	// Initialize services
-	authService := service.NewAuthService(userRepo, config.JWTSecret)
-	taskService := service.NewTaskService(taskRepo)
-
-	// Initialize handlers
-	authHandler := handler.NewAuthHandler(authService)
-	taskHandler := handler.NewTaskHandler(taskService, authService)
-
-	// Setup router
-	router := mux.NewRouter()
-
-	// Public routes
-	router.HandleFunc("/register", authHandler.Register).Methods("POST")
-	router.HandleFunc("/login", authHandler.Login).Methods("POST")
-
-	// Health check endpoint
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		utils.RespondJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
-	}).Methods("GET")
-
-	// Protected routes
-	api := router.PathPrefix("/tasks").Subrouter()
-	api.Use(authService.AuthMiddleware)
-	api.HandleFunc("", taskHandler.CreateTask).Methods("POST")
-	api.HandleFunc("", taskHandler.ListTasks).Methods("GET")
-	api.HandleFunc("/{id}", taskHandler.GetTask).Methods("GET")
-	api.HandleFunc("/{id}", taskHandler.DeleteTask).Methods("DELETE")
-
-	// Start background worker
-	taskWorker := service.NewTaskWorker(taskRepo, config.AutoCompleteMinutes)
-	go taskWorker.Start(ctx)
+	api, metrics := newServer(ctx, config, db)
 
 	// Setup server
 	srv := &http.Server{
 		Addr:         ":" + config.Port,
-		Handler:      router,
+		Handler:      api,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Start server in goroutine
-	serverErrors := make(chan error, 1)
+	// Metrics name internal hosts, so they are served on their own port that
+	// is not published with the API
+	metricsSrv := &http.Server{
+		Addr:         ":" + config.MetricsPort,
+		Handler:      metrics,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+
+	// Start servers in goroutines
+	serverErrors := make(chan error, 2)
 	go func() {
 		log.Printf("Server starting on port %s", config.Port)
 		serverErrors <- srv.ListenAndServe()
 	}()
+	go func() {
+		log.Printf("Metrics server starting on port %s", config.MetricsPort)
+		serverErrors <- metricsSrv.ListenAndServe()
+	}()
 
 	// Wait for interrupt signal or server error for graceful shutdown
 	quit := make(chan os.Signal, 1)
@@ -125,6 +120,520 @@ func main() {
 			log.Fatal("Error closing server:", err)
 		}
 	}
+	metricsSrv.Close()
 
 	log.Println("Server exited gracefully")
 }
+
+// newServer wires the repositories, services and handlers on db, starts the
+// background workers and scheduled jobs, which stop when ctx is done, and
+// returns the handlers that serve the API and the metrics.
+func newServer(ctx context.Context, config *config.Config, db *database.MongoDB) (api http.Handler, metrics http.Handler) {
+	// Time source; sandbox mode uses a controllable clock
+	var clk clock.Clock = clock.New()
+	sandboxClock := clock.NewControllable()
+	if config.SandboxMode {
+		clk = sandboxClock
+	}
+
+	// Initialize repositories
+	userRepo := repository.NewUserRepository(db)
+	taskRepo := repository.NewTaskRepository(db, clk)
+	auditRepo := repository.NewAuditRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	historyRepo := repository.NewTaskHistoryRepository(db)
+	refreshRepo := repository.NewRefreshTokenRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+
+	// Refuse to run against a schema this build does not understand
+	schemaService := service.NewSchemaService(repository.NewSchemaRepository(db), clk)
+	schemaStatus, err := schemaService.EnsureCompatible(ctx)
+	if err != nil {
+		if config.SchemaCheckStrict {
+			log.Fatal("Schema compatibility check failed:", err)
+		}
+		log.Printf("WARNING: schema compatibility check failed: %v", err)
+	} else {
+		log.Printf("Database schema version %d (code supports %d-%d)", schemaStatus.DatabaseVersion, service.MinSchemaVersion, service.SchemaVersion)
+	}
+
+	// Build missing indexes in the background so long builds never delay startup
+	indexService := service.NewIndexService(repository.NewIndexRepository(db), database.IndexSpecs, clk)
+	go indexService.Ensure(ctx)
+
+	// Initialize services
+	switch config.EmailVerificationGate {
+	case service.VerificationGateNone, service.VerificationGateLogin, service.VerificationGateTasks:
+	default:
+		log.Fatalf("Invalid EMAIL_VERIFICATION_GATE %q, must be one of: none, login, tasks", config.EmailVerificationGate)
+	}
+	// Mail goes out in the background; without an SMTP server it is only logged
+	var mailSender notifications.EmailSender = notifications.NewLogSender()
+	if config.SMTPHost != "" {
+		smtpSender, err := notifications.NewSMTPSender(notifications.SMTPConfig{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+			TLS:      config.SMTPTLS,
+		})
+		if err != nil {
+			log.Fatalf("Invalid SMTP settings: %v", err)
+		}
+		mailSender = smtpSender
+	}
+	if config.NotificationQueueSize < 1 || config.NotificationMaxAttempts < 1 {
+		log.Fatalf("Invalid notification queue settings: NOTIFICATION_QUEUE_SIZE and NOTIFICATION_MAX_ATTEMPTS must be at least 1")
+	}
+	// Email and chat messages go out in the background through one queue
+	mailer := notifications.NewQueue(mailSender, notifications.QueueConfig{Size: config.NotificationQueueSize, MaxAttempts: config.NotificationMaxAttempts})
+	go mailer.Start(ctx)
+	// Messages use the templates admins customized, and the built-ins otherwise
+	notificationTemplateService := service.NewNotificationTemplateService(repository.NewNotificationTemplateRepository(db), auditRepo, clk)
+	templates := notifications.NewRenderer(notificationTemplateService)
+	verification := service.EmailVerificationConfig{
+		Gate:      config.EmailVerificationGate,
+		BaseURL:   config.PublicBaseURL,
+		Mailer:    mailer,
+		Templates: templates,
+	}
+	lockout := service.LockoutConfig{
+		MaxFailures: config.AccountLockoutThreshold,
+		Duration:    time.Duration(config.AccountLockoutMins) * time.Minute,
+	}
+	var jwtKeys *service.JWTKeys
+	switch config.JWTSigningMethod {
+	case service.SigningMethodHS256:
+		jwtKeys = service.NewHMACKeys(config.JWTSecret)
+	case service.SigningMethodRS256:
+		jwtKeys, err = service.LoadRSAKeys(config.JWTPrivateKeyFile, config.JWTPublicKeyFiles)
+		if err != nil {
+			log.Fatal("Failed to load JWT keys:", err)
+		}
+	default:
+		log.Fatalf("Invalid JWT_SIGNING_METHOD %q, must be one of: HS256, RS256", config.JWTSigningMethod)
+	}
+	// Services publish what happened to the bus; webhooks, notifications,
+	// projections, achievements and the audit log subscribe to it below
+	bus := events.NewBus()
+	service.NewAuditService(auditRepo, clk).Subscribe(bus)
+	authService := service.NewAuthService(userRepo, refreshRepo, repository.NewSessionRepository(db), auditRepo, jwtKeys, time.Duration(config.RefreshTokenTTLHours)*time.Hour, verification, lockout, bus, clk)
+	var loginAttempts service.LoginAttemptStore
+	switch config.LoginAttemptStore {
+	case service.LoginAttemptStoreMemory:
+		loginAttempts = service.NewMemoryLoginAttemptStore()
+	case service.LoginAttemptStoreMongo:
+		loginAttempts = repository.NewLoginAttemptRepository(db)
+	default:
+		log.Fatalf("Invalid LOGIN_ATTEMPT_STORE %q, must be one of: memory, mongo", config.LoginAttemptStore)
+	}
+	loginLimiter := service.NewLoginLimiter(loginAttempts, service.LoginLimitConfig{
+		MaxFailuresPerEmail: config.LoginMaxFailuresPerEmail,
+		MaxFailuresPerIP:    config.LoginMaxFailuresPerIP,
+		Window:              time.Duration(config.LoginFailureWindowMins) * time.Minute,
+	}, clk)
+	fieldPolicyService := service.NewFieldPolicyService(repository.NewFieldPolicyRepository(db), auditRepo, clk)
+	taskService := service.NewTaskService(taskRepo, historyRepo, userRepo, auditRepo, fieldPolicyService, config.RequireSubtasksCompleted, bus, clk)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, clk)
+	// Reset emails link to the page of the web client where users choose a new password
+	passwordResetURL := config.PasswordResetURL
+	if passwordResetURL == "" {
+		passwordResetURL = strings.TrimRight(config.PublicBaseURL, "/") + "/reset-password"
+	}
+	if u, err := url.Parse(passwordResetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("Invalid PASSWORD_RESET_URL %q, must be an http or https URL", passwordResetURL)
+	}
+	adminService := service.NewAdminService(userRepo, refreshRepo, apiKeyRepo, auditRepo, taskService, mailer, templates, passwordResetURL, clk)
+
+	// Store role defaults on users created before per-user permissions
+	if migrated, err := adminService.MigrateRolePermissions(ctx); err != nil {
+		log.Printf("WARNING: permission backfill failed, role defaults still apply: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Backfilled permissions for %d users", migrated)
+	}
+
+	if config.WorkerPollInterval <= 0 || config.WorkerConcurrency < 1 || config.WorkerBatchSize < 1 || config.WorkerMaxAttempts < 1 {
+		log.Fatalf("Invalid worker settings: WORKER_POLL_INTERVAL must be positive, WORKER_CONCURRENCY, WORKER_BATCH_SIZE and WORKER_MAX_ATTEMPTS at least 1")
+	}
+	deadLetterRepo := repository.NewDeadLetterRepository(db)
+	workerStateRepo := repository.NewWorkerStateRepository(db)
+	// Queued auto-completions are kept in MongoDB so they survive restarts
+	var workerQueue queue.Queue
+	switch config.WorkerQueue {
+	case "mongo":
+		workerQueue = repository.NewQueueRepository(db, service.AutoCompleteQueue)
+	case "memory":
+		workerQueue = queue.NewMemoryQueue()
+	default:
+		log.Fatalf("Invalid WORKER_QUEUE %q, use mongo or memory", config.WorkerQueue)
+	}
+	taskWorker := service.NewTaskWorker(taskRepo, userRepo, taskService, config.AutoCompleteMinutes, service.WorkerConfig{
+		Concurrency: config.WorkerConcurrency,
+		BatchSize:   config.WorkerBatchSize,
+		MaxAttempts: config.WorkerMaxAttempts,
+	}, workerQueue, deadLetterRepo, workerStateRepo, clk)
+	retentionService := service.NewRetentionService(repository.NewRetentionRepository(db), taskRepo, userRepo, auditRepo, clk)
+	projectionService := service.NewProjectionService(taskRepo, repository.NewProjectionRepository(db), clk)
+	projectionService.Subscribe(bus)
+	taskQueries := service.NewTaskQueryService(taskRepo, historyRepo, userRepo, projectionService, clk)
+	commentService := service.NewCommentService(commentRepo, taskQueries, clk)
+	pomodoroService := service.NewPomodoroService(repository.NewFocusSessionRepository(db), taskRepo, taskQueries, clk)
+	myDayService := service.NewMyDayService(repository.NewMyDayRepository(db), taskRepo, taskQueries, clk)
+	achievementService := service.NewAchievementService(repository.NewAchievementRepository(db), clk)
+	achievementService.Subscribe(bus)
+
+	// Initialize handlers
+	authHandler := handler.NewAuthHandler(authService, loginLimiter)
+	taskHandler := handler.NewTaskHandler(taskService, taskQueries, authService)
+	adminHandler := handler.NewAdminHandler(adminService, schemaService, indexService, config)
+	commentHandler := handler.NewCommentHandler(commentService)
+	retentionHandler := handler.NewRetentionHandler(retentionService)
+	accountService := service.NewAccountService(userRepo, repository.NewAccountRepository(db), auditRepo, clk)
+	userHandler := handler.NewUserHandler(authService, accountService)
+	workerHandler := handler.NewWorkerHandler(taskWorker, service.NewWorkerAdminService(taskWorker, workerStateRepo, deadLetterRepo, auditRepo, clk))
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	sessionHandler := handler.NewSessionHandler(authService)
+	myDayHandler := handler.NewMyDayHandler(myDayService)
+	pomodoroHandler := handler.NewPomodoroHandler(pomodoroService)
+
+	// Setup router
+	router := mux.NewRouter()
+
+	// JSON 404/405 responses and OPTIONS handling for every route group
+	routeHandler := handler.NewRouteHandler(router)
+	router.NotFoundHandler = http.HandlerFunc(routeHandler.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(routeHandler.MethodNotAllowed)
+
+	// Shared client for every outbound call, with per-host limits and SSRF protection
+	privateHosts := config.OutboundPrivateHosts
+	if shadowURL, err := url.Parse(config.ShadowBaseURL); err == nil && shadowURL.Hostname() != "" {
+		privateHosts = append(privateHosts, shadowURL.Hostname())
+		config.Override("OUTBOUND_ALLOWED_PRIVATE_HOSTS", strings.Join(privateHosts, ","))
+	}
+	// Metrics name the hosts of known providers and of configured services only
+	var metricHosts []string
+	for _, configured := range []string{config.ShadowBaseURL, config.OIDCIssuerURL, config.AuditArchiveS3Endpoint, config.TodoistAPIURL} {
+		if u, err := url.Parse(configured); err == nil && u.Hostname() != "" {
+			metricHosts = append(metricHosts, u.Hostname())
+		}
+	}
+	outboundClient := outbound.New(outbound.Config{
+		Timeout:             time.Duration(config.OutboundTimeoutMS) * time.Millisecond,
+		RatePerHost:         config.OutboundRatePerHost,
+		BurstPerHost:        config.OutboundBurstPerHost,
+		AllowedPrivateHosts: privateHosts,
+		MetricHosts:         metricHosts,
+	})
+
+	// Audit logs past AUDIT_ARCHIVE_AFTER_DAYS move to compressed daily archives
+	var archiveStore archive.Store
+	switch config.AuditArchiveStore {
+	case "":
+	case "dir":
+		archiveStore = archive.NewDirStore(config.AuditArchiveDir)
+	case "s3":
+		archiveStore, err = archive.NewS3Store(archive.S3Config{
+			Endpoint:        config.AuditArchiveS3Endpoint,
+			Bucket:          config.AuditArchiveS3Bucket,
+			Region:          config.AuditArchiveS3Region,
+			AccessKeyID:     config.AuditArchiveS3AccessKey,
+			SecretAccessKey: config.AuditArchiveS3SecretKey,
+		}, outboundClient.HTTPClient(0))
+		if err != nil {
+			log.Fatalf("Failed to configure audit archive store: %v", err)
+		}
+	default:
+		log.Fatalf("Invalid AUDIT_ARCHIVE_STORE %q, must be one of: dir, s3", config.AuditArchiveStore)
+	}
+	auditArchiveService := service.NewAuditArchiveService(auditRepo, repository.NewAuditArchiveRepository(db), archiveStore, config.AuditArchiveAfterDays, clk)
+
+	// Imports from other services run as background jobs
+	importJobService := service.NewImportJobService(repository.NewImportJobRepository(db), taskService,
+		service.NewTodoistClient(config.TodoistAPIURL, outboundClient.HTTPClient(0)), clk)
+
+	// Task lifecycle events are delivered to user webhooks in the background
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookService := service.NewWebhookService(webhookRepo, outboundClient, clk)
+	webhookService.Subscribe(bus)
+
+	// Owners hear about their tasks by email, in the chat channels they connect
+	// and through push messages to their browsers
+	notificationService := service.NewNotificationService(repository.NewNotificationChannelRepository(db), repository.NewPushSubscriptionRepository(db), repository.NewNotificationPreferenceRepository(db), userRepo, mailer, templates, outboundClient, clk)
+	notificationService.Subscribe(bus)
+	if config.TelegramBotToken != "" {
+		if config.TelegramBotUsername == "" || len(config.TelegramWebhookSecret) < 16 {
+			log.Fatalf("Invalid Telegram settings: TELEGRAM_BOT_USERNAME and a TELEGRAM_WEBHOOK_SECRET of at least 16 characters are required with TELEGRAM_BOT_TOKEN")
+		}
+		notificationService.EnableTelegram(notifications.NewTelegramBot(outboundClient, config.TelegramBotToken, config.TelegramBotUsername), config.TelegramWebhookSecret)
+	}
+	if config.VAPIDPrivateKey != "" {
+		vapid, err := notifications.NewVAPID(config.VAPIDPublicKey, config.VAPIDPrivateKey, config.VAPIDSubject)
+		if err != nil {
+			log.Fatalf("Invalid VAPID settings: %v", err)
+		}
+		notificationService.EnablePush(vapid)
+	}
+
+	// Plan limits for hosted deployments, with subscriptions reported by the payment provider
+	plans := billing.DefaultPlans
+	if len(config.BillingPlans) > 0 {
+		if plans, err = billing.ParsePlans(config.BillingPlans); err != nil {
+			log.Fatalf("Invalid BILLING_PLANS: %v", err)
+		}
+	}
+	if _, ok := plans[config.BillingDefaultPlan]; !ok {
+		log.Fatalf("Invalid BILLING_DEFAULT_PLAN %q, not one of the configured plans", config.BillingDefaultPlan)
+	}
+	if _, ok := plans[config.BillingTrialPlan]; config.BillingTrialPlan != "" && !ok {
+		log.Fatalf("Invalid BILLING_TRIAL_PLAN %q, not one of the configured plans", config.BillingTrialPlan)
+	}
+	prices, err := billing.ParsePrices(config.StripePricePlans, plans)
+	if err != nil {
+		log.Fatalf("Invalid STRIPE_PRICE_PLANS: %v", err)
+	}
+	var billingProviders []billing.Provider
+	if config.StripeWebhookSecret != "" {
+		stripe, err := billing.NewStripe(config.StripeWebhookSecret)
+		if err != nil {
+			log.Fatalf("Failed to configure Stripe: %v", err)
+		}
+		billingProviders = append(billingProviders, stripe)
+	}
+	billingService := service.NewBillingService(repository.NewSubscriptionRepository(db), userRepo, taskRepo, webhookRepo, auditRepo, service.BillingConfig{
+		Enabled:     config.BillingEnabled,
+		Plans:       plans,
+		DefaultPlan: config.BillingDefaultPlan,
+		TrialPlan:   config.BillingTrialPlan,
+		TrialDays:   config.BillingTrialDays,
+		UpgradeURL:  config.BillingUpgradeURL,
+		Prices:      prices,
+	}, clk, billingProviders...)
+	if config.BillingEnabled {
+		log.Printf("Enforcing plan limits, default plan %s", config.BillingDefaultPlan)
+		taskService.EnforceQuota(billingService.CheckTasks)
+		webhookService.EnforceQuota(billingService.CheckWebhooks)
+	}
+
+	// Binary response formats for clients that ask for them in Accept
+	utils.RegisterEncoder(codec.MessagePack{}, "application/msgpack", "application/x-msgpack")
+	utils.RegisterEncoder(codec.ProtobufValue{}, "application/x-protobuf", "application/protobuf")
+
+	// Request IDs on every response, and traces of 5xx responses for admins
+	requestTracer := service.NewRequestTracer(repository.NewRequestTraceRepository(db), version.String(), clk)
+
+	// Per-route SLO tracking for every matched route
+	sloTracker := service.NewSLOTracker(service.SLOConfig{
+		AvailabilityTarget: config.SLOAvailabilityTarget,
+		LatencyTarget:      time.Duration(config.SLOLatencyTargetMS) * time.Millisecond,
+		AlertBurnRate:      config.SLOAlertBurnRate,
+		AlertsEnabled:      config.SLOAlertsEnabled,
+	})
+	router.Use(sloTracker.Middleware)
+
+	// Hourly usage per account and API key
+	usageTracker := service.NewUsageTracker(repository.NewUsageRepository(db), apiKeyRepo, clk)
+	router.Use(usageTracker.Middleware)
+
+	// Clients still using deprecated routes and fields, inside usage tracking
+	// so they are known by account and API key
+	var deprecations []models.Deprecation
+	if config.LegacyRoutesEnabled {
+		deprecations = append(deprecations, models.Deprecation{
+			ID:          "legacy-paths",
+			Kind:        models.DeprecationKindRoute,
+			Description: "Unprefixed paths of the API before versioning, such as /tasks",
+			Sunset:      config.LegacyRoutesSunset,
+			Successor:   "/api/v1",
+		})
+	}
+	deprecationTracker := service.NewDeprecationTracker(repository.NewDeprecationRepository(db), userRepo, apiKeyRepo, clk, deprecations...)
+	router.Use(deprecationTracker.Middleware)
+
+	// Optional mirroring of read traffic to a candidate deployment
+	if config.ShadowBaseURL != "" && config.ShadowPercent > 0 {
+		log.Printf("Mirroring %.1f%% of GET traffic to %s", config.ShadowPercent, config.ShadowBaseURL)
+		shadowMirror := service.NewShadowMirror(service.ShadowConfig{
+			BaseURL:            config.ShadowBaseURL,
+			Percent:            config.ShadowPercent,
+			Client:             outboundClient.HTTPClient(time.Duration(config.ShadowTimeoutMS) * time.Millisecond),
+			ForwardCredentials: config.ShadowForwardCredentials,
+		})
+		router.Use(shadowMirror.Middleware)
+	}
+
+	metricsHandler := handler.NewMetricsHandler(sloTracker, outboundClient, db.ReadMetrics)
+	metricsRouter := mux.NewRouter()
+	metricsRouter.HandleFunc("/metrics", metricsHandler.Metrics).Methods("GET")
+
+	// External login providers, each enabled by configuring its client
+	callbackURL := func(configured, provider string) string {
+		if configured != "" {
+			return configured
+		}
+		return strings.TrimRight(config.PublicBaseURL, "/") + "/api/v1/auth/" + provider + "/callback"
+	}
+	var oauthProviders []service.OAuthProvider
+	if config.GoogleClientID != "" {
+		oauthProviders = append(oauthProviders, service.NewGoogleOAuth(config.GoogleClientID, config.GoogleClientSecret, callbackURL(config.GoogleRedirectURL, "google"), outboundClient.HTTPClient(0)))
+	}
+	if config.GitHubClientID != "" {
+		oauthProviders = append(oauthProviders, service.NewGitHubOAuth(config.GitHubClientID, config.GitHubClientSecret, callbackURL(config.GitHubRedirectURL, "github"), outboundClient.HTTPClient(0)))
+	}
+	if config.OIDCIssuerURL != "" {
+		oidcProvider, err := service.DiscoverOIDC(ctx, service.OIDCConfig{
+			Name:         config.OIDCProviderName,
+			IssuerURL:    config.OIDCIssuerURL,
+			ClientID:     config.OIDCClientID,
+			ClientSecret: config.OIDCClientSecret,
+			RedirectURL:  callbackURL(config.OIDCRedirectURL, config.OIDCProviderName),
+			Scopes:       config.OIDCScopes,
+			RoleClaim:    config.OIDCRoleClaim,
+			RoleMapping:  config.OIDCRoleMapping,
+		}, outboundClient.HTTPClient(0), clk)
+		if err != nil {
+			log.Fatalf("Failed to configure OIDC login: %v", err)
+		}
+		oauthProviders = append(oauthProviders, oidcProvider)
+	}
+
+	// API documentation
+	docsHandler := handler.NewDocsHandler(router)
+	router.HandleFunc("/docs/postman.json", docsHandler.PostmanCollection).Methods("GET")
+	router.HandleFunc("/docs/postman-environment.json", docsHandler.PostmanEnvironment).Methods("GET")
+	router.HandleFunc("/meta/errors", docsHandler.ErrorCatalog).Methods("GET")
+	router.HandleFunc("/openapi.json", docsHandler.OpenAPI).Methods("GET")
+	if config.SwaggerUIEnabled {
+		router.HandleFunc("/docs", docsHandler.SwaggerUI).Methods("GET")
+	}
+
+	// Public keys for verifying access tokens
+	router.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		utils.RespondJSON(w, http.StatusOK, authService.JWKS())
+	}).Methods("GET")
+
+	// Build information
+	router.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondJSON(w, http.StatusOK, version.Get())
+	}).Methods("GET")
+
+	// Health check endpoint
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+	}).Methods("GET")
+
+	graphqlHandler, err := handler.NewGraphQLHandler(taskService, taskQueries, authService)
+	if err != nil {
+		log.Fatalf("Failed to set up GraphQL: %v", err)
+	}
+
+	// Versioned API. The unprefixed paths of earlier releases stay available as
+	// deprecated aliases of v1 until the sunset date.
+	v1 := &apiV1{
+		authService:         authService,
+		apiKeyService:       apiKeyService,
+		billingService:      billingService,
+		oauthProviders:      oauthProviders,
+		authHandler:         authHandler,
+		oauthHandler:        handler.NewOAuthHandler(authService),
+		userHandler:         userHandler,
+		settingsHandler:     handler.NewSettingsHandler(service.NewSettingsService(userRepo, taskWorker)),
+		apiKeyHandler:       apiKeyHandler,
+		sessionHandler:      sessionHandler,
+		achievementHandler:  handler.NewAchievementHandler(achievementService),
+		webhookHandler:      handler.NewWebhookHandler(webhookService),
+		notificationHandler: handler.NewNotificationHandler(notificationService),
+		taskHandler:         taskHandler,
+		myDayHandler:        myDayHandler,
+		pomodoroHandler:     pomodoroHandler,
+		projectionHandler:   handler.NewProjectionHandler(projectionService),
+		commentHandler:      commentHandler,
+		adminHandler:        adminHandler,
+		metricsHandler:      metricsHandler,
+		workerHandler:       workerHandler,
+		deadLetterHandler:   handler.NewDeadLetterHandler(service.NewDeadLetterService(deadLetterRepo, auditRepo, map[string]queue.Queue{service.AutoCompleteQueue: workerQueue}, clk)),
+		retentionHandler:    retentionHandler,
+		auditArchiveHandler: handler.NewAuditArchiveHandler(auditArchiveService),
+		requestTraceHandler: handler.NewRequestTraceHandler(requestTracer),
+		importJobHandler:    handler.NewImportJobHandler(importJobService),
+		reportHandler:       handler.NewReportHandler(taskQueries),
+		usageHandler:        handler.NewUsageHandler(usageTracker),
+		deprecationHandler:  handler.NewDeprecationHandler(deprecationTracker),
+		billingHandler:      handler.NewBillingHandler(billingService),
+		exportJobHandler:    handler.NewExportJobHandler(service.NewExportJobService(repository.NewExportJobRepository(db), taskRepo, taskQueries, clk)),
+		fieldPolicyHandler:  handler.NewFieldPolicyHandler(fieldPolicyService),
+		templateHandler:     handler.NewNotificationTemplateHandler(notificationTemplateService),
+		graphqlHandler:      graphqlHandler,
+	}
+	v1.mount(router.PathPrefix("/api/v1").Subrouter())
+	if config.LegacyRoutesEnabled {
+		sunset, err := time.Parse("2006-01-02", config.LegacyRoutesSunset)
+		if err != nil {
+			log.Fatalf("Invalid LEGACY_ROUTES_SUNSET %q, use YYYY-MM-DD", config.LegacyRoutesSunset)
+		}
+		legacy := router.NewRoute().Subrouter()
+		legacy.Use(handler.DeprecatedAlias("/api/v1", sunset), deprecationTracker.Track("legacy-paths"))
+		v1.mount(legacy)
+	}
+
+	// Sandbox routes for client contract tests; never enable in production
+	if config.SandboxMode {
+		log.Println("WARNING: sandbox mode enabled, POST /sandbox/reset wipes all data")
+		sandboxHandler := handler.NewSandboxHandler(service.NewSandboxService(repository.NewSandboxRepository(db), sandboxClock))
+		router.HandleFunc("/sandbox/reset", sandboxHandler.Reset).Methods("POST")
+		router.HandleFunc("/sandbox/time", sandboxHandler.GetTime).Methods("GET")
+		router.HandleFunc("/sandbox/time", sandboxHandler.SetTime).Methods("POST")
+	}
+
+	// Start background worker
+	go taskWorker.Start(ctx)
+
+	// Start scheduled jobs on whichever instance is the leader
+	if config.LeaderLeaseTTL < 3*time.Second {
+		log.Fatalf("Invalid LEADER_LEASE_TTL %v, must be at least 3s", config.LeaderLeaseTTL)
+	}
+	instanceID := config.InstanceID
+	if instanceID == "" {
+		host, _ := os.Hostname()
+		instanceID = host + "-" + primitive.NewObjectID().Hex()
+	}
+	leader := service.NewLeaderElector(repository.NewLeaseRepository(db), "scheduler", instanceID, config.LeaderLeaseTTL, clk)
+	go leader.Start(ctx)
+	if config.WorkerChangeStreams {
+		go taskWorker.Watch(ctx, leader)
+	}
+	scheduler := service.NewScheduler(repository.NewScheduledJobRepository(db), leader, clk)
+	addJob := func(name string, enabled bool, schedule string, run func(context.Context) error) {
+		if !enabled {
+			return
+		}
+		if err := scheduler.Add(name, schedule, run); err != nil {
+			log.Fatalf("Invalid schedule for job %s: %v", name, err)
+		}
+	}
+	addJob("auto_complete", config.AutoCompleteJob.Enabled, config.AutoCompleteJob.Schedule, taskWorker.QueueDue)
+	addJob("retention", config.RetentionJob.Enabled, config.RetentionJob.Schedule, retentionService.RunScheduled)
+	if archiveStore != nil && config.AuditArchiveAfterDays > 0 {
+		addJob("audit_archive", config.AuditArchiveJob.Enabled, config.AuditArchiveJob.Schedule, auditArchiveService.RunScheduled)
+	}
+	addJob("my_day_rollover", config.MyDayRolloverJob.Enabled, config.MyDayRolloverJob.Schedule, myDayService.RunScheduled)
+	addJob("projection_check", config.ProjectionCheckJob.Enabled, config.ProjectionCheckJob.Schedule, projectionService.RunScheduled)
+	digestService := service.NewDigestService(taskRepo, userRepo, mailer, clk)
+	addJob("digest", config.DigestJob.Enabled, config.DigestJob.Schedule, digestService.SendDigests)
+	if config.ReminderLeadMinutes < 1 {
+		log.Fatalf("Invalid REMINDER_LEAD_MINUTES %d, must be at least 1", config.ReminderLeadMinutes)
+	}
+	reminderService := service.NewReminderService(taskRepo, taskService, config.ReminderLeadMinutes, clk)
+	addJob("reminders", config.ReminderJob.Enabled, config.ReminderJob.Schedule, reminderService.SendReminders)
+	go scheduler.Start(ctx)
+
+	// Start webhook delivery worker
+	go webhookService.Start(ctx)
+
+	// Start usage flushing
+	go usageTracker.Start(ctx)
+	go deprecationTracker.Start(ctx)
+
+	return version.Middleware(requestTracer.Middleware(utils.WithClock(clk)(utils.Negotiate(config.JSONInt64AsString)(router)))), metricsRouter
+}
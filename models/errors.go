@@ -0,0 +1,328 @@
+package models
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ErrorDefinition describes one error the API can return. Codes are stable;
+// messages are English defaults that clients may replace with their own copy.
+type ErrorDefinition struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Message     string `json:"message"`
+	Description string `json:"description"`
+}
+
+func errorDef(code string, status int, message, description string) ErrorDefinition {
+	return ErrorDefinition{Code: code, Status: status, Message: message, Description: description}
+}
+
+// ErrorCatalog lists every error the API can return. Status is the usual
+// status for the code; {placeholders} in a message stand for variable parts.
+var ErrorCatalog = []ErrorDefinition{
+	// Generic
+	errorDef("bad_request", http.StatusBadRequest, "bad request", "The request was rejected; see message for details."),
+	errorDef("invalid_request_body", http.StatusBadRequest, "invalid request body", "The body is not valid JSON or has fields of the wrong type."),
+	errorDef("unauthorized", http.StatusUnauthorized, "unauthorized", "No authenticated user for this request."),
+	errorDef("forbidden", http.StatusForbidden, "forbidden", "The authenticated user may not perform this action."),
+	errorDef("not_found", http.StatusNotFound, "resource not found", "No route or resource matches the request."),
+	errorDef("method_not_allowed", http.StatusMethodNotAllowed, "method {method} not allowed, allowed methods: {methods}", "The route exists but not for this HTTP method."),
+	errorDef("conflict", http.StatusConflict, "conflict", "The request conflicts with the current state of the resource."),
+	errorDef("rate_limited", http.StatusTooManyRequests, "too many requests", "Slow down and retry after the Retry-After header's number of seconds."),
+	errorDef("internal_error", http.StatusInternalServerError, "internal server error", "An unexpected server error; safe to retry."),
+	errorDef("bad_gateway", http.StatusBadGateway, "bad gateway", "An upstream service failed."),
+	errorDef("service_unavailable", http.StatusServiceUnavailable, "service unavailable", "The server cannot handle the request right now."),
+
+	// Authentication
+	errorDef("missing_authorization", http.StatusUnauthorized, "missing authorization header", "Send Authorization: Bearer <token>."),
+	errorDef("invalid_authorization_format", http.StatusUnauthorized, "invalid authorization header format", "The Authorization header is not a Bearer token."),
+	errorDef("invalid_token", http.StatusUnauthorized, "invalid or expired token", "The access token is malformed, expired or revoked."),
+	errorDef("invalid_credentials", http.StatusUnauthorized, "invalid credentials", "Email or password is wrong."),
+	errorDef("invalid_refresh_token", http.StatusUnauthorized, "invalid refresh token", "The refresh token is unknown, expired or already used."),
+	errorDef("refresh_token_required", http.StatusBadRequest, "refresh_token is required", "The refresh request has no refresh_token."),
+	errorDef("registration_fields_required", http.StatusBadRequest, "email, username, and password are required", "Registration needs all three fields."),
+	errorDef("login_fields_required", http.StatusBadRequest, "email and password are required", "Login needs both fields."),
+	errorDef("password_too_short", http.StatusBadRequest, "password must be at least 6 characters", "Choose a longer password."),
+	errorDef("email_taken", http.StatusConflict, "user with this email already exists", "Another account uses this email."),
+	errorDef("email_not_verified", http.StatusForbidden, "email not verified", "Verify the email address before continuing."),
+	errorDef("email_required", http.StatusBadRequest, "email is required", "The request has no email."),
+	errorDef("token_required", http.StatusBadRequest, "token is required", "The request has no token."),
+	errorDef("invalid_verification_token", http.StatusBadRequest, "invalid or expired verification token", "Request a new verification email."),
+	errorDef("reset_fields_required", http.StatusBadRequest, "token and new_password are required", "Password reset needs both fields."),
+	errorDef("invalid_reset_token", http.StatusBadRequest, "invalid or expired reset token", "Ask an admin for a new reset token."),
+	errorDef("change_password_fields_required", http.StatusBadRequest, "current_password and new_password are required", "Password change needs both fields."),
+	errorDef("current_password_incorrect", http.StatusUnauthorized, "current password is incorrect", "The current password does not match."),
+	errorDef("password_unchanged", http.StatusBadRequest, "new password must differ from the current password", "Pick a different password."),
+	errorDef("permission_required", http.StatusForbidden, "permission {permission} required", "The user lacks the permission this route needs."),
+	errorDef("account_locked", http.StatusForbidden, "account is temporarily locked", "Too many consecutive wrong passwords; wait for the lock to expire or ask an admin to unlock the account."),
+	errorDef("login_rate_limited", http.StatusTooManyRequests, "too many failed login attempts, try again later", "Wait for the Retry-After header's number of seconds."),
+	errorDef("invalid_oauth_state", http.StatusBadRequest, "invalid oauth state", "The login state cookie is missing or does not match; start the login again."),
+	errorDef("oauth_login_incomplete", http.StatusUnauthorized, "login was not completed: {reason}", "The provider reported an error or the user cancelled."),
+	errorDef("oauth_code_required", http.StatusBadRequest, "code is required", "The provider callback has no code."),
+	errorDef("oauth_provider_failed", http.StatusBadGateway, "failed to verify {provider} login", "The provider could not be reached or rejected the code."),
+
+	// API keys
+	errorDef("invalid_api_key", http.StatusUnauthorized, "invalid api key", "The X-API-Key is unknown or revoked."),
+	errorDef("api_key_scope_missing", http.StatusForbidden, "api key lacks the {scope} scope", "Create a key with the required scope."),
+	errorDef("token_scope_missing", http.StatusForbidden, "token lacks the {scope} scope", "Issue a token with the required scope."),
+	errorDef("scoped_token_not_allowed", http.StatusForbidden, "scoped tokens are not accepted here", "Scoped tokens only work on /tasks, /reports and /exports routes; use a login token."),
+	errorDef("scopes_required", http.StatusBadRequest, "scopes is required", "List at least one scope."),
+	errorDef("invalid_token_lifetime", http.StatusBadRequest, "expires_in_hours must be between 1 and {max}", "Pick a lifetime within the allowed range."),
+	errorDef("api_key_name_required", http.StatusBadRequest, "name is required", "Give the key a name."),
+	errorDef("api_key_name_too_long", http.StatusBadRequest, "name must be at most {max} characters", "Shorten the key name."),
+	errorDef("invalid_api_key_scope", http.StatusBadRequest, "invalid scope, must be one of: {scopes}", "Use one of the listed scopes."),
+	errorDef("api_key_limit_reached", http.StatusConflict, "at most {max} active api keys are allowed", "Revoke an unused key first."),
+	errorDef("invalid_session_id", http.StatusBadRequest, "invalid session ID", "The ID is not a valid ObjectID."),
+	errorDef("session_not_found", http.StatusNotFound, "session not found", "No active session with this ID belongs to the user."),
+	errorDef("invalid_api_key_id", http.StatusBadRequest, "invalid api key ID", "The ID is not a valid ObjectID."),
+	errorDef("api_key_not_found", http.StatusNotFound, "api key not found", "No active key with this ID belongs to the user."),
+
+	// Webhooks
+	errorDef("webhook_url_required", http.StatusBadRequest, "url is required", "Give the endpoint to deliver events to."),
+	errorDef("invalid_webhook_url", http.StatusBadRequest, "invalid url, must be an absolute http or https URL", "Use a full http:// or https:// URL of at most 2048 characters."),
+	errorDef("invalid_webhook_event", http.StatusBadRequest, "invalid event, must be one of: {events}", "Use one of the listed events, or omit events to receive all of them."),
+	errorDef("webhook_secret_too_short", http.StatusBadRequest, "secret must be at least {min} characters", "Use a longer secret, or omit it to have one generated."),
+	errorDef("invalid_webhook_schema_version", http.StatusBadRequest, "invalid schema_version, must be one of: {versions}", "Use a version listed at GET /webhooks/schemas, or omit it for the latest."),
+	errorDef("webhook_update_required", http.StatusBadRequest, "schema_version or enabled is required", "Give the version to pin the webhook to, or whether it is enabled."),
+	errorDef("webhook_limit_reached", http.StatusConflict, "at most {max} webhooks are allowed", "Delete an unused webhook first."),
+	errorDef("invalid_webhook_id", http.StatusBadRequest, "invalid webhook ID", "The ID is not a valid ObjectID."),
+	errorDef("webhook_not_found", http.StatusNotFound, "webhook not found", "No webhook with this ID belongs to the user."),
+
+	// Notification templates
+	errorDef("notification_template_not_found", http.StatusNotFound, "notification template not found", "Use one of the names GET /admin/notification-templates lists."),
+	errorDef("custom_notification_template_not_found", http.StatusNotFound, "custom notification template not found", "The template is already the built-in one."),
+	errorDef("notification_template_required", http.StatusBadRequest, "subject and body are required", "Give both the subject and the body template; text is optional."),
+	errorDef("invalid_notification_template", http.StatusBadRequest, "invalid template: {reason}", "Fix the template syntax, and only use the fields of the template's data."),
+
+	// Notification channels
+	errorDef("notification_channel_type_required", http.StatusBadRequest, "type is required", "Name the kind of channel to connect."),
+	errorDef("invalid_notification_channel_type", http.StatusBadRequest, "invalid type, must be one of: {types}", "Use one of the listed channel types."),
+	errorDef("invalid_notification_event", http.StatusBadRequest, "invalid notification event, must be one of: {events}", "Use one of the listed events, or omit events for the channel type's defaults."),
+	errorDef("slack_target_required", http.StatusBadRequest, "slack is required", "Give the incoming webhook URL, or a bot token and channel."),
+	errorDef("invalid_slack_target", http.StatusBadRequest, "slack needs either webhook_url, or bot_token and channel", "Set one way of posting, not both."),
+	errorDef("invalid_slack_webhook_url", http.StatusBadRequest, "invalid slack webhook_url, must start with https://hooks.slack.com/services/", "Copy the URL of an incoming webhook from the Slack app's settings."),
+	errorDef("notification_channel_limit_reached", http.StatusConflict, "at most {max} notification channels are allowed", "Delete an unused channel first."),
+	errorDef("invalid_notification_channel_id", http.StatusBadRequest, "invalid notification channel ID", "The ID is not a valid ObjectID."),
+	errorDef("notification_channel_not_found", http.StatusNotFound, "notification channel not found", "No notification channel with this ID belongs to the user."),
+	errorDef("telegram_not_configured", http.StatusServiceUnavailable, "telegram notifications are not configured", "Set TELEGRAM_BOT_TOKEN to enable them."),
+	errorDef("notification_channel_not_linked", http.StatusConflict, "notification channel is not linked yet", "Open the channel's link_url in Telegram and tap Start first."),
+	errorDef("invalid_telegram_secret", http.StatusUnauthorized, "invalid secret token", "The update does not carry the configured TELEGRAM_WEBHOOK_SECRET."),
+	errorDef("invalid_telegram_update", http.StatusBadRequest, "invalid update body", "The body is not a Telegram update."),
+	errorDef("notification_preferences_required", http.StatusBadRequest, "channels is required", "Name at least one channel and the events it gets."),
+	errorDef("invalid_notification_preference_channel", http.StatusBadRequest, "invalid notification channel, must be one of: {channels}", "Use one of the listed channels."),
+	errorDef("push_not_configured", http.StatusServiceUnavailable, "push notifications are not configured", "Set VAPID_PRIVATE_KEY and VAPID_SUBJECT to enable them."),
+	errorDef("push_endpoint_required", http.StatusBadRequest, "endpoint is required", "Send the browser's PushSubscription as JSON."),
+	errorDef("invalid_push_endpoint", http.StatusBadRequest, "invalid endpoint, must be an https URL", "Send the endpoint the browser's push service gave out, of at most 2048 characters."),
+	errorDef("invalid_push_p256dh", http.StatusBadRequest, "invalid keys.p256dh", "Send the subscription's p256dh key as URL-safe base64."),
+	errorDef("invalid_push_auth", http.StatusBadRequest, "invalid keys.auth", "Send the subscription's 16-byte auth secret as URL-safe base64."),
+	errorDef("push_subscription_expired", http.StatusBadRequest, "subscription has already expired", "Subscribe again in the browser."),
+	errorDef("push_subscription_limit_reached", http.StatusConflict, "at most {max} push subscriptions are allowed", "Remove a browser you no longer use first."),
+	errorDef("invalid_push_subscription_id", http.StatusBadRequest, "invalid push subscription ID", "The ID is not a valid ObjectID."),
+	errorDef("push_subscription_not_found", http.StatusNotFound, "push subscription not found", "No push subscription with this ID belongs to the user."),
+	errorDef("notification_test_failed", http.StatusBadGateway, "test message failed: {reason}", "The chat service refused the message or could not be reached; check the channel's settings."),
+
+	// Account
+	errorDef("profile_fields_required", http.StatusBadRequest, "username, email or preferences is required", "Send at least one field to update."),
+	errorDef("username_empty", http.StatusBadRequest, "username must not be empty", "The username cannot be blank."),
+	errorDef("email_empty", http.StatusBadRequest, "email must not be empty", "The email cannot be blank."),
+	errorDef("settings_fields_required", http.StatusBadRequest, "auto_complete or auto_complete_minutes is required", "Send at least one setting to change."),
+	errorDef("invalid_auto_complete_minutes", http.StatusBadRequest, "auto_complete_minutes must be between 0 and 1440", "Pass the minutes after which your open tasks are auto-completed, at most a day, or 0 for the server default."),
+	errorDef("password_required", http.StatusBadRequest, "password is required", "Confirm the action with the current password."),
+	errorDef("password_incorrect", http.StatusUnauthorized, "password is incorrect", "The password does not match."),
+	errorDef("invalid_tasks_option", http.StatusBadRequest, "tasks must be one of: delete, reassign", "Choose what happens to the user's tasks."),
+	errorDef("invalid_reassign_to", http.StatusBadRequest, "invalid reassign_to", "reassign_to must be a user ID or unassigned."),
+	errorDef("account_legal_hold", http.StatusConflict, "account is under legal hold", "Held accounts cannot be deleted."),
+	errorDef("replica_set_required", http.StatusServiceUnavailable, "account deletion requires a MongoDB replica set", "Transactions need a replica set."),
+	errorDef("passphrase_too_short", http.StatusBadRequest, "passphrase must be at least {min} characters", "Choose a longer private passphrase."),
+	errorDef("private_passphrase_set", http.StatusConflict, "private passphrase is already set", "The passphrase cannot be changed; private tasks are encrypted with it."),
+
+	// Tasks
+	errorDef("invalid_task_id", http.StatusBadRequest, "invalid task ID", "The ID is not a valid ObjectID."),
+	errorDef("task_not_found", http.StatusNotFound, "task not found", "The task does not exist."),
+	errorDef("task_access_denied", http.StatusForbidden, "you don't have permission to access this task", "The task belongs to another user."),
+	errorDef("task_update_denied", http.StatusForbidden, "you don't have permission to update this task", "The task belongs to another user."),
+	errorDef("task_delete_denied", http.StatusForbidden, "you don't have permission to delete this task", "The task belongs to another user."),
+	errorDef("title_required", http.StatusBadRequest, "title is required", "Tasks need a title."),
+	errorDef("title_empty", http.StatusBadRequest, "title cannot be empty", "The title cannot be blank."),
+	errorDef("invalid_status", http.StatusBadRequest, "invalid status, must be one of: pending, in_progress, completed", "Use one of the listed statuses."),
+	errorDef("invalid_priority", http.StatusBadRequest, "invalid priority, must be one of: low, medium, high", "Use one of the listed priorities, or an empty string for none."),
+	errorDef("invalid_status_filter", http.StatusBadRequest, "invalid status filter, must be one of: pending, in_progress, completed", "Use one of the listed statuses."),
+	errorDef("invalid_include_archived", http.StatusBadRequest, "invalid include_archived, must be true or false", "Use true or false."),
+	errorDef("invalid_parent_id", http.StatusBadRequest, "invalid parent_id", "parent_id must be a task ID."),
+	errorDef("parent_task_not_found", http.StatusBadRequest, "parent task not found", "The parent task does not exist or belongs to another user."),
+	errorDef("open_subtasks", http.StatusConflict, "task cannot be completed while it has open subtasks", "Complete the subtasks first."),
+	errorDef("subtasks_option_required", http.StatusConflict, "task has subtasks, specify subtasks=cascade or subtasks=orphan", "Choose what happens to the subtasks."),
+	errorDef("field_update_denied", http.StatusForbidden, "you don't have permission to change some fields of this task", "The field policy reserves the fields in details.blocked_fields for other roles."),
+	errorDef("task_blocked", http.StatusConflict, "task cannot be started or completed while its blockers are open", "Complete the blocking tasks first."),
+	errorDef("task_precondition_failed", http.StatusPreconditionFailed, "task has changed since the given ETag, fetch it again", "If-Match does not list the task's current ETag."),
+	errorDef("task_modified", http.StatusConflict, "task was modified by another request, retry", "Another write changed the task while this one was applied."),
+	errorDef("private_passphrase_required", http.StatusBadRequest, "private passphrase is required", "Send X-Private-Passphrase to change a private task's content."),
+	errorDef("private_passphrase_not_set", http.StatusBadRequest, "private passphrase is not set up", "Set one with PUT /me/private-passphrase first."),
+	errorDef("invalid_private_passphrase", http.StatusForbidden, "invalid private passphrase", "X-Private-Passphrase does not match the one that was set up."),
+	errorDef("private_task_owner_only", http.StatusForbidden, "only the owner can change a private task", "Private tasks are encrypted with their owner's key."),
+	errorDef("self_blocking", http.StatusBadRequest, "a task cannot block itself", "Remove the task's own ID from blocked_by."),
+	errorDef("blocker_not_found", http.StatusBadRequest, "blocked_by task not found", "A blocking task does not exist."),
+	errorDef("blocker_cycle", http.StatusBadRequest, "blocked_by would create a dependency cycle", "Blockers must not form a cycle."),
+	errorDef("too_many_blockers", http.StatusBadRequest, "a task can be blocked by at most {max} tasks", "Remove some blockers."),
+	errorDef("invalid_blocker_id", http.StatusBadRequest, "invalid blocked_by task ID: {id}", "blocked_by must contain task IDs."),
+	errorDef("invalid_recurrence_rule", http.StatusBadRequest, "invalid recurrence rule", "The rule is not valid RRULE syntax."),
+	errorDef("invalid_recurrence_interval", http.StatusBadRequest, "invalid recurrence interval", "INTERVAL must be a positive integer."),
+	errorDef("recurrence_freq_required", http.StatusBadRequest, "recurrence rule requires FREQ", "Add FREQ to the rule."),
+	errorDef("unsupported_recurrence", http.StatusBadRequest, "unsupported recurrence {part}", "Use only the supported rule parts."),
+	errorDef("freeze_conflict", http.StatusBadRequest, "freeze_at and unfreeze cannot be combined", "Send only one of the two fields."),
+	errorDef("bulk_filter_required", http.StatusBadRequest, "bulk delete requires a filter", "Pass ids or status."),
+	errorDef("bulk_ids_or_status_required", http.StatusBadRequest, "ids or status is required", "Pass ids or status."),
+	errorDef("bulk_too_many_ids", http.StatusBadRequest, "at most {max} ids can be deleted at once", "Split the request."),
+	errorDef("invalid_bulk_task_id", http.StatusBadRequest, "invalid task ID: {id}", "ids must contain task IDs."),
+	errorDef("invalid_bulk_user_id", http.StatusBadRequest, "invalid user_id", "user_id must be the ID of an existing user."),
+	errorDef("bulk_delete_denied", http.StatusForbidden, "you don't have permission to delete other users' tasks", "Deleting another user's tasks needs tasks:delete_any."),
+	errorDef("invalid_subtasks_option", http.StatusBadRequest, "invalid subtasks, must be one of: cascade, orphan", "subtasks must be cascade or orphan."),
+	errorDef("invalid_week", http.StatusBadRequest, "invalid week, use YYYY-Www such as 2024-W21", "week is an ISO 8601 week."),
+	errorDef("week_out_of_range", http.StatusBadRequest, "invalid week, {year} has no week {week}", "Weeks run from 01 to 52 or 53."),
+	errorDef("schedule_date_required", http.StatusBadRequest, "date is required", "Send a date, or null to unschedule."),
+	errorDef("invalid_schedule_date", http.StatusBadRequest, "invalid date, use YYYY-MM-DD", "Send a calendar date."),
+	errorDef("task_not_in_my_day", http.StatusNotFound, "task is not in my day", "The task is not on your my day list."),
+	errorDef("pomodoro_running", http.StatusConflict, "a pomodoro session is already running", "Stop the running session or wait for it to end."),
+	errorDef("pomodoro_not_running", http.StatusNotFound, "no pomodoro session is running for this task", "Only a running session can be stopped."),
+	errorDef("invalid_pomodoro_minutes", http.StatusBadRequest, "minutes must be between 1 and {max}", "Pick a shorter session."),
+	errorDef("invalid_focus_period", http.StatusBadRequest, "invalid period, must be one of: day, week", "Use one of the listed periods."),
+	errorDef("unsupported_export_format", http.StatusBadRequest, "unsupported export format, must be one of: markdown, xlsx", "Pass format=markdown or format=xlsx."),
+	errorDef("import_too_large", http.StatusRequestEntityTooLarge, "import must be at most 1MB", "Split the checklist into smaller imports."),
+	errorDef("import_no_items", http.StatusBadRequest, "no checklist items found", "The body has no \"- [ ] title\" lines."),
+	errorDef("import_too_many_items", http.StatusBadRequest, "at most {max} tasks can be imported at once", "Split the checklist into smaller imports."),
+	errorDef("import_invalid_due_date", http.StatusBadRequest, "line {line}: invalid due date {value}, use YYYY-MM-DD or RFC 3339", "Fix the (due ...) suffix on the named line."),
+	errorDef("import_title_required", http.StatusBadRequest, "line {line}: title is required", "The checklist item on the named line has no title."),
+	errorDef("import_invalid_json", http.StatusBadRequest, "invalid JSON, expected an array of tasks", "Send a JSON array, or NDJSON with Content-Type: application/x-ndjson."),
+	errorDef("import_no_tasks", http.StatusBadRequest, "no tasks found", "The JSON import holds no records."),
+	errorDef("api_token_required", http.StatusBadRequest, "api_token is required", "Pass the Todoist API token from Todoist's integration settings."),
+	errorDef("invalid_todoist_token", http.StatusBadRequest, "invalid Todoist API token", "Todoist rejected the token; copy it again from Todoist's integration settings."),
+	errorDef("todoist_unreachable", http.StatusBadGateway, "failed to reach Todoist", "Todoist did not answer; try again later."),
+	errorDef("import_already_running", http.StatusConflict, "an import is already running", "Wait for the running import to finish; its status is at /tasks/import/jobs/{id}."),
+	errorDef("invalid_import_job_id", http.StatusBadRequest, "invalid import job ID", "Use the id of the job returned when the import was started."),
+	errorDef("import_job_not_found", http.StatusNotFound, "import job not found", "Import jobs are kept for 30 days."),
+	errorDef("invalid_task_fields", http.StatusBadRequest, "unknown field {field} in fields, must be one of: {fields}", "List task fields separated by commas, e.g. fields=title,status."),
+	errorDef("invalid_export_format", http.StatusBadRequest, "invalid format, must be one of: csv, json", "Pass format csv or json."),
+	errorDef("export_already_running", http.StatusConflict, "an export is already running", "Wait for the running export to finish; its status is at /exports/{id}."),
+	errorDef("invalid_export_job_id", http.StatusBadRequest, "invalid export job ID", "Use the id of the job returned when the export was started."),
+	errorDef("export_job_not_found", http.StatusNotFound, "export job not found", "Exports are kept for 7 days."),
+	errorDef("export_not_ready", http.StatusConflict, "export is not ready", "Download once GET /exports/{id} reports the job completed."),
+	errorDef("invalid_report_range", http.StatusBadRequest, "from must not be after to", "Swap the dates; both days are included in the report."),
+	errorDef("invalid_usage_range", http.StatusBadRequest, "usage reports cover at most {days} days", "Split the range into shorter reports."),
+	errorDef("task_legal_hold", http.StatusConflict, "task is under legal hold", "Held tasks cannot be deleted."),
+
+	// Comments
+	errorDef("invalid_comment_id", http.StatusBadRequest, "invalid comment ID", "The ID is not a valid ObjectID."),
+	errorDef("comment_not_found", http.StatusNotFound, "comment not found", "The comment does not exist."),
+	errorDef("comment_body_required", http.StatusBadRequest, "body is required", "Comments need a body."),
+	errorDef("comment_body_too_long", http.StatusBadRequest, "body must be at most {max} characters", "Shorten the comment."),
+
+	// Billing
+	errorDef("plan_limit_reached", http.StatusPaymentRequired, "plan limit reached: the {plan} plan allows {max} {what}", "Delete records you no longer need or move to a larger plan; details.upgrade_url links to the upgrade page if there is one."),
+	errorDef("plan_feature_unavailable", http.StatusPaymentRequired, "the {plan} plan does not include {feature}", "Move to a plan that includes the feature; details.upgrade_url links to the upgrade page if there is one."),
+	errorDef("billing_disabled", http.StatusNotFound, "billing is not enabled", "This deployment has no plans; nothing is limited."),
+	errorDef("billing_provider_not_found", http.StatusNotFound, "billing provider not found", "Events are accepted from configured providers only, and only when billing is enabled."),
+	errorDef("invalid_billing_signature", http.StatusBadRequest, "invalid signature", "The event is not signed with the configured webhook secret, or is more than 5 minutes old."),
+	errorDef("invalid_billing_event", http.StatusBadRequest, "invalid event body", "The body is not a provider event."),
+	errorDef("billing_event_too_large", http.StatusRequestEntityTooLarge, "billing event is too large", "Events are limited to 256KB."),
+
+	// Admin
+	errorDef("permissions_required", http.StatusBadRequest, "permissions is required", "Send the full list of permissions, which may be empty."),
+	errorDef("invalid_permission", http.StatusBadRequest, "invalid permission: {permission}", "Use a permission from the documented list."),
+	errorDef("permission_not_held", http.StatusForbidden, "cannot grant a permission you do not hold: {permission}", "Only permissions the caller holds can be granted."),
+	errorDef("self_lockout", http.StatusForbidden, "cannot remove users:manage from yourself", "Ask another user manager to change your permissions."),
+	errorDef("login_attempts_filter_required", http.StatusBadRequest, "email or ip is required", "Name the email and/or IP to clear."),
+	errorDef("invalid_user_id", http.StatusBadRequest, "invalid user ID", "The ID is not a valid ObjectID."),
+	errorDef("user_not_found", http.StatusNotFound, "user not found", "The user does not exist."),
+	errorDef("reset_email_unverified", http.StatusConflict, "user email address is not verified", "The reset link can only go to a verified address. Use force-logout to sign the user out instead."),
+	errorDef("reassign_target_required", http.StatusBadRequest, "to is required", "Name the target user or unassigned."),
+	errorDef("invalid_target_user_id", http.StatusBadRequest, "invalid target user ID", "to must be a user ID or unassigned."),
+	errorDef("reassign_same_user", http.StatusBadRequest, "target user must differ from the source user", "Pick another target."),
+	errorDef("target_user_not_found", http.StatusNotFound, "target user not found", "The target user does not exist."),
+	errorDef("legal_hold_enabled_required", http.StatusBadRequest, "enabled is required", "Send enabled: true or false."),
+	errorDef("negative_grace_days", http.StatusBadRequest, "grace_days must not be negative", "Use zero or more days."),
+	errorDef("negative_completed_task_days", http.StatusBadRequest, "completed_task_days must not be negative", "Use zero or more days."),
+	errorDef("negative_audit_log_days", http.StatusBadRequest, "audit_log_days must not be negative", "Use zero or more days."),
+	errorDef("request_trace_not_found", http.StatusNotFound, "request trace not found", "Only requests that failed with a 5xx status in the last 7 days are kept."),
+	errorDef("invalid_older_than_days", http.StatusBadRequest, "older_than_days must be at least 1", "Archive whole days older than today."),
+	errorDef("audit_archive_disabled", http.StatusServiceUnavailable, "audit archiving is not configured", "Set AUDIT_ARCHIVE_STORE to enable it."),
+	errorDef("audit_archive_running", http.StatusConflict, "audit archive run already in progress", "Retry once the current run finishes."),
+	errorDef("invalid_audit_archive_id", http.StatusBadRequest, "invalid audit archive ID", "The ID is not a valid ObjectID."),
+	errorDef("audit_archive_not_found", http.StatusNotFound, "audit archive not found", "The archive does not exist."),
+	errorDef("invalid_archive_from", http.StatusBadRequest, "invalid from, use YYYY-MM-DD", "Send a calendar date."),
+	errorDef("invalid_archive_to", http.StatusBadRequest, "invalid to, use YYYY-MM-DD", "Send a calendar date."),
+	errorDef("invalid_dead_letter_id", http.StatusBadRequest, "invalid dead letter ID", "The ID is not a valid ObjectID."),
+	errorDef("dead_letter_not_found", http.StatusNotFound, "dead letter not found", "The dead letter does not exist or was already requeued."),
+	errorDef("dead_letter_queue_unknown", http.StatusConflict, "queue {queue} no longer exists", "The job's queue is not served by this deployment; it cannot be requeued."),
+	errorDef("invalid_policy_field", http.StatusBadRequest, "invalid field {field}, must be one of: {fields}", "Only task fields that can be updated can be restricted."),
+	errorDef("invalid_policy_role", http.StatusBadRequest, "invalid role {role}, must be one of: user, admin", "Use a role from the documented list."),
+}
+
+var genericErrorCodes = map[int]string{
+	http.StatusBadRequest:          "bad_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusPaymentRequired:     "payment_required",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusMethodNotAllowed:    "method_not_allowed",
+	http.StatusConflict:            "conflict",
+	http.StatusTooManyRequests:     "rate_limited",
+	http.StatusInternalServerError: "internal_error",
+	http.StatusBadGateway:          "bad_gateway",
+	http.StatusServiceUnavailable:  "service_unavailable",
+}
+
+// ErrorCode resolves the catalog code for a message. Unlisted messages,
+// including every 5xx "failed to ..." message, get the generic code for the status.
+func ErrorCode(status int, message string) string {
+	for _, def := range ErrorCatalog {
+		if matchErrorMessage(def.Message, message) {
+			return def.Code
+		}
+	}
+
+	if code, ok := genericErrorCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return "bad_request"
+}
+
+// matchErrorMessage reports whether message fits template, where each
+// {placeholder} in template matches any run of characters.
+func matchErrorMessage(template, message string) bool {
+	if !strings.Contains(template, "{") {
+		return template == message
+	}
+
+	var parts []string
+	rest := template
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		parts = append(parts, rest[:start])
+		rest = rest[start+end+1:]
+	}
+	parts = append(parts, rest)
+
+	if !strings.HasPrefix(message, parts[0]) {
+		return false
+	}
+	message = message[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(message, part)
+		if idx < 0 {
+			return false
+		}
+		message = message[idx+len(part):]
+	}
+	return strings.HasSuffix(message, parts[len(parts)-1])
+}
@@ -0,0 +1,697 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSON encoding policy for API models:
+//   - timestamps are always rendered as RFC3339Nano in UTC, independent of server locale
+//   - optional value fields use omitempty and are left out when unset
+//   - nullable fields are pointers without omitempty, so a cleared value is rendered as null
+const TimeFormat = time.RFC3339Nano
+
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeFormat)
+}
+
+func formatNullableTime(t *time.Time) *string {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	formatted := FormatTime(*t)
+	return &formatted
+}
+
+// Int64 is an int64 response counter. It is rendered as a number; clients
+// that read numbers as doubles can ask for decimal strings instead, which
+// utils.RespondJSON renders per request. Either form is read back.
+type Int64 int64
+
+func (n Int64) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(n), 10)), nil
+}
+
+func (n *Int64) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid int64 %s", data)
+	}
+	*n = Int64(value)
+	return nil
+}
+
+// Nullable tracks whether a request field was present at all, so PATCH bodies
+// can tell an omitted field (leave unchanged) from an explicit null (clear it).
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+	return nil
+}
+
+func (t Task) MarshalJSON() ([]byte, error) {
+	type taskAlias Task
+	return json.Marshal(struct {
+		taskAlias
+		DueDate   *string `json:"due_date"`
+		PurgeAt   *string `json:"purge_at"`
+		CreatedAt string  `json:"created_at"`
+		UpdatedAt string  `json:"updated_at"`
+	}{
+		taskAlias: taskAlias(t),
+		DueDate:   formatNullableTime(t.DueDate),
+		PurgeAt:   formatNullableTime(t.PurgeAt),
+		CreatedAt: FormatTime(t.CreatedAt),
+		UpdatedAt: FormatTime(t.UpdatedAt),
+	})
+}
+
+// MarshalJSON encodes the whole task and copies out the selected members
+func (s SparseTask) MarshalJSON() ([]byte, error) {
+	full, err := json.Marshal(s.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(full))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	out := []byte{'{'}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(s.Fields, key.(string)) {
+			continue
+		}
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = strconv.AppendQuote(out, key.(string))
+		out = append(out, ':')
+		out = append(out, value...)
+	}
+	return append(out, '}'), nil
+}
+
+func (u User) MarshalJSON() ([]byte, error) {
+	type userAlias User
+	return json.Marshal(struct {
+		userAlias
+		Permissions         []Permission `json:"permissions"`
+		EmailVerified       bool         `json:"email_verified"`
+		EmailVerifiedAt     *string      `json:"email_verified_at"`
+		PrivateTasksEnabled bool         `json:"private_tasks_enabled"`
+		CreatedAt           string       `json:"created_at"`
+	}{
+		userAlias:           userAlias(u),
+		Permissions:         u.EffectivePermissions(),
+		EmailVerified:       u.IsEmailVerified(),
+		EmailVerifiedAt:     formatNullableTime(u.EmailVerifiedAt),
+		PrivateTasksEnabled: len(u.PrivateKeySalt) > 0,
+		CreatedAt:           FormatTime(u.CreatedAt),
+	})
+}
+
+func (c Comment) MarshalJSON() ([]byte, error) {
+	type commentAlias Comment
+	return json.Marshal(struct {
+		commentAlias
+		CreatedAt string `json:"created_at"`
+	}{
+		commentAlias: commentAlias(c),
+		CreatedAt:    FormatTime(c.CreatedAt),
+	})
+}
+
+func (h TaskHistory) MarshalJSON() ([]byte, error) {
+	type historyAlias TaskHistory
+	return json.Marshal(struct {
+		historyAlias
+		CreatedAt string `json:"created_at"`
+	}{
+		historyAlias: historyAlias(h),
+		CreatedAt:    FormatTime(h.CreatedAt),
+	})
+}
+
+func (r ResetCredentialsResponse) MarshalJSON() ([]byte, error) {
+	type responseAlias ResetCredentialsResponse
+	return json.Marshal(struct {
+		responseAlias
+		ExpiresAt string `json:"expires_at"`
+	}{
+		responseAlias: responseAlias(r),
+		ExpiresAt:     FormatTime(r.ExpiresAt),
+	})
+}
+
+func (r SandboxTimeResponse) MarshalJSON() ([]byte, error) {
+	type responseAlias SandboxTimeResponse
+	return json.Marshal(struct {
+		responseAlias
+		Now string `json:"now"`
+	}{
+		responseAlias: responseAlias(r),
+		Now:           FormatTime(r.Now),
+	})
+}
+
+func (s SchemaStatus) MarshalJSON() ([]byte, error) {
+	type statusAlias SchemaStatus
+	return json.Marshal(struct {
+		statusAlias
+		UpdatedAt *string `json:"updated_at"`
+	}{
+		statusAlias: statusAlias(s),
+		UpdatedAt:   formatNullableTime(s.UpdatedAt),
+	})
+}
+
+func (i IndexInfo) MarshalJSON() ([]byte, error) {
+	type indexAlias IndexInfo
+	return json.Marshal(struct {
+		indexAlias
+		Since *string `json:"since"`
+	}{
+		indexAlias: indexAlias(i),
+		Since:      formatNullableTime(i.Since),
+	})
+}
+
+func (s IndexBuildStatus) MarshalJSON() ([]byte, error) {
+	type statusAlias IndexBuildStatus
+	return json.Marshal(struct {
+		statusAlias
+		StartedAt  *string `json:"started_at"`
+		FinishedAt *string `json:"finished_at"`
+	}{
+		statusAlias: statusAlias(s),
+		StartedAt:   formatNullableTime(s.StartedAt),
+		FinishedAt:  formatNullableTime(s.FinishedAt),
+	})
+}
+
+func (p RetentionPolicy) MarshalJSON() ([]byte, error) {
+	type policyAlias RetentionPolicy
+	return json.Marshal(struct {
+		policyAlias
+		UpdatedAt *string `json:"updated_at"`
+	}{
+		policyAlias: policyAlias(p),
+		UpdatedAt:   formatNullableTime(p.UpdatedAt),
+	})
+}
+
+func (p TaskFieldPolicy) MarshalJSON() ([]byte, error) {
+	type policyAlias TaskFieldPolicy
+	return json.Marshal(struct {
+		policyAlias
+		UpdatedAt *string `json:"updated_at"`
+	}{
+		policyAlias: policyAlias(p),
+		UpdatedAt:   formatNullableTime(p.UpdatedAt),
+	})
+}
+
+func (r RetentionReport) MarshalJSON() ([]byte, error) {
+	type reportAlias RetentionReport
+	return json.Marshal(struct {
+		reportAlias
+		RanAt string `json:"ran_at"`
+	}{
+		reportAlias: reportAlias(r),
+		RanAt:       FormatTime(r.RanAt),
+	})
+}
+
+func (a AuditLog) MarshalJSON() ([]byte, error) {
+	type auditLogAlias AuditLog
+	return json.Marshal(struct {
+		auditLogAlias
+		CreatedAt string `json:"created_at"`
+	}{
+		auditLogAlias: auditLogAlias(a),
+		CreatedAt:     FormatTime(a.CreatedAt),
+	})
+}
+
+func (a AuditArchive) MarshalJSON() ([]byte, error) {
+	type archiveAlias AuditArchive
+	return json.Marshal(struct {
+		archiveAlias
+		Day          string `json:"day"`
+		FirstEntryAt string `json:"first_entry_at"`
+		LastEntryAt  string `json:"last_entry_at"`
+		CreatedAt    string `json:"created_at"`
+	}{
+		archiveAlias: archiveAlias(a),
+		Day:          a.Day.UTC().Format("2006-01-02"),
+		FirstEntryAt: FormatTime(a.FirstEntryAt),
+		LastEntryAt:  FormatTime(a.LastEntryAt),
+		CreatedAt:    FormatTime(a.CreatedAt),
+	})
+}
+
+func (r AuditArchiveRunReport) MarshalJSON() ([]byte, error) {
+	type reportAlias AuditArchiveRunReport
+	return json.Marshal(struct {
+		reportAlias
+		Cutoff string `json:"cutoff"`
+	}{
+		reportAlias: reportAlias(r),
+		Cutoff:      FormatTime(r.Cutoff),
+	})
+}
+
+func (t RequestTrace) MarshalJSON() ([]byte, error) {
+	type traceAlias RequestTrace
+	if t.Logs == nil {
+		t.Logs = []string{}
+	}
+	return json.Marshal(struct {
+		traceAlias
+		StartedAt string `json:"started_at"`
+	}{
+		traceAlias: traceAlias(t),
+		StartedAt:  FormatTime(t.StartedAt),
+	})
+}
+
+func (j ImportJob) MarshalJSON() ([]byte, error) {
+	type jobAlias ImportJob
+	if j.Failures == nil {
+		j.Failures = []ImportJobFailure{}
+	}
+	return json.Marshal(struct {
+		jobAlias
+		CreatedAt  string  `json:"created_at"`
+		UpdatedAt  string  `json:"updated_at"`
+		FinishedAt *string `json:"finished_at"`
+	}{
+		jobAlias:   jobAlias(j),
+		CreatedAt:  FormatTime(j.CreatedAt),
+		UpdatedAt:  FormatTime(j.UpdatedAt),
+		FinishedAt: formatNullableTime(j.FinishedAt),
+	})
+}
+
+func (c DeprecationClient) MarshalJSON() ([]byte, error) {
+	type clientAlias DeprecationClient
+	return json.Marshal(struct {
+		clientAlias
+		FirstSeen string `json:"first_seen"`
+		LastSeen  string `json:"last_seen"`
+	}{
+		clientAlias: clientAlias(c),
+		FirstSeen:   FormatTime(c.FirstSeen),
+		LastSeen:    FormatTime(c.LastSeen),
+	})
+}
+
+func (j ExportJob) MarshalJSON() ([]byte, error) {
+	type jobAlias ExportJob
+	return json.Marshal(struct {
+		jobAlias
+		CreatedAt  string  `json:"created_at"`
+		UpdatedAt  string  `json:"updated_at"`
+		FinishedAt *string `json:"finished_at"`
+		ExpiresAt  string  `json:"expires_at"`
+	}{
+		jobAlias:   jobAlias(j),
+		CreatedAt:  FormatTime(j.CreatedAt),
+		UpdatedAt:  FormatTime(j.UpdatedAt),
+		FinishedAt: formatNullableTime(j.FinishedAt),
+		ExpiresAt:  FormatTime(j.ExpiresAt),
+	})
+}
+
+func (i Identity) MarshalJSON() ([]byte, error) {
+	type identityAlias Identity
+	return json.Marshal(struct {
+		identityAlias
+		LinkedAt string `json:"linked_at"`
+	}{
+		identityAlias: identityAlias(i),
+		LinkedAt:      FormatTime(i.LinkedAt),
+	})
+}
+
+func (r ScopedTokenResponse) MarshalJSON() ([]byte, error) {
+	type responseAlias ScopedTokenResponse
+	return json.Marshal(struct {
+		responseAlias
+		ExpiresAt string `json:"expires_at"`
+	}{
+		responseAlias: responseAlias(r),
+		ExpiresAt:     FormatTime(r.ExpiresAt),
+	})
+}
+
+func (s Session) MarshalJSON() ([]byte, error) {
+	type sessionAlias Session
+	return json.Marshal(struct {
+		sessionAlias
+		CreatedAt  string `json:"created_at"`
+		LastUsedAt string `json:"last_used_at"`
+		ExpiresAt  string `json:"expires_at"`
+	}{
+		sessionAlias: sessionAlias(s),
+		CreatedAt:    FormatTime(s.CreatedAt),
+		LastUsedAt:   FormatTime(s.LastUsedAt),
+		ExpiresAt:    FormatTime(s.ExpiresAt),
+	})
+}
+
+func (e MyDayEntry) MarshalJSON() ([]byte, error) {
+	type entryAlias MyDayEntry
+	return json.Marshal(struct {
+		entryAlias
+		AddedAt string `json:"added_at"`
+	}{
+		entryAlias: entryAlias(e),
+		AddedAt:    FormatTime(e.AddedAt),
+	})
+}
+
+func (t TaskSummary) MarshalJSON() ([]byte, error) {
+	type summaryAlias TaskSummary
+	return json.Marshal(struct {
+		summaryAlias
+		UpdatedAt string `json:"updated_at"`
+	}{
+		summaryAlias: summaryAlias(t),
+		UpdatedAt:    FormatTime(t.UpdatedAt),
+	})
+}
+
+func (r ProjectionCheckReport) MarshalJSON() ([]byte, error) {
+	type reportAlias ProjectionCheckReport
+	return json.Marshal(struct {
+		reportAlias
+		CheckedAt string `json:"checked_at"`
+	}{
+		reportAlias: reportAlias(r),
+		CheckedAt:   FormatTime(r.CheckedAt),
+	})
+}
+
+func (b Badge) MarshalJSON() ([]byte, error) {
+	type badgeAlias Badge
+	return json.Marshal(struct {
+		badgeAlias
+		AwardedAt string `json:"awarded_at"`
+	}{
+		badgeAlias: badgeAlias(b),
+		AwardedAt:  FormatTime(b.AwardedAt),
+	})
+}
+
+func (s FocusSession) MarshalJSON() ([]byte, error) {
+	type sessionAlias FocusSession
+	return json.Marshal(struct {
+		sessionAlias
+		StartedAt string  `json:"started_at"`
+		EndsAt    string  `json:"ends_at"`
+		EndedAt   *string `json:"ended_at"`
+	}{
+		sessionAlias: sessionAlias(s),
+		StartedAt:    FormatTime(s.StartedAt),
+		EndsAt:       FormatTime(s.EndsAt),
+		EndedAt:      formatNullableTime(s.EndedAt),
+	})
+}
+
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	type keyAlias APIKey
+	return json.Marshal(struct {
+		keyAlias
+		LastUsedAt *string `json:"last_used_at"`
+		CreatedAt  string  `json:"created_at"`
+		RevokedAt  *string `json:"revoked_at"`
+	}{
+		keyAlias:   keyAlias(k),
+		LastUsedAt: formatNullableTime(k.LastUsedAt),
+		CreatedAt:  FormatTime(k.CreatedAt),
+		RevokedAt:  formatNullableTime(k.RevokedAt),
+	})
+}
+
+// CreateAPIKeyResponse would otherwise inherit APIKey's MarshalJSON and drop the raw key
+func (r CreateAPIKeyResponse) MarshalJSON() ([]byte, error) {
+	keyJSON, err := json.Marshal(r.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	rawJSON, err := json.Marshal(r.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(keyJSON[:len(keyJSON)-1:len(keyJSON)-1], `,"key":`...)
+	out = append(out, rawJSON...)
+	return append(out, '}'), nil
+}
+
+func (w Webhook) MarshalJSON() ([]byte, error) {
+	type webhookAlias Webhook
+	return json.Marshal(struct {
+		webhookAlias
+		SchemaVersion int     `json:"schema_version"`
+		FailingSince  *string `json:"failing_since"`
+		DisabledAt    *string `json:"disabled_at"`
+		CreatedAt     string  `json:"created_at"`
+	}{
+		webhookAlias:  webhookAlias(w),
+		SchemaVersion: w.PinnedSchemaVersion(),
+		FailingSince:  formatNullableTime(w.FailingSince),
+		DisabledAt:    formatNullableTime(w.DisabledAt),
+		CreatedAt:     FormatTime(w.CreatedAt),
+	})
+}
+
+// CreateWebhookResponse would otherwise inherit Webhook's MarshalJSON and drop the secret
+func (r CreateWebhookResponse) MarshalJSON() ([]byte, error) {
+	webhookJSON, err := json.Marshal(r.Webhook)
+	if err != nil {
+		return nil, err
+	}
+	secretJSON, err := json.Marshal(r.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(webhookJSON[:len(webhookJSON)-1:len(webhookJSON)-1], `,"secret":`...)
+	out = append(out, secretJSON...)
+	return append(out, '}'), nil
+}
+
+func (p WebhookPayload) MarshalJSON() ([]byte, error) {
+	type payloadAlias WebhookPayload
+	return json.Marshal(struct {
+		payloadAlias
+		OccurredAt string `json:"occurred_at"`
+	}{
+		payloadAlias: payloadAlias(p),
+		OccurredAt:   FormatTime(p.OccurredAt),
+	})
+}
+
+// The payload is the exact JSON body that was signed and is embedded as JSON
+func (d WebhookDelivery) MarshalJSON() ([]byte, error) {
+	type deliveryAlias WebhookDelivery
+	return json.Marshal(struct {
+		deliveryAlias
+		Payload       json.RawMessage `json:"payload"`
+		NextAttemptAt *string         `json:"next_attempt_at"`
+		LastAttemptAt *string         `json:"last_attempt_at"`
+		DeliveredAt   *string         `json:"delivered_at"`
+		CreatedAt     string          `json:"created_at"`
+	}{
+		deliveryAlias: deliveryAlias(d),
+		Payload:       json.RawMessage(d.Payload),
+		NextAttemptAt: formatNullableTime(d.NextAttemptAt),
+		LastAttemptAt: formatNullableTime(d.LastAttemptAt),
+		DeliveredAt:   formatNullableTime(d.DeliveredAt),
+		CreatedAt:     FormatTime(d.CreatedAt),
+	})
+}
+
+func (a LoginAttempt) MarshalJSON() ([]byte, error) {
+	type attemptAlias LoginAttempt
+	return json.Marshal(struct {
+		attemptAlias
+		WindowStart string `json:"window_start"`
+		ResetAt     string `json:"reset_at"`
+	}{
+		attemptAlias: attemptAlias(a),
+		WindowStart:  FormatTime(a.WindowStart),
+		ResetAt:      FormatTime(a.ResetAt),
+	})
+}
+
+func (v WorkerVerdict) MarshalJSON() ([]byte, error) {
+	type verdictAlias WorkerVerdict
+	return json.Marshal(struct {
+		verdictAlias
+		EligibleAt  string `json:"eligible_at"`
+		EvaluatedAt string `json:"evaluated_at"`
+	}{
+		verdictAlias: verdictAlias(v),
+		EligibleAt:   FormatTime(v.EligibleAt),
+		EvaluatedAt:  FormatTime(v.EvaluatedAt),
+	})
+}
+
+func (d DeadLetter) MarshalJSON() ([]byte, error) {
+	type deadLetterAlias DeadLetter
+	return json.Marshal(struct {
+		deadLetterAlias
+		EnqueuedAt string `json:"enqueued_at"`
+		FailedAt   string `json:"failed_at"`
+	}{
+		deadLetterAlias: deadLetterAlias(d),
+		EnqueuedAt:      FormatTime(d.EnqueuedAt),
+		FailedAt:        FormatTime(d.FailedAt),
+	})
+}
+
+func (s WorkerStatus) MarshalJSON() ([]byte, error) {
+	type statusAlias WorkerStatus
+	return json.Marshal(struct {
+		statusAlias
+		PausedAt   *string `json:"paused_at"`
+		LastScanAt *string `json:"last_scan_at"`
+	}{
+		statusAlias: statusAlias(s),
+		PausedAt:    formatNullableTime(s.PausedAt),
+		LastScanAt:  formatNullableTime(s.LastScanAt),
+	})
+}
+
+func (s PushSubscription) MarshalJSON() ([]byte, error) {
+	type subscriptionAlias PushSubscription
+	return json.Marshal(struct {
+		subscriptionAlias
+		ExpiresAt *string `json:"expires_at"`
+		CreatedAt string  `json:"created_at"`
+	}{
+		subscriptionAlias: subscriptionAlias(s),
+		ExpiresAt:         formatNullableTime(s.ExpiresAt),
+		CreatedAt:         FormatTime(s.CreatedAt),
+	})
+}
+
+func (c NotificationChannel) MarshalJSON() ([]byte, error) {
+	type channelAlias NotificationChannel
+	return json.Marshal(struct {
+		channelAlias
+		CreatedAt string `json:"created_at"`
+	}{
+		channelAlias: channelAlias(c),
+		CreatedAt:    FormatTime(c.CreatedAt),
+	})
+}
+
+func (t TelegramTarget) MarshalJSON() ([]byte, error) {
+	type targetAlias TelegramTarget
+	return json.Marshal(struct {
+		targetAlias
+		LinkExpiresAt *string `json:"link_expires_at,omitempty"`
+		LinkedAt      *string `json:"linked_at"`
+	}{
+		targetAlias:   targetAlias(t),
+		LinkExpiresAt: formatNullableTime(t.LinkExpiresAt),
+		LinkedAt:      formatNullableTime(t.LinkedAt),
+	})
+}
+
+func (j ScheduledJob) MarshalJSON() ([]byte, error) {
+	type jobAlias ScheduledJob
+	return json.Marshal(struct {
+		jobAlias
+		LastStartedAt  *string `json:"last_started_at"`
+		LastFinishedAt *string `json:"last_finished_at"`
+		NextRunAt      *string `json:"next_run_at"`
+	}{
+		jobAlias:       jobAlias(j),
+		LastStartedAt:  formatNullableTime(j.LastStartedAt),
+		LastFinishedAt: formatNullableTime(j.LastFinishedAt),
+		NextRunAt:      formatNullableTime(j.NextRunAt),
+	})
+}
+
+func (p NotificationPreferences) MarshalJSON() ([]byte, error) {
+	type preferencesAlias NotificationPreferences
+	return json.Marshal(struct {
+		preferencesAlias
+		UpdatedAt *string `json:"updated_at"`
+	}{
+		preferencesAlias: preferencesAlias(p),
+		UpdatedAt:        formatNullableTime(p.UpdatedAt),
+	})
+}
+
+func (t NotificationTemplate) MarshalJSON() ([]byte, error) {
+	type templateAlias NotificationTemplate
+	return json.Marshal(struct {
+		templateAlias
+		UpdatedAt *string `json:"updated_at"`
+	}{
+		templateAlias: templateAlias(t),
+		UpdatedAt:     formatNullableTime(t.UpdatedAt),
+	})
+}
+
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	type subscriptionAlias Subscription
+	return json.Marshal(struct {
+		subscriptionAlias
+		CurrentPeriodEnd *string `json:"current_period_end,omitempty"`
+		UpdatedAt        string  `json:"updated_at"`
+	}{
+		subscriptionAlias: subscriptionAlias(s),
+		CurrentPeriodEnd:  formatNullableTime(s.CurrentPeriodEnd),
+		UpdatedAt:         FormatTime(s.UpdatedAt),
+	})
+}
+
+func (r SubscriptionResponse) MarshalJSON() ([]byte, error) {
+	type responseAlias SubscriptionResponse
+	return json.Marshal(struct {
+		responseAlias
+		CurrentPeriodEnd *string `json:"current_period_end,omitempty"`
+		TrialEndsAt      *string `json:"trial_ends_at,omitempty"`
+	}{
+		responseAlias:    responseAlias(r),
+		CurrentPeriodEnd: formatNullableTime(r.CurrentPeriodEnd),
+		TrialEndsAt:      formatNullableTime(r.TrialEndsAt),
+	})
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var listKeys = []string{"limit", "page", "total_count", "total_pages"}
+
+// decodeObject marshals v and returns its top-level members as raw JSON
+func decodeObject(t *testing.T, v interface{}) map[string]json.RawMessage {
+	t.Helper()
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		t.Fatalf("unmarshal %s: %v", body, err)
+	}
+	return members
+}
+
+func assertKeys(t *testing.T, members map[string]json.RawMessage, want ...string) {
+	t.Helper()
+
+	got := make([]string, 0, len(members))
+	for key := range members {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+	want = slices.Clone(want)
+	sort.Strings(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+}
+
+func assertMember(t *testing.T, members map[string]json.RawMessage, key, want string) {
+	t.Helper()
+
+	if got := string(members[key]); got != want {
+		t.Errorf("%s = %s, want %s", key, got, want)
+	}
+}
+
+func testTasks(n int) []*Task {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	userID := primitive.NewObjectID()
+	tasks := make([]*Task, n)
+	for i := range tasks {
+		tasks[i] = NewTask(userID, fmt.Sprintf("task %d", i), "", TaskStatusPending, now)
+		tasks[i].ID = primitive.NewObjectID()
+	}
+	return tasks
+}
+
+func TestNewTaskListResponseShape(t *testing.T) {
+	tests := []struct {
+		name       string
+		tasks      []*Task
+		page       int
+		limit      int
+		totalCount int64
+		wantLen    int
+		wantPages  string
+	}{
+		{name: "empty", tasks: nil, page: 1, limit: 20, totalCount: 0, wantLen: 0, wantPages: "0"},
+		{name: "single page", tasks: testTasks(3), page: 1, limit: 20, totalCount: 3, wantLen: 3, wantPages: "1"},
+		{name: "multi page", tasks: testTasks(20), page: 2, limit: 20, totalCount: 45, wantLen: 20, wantPages: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := decodeObject(t, NewTaskListResponse(tt.tasks, tt.page, tt.limit, tt.totalCount))
+
+			assertKeys(t, members, append([]string{"tasks"}, listKeys...)...)
+			assertMember(t, members, "page", fmt.Sprint(tt.page))
+			assertMember(t, members, "limit", fmt.Sprint(tt.limit))
+			assertMember(t, members, "total_count", fmt.Sprint(tt.totalCount))
+			assertMember(t, members, "total_pages", tt.wantPages)
+
+			var tasks []json.RawMessage
+			if err := json.Unmarshal(members["tasks"], &tasks); err != nil {
+				t.Fatalf("tasks: %v", err)
+			}
+			if tasks == nil {
+				t.Fatalf("tasks = null, want an array")
+			}
+			if len(tasks) != tt.wantLen {
+				t.Errorf("len(tasks) = %d, want %d", len(tasks), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestTaskListEntryShape(t *testing.T) {
+	list := NewTaskListResponse(testTasks(1), 1, 20, 1)
+
+	var body struct {
+		Tasks []map[string]json.RawMessage `json:"tasks"`
+	}
+	raw, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	task := body.Tasks[0]
+	assertKeys(t, task,
+		"id", "user_id", "parent_id", "title", "status", "due_date",
+		"archived", "purge_at", "created_at", "updated_at", "private")
+	assertMember(t, task, "parent_id", "null")
+	assertMember(t, task, "due_date", "null")
+	assertMember(t, task, "purge_at", "null")
+	assertMember(t, task, "created_at", `"2026-01-02T03:04:05Z"`)
+}
+
+func TestNewSparseTaskListResponseShape(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []*Task
+		want  string
+	}{
+		{name: "empty", tasks: nil, want: `[]`},
+		{name: "single page", tasks: testTasks(1), want: `[{"title":"task 0","status":"pending"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := NewTaskListResponse(tt.tasks, 1, 20, int64(len(tt.tasks)))
+			members := decodeObject(t, NewSparseTaskListResponse(list, []string{"title", "status"}))
+
+			assertKeys(t, members, append([]string{"tasks"}, listKeys...)...)
+			assertMember(t, members, "tasks", tt.want)
+		})
+	}
+}
+
+func TestListConstructorsRenderEmptyArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		response interface{}
+		key      string
+	}{
+		{"comments", NewCommentListResponse(nil, 1, 20, 0), "comments"},
+		{"focus sessions", NewFocusSessionListResponse(nil, 1, 20, 0), "sessions"},
+		{"task history", NewTaskHistoryListResponse(nil, 1, 20, 0), "history"},
+		{"retention reports", NewRetentionReportListResponse(nil, 1, 20, 0), "reports"},
+		{"dead letters", NewDeadLetterListResponse(nil, 1, 20, 0), "dead_letters"},
+		{"audit archives", NewAuditArchiveListResponse(nil, 1, 20, 0), "archives"},
+		{"webhook deliveries", NewWebhookDeliveryListResponse(nil, 1, 20, 0), "deliveries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := decodeObject(t, tt.response)
+
+			assertKeys(t, members, append([]string{tt.key}, listKeys...)...)
+			assertMember(t, members, tt.key, "[]")
+			assertMember(t, members, "total_count", "0")
+			assertMember(t, members, "total_pages", "0")
+		})
+	}
+}
+
+func TestNewImportReportShape(t *testing.T) {
+	tests := []struct {
+		name         string
+		tasks        []*Task
+		failures     []ImportFailure
+		wantImported string
+		wantFailures string
+	}{
+		{name: "empty", wantImported: "0", wantFailures: "[]"},
+		{
+			name:         "partial",
+			tasks:        testTasks(2),
+			failures:     []ImportFailure{{Line: 3, Error: "title is required"}},
+			wantImported: "2",
+			wantFailures: `[{"line":3,"error":"title is required"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := decodeObject(t, NewImportReport(tt.tasks, tt.failures))
+
+			assertKeys(t, members, "imported_count", "failed_count", "tasks", "failures")
+			assertMember(t, members, "imported_count", tt.wantImported)
+			assertMember(t, members, "failures", tt.wantFailures)
+			if string(members["tasks"]) == "null" {
+				t.Errorf("tasks = null, want an array")
+			}
+		})
+	}
+}
@@ -14,6 +14,22 @@ const (
 	TaskStatusCompleted  TaskStatus = "completed"
 )
 
+// TaskPriority is optional; tasks without one have an empty priority
+type TaskPriority string
+
+const (
+	TaskPriorityLow    TaskPriority = "low"
+	TaskPriorityMedium TaskPriority = "medium"
+	TaskPriorityHigh   TaskPriority = "high"
+)
+
+type SubtaskDeleteMode string
+
+const (
+	SubtaskDeleteCascade SubtaskDeleteMode = "cascade"
+	SubtaskDeleteOrphan  SubtaskDeleteMode = "orphan"
+)
+
 type UserRole string
 
 const (
@@ -21,14 +37,62 @@ const (
 	UserRoleAdmin UserRole = "admin"
 )
 
+type Permission string
+
+const (
+	PermissionTasksReadAll     Permission = "tasks:read_all"
+	PermissionTasksUpdateAny   Permission = "tasks:update_any"
+	PermissionTasksDeleteAny   Permission = "tasks:delete_any"
+	PermissionUsersManage      Permission = "users:manage"
+	PermissionComplianceManage Permission = "compliance:manage"
+	PermissionSystemRead       Permission = "system:read"
+)
+
+var AllPermissions = []Permission{
+	PermissionTasksReadAll,
+	PermissionTasksUpdateAny,
+	PermissionTasksDeleteAny,
+	PermissionUsersManage,
+	PermissionComplianceManage,
+	PermissionSystemRead,
+}
+
+// RolePermissions are the permissions a role grants to users whose documents
+// predate per-user permissions, and the starting set for new users.
+var RolePermissions = map[UserRole][]Permission{
+	UserRoleUser:  {},
+	UserRoleAdmin: AllPermissions,
+}
+
 type Task struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
-	Title       string             `json:"title" bson:"title"`
-	Description string             `json:"description" bson:"description"`
-	Status      TaskStatus         `json:"status" bson:"status"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID               primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	UserID           primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	ParentID         *primitive.ObjectID  `json:"parent_id" bson:"parent_id,omitempty"`
+	BlockedBy        []primitive.ObjectID `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+	Title            string               `json:"title" bson:"title"`
+	Description      string               `json:"description,omitempty" bson:"description"`
+	Status           TaskStatus           `json:"status" bson:"status"`
+	Priority         TaskPriority         `json:"priority,omitempty" bson:"priority,omitempty"`
+	DueDate          *time.Time           `json:"due_date" bson:"due_date,omitempty"`
+	Recurrence       string               `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	NextOccurrenceID *primitive.ObjectID  `json:"next_occurrence_id,omitempty" bson:"next_occurrence_id,omitempty"`
+	Archived         bool                 `json:"archived" bson:"archived"`
+	PurgeAt          *time.Time           `json:"purge_at" bson:"purge_at,omitempty"`
+	LegalHold        bool                 `json:"-" bson:"legal_hold,omitempty"`
+	CreatedAt        time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at" bson:"updated_at"`
+
+	// Private tasks are stored with an empty title and description; both are
+	// encrypted in Sealed with a key derived from the owner's private passphrase
+	Private bool   `json:"private" bson:"private,omitempty"`
+	Sealed  []byte `json:"-" bson:"sealed,omitempty"`
+
+	// When the owner was reminded of the due date; changing the due date clears it
+	RemindedAt *time.Time `json:"-" bson:"reminded_at,omitempty"`
+
+	// Incremented by every write that changes the task; clients see it as the ETag.
+	// Tasks written before versions existed have none and read as 0.
+	Version int64 `json:"-" bson:"version,omitempty"`
 }
 
 type User struct {
@@ -38,12 +102,747 @@ type User struct {
 	Password  string             `json:"-" bson:"password"`
 	Role      UserRole           `json:"role" bson:"role"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+
+	// Nil until set or backfilled; the role's defaults apply until then
+	Permissions []Permission `json:"-" bson:"permissions,omitempty"`
+
+	// Tokens issued before this instant are rejected
+	TokensRevokedAt        *time.Time `json:"-" bson:"tokens_revoked_at,omitempty"`
+	PasswordResetTokenHash string     `json:"-" bson:"password_reset_token_hash,omitempty"`
+	PasswordResetExpiresAt *time.Time `json:"-" bson:"password_reset_expires_at,omitempty"`
+
+	// Users registered before verification existed have neither field and count as verified
+	EmailVerifiedAt            *time.Time `json:"email_verified_at" bson:"email_verified_at,omitempty"`
+	EmailVerificationTokenHash string     `json:"-" bson:"email_verification_token_hash,omitempty"`
+	EmailVerificationExpiresAt *time.Time `json:"-" bson:"email_verification_expires_at,omitempty"`
+
+	// Held users and their tasks are exempt from purges and deletion
+	LegalHold bool `json:"-" bson:"legal_hold,omitempty"`
+
+	// Consecutive wrong passwords; reaching the limit sets LockedUntil and restarts the count
+	FailedLoginCount int        `json:"-" bson:"failed_login_count,omitempty"`
+	LockedUntil      *time.Time `json:"-" bson:"locked_until,omitempty"`
+
+	Identities []Identity `json:"identities,omitempty" bson:"identities,omitempty"`
+
+	// Salt for deriving the private task key and a value sealed with that key,
+	// which tells a wrong passphrase apart; both are unset until PUT /me/private-passphrase
+	PrivateKeySalt  []byte `json:"-" bson:"private_key_salt,omitempty"`
+	PrivateKeyCheck []byte `json:"-" bson:"private_key_check,omitempty"`
+
+	Preferences UserPreferences `json:"preferences" bson:"preferences"`
+	Settings    UserSettings    `json:"-" bson:"settings"`
+}
+
+// UserPreferences are settings users change themselves through PUT /me
+type UserPreferences struct {
+	// Opted-out users' completions count towards neither streaks nor badges
+	AchievementsOptOut bool `json:"achievements_opt_out" bson:"achievements_opt_out,omitempty"`
+}
+
+// UserSettings are settings users change themselves through /me/settings
+type UserSettings struct {
+	// Disabled users' tasks are never auto-completed
+	AutoCompleteDisabled bool `bson:"auto_complete_disabled,omitempty"`
+	// Replaces AUTO_COMPLETE_MINUTES for the user's tasks; 0 keeps it
+	AutoCompleteMinutes int `bson:"auto_complete_minutes,omitempty"`
+}
+
+// Identity links a user to an account at an external login provider
+type Identity struct {
+	Provider string    `json:"provider" bson:"provider"`
+	Subject  string    `json:"-" bson:"subject"`
+	Email    string    `json:"email" bson:"email"`
+	LinkedAt time.Time `json:"linked_at" bson:"linked_at"`
+}
+
+type RefreshToken struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	TokenHash  string              `json:"-" bson:"token_hash"`
+	ExpiresAt  time.Time           `json:"expires_at" bson:"expires_at"`
+	CreatedAt  time.Time           `json:"created_at" bson:"created_at"`
+	RevokedAt  *time.Time          `json:"revoked_at" bson:"revoked_at,omitempty"`
+	ReplacedBy *primitive.ObjectID `json:"-" bson:"replaced_by,omitempty"`
+	SessionID  primitive.ObjectID  `json:"-" bson:"session_id,omitempty"`
+}
+
+// JWK is an RSA public key in JSON Web Key form
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type CreateScopedTokenRequest struct {
+	Scopes         []string `json:"scopes"`
+	ExpiresInHours int      `json:"expires_in_hours"`
+}
+
+type ScopedTokenResponse struct {
+	Token     string    `json:"token"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ClientInfo describes the client a login or refresh came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session is one login, kept alive by refresh token rotation. It expires with
+// its latest refresh token and ends when revoked.
+type Session struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"-" bson:"user_id"`
+	Device     string             `json:"device" bson:"device"`
+	IP         string             `json:"ip" bson:"ip"`
+	UserAgent  string             `json:"user_agent" bson:"user_agent"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	LastUsedAt time.Time          `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+	RevokedAt  *time.Time         `json:"-" bson:"revoked_at,omitempty"`
+	Current    bool               `json:"current" bson:"-"`
+}
+
+// MyDayItem puts a task on a user's "my day" list. Day is the UTC date
+// (YYYY-MM-DD) the item belongs to; the rollover job moves unfinished items to
+// the next day and adds the days skipped to RolloverCount.
+type MyDayItem struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `bson:"user_id"`
+	TaskID        primitive.ObjectID `bson:"task_id"`
+	Day           string             `bson:"day"`
+	AddedAt       time.Time          `bson:"added_at"`
+	RolloverCount int                `bson:"rollover_count"`
+}
+
+type MyDayEntry struct {
+	Task          *Task     `json:"task"`
+	AddedAt       time.Time `json:"added_at"`
+	RolloverCount int       `json:"rollover_count"`
+}
+
+type MyDayResponse struct {
+	Date  string        `json:"date"`
+	Tasks []*MyDayEntry `json:"tasks"`
+}
+
+// FocusSession is a pomodoro worked against a task. It ends at EndsAt unless
+// stopped earlier, which sets EndedAt; sessions are never extended.
+type FocusSession struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID         primitive.ObjectID `json:"user_id" bson:"user_id"`
+	TaskID         primitive.ObjectID `json:"task_id" bson:"task_id"`
+	PlannedMinutes int                `json:"planned_minutes" bson:"planned_minutes"`
+	StartedAt      time.Time          `json:"started_at" bson:"started_at"`
+	EndsAt         time.Time          `json:"ends_at" bson:"ends_at"`
+	EndedAt        *time.Time         `json:"ended_at" bson:"ended_at,omitempty"`
+
+	// Derived when the session is returned
+	Running      bool  `json:"running" bson:"-"`
+	Completed    bool  `json:"completed" bson:"-"`
+	FocusSeconds Int64 `json:"focus_seconds" bson:"-"`
+}
+
+// Finish reports when the session ended or will end, and whether it ran its full length
+func (s *FocusSession) Finish() (time.Time, bool) {
+	if s.EndedAt != nil {
+		return *s.EndedAt, !s.EndedAt.Before(s.EndsAt)
+	}
+	return s.EndsAt, true
+}
+
+type StartPomodoroRequest struct {
+	Minutes int `json:"minutes"`
+}
+
+type FocusSessionListResponse struct {
+	Sessions   []*FocusSession `json:"sessions"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalCount Int64           `json:"total_count"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// FocusStats sums a user's focus time over a day or an ISO week (UTC), by day and by task.
+type FocusStats struct {
+	Period            string            `json:"period"`
+	StartDate         string            `json:"start_date"`
+	EndDate           string            `json:"end_date"`
+	FocusSeconds      Int64             `json:"focus_seconds"`
+	Sessions          int               `json:"sessions"`
+	CompletedSessions int               `json:"completed_sessions"`
+	Days              []*FocusDayStats  `json:"days"`
+	Tasks             []*FocusTaskStats `json:"tasks"`
+}
+
+type FocusDayStats struct {
+	Date         string `json:"date"`
+	FocusSeconds Int64  `json:"focus_seconds"`
+	Sessions     int    `json:"sessions"`
+}
+
+type FocusTaskStats struct {
+	TaskID       primitive.ObjectID `json:"task_id"`
+	Title        string             `json:"title"`
+	FocusSeconds Int64              `json:"focus_seconds"`
+	Sessions     int                `json:"sessions"`
+}
+
+// TaskSummary is the projection of a user's tasks read by dashboards. Status
+// counts cover unarchived tasks; archived ones are counted apart.
+type TaskSummary struct {
+	UserID     primitive.ObjectID `json:"user_id" bson:"_id"`
+	Total      Int64              `json:"total" bson:"total"`
+	Pending    Int64              `json:"pending" bson:"pending"`
+	InProgress Int64              `json:"in_progress" bson:"in_progress"`
+	Completed  Int64              `json:"completed" bson:"completed"`
+	Archived   Int64              `json:"archived" bson:"archived"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ProjectionCheckReport compares stored task summaries with a fresh
+// computation from the tasks. Summaries written after the check started are
+// newer than the computation and are skipped.
+type ProjectionCheckReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Checked   int       `json:"checked"`
+	Drifted   int       `json:"drifted"`
+	Missing   int       `json:"missing"`
+	Orphaned  int       `json:"orphaned"`
+	Repaired  bool      `json:"repaired"`
+}
+
+// UserAchievements holds a user's completion streak and badges. Days are UTC
+// dates (YYYY-MM-DD); the streak counts consecutive days with a completion.
+type UserAchievements struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty"`
+	UserID            primitive.ObjectID `bson:"user_id"`
+	TotalCompleted    int64              `bson:"total_completed"`
+	CurrentStreak     int                `bson:"current_streak"`
+	LongestStreak     int                `bson:"longest_streak"`
+	LastCompletionDay string             `bson:"last_completion_day"`
+	Badges            []*Badge           `bson:"badges"`
+}
+
+type Badge struct {
+	Code        string    `json:"code" bson:"code"`
+	Name        string    `json:"name" bson:"-"`
+	Description string    `json:"description" bson:"-"`
+	AwardedAt   time.Time `json:"awarded_at" bson:"awarded_at"`
+}
+
+type AchievementsResponse struct {
+	OptedOut           bool     `json:"opted_out"`
+	TotalCompleted     Int64    `json:"total_completed"`
+	CurrentStreak      int      `json:"current_streak"`
+	LongestStreak      int      `json:"longest_streak"`
+	LastCompletionDate *string  `json:"last_completion_date"`
+	Badges             []*Badge `json:"badges"`
+}
+
+type APIKey struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name       string             `json:"name" bson:"name"`
+	Prefix     string             `json:"prefix" bson:"prefix"`
+	KeyHash    string             `json:"-" bson:"key_hash"`
+	Scopes     []string           `json:"scopes" bson:"scopes"`
+	LastUsedAt *time.Time         `json:"last_used_at" bson:"last_used_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	RevokedAt  *time.Time         `json:"revoked_at" bson:"revoked_at,omitempty"`
+}
+
+// Task lifecycle events, as delivered to webhooks
+const (
+	TaskEventCreated   = "task.created"
+	TaskEventCompleted = "task.completed"
+	TaskEventDeleted   = "task.deleted"
+	TaskEventDueSoon   = "task.due_soon"
+)
+
+var TaskEvents = []string{TaskEventCreated, TaskEventCompleted, TaskEventDeleted, TaskEventDueSoon}
+
+// Webhook receives a signed POST for each subscribed event on its owner's tasks
+type Webhook struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
+	URL    string             `json:"url" bson:"url"`
+	Events []string           `json:"events" bson:"events"`
+	// Kept in plaintext because every delivery is signed with it
+	Secret string `json:"-" bson:"secret"`
+	// Payload schema the webhook is pinned to; webhooks created before
+	// versioning have none and are pinned to version 1
+	SchemaVersion int `json:"schema_version" bson:"schema_version,omitempty"`
+	// Attempts that failed in a row, across deliveries, since FailingSince;
+	// any 2xx response resets both
+	ConsecutiveFailures int        `json:"consecutive_failures" bson:"consecutive_failures,omitempty"`
+	FailingSince        *time.Time `json:"failing_since" bson:"failing_since,omitempty"`
+	// Set when the webhook was turned off, by its owner or after failing for
+	// too long; disabled webhooks get no deliveries
+	DisabledAt *time.Time `json:"disabled_at" bson:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+}
+
+// Task events notification channels subscribe to. Auto-completions are
+// task.completed for webhooks, but are told apart in notifications;
+// task.assigned is sent once per reassignment, not per task.
+const (
+	NotificationEventAutoCompleted = "task.auto_completed"
+	NotificationEventAssigned      = "task.assigned"
+)
+
+var NotificationEvents = []string{TaskEventCreated, TaskEventCompleted, NotificationEventAutoCompleted, NotificationEventAssigned, TaskEventDueSoon}
+
+const (
+	NotificationChannelSlack    = "slack"
+	NotificationChannelTelegram = "telegram"
+	// Email and push are not connected as channels, but preferences name
+	// them alongside the channel types
+	NotificationChannelEmail = "email"
+	NotificationChannelPush  = "push"
+)
+
+var NotificationChannelTypes = []string{NotificationChannelSlack, NotificationChannelTelegram}
+
+// NotificationPreferenceChannels are the ways of reaching a user that
+// notification preferences choose events for
+var NotificationPreferenceChannels = []string{NotificationChannelEmail, NotificationChannelSlack, NotificationChannelTelegram, NotificationChannelPush}
+
+// NotificationPreferences choose, for each of NotificationPreferenceChannels,
+// the events that reach the user that way. A channel missing from Channels
+// keeps its defaults. Slack and Telegram channels also only get the events
+// they subscribe to.
+type NotificationPreferences struct {
+	UserID    primitive.ObjectID  `json:"-" bson:"_id"`
+	Channels  map[string][]string `json:"channels" bson:"channels"`
+	UpdatedAt *time.Time          `json:"updated_at" bson:"updated_at,omitempty"`
+}
+
+// UpdateNotificationPreferencesRequest replaces the events of each channel
+// it names; an empty list turns the channel off
+type UpdateNotificationPreferencesRequest struct {
+	Channels map[string][]string `json:"channels"`
+}
+
+// NotificationChannel sends a short message to a chat service for each
+// subscribed event on its owner's tasks. The target matching Type is set.
+type NotificationChannel struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Type      string             `json:"type" bson:"type"`
+	Events    []string           `json:"events" bson:"events"`
+	Slack     *SlackTarget       `json:"slack,omitempty" bson:"slack,omitempty"`
+	Telegram  *TelegramTarget    `json:"telegram,omitempty" bson:"telegram,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// SlackTarget posts through an incoming webhook, or as a bot to Channel.
+// The webhook URL and bot token are kept in plaintext because every message
+// is sent with them, and are never shown again.
+type SlackTarget struct {
+	WebhookURL string `json:"-" bson:"webhook_url,omitempty"`
+	BotToken   string `json:"-" bson:"bot_token,omitempty"`
+	Channel    string `json:"channel,omitempty" bson:"channel,omitempty"`
+}
+
+// TelegramTarget is a chat with the bot. It stays pending, with a link token
+// and expiry, until the user opens the link; pending targets get no messages
+// and are deleted once the link expires.
+type TelegramTarget struct {
+	ChatID        int64      `json:"-" bson:"chat_id,omitempty"`
+	LinkTokenHash string     `json:"-" bson:"link_token_hash,omitempty"`
+	LinkExpiresAt *time.Time `json:"link_expires_at,omitempty" bson:"link_expires_at,omitempty"`
+	LinkedAt      *time.Time `json:"linked_at" bson:"linked_at,omitempty"`
+}
+
+// PushSubscription is a browser subscribed to Web Push messages. It gets
+// reminders of tasks due soon and word of tasks assigned to its user.
+type PushSubscription struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID   primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Endpoint string             `json:"endpoint" bson:"endpoint"`
+	// Kept in plaintext because every message is encrypted for them
+	P256DH    string     `json:"-" bson:"p256dh"`
+	Auth      string     `json:"-" bson:"auth"`
+	Device    string     `json:"device" bson:"device"`
+	ExpiresAt *time.Time `json:"expires_at" bson:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+}
+
+// PinnedSchemaVersion is the payload schema version deliveries to the webhook use
+func (w *Webhook) PinnedSchemaVersion() int {
+	if w.SchemaVersion == 0 {
+		return 1
+	}
+	return w.SchemaVersion
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event on its way to a webhook, kept as a delivery log
+type WebhookDelivery struct {
+	ID        primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	WebhookID primitive.ObjectID    `json:"webhook_id" bson:"webhook_id"`
+	UserID    primitive.ObjectID    `json:"-" bson:"user_id"`
+	Event     string                `json:"event" bson:"event"`
+	Payload   string                `json:"payload" bson:"payload"`
+	Status    WebhookDeliveryStatus `json:"status" bson:"status"`
+	Attempts  int                   `json:"attempts" bson:"attempts"`
+	// Set while pending; the worker also pushes it forward while an attempt is in flight
+	NextAttemptAt *time.Time `json:"next_attempt_at" bson:"next_attempt_at,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at" bson:"last_attempt_at,omitempty"`
+	// Status code and start of the body of the last response, empty when the
+	// request failed without one
+	ResponseStatus int        `json:"response_status,omitempty" bson:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty" bson:"response_body,omitempty"`
+	LastError      string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at" bson:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" bson:"created_at"`
+}
+
+// Scheduled job run statuses
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// ScheduledJob is the bookkeeping of one named job run by the scheduler,
+// shared by every instance running it
+type ScheduledJob struct {
+	Name           string     `json:"name" bson:"_id"`
+	Schedule       string     `json:"schedule" bson:"schedule"`
+	LastStatus     string     `json:"last_status" bson:"last_status"`
+	LastError      string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at" bson:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at" bson:"last_finished_at,omitempty"`
+	LastDurationMS Int64      `json:"last_duration_ms" bson:"last_duration_ms"`
+	NextRunAt      *time.Time `json:"next_run_at" bson:"next_run_at,omitempty"`
+	Runs           Int64      `json:"runs" bson:"runs"`
+	Failures       Int64      `json:"failures" bson:"failures"`
+}
+
+// WorkerState is the auto-completion worker's state shared by every
+// instance: whether operators paused it and what it has done so far
+type WorkerState struct {
+	Name         string              `bson:"_id"`
+	Paused       bool                `bson:"paused"`
+	PausedBy     *primitive.ObjectID `bson:"paused_by,omitempty"`
+	PausedAt     *time.Time          `bson:"paused_at,omitempty"`
+	LastScanAt   *time.Time          `bson:"last_scan_at,omitempty"`
+	Processed    int64               `bson:"processed"`
+	Failed       int64               `bson:"failed"`
+	DeadLettered int64               `bson:"dead_lettered"`
+}
+
+// WorkerQueueDepth counts the queued auto-completions
+type WorkerQueueDepth struct {
+	Ready       Int64 `json:"ready"`
+	Leased      Int64 `json:"leased"`
+	Waiting     Int64 `json:"waiting"`
+	DeadLetters Int64 `json:"dead_letters"`
+}
+
+type WorkerStatus struct {
+	Paused              bool                `json:"paused"`
+	PausedBy            *primitive.ObjectID `json:"paused_by"`
+	PausedAt            *time.Time          `json:"paused_at"`
+	LastScanAt          *time.Time          `json:"last_scan_at"`
+	Processed           Int64               `json:"processed"`
+	Failed              Int64               `json:"failed"`
+	DeadLettered        Int64               `json:"dead_lettered"`
+	Queue               WorkerQueueDepth    `json:"queue"`
+	AutoCompleteMinutes int                 `json:"auto_complete_minutes"`
+	Concurrency         int                 `json:"concurrency"`
+	// ChangeStream tells whether the instance answering follows task changes
+	ChangeStream bool `json:"change_stream"`
+}
+
+type WorkerTriggerResponse struct {
+	Queued int `json:"queued"`
+}
+
+// DeadLetter is a queued job that failed on every attempt, set aside for an
+// admin to inspect and requeue. Key is the job's payload: the task ID for
+// auto_complete jobs.
+type DeadLetter struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Queue      string             `json:"queue" bson:"queue"`
+	Key        string             `json:"key" bson:"key"`
+	Attempts   int                `json:"attempts" bson:"attempts"`
+	Error      string             `json:"error" bson:"error"`
+	EnqueuedAt time.Time          `json:"enqueued_at" bson:"enqueued_at"`
+	FailedAt   time.Time          `json:"failed_at" bson:"failed_at"`
+}
+
+type DeadLetterListResponse struct {
+	DeadLetters []*DeadLetter `json:"dead_letters"`
+	Page        int           `json:"page"`
+	Limit       int           `json:"limit"`
+	TotalCount  Int64         `json:"total_count"`
+	TotalPages  int           `json:"total_pages"`
+}
+
+// DeadLetterRequeueResult reports a requeued dead letter, which is removed.
+// AlreadyQueued is set when a job with the same key was queued meanwhile.
+type DeadLetterRequeueResult struct {
+	DeadLetter    *DeadLetter `json:"dead_letter"`
+	AlreadyQueued bool        `json:"already_queued"`
+}
+
+// WorkerVerdict explains whether the worker would auto-complete a task
+type WorkerVerdict struct {
+	TaskID           primitive.ObjectID `json:"task_id"`
+	WouldComplete    bool               `json:"would_complete"`
+	Status           TaskStatus         `json:"status"`
+	AgeMinutes       int                `json:"age_minutes"`
+	ThresholdMinutes int                `json:"threshold_minutes"`
+	EligibleAt       time.Time          `json:"eligible_at"`
+	EvaluatedAt      time.Time          `json:"evaluated_at"`
+	Checks           []WorkerCheck      `json:"checks"`
+}
+
+type WorkerCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+type SimulateWorkerRequest struct {
+	TaskID string `json:"task_id"`
+}
+
+// LoginAttempt counts failed logins for one email or client IP
+type LoginAttempt struct {
+	Key         string    `json:"key" bson:"_id"`
+	Failures    int       `json:"failures" bson:"failures"`
+	WindowStart time.Time `json:"window_start" bson:"window_start"`
+	ResetAt     time.Time `json:"reset_at" bson:"reset_at"`
+	Locked      bool      `json:"locked" bson:"-"`
+}
+
+type Comment struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID `json:"task_id" bson:"task_id"`
+	AuthorID  primitive.ObjectID `json:"author_id" bson:"author_id"`
+	Body      string             `json:"body" bson:"body"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type HistoryActor string
+
+const (
+	HistoryActorUser   HistoryActor = "user"
+	HistoryActorSystem HistoryActor = "system"
+)
+
+type TaskHistory struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID  `json:"task_id" bson:"task_id"`
+	Field     string              `json:"field" bson:"field"`
+	OldValue  string              `json:"old_value" bson:"old_value"`
+	NewValue  string              `json:"new_value" bson:"new_value"`
+	Actor     HistoryActor        `json:"actor" bson:"actor"`
+	ActorID   *primitive.ObjectID `json:"actor_id" bson:"actor_id,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
+
+type AuditLog struct {
+	ID         primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	ActorID    primitive.ObjectID     `json:"actor_id" bson:"actor_id"`
+	Action     string                 `json:"action" bson:"action"`
+	TargetType string                 `json:"target_type" bson:"target_type"`
+	TargetID   primitive.ObjectID     `json:"target_id" bson:"target_id"`
+	Details    map[string]interface{} `json:"details,omitempty" bson:"details,omitempty"`
+	CreatedAt  time.Time              `json:"created_at" bson:"created_at"`
 }
 
 type CreateTaskRequest struct {
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Status      TaskStatus   `json:"status"`
+	Priority    TaskPriority `json:"priority"`
+	ParentID    string       `json:"parent_id"`
+	BlockedBy   []string     `json:"blocked_by"`
+	DueDate     *time.Time   `json:"due_date"`
+	Recurrence  string       `json:"recurrence"`
+	Private     bool         `json:"private"`
+}
+
+type UpdateTaskRequest struct {
+	Title       *string             `json:"title"`
+	Description *string             `json:"description"`
+	Status      *TaskStatus         `json:"status"`
+	Priority    *TaskPriority       `json:"priority"`
+	BlockedBy   *[]string           `json:"blocked_by"`
+	DueDate     Nullable[time.Time] `json:"due_date"`
+	Recurrence  *string             `json:"recurrence"`
+	Private     *bool               `json:"private"`
+
+	// Versions from If-Match; nil applies the update whatever the current version
+	IfMatch []int64 `json:"-"`
+}
+
+type BulkDeleteTasksRequest struct {
+	IDs    []string   `json:"ids"`
+	Status TaskStatus `json:"status"`
+	// UserID deletes another user's tasks instead of the caller's, which
+	// needs tasks:delete_any
+	UserID string `json:"user_id,omitempty"`
+	// Subtasks decides what happens to subtasks of deleted tasks that are not
+	// deleted themselves, as the subtasks parameter of a single delete does
+	Subtasks SubtaskDeleteMode `json:"subtasks,omitempty"`
+}
+
+type BulkDeleteTasksResponse struct {
+	DeletedCount Int64 `json:"deleted_count"`
+	// More is set when more tasks matched than one request deletes
+	More bool `json:"more"`
+}
+
+// ScheduleTaskRequest places a task on a day; a null date unschedules it
+type ScheduleTaskRequest struct {
+	Date Nullable[string] `json:"date"`
+}
+
+// WeeklyPlan groups one ISO week's tasks by due day. Dates are YYYY-MM-DD in UTC.
+type WeeklyPlan struct {
+	Week        string     `json:"week"`
+	StartDate   string     `json:"start_date"`
+	EndDate     string     `json:"end_date"`
+	Days        []*PlanDay `json:"days"`
+	Unscheduled []*Task    `json:"unscheduled"`
+}
+
+type PlanDay struct {
+	Date    string  `json:"date"`
+	Weekday string  `json:"weekday"`
+	Tasks   []*Task `json:"tasks"`
+}
+
+type ImportTasksResponse struct {
+	ImportedCount int     `json:"imported_count"`
+	Tasks         []*Task `json:"tasks"`
+}
+
+// ImportReport is the result of a JSON import: the tasks created and, per
+// record that was skipped, its line and why
+type ImportReport struct {
+	ImportedCount int             `json:"imported_count"`
+	FailedCount   int             `json:"failed_count"`
+	Tasks         []*Task         `json:"tasks"`
+	Failures      []ImportFailure `json:"failures"`
+}
+
+type ImportFailure struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportJobStatus string
+
+const (
+	ImportJobStatusQueued    ImportJobStatus = "queued"
+	ImportJobStatusRunning   ImportJobStatus = "running"
+	ImportJobStatusCompleted ImportJobStatus = "completed"
+	ImportJobStatusFailed    ImportJobStatus = "failed"
+)
+
+// ImportJob is an import from another service that runs in the background.
+// UpdatedAt moves with every step, so a job that stops moving was interrupted.
+type ImportJob struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Source        string             `json:"source" bson:"source"`
+	Status        ImportJobStatus    `json:"status" bson:"status"`
+	ImportedCount int                `json:"imported_count" bson:"imported_count"`
+	FailedCount   int                `json:"failed_count" bson:"failed_count"`
+	Failures      []ImportJobFailure `json:"failures" bson:"failures"`
+	Error         string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	FinishedAt    *time.Time         `json:"finished_at" bson:"finished_at,omitempty"`
+}
+
+// ImportJobFailure is an item of the source that could not be imported
+type ImportJobFailure struct {
+	SourceID string `json:"source_id" bson:"source_id"`
+	Title    string `json:"title" bson:"title"`
+	Error    string `json:"error" bson:"error"`
+}
+
+type StartTodoistImportRequest struct {
+	APIToken string `json:"api_token"`
+}
+
+type ExportJobStatus string
+
+const (
+	ExportJobStatusQueued    ExportJobStatus = "queued"
+	ExportJobStatusRunning   ExportJobStatus = "running"
+	ExportJobStatusCompleted ExportJobStatus = "completed"
+	ExportJobStatusFailed    ExportJobStatus = "failed"
+)
+
+// ExportJob is an export of all of a user's tasks built in the background.
+// The file is kept with the job until both expire.
+type ExportJob struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Format    string             `json:"format" bson:"format"`
+	Status    ExportJobStatus    `json:"status" bson:"status"`
+	TaskCount int                `json:"task_count" bson:"task_count"`
+	// HiddenPrivateCount is the private tasks left out because the export was
+	// requested without the private passphrase
+	HiddenPrivateCount int        `json:"hidden_private_count" bson:"hidden_private_count"`
+	SizeBytes          Int64      `json:"size_bytes" bson:"size_bytes"`
+	Error              string     `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt          time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at" bson:"updated_at"`
+	FinishedAt         *time.Time `json:"finished_at" bson:"finished_at,omitempty"`
+	ExpiresAt          time.Time  `json:"expires_at" bson:"expires_at"`
+	// DownloadURL is set on responses once the file is ready
+	DownloadURL string `json:"download_url,omitempty" bson:"-"`
+}
+
+type CreateExportRequest struct {
+	Format string `json:"format"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type CommentListResponse struct {
+	Comments   []*Comment `json:"comments"`
+	Page       int        `json:"page"`
+	Limit      int        `json:"limit"`
+	TotalCount Int64      `json:"total_count"`
+	TotalPages int        `json:"total_pages"`
+}
+
+type TaskHistoryListResponse struct {
+	History    []*TaskHistory `json:"history"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalCount Int64          `json:"total_count"`
+	TotalPages int            `json:"total_pages"`
 }
 
 type RegisterRequest struct {
@@ -58,25 +857,765 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	User         *User  `json:"user"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+type UpdateProfileRequest struct {
+	Username    *string                   `json:"username"`
+	Email       *string                   `json:"email"`
+	Preferences *UpdatePreferencesRequest `json:"preferences"`
+}
+
+type UpdatePreferencesRequest struct {
+	AchievementsOptOut *bool `json:"achievements_opt_out"`
+}
+
+// UserSettingsResponse shows the user's settings. AutoCompleteMinutes is
+// null while the server default applies.
+type UserSettingsResponse struct {
+	AutoComplete               bool `json:"auto_complete"`
+	AutoCompleteMinutes        *int `json:"auto_complete_minutes"`
+	DefaultAutoCompleteMinutes int  `json:"default_auto_complete_minutes"`
+}
+
+func NewUserSettingsResponse(settings UserSettings, defaultMinutes int) *UserSettingsResponse {
+	response := &UserSettingsResponse{
+		AutoComplete:               !settings.AutoCompleteDisabled,
+		DefaultAutoCompleteMinutes: defaultMinutes,
+	}
+	if settings.AutoCompleteMinutes > 0 {
+		minutes := settings.AutoCompleteMinutes
+		response.AutoCompleteMinutes = &minutes
+	}
+	return response
+}
+
+// UpdateUserSettingsRequest changes the fields that are set;
+// auto_complete_minutes 0 returns to the server default
+type UpdateUserSettingsRequest struct {
+	AutoComplete        *bool `json:"auto_complete"`
+	AutoCompleteMinutes *int  `json:"auto_complete_minutes"`
+}
+
+type DeleteAccountRequest struct {
+	Password   string `json:"password"`
+	Tasks      string `json:"tasks"`
+	ReassignTo string `json:"reassign_to"`
+}
+
+type DeleteAccountResponse struct {
+	TasksDeleted    Int64 `json:"tasks_deleted"`
+	TasksReassigned Int64 `json:"tasks_reassigned"`
+	CommentsDeleted Int64 `json:"comments_deleted"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse carries the raw key, which is only ever shown once
+type CreateAPIKeyResponse struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	// Zero pins the webhook to the latest schema version
+	SchemaVersion int `json:"schema_version"`
+}
+
+type UpdateWebhookRequest struct {
+	SchemaVersion *int `json:"schema_version"`
+	// Enabled turns the webhook off, or back on after it was disabled
+	Enabled *bool `json:"enabled"`
+}
+
+// CreateWebhookResponse carries the signing secret, which is only ever shown once
+type CreateWebhookResponse struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+type CreateNotificationChannelRequest struct {
+	Type   string              `json:"type"`
+	Events []string            `json:"events"`
+	Slack  *SlackTargetRequest `json:"slack"`
+}
+
+// CreatePushSubscriptionRequest is the browser's PushSubscription.toJSON()
+type CreatePushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	// Milliseconds since the epoch, or null when the subscription never expires
+	ExpirationTime *int64 `json:"expirationTime"`
+	Keys           struct {
+		P256DH string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type PushPublicKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+// CreateNotificationChannelResponse carries the link that connects a Telegram
+// chat, which is only ever shown once
+type CreateNotificationChannelResponse struct {
+	*NotificationChannel
+	LinkURL string `json:"link_url,omitempty"`
+}
+
+// SlackTargetRequest sets either webhook_url, or bot_token and channel
+type SlackTargetRequest struct {
+	WebhookURL string `json:"webhook_url"`
+	BotToken   string `json:"bot_token"`
+	Channel    string `json:"channel"`
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook. ID is the delivery ID,
+// the same on every retry, so receivers can drop duplicates. The envelope is
+// the same in every schema version; Data is shaped by SchemaVersion.
+type WebhookPayload struct {
+	ID            string      `json:"id"`
+	SchemaVersion int         `json:"schema_version"`
+	Event         string      `json:"event"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	Data          interface{} `json:"data"`
+}
+
+// WebhookSchemaVersion holds the JSON Schema of every event's payload in one version
+type WebhookSchemaVersion struct {
+	Version int                               `json:"version"`
+	Events  map[string]map[string]interface{} `json:"events"`
+}
+
+type WebhookSchemasResponse struct {
+	LatestVersion int                     `json:"latest_version"`
+	Versions      []*WebhookSchemaVersion `json:"versions"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	TotalCount Int64              `json:"total_count"`
+	TotalPages int                `json:"total_pages"`
+}
+
+type SetPermissionsRequest struct {
+	Permissions []Permission `json:"permissions"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// SetPrivatePassphraseRequest sets the passphrase private tasks are encrypted with
+type SetPrivatePassphraseRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ResetCredentialsResponse never carries the reset token, which only goes to
+// the user's verified address
+type ResetCredentialsResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	// EmailSent is false when the mail could not be queued; reset again to send a new link
+	EmailSent bool `json:"email_sent"`
+}
+
+type SandboxResetResponse struct {
+	Users    []*User              `json:"users"`
+	Password string               `json:"password"`
+	Tasks    []*Task              `json:"tasks"`
+	Time     *SandboxTimeResponse `json:"time"`
+}
+
+type SandboxTimeRequest struct {
+	FreezeAt       *time.Time `json:"freeze_at"`
+	Unfreeze       bool       `json:"unfreeze"`
+	OffsetSeconds  *int64     `json:"offset_seconds"`
+	AdvanceSeconds int64      `json:"advance_seconds"`
+}
+
+type SandboxTimeResponse struct {
+	Now           time.Time `json:"now"`
+	Frozen        bool      `json:"frozen"`
+	OffsetSeconds Int64     `json:"offset_seconds"`
+}
+
+type SLORouteReport struct {
+	Method               string  `json:"method"`
+	Route                string  `json:"route"`
+	Requests             Int64   `json:"requests"`
+	Errors               Int64   `json:"errors"`
+	Availability         float64 `json:"availability"`
+	AvailabilityMet      bool    `json:"availability_met"`
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+	BurnRate             float64 `json:"burn_rate_5m"`
+	LatencyP50MS         float64 `json:"latency_p50_ms"`
+	LatencyP95MS         float64 `json:"latency_p95_ms"`
+	LatencyP99MS         float64 `json:"latency_p99_ms"`
+	LatencyMet           bool    `json:"latency_met"`
+}
+
+type SLOReport struct {
+	AvailabilityTarget float64           `json:"availability_target"`
+	LatencyTargetMS    Int64             `json:"latency_target_ms"`
+	Routes             []*SLORouteReport `json:"routes"`
+}
+
+type SchemaRecord struct {
+	ID        string    `bson:"_id"`
+	Version   int       `bson:"version"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+type SchemaStatus struct {
+	CodeVersion     int        `json:"code_version"`
+	MinCompatible   int        `json:"min_compatible_version"`
+	DatabaseVersion int        `json:"database_version"`
+	Compatible      bool       `json:"compatible"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+// IndexInfo is an index as it exists in the database. Ops and Since come
+// from $indexStats and stay nil when usage statistics are unavailable.
+type IndexInfo struct {
+	Name               string     `json:"name"`
+	Key                string     `json:"key"`
+	Unique             bool       `json:"unique"`
+	Sparse             bool       `json:"sparse"`
+	ExpireAfterSeconds *int32     `json:"expire_after_seconds,omitempty"`
+	Ops                *Int64     `json:"ops"`
+	Since              *time.Time `json:"since"`
+}
+
+// IndexDrift is a desired index whose key exists with different options.
+// It is reported but never rebuilt automatically.
+type IndexDrift struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type CollectionIndexReport struct {
+	Collection string       `json:"collection"`
+	Indexes    []IndexInfo  `json:"indexes"`
+	Missing    []string     `json:"missing"`
+	Drifted    []IndexDrift `json:"drifted"`
+	Unexpected []string     `json:"unexpected"`
+	Unused     []string     `json:"unused"`
+}
+
+// Index build states
+const (
+	IndexBuildPending   = "pending"
+	IndexBuildRunning   = "running"
+	IndexBuildCompleted = "completed"
+	IndexBuildFailed    = "failed"
+)
+
+type IndexBuildStatus struct {
+	State      string     `json:"state"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Created    []string   `json:"created"`
+	Errors     []string   `json:"errors"`
+}
+
+type IndexReport struct {
+	Build       IndexBuildStatus         `json:"build"`
+	Collections []*CollectionIndexReport `json:"collections"`
+}
+
+// ReassignUnassigned is the reassignment target for the unassigned task pool
+const ReassignUnassigned = "unassigned"
+
+type ReassignTasksRequest struct {
+	To string `json:"to"`
+}
+
+type ReassignTasksResponse struct {
+	ReassignedCount Int64  `json:"reassigned_count"`
+	To              string `json:"to"`
+}
+
+type RetentionPolicy struct {
+	ID                string              `json:"-" bson:"_id"`
+	CompletedTaskDays int                 `json:"completed_task_days" bson:"completed_task_days"`
+	AuditLogDays      int                 `json:"audit_log_days" bson:"audit_log_days"`
+	GraceDays         int                 `json:"grace_days" bson:"grace_days"`
+	UpdatedBy         *primitive.ObjectID `json:"updated_by" bson:"updated_by,omitempty"`
+	UpdatedAt         *time.Time          `json:"updated_at" bson:"updated_at,omitempty"`
+}
+
+// TaskFieldPolicy restricts which roles may change task fields. A listed field
+// can only be changed by the listed roles; an empty list locks it. Fields that
+// are not listed can be changed by anyone allowed to update the task.
+type TaskFieldPolicy struct {
+	ID        string                `json:"-" bson:"_id"`
+	Fields    map[string][]UserRole `json:"fields" bson:"fields"`
+	UpdatedBy *primitive.ObjectID   `json:"updated_by" bson:"updated_by,omitempty"`
+	UpdatedAt *time.Time            `json:"updated_at" bson:"updated_at,omitempty"`
+}
+
+// NotificationTemplate is a message template in Go text/template syntax. A
+// stored one is Custom and replaces the built-in template of the same name;
+// Text is the one-line chat and push version, falling back to Subject.
+type NotificationTemplate struct {
+	Name      string              `json:"name" bson:"_id"`
+	Subject   string              `json:"subject" bson:"subject"`
+	Body      string              `json:"body" bson:"body"`
+	Text      string              `json:"text" bson:"text,omitempty"`
+	Custom    bool                `json:"custom" bson:"-"`
+	UpdatedBy *primitive.ObjectID `json:"updated_by" bson:"updated_by,omitempty"`
+	UpdatedAt *time.Time          `json:"updated_at" bson:"updated_at,omitempty"`
+}
+
+type UpdateNotificationTemplateRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Text    string `json:"text"`
+}
+
+// NotificationTemplatePreview is a template rendered with example data
+type NotificationTemplatePreview struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Text    string `json:"text"`
+}
+
+// TaskPolicyFields are the fields of UpdateTaskRequest a policy can restrict
+var TaskPolicyFields = []string{"title", "description", "status", "priority", "due_date", "recurrence", "blocked_by"}
+
+// UpdateTaskFieldPolicyRequest replaces the whole policy; send {} to lift all restrictions
+type UpdateTaskFieldPolicyRequest struct {
+	Fields map[string][]UserRole `json:"fields"`
+}
+
+type RetentionReport struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TasksMarked     Int64              `json:"tasks_marked" bson:"tasks_marked"`
+	TasksPurged     Int64              `json:"tasks_purged" bson:"tasks_purged"`
+	AuditLogsMarked Int64              `json:"audit_logs_marked" bson:"audit_logs_marked"`
+	AuditLogsPurged Int64              `json:"audit_logs_purged" bson:"audit_logs_purged"`
+	HeldUsers       int                `json:"held_users" bson:"held_users"`
+	HeldTasks       int                `json:"held_tasks" bson:"held_tasks"`
+	RanAt           time.Time          `json:"ran_at" bson:"ran_at"`
+}
+
+type UpdateRetentionPolicyRequest struct {
+	CompletedTaskDays *int `json:"completed_task_days"`
+	AuditLogDays      *int `json:"audit_log_days"`
+	GraceDays         *int `json:"grace_days"`
+}
+
+type SetLegalHoldRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+type LegalHoldResponse struct {
+	TargetType string             `json:"target_type"`
+	TargetID   primitive.ObjectID `json:"target_id"`
+	LegalHold  bool               `json:"legal_hold"`
+}
+
+type RetentionStatusResponse struct {
+	Policy       *RetentionPolicy `json:"policy"`
+	PendingPurge RetentionPending `json:"pending_purge"`
+}
+
+type RetentionPending struct {
+	Tasks     Int64 `json:"tasks"`
+	AuditLogs Int64 `json:"audit_logs"`
+}
+
+type RetentionReportListResponse struct {
+	Reports    []*RetentionReport `json:"reports"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	TotalCount Int64              `json:"total_count"`
+	TotalPages int                `json:"total_pages"`
+}
+
+// AuditArchive describes one UTC day of audit logs moved out of MongoDB into
+// a gzipped NDJSON object in the archive store
+type AuditArchive struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id"`
+	Day          time.Time           `json:"day" bson:"day"`
+	Key          string              `json:"key" bson:"key"`
+	EntryCount   int                 `json:"entry_count" bson:"entry_count"`
+	FirstEntryAt time.Time           `json:"first_entry_at" bson:"first_entry_at"`
+	LastEntryAt  time.Time           `json:"last_entry_at" bson:"last_entry_at"`
+	Bytes        int                 `json:"bytes" bson:"bytes"`
+	SHA256       string              `json:"sha256" bson:"sha256"`
+	CreatedBy    *primitive.ObjectID `json:"created_by" bson:"created_by,omitempty"`
+	CreatedAt    time.Time           `json:"created_at" bson:"created_at"`
+}
+
+type RunAuditArchiveRequest struct {
+	// OlderThanDays overrides AUDIT_ARCHIVE_AFTER_DAYS for this run
+	OlderThanDays *int `json:"older_than_days"`
+}
+
+type AuditArchiveRunReport struct {
+	Cutoff          time.Time       `json:"cutoff"`
+	ArchivesCreated int             `json:"archives_created"`
+	EntriesArchived int             `json:"entries_archived"`
+	Archives        []*AuditArchive `json:"archives"`
+	// More is set when the run stopped at its day limit with older logs left
+	More bool `json:"more"`
+}
+
+type AuditArchiveListResponse struct {
+	Archives   []*AuditArchive `json:"archives"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalCount Int64           `json:"total_count"`
+	TotalPages int             `json:"total_pages"`
 }
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error   string                 `json:"error"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	// RequestID and Timestamp are set on 5xx responses so users can quote them
+	RequestID string `json:"request_id,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// RequestTrace summarizes a request that failed with a 5xx status, for
+// looking it up by the request ID quoted from the error response
+type RequestTrace struct {
+	ID         string              `json:"request_id" bson:"_id"`
+	Method     string              `json:"method" bson:"method"`
+	Path       string              `json:"path" bson:"path"`
+	Status     int                 `json:"status" bson:"status"`
+	Code       string              `json:"code" bson:"code"`
+	Message    string              `json:"message" bson:"message"`
+	UserID     *primitive.ObjectID `json:"user_id" bson:"user_id,omitempty"`
+	DurationMS float64             `json:"duration_ms" bson:"duration_ms"`
+	// Logs are the lines the server logged while handling the request
+	Logs      []string  `json:"logs" bson:"logs"`
+	Version   string    `json:"version" bson:"version"`
+	StartedAt time.Time `json:"started_at" bson:"started_at"`
+}
+
+// UsageCounts are the requests of a usage report row and how many failed,
+// split into client (4xx) and server (5xx) errors
+type UsageCounts struct {
+	Requests     Int64 `json:"requests" bson:"requests"`
+	ClientErrors Int64 `json:"client_errors" bson:"client_errors"`
+	ServerErrors Int64 `json:"server_errors" bson:"server_errors"`
+}
+
+// UsageReport sums an account's API requests over whole UTC days, from the
+// hourly usage buckets
+type UsageReport struct {
+	UserID    primitive.ObjectID `json:"user_id"`
+	StartDate string             `json:"start_date"`
+	EndDate   string             `json:"end_date"`
+	UsageCounts
+	// ErrorRate is the share of requests that failed with a 4xx or 5xx status
+	ErrorRate    float64          `json:"error_rate"`
+	TopEndpoints []*UsageEndpoint `json:"top_endpoints"`
+	APIKeys      []*UsageAPIKey   `json:"api_keys"`
+	Days         []*UsageDay      `json:"days"`
+}
+
+type UsageEndpoint struct {
+	Route       string `json:"route" bson:"_id"`
+	UsageCounts `bson:",inline"`
+}
+
+// UsageAPIKey is one API key's share of a usage report; requests made with a
+// login or scoped token have no key ID
+type UsageAPIKey struct {
+	APIKeyID    *primitive.ObjectID `json:"api_key_id" bson:"_id"`
+	Name        string              `json:"name,omitempty" bson:"-"`
+	UsageCounts `bson:",inline"`
+}
+
+type UsageDay struct {
+	Date        string `json:"date" bson:"_id"`
+	UsageCounts `bson:",inline"`
+}
+
+// Deprecation kinds: a route, or a request field or parameter
+const (
+	DeprecationKindRoute = "route"
+	DeprecationKindField = "field"
+)
+
+// Deprecation is part of the API that will be removed. Requests using it are
+// counted per client so maintainers know who must migrate first.
+type Deprecation struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	// Sunset is the announced removal date, YYYY-MM-DD, if there is one
+	Sunset string `json:"sunset,omitempty"`
+	// Successor is what clients should use instead
+	Successor string `json:"successor,omitempty"`
+}
+
+// DeprecationClient is one client still using a deprecation: an account, the
+// API key it used, if any, and its user agent. Anonymous requests, such as
+// logins, have no user.
+type DeprecationClient struct {
+	UserID     *primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Email      string              `json:"email,omitempty" bson:"-"`
+	APIKeyID   *primitive.ObjectID `json:"api_key_id" bson:"api_key_id"`
+	APIKeyName string              `json:"api_key_name,omitempty" bson:"-"`
+	UserAgent  string              `json:"user_agent" bson:"user_agent"`
+	Requests   Int64               `json:"requests" bson:"requests"`
+	// Routes are the routes the client used the deprecation on
+	Routes    []string  `json:"routes" bson:"routes"`
+	FirstSeen time.Time `json:"-" bson:"first_seen"`
+	LastSeen  time.Time `json:"-" bson:"last_seen"`
+}
+
+type DeprecationUsage struct {
+	Deprecation
+	Requests Int64                `json:"requests"`
+	Clients  []*DeprecationClient `json:"clients"`
+}
+
+type DeprecationReport struct {
+	Deprecations []*DeprecationUsage `json:"deprecations"`
+}
+
+// Subscription is an account's paid plan as last reported by the payment
+// provider. Accounts without one are on the default plan.
+type Subscription struct {
+	ID               primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	UserID           primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Provider         string             `json:"provider" bson:"provider"`
+	CustomerID       string             `json:"-" bson:"customer_id"`
+	SubscriptionID   string             `json:"-" bson:"subscription_id"`
+	Plan             string             `json:"plan" bson:"plan"`
+	Status           string             `json:"status" bson:"status"`
+	CurrentPeriodEnd *time.Time         `json:"current_period_end,omitempty" bson:"current_period_end,omitempty"`
+	// EventAt is when the provider created the event last applied, so
+	// events delivered out of order never overwrite newer ones
+	EventAt   time.Time `json:"-" bson:"event_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// PlanLimits caps what an account may keep; null means unlimited
+type PlanLimits struct {
+	Tasks    *Int64 `json:"tasks"`
+	Webhooks *Int64 `json:"webhooks"`
+}
+
+type PlanUsage struct {
+	Tasks    Int64 `json:"tasks"`
+	Webhooks Int64 `json:"webhooks"`
+}
+
+type SubscriptionResponse struct {
+	Plan string `json:"plan"`
+	// Status is the provider's subscription status, "trialing" during the
+	// trial without a subscription, or "none"
+	Status           string     `json:"status"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end,omitempty"`
+	TrialEndsAt      *time.Time `json:"trial_ends_at,omitempty"`
+	Features         []string   `json:"features"`
+	Limits           PlanLimits `json:"limits"`
+	Usage            PlanUsage  `json:"usage"`
+	UpgradeURL       string     `json:"upgrade_url,omitempty"`
+}
+
+type ErrorCatalogResponse struct {
+	Errors []ErrorDefinition `json:"errors"`
 }
 
 type TaskListResponse struct {
 	Tasks      []*Task `json:"tasks"`
 	Page       int     `json:"page"`
 	Limit      int     `json:"limit"`
-	TotalCount int64   `json:"total_count"`
+	TotalCount Int64   `json:"total_count"`
 	TotalPages int     `json:"total_pages"`
 }
 
-func NewTask(userID primitive.ObjectID, title, description string, status TaskStatus) *Task {
-	now := time.Now()
+// TaskFields are the task fields a listing's fields parameter can select. The
+// stored field has the same name, except id, which is _id.
+var TaskFields = []string{
+	"id", "user_id", "parent_id", "blocked_by", "title", "description", "status", "priority", "due_date",
+	"recurrence", "next_occurrence_id", "archived", "purge_at", "created_at", "updated_at", "private",
+}
+
+// SparseTask renders only the selected fields of a task, in their usual order
+type SparseTask struct {
+	Task   *Task
+	Fields []string
+}
+
+// SparseTaskListResponse is a task page with each task cut down to the fields asked for
+type SparseTaskListResponse struct {
+	Tasks      []SparseTask `json:"tasks"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+	TotalCount Int64        `json:"total_count"`
+	TotalPages int          `json:"total_pages"`
+}
+
+// List response constructors always return empty arrays rather than null,
+// so clients never need to special-case an empty page.
+
+func NewSparseTaskListResponse(list *TaskListResponse, fields []string) *SparseTaskListResponse {
+	tasks := make([]SparseTask, len(list.Tasks))
+	for i, task := range list.Tasks {
+		tasks[i] = SparseTask{Task: task, Fields: fields}
+	}
+	return &SparseTaskListResponse{
+		Tasks:      tasks,
+		Page:       list.Page,
+		Limit:      list.Limit,
+		TotalCount: list.TotalCount,
+		TotalPages: list.TotalPages,
+	}
+}
+
+func NewTaskListResponse(tasks []*Task, page, limit int, totalCount int64) *TaskListResponse {
+	if tasks == nil {
+		tasks = []*Task{}
+	}
+	return &TaskListResponse{
+		Tasks:      tasks,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: Int64(totalCount),
+		TotalPages: totalPages(totalCount, limit),
+	}
+}
+
+func NewImportReport(tasks []*Task, failures []ImportFailure) *ImportReport {
+	if tasks == nil {
+		tasks = []*Task{}
+	}
+	if failures == nil {
+		failures = []ImportFailure{}
+	}
+	return &ImportReport{
+		ImportedCount: len(tasks),
+		FailedCount:   len(failures),
+		Tasks:         tasks,
+		Failures:      failures,
+	}
+}
+
+func NewCommentListResponse(comments []*Comment, page, limit int, totalCount int64) *CommentListResponse {
+	if comments == nil {
+		comments = []*Comment{}
+	}
+	return &CommentListResponse{
+		Comments:   comments,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: Int64(totalCount),
+		TotalPages: totalPages(totalCount, limit),
+	}
+}
+
+func NewFocusSessionListResponse(sessions []*FocusSession, page, limit int, totalCount int64) *FocusSessionListResponse {
+	if sessions == nil {
+		sessions = []*FocusSession{}
+	}
+	return &FocusSessionListResponse{
+		Sessions:   sessions,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: Int64(totalCount),
+		TotalPages: totalPages(totalCount, limit),
+	}
+}
+
+func NewTaskHistoryListResponse(history []*TaskHistory, page, limit int, totalCount int64) *TaskHistoryListResponse {
+	if history == nil {
+		history = []*TaskHistory{}
+	}
+	return &TaskHistoryListResponse{
+		History:    history,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: Int64(totalCount),
+		TotalPages: totalPages(totalCount, limit),
+	}
+}
+
+func NewRetentionReportListResponse(reports []*RetentionReport, page, limit int, totalCount int64) *RetentionReportListResponse {
+	if reports == nil {
+		reports = []*RetentionReport{}
+	}
+	return &RetentionReportListResponse{
+		Reports:    reports,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: Int64(totalCount),
+		TotalPages: totalPages(totalCount, limit),
+	}
+}
+
+func NewDeadLetterListResponse(deadLetters []*DeadLetter, page, limit int, totalCount int64) *DeadLetterListResponse {
+	if deadLetters == nil {
+		deadLetters = []*DeadLetter{}
+	}
+	return &DeadLetterListResponse{
+		DeadLetters: deadLetters,
+		Page:        page,
+		Limit:       limit,
+		TotalCount:  Int64(totalCount),
+		TotalPages:  totalPages(totalCount, limit),
+	}
+}
+
+func NewAuditArchiveListResponse(archives []*AuditArchive, page, limit int, totalCount int64) *AuditArchiveListResponse {
+	if archives == nil {
+		archives = []*AuditArchive{}
+	}
+	return &AuditArchiveListResponse{
+		Archives:   archives,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: Int64(totalCount),
+		TotalPages: totalPages(totalCount, limit),
+	}
+}
+
+func totalPages(totalCount int64, limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	pages := int(totalCount) / limit
+	if int(totalCount)%limit > 0 {
+		pages++
+	}
+	return pages
+}
+
+func NewTask(userID primitive.ObjectID, title, description string, status TaskStatus, now time.Time) *Task {
 	return &Task{
 		UserID:      userID,
 		Title:       title,
@@ -87,12 +1626,152 @@ func NewTask(userID primitive.ObjectID, title, description string, status TaskSt
 	}
 }
 
-func NewUser(email, username, hashedPassword string, role UserRole) *User {
+func NewComment(taskID, authorID primitive.ObjectID, body string, now time.Time) *Comment {
+	return &Comment{
+		TaskID:    taskID,
+		AuthorID:  authorID,
+		Body:      body,
+		CreatedAt: now,
+	}
+}
+
+func NewTaskHistory(taskID primitive.ObjectID, field, oldValue, newValue string, actorID *primitive.ObjectID, now time.Time) *TaskHistory {
+	actor := HistoryActorSystem
+	if actorID != nil {
+		actor = HistoryActorUser
+	}
+	return &TaskHistory{
+		TaskID:    taskID,
+		Field:     field,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		Actor:     actor,
+		ActorID:   actorID,
+		CreatedAt: now,
+	}
+}
+
+func NewAuditLog(actorID primitive.ObjectID, action, targetType string, targetID primitive.ObjectID, details map[string]interface{}, now time.Time) *AuditLog {
+	return &AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		CreatedAt:  now,
+	}
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil || u.EmailVerificationTokenHash == ""
+}
+
+func NewAPIKey(userID primitive.ObjectID, name, prefix, keyHash string, scopes []string, now time.Time) *APIKey {
+	return &APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+}
+
+func NewWebhook(userID primitive.ObjectID, url string, events []string, secret string, now time.Time) *Webhook {
+	return &Webhook{
+		UserID:    userID,
+		URL:       url,
+		Events:    events,
+		Secret:    secret,
+		CreatedAt: now,
+	}
+}
+
+func NewPushSubscription(userID primitive.ObjectID, endpoint, p256dh, auth, device string, expiresAt *time.Time, now time.Time) *PushSubscription {
+	return &PushSubscription{
+		UserID:    userID,
+		Endpoint:  endpoint,
+		P256DH:    p256dh,
+		Auth:      auth,
+		Device:    device,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+}
+
+func NewNotificationChannel(userID primitive.ObjectID, channelType string, events []string, now time.Time) *NotificationChannel {
+	return &NotificationChannel{
+		UserID:    userID,
+		Type:      channelType,
+		Events:    events,
+		CreatedAt: now,
+	}
+}
+
+func NewWebhookDelivery(webhook *Webhook, event, payload string, now time.Time) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     webhook.ID,
+		UserID:        webhook.UserID,
+		Event:         event,
+		Payload:       payload,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+}
+
+func NewWebhookDeliveryListResponse(deliveries []*WebhookDelivery, page, limit int, totalCount int64) *WebhookDeliveryListResponse {
+	if deliveries == nil {
+		deliveries = []*WebhookDelivery{}
+	}
+	return &WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Page:       page,
+		Limit:      limit,
+		TotalCount: Int64(totalCount),
+		TotalPages: totalPages(totalCount, limit),
+	}
+}
+
+func (v *WorkerVerdict) AddCheck(name string, passed bool, detail string) {
+	v.Checks = append(v.Checks, WorkerCheck{Name: name, Passed: passed, Detail: detail})
+}
+
+func NewUser(email, username, hashedPassword string, role UserRole, now time.Time) *User {
 	return &User{
-		Email:     email,
-		Username:  username,
-		Password:  hashedPassword,
-		Role:      role,
-		CreatedAt: time.Now(),
+		Email:       email,
+		Username:    username,
+		Password:    hashedPassword,
+		Role:        role,
+		Permissions: RolePermissions[role],
+		CreatedAt:   now,
+	}
+}
+
+// EffectivePermissions returns the user's permissions, falling back to the
+// role's defaults for documents that have none stored.
+func (u *User) EffectivePermissions() []Permission {
+	if u.Permissions != nil {
+		return u.Permissions
+	}
+	return RolePermissions[u.Role]
+}
+
+func (u *User) HasPermission(permission Permission) bool {
+	for _, p := range u.EffectivePermissions() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidPermission(permission Permission) bool {
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,158 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Custom is a template an operator stored to replace a built-in one. An empty
+// Text falls back to Subject, as for built-ins without a text.
+type Custom struct {
+	Subject string
+	Body    string
+	Text    string
+}
+
+// TemplateStore looks up custom templates. It returns nil when the built-in
+// template applies.
+type TemplateStore interface {
+	CustomTemplate(ctx context.Context, name string) (*Custom, error)
+}
+
+// Renderer renders templates, preferring the custom ones in its store. A nil
+// Renderer renders the built-ins.
+type Renderer struct {
+	store TemplateStore
+}
+
+func NewRenderer(store TemplateStore) *Renderer {
+	return &Renderer{store: store}
+}
+
+// Render fills in the custom template for name, or the built-in one when
+// there is none. A custom template that cannot be looked up or fails to
+// render is logged and the built-in one is used, so messages still go out.
+func (r *Renderer) Render(ctx context.Context, name string, data interface{}) (*Message, error) {
+	if r == nil || r.store == nil {
+		return Render(name, data)
+	}
+	custom, err := r.store.CustomTemplate(ctx, name)
+	if err != nil {
+		log.Printf("Failed to find custom %s template, using the built-in one: %v", name, err)
+		return Render(name, data)
+	}
+	if custom == nil {
+		return Render(name, data)
+	}
+	message, err := RenderCustom(name, custom, data)
+	if err != nil {
+		log.Printf("Failed to render custom %s template, using the built-in one: %v", name, err)
+		return Render(name, data)
+	}
+	return message, nil
+}
+
+// SendTemplate renders the named template and sends it to one address
+func (r *Renderer) SendTemplate(ctx context.Context, sender EmailSender, to, name string, data interface{}) error {
+	message, err := r.Render(ctx, name, data)
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, to, message.Subject, message.Body)
+}
+
+// RenderCustom fills in a custom template for name
+func RenderCustom(name string, custom *Custom, data interface{}) (*Message, error) {
+	subject, body, text, err := parseCustom(name, custom)
+	if err != nil {
+		return nil, err
+	}
+	return execute(name, subject, body, text, data)
+}
+
+// ValidateCustom checks a custom template parses and renders with example
+// data of its template's type, so fields that do not exist are caught before
+// it is stored
+func ValidateCustom(name string, custom *Custom) error {
+	_, err := PreviewCustom(name, custom)
+	return err
+}
+
+// PreviewCustom renders a custom template with example data
+func PreviewCustom(name string, custom *Custom) (*Message, error) {
+	sample, ok := sampleData[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %s", name)
+	}
+	subject, body, text, err := parseCustom(name, custom)
+	if err != nil {
+		return nil, err
+	}
+	message, err := execute(name, subject, body, text, sample)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %s", strings.TrimPrefix(errors.Unwrap(err).Error(), "template: "))
+	}
+	return message, nil
+}
+
+// BuiltIn returns the source of a built-in template, as a starting point for
+// a custom one
+func BuiltIn(name string) (*Custom, bool) {
+	subject, body := templates.Lookup(name+".subject"), templates.Lookup(name+".body")
+	if subject == nil || body == nil {
+		return nil, false
+	}
+	builtIn := &Custom{Subject: subject.Tree.Root.String(), Body: body.Tree.Root.String()}
+	if text := templates.Lookup(name + ".text"); text != nil {
+		builtIn.Text = text.Tree.Root.String()
+	}
+	return builtIn, true
+}
+
+func parseCustom(name string, custom *Custom) (subject, body, text *template.Template, err error) {
+	parse := func(part, source string) (*template.Template, error) {
+		t, err := template.New(name + "." + part).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %s", strings.TrimPrefix(err.Error(), "template: "))
+		}
+		return t, nil
+	}
+
+	if strings.TrimSpace(custom.Subject) == "" || strings.TrimSpace(custom.Body) == "" {
+		return nil, nil, nil, fmt.Errorf("subject and body are required")
+	}
+	if subject, err = parse("subject", custom.Subject); err != nil {
+		return nil, nil, nil, err
+	}
+	if body, err = parse("body", custom.Body); err != nil {
+		return nil, nil, nil, err
+	}
+	text = subject
+	if custom.Text != "" {
+		if text, err = parse("text", custom.Text); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return subject, body, text, nil
+}
+
+// sampleData is example data of each template's type, for validating and
+// previewing custom templates
+var sampleData = map[string]interface{}{
+	TemplateWelcome:           WelcomeData{Username: "ada"},
+	TemplateVerifyEmail:       VerifyEmailData{Username: "ada", Link: "https://tasks.example.com/verify?token=abc123"},
+	TemplatePasswordReset:     PasswordResetData{Username: "ada", Token: "abc123", ResetURL: "https://tasks.example.com/reset-password?token=abc123", ExpiresAt: sampleTime.Add(24 * time.Hour)},
+	TemplateTaskCreated:       TaskData{Username: "ada", Title: "Write report", DueAt: &sampleTime, Minutes: 30},
+	TemplateTaskCompleted:     TaskData{Username: "ada", Title: "Write report", DueAt: &sampleTime, Minutes: 30},
+	TemplateTaskAutoCompleted: TaskData{Username: "ada", Title: "Write report", DueAt: &sampleTime, Minutes: 30},
+	TemplateTaskDueSoon:       TaskData{Username: "ada", Title: "Write report", DueAt: &sampleTime, Minutes: 30},
+	TemplateTasksAssigned:     TasksAssignedData{Username: "ada", Count: 3},
+	TemplateTest:              nil,
+}
+
+var sampleTime = time.Date(2024, time.June, 3, 9, 0, 0, 0, time.UTC)
@@ -0,0 +1,208 @@
+// Package notifications sends messages to users outside the API, by email,
+// chat or browser push. Notifiers deliver a single message to one
+// destination; Queue sends them in the background so requests never wait on
+// delivery.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailSender delivers one plain-text email
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// LogSender writes outgoing mail to the server log, for development setups without a mail server.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("Mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// How SMTPSender secures the connection
+const (
+	// TLSStartTLS upgrades a plain connection, usually on port 587
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects with TLS from the start, usually on port 465
+	TLSImplicit = "tls"
+	// TLSNone sends in the clear, for a relay on the same host or network
+	TLSNone = "none"
+)
+
+// smtpTimeout bounds a send when the caller's context has no deadline
+const smtpTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender address, optionally with a name: "Tasks <tasks@example.com>"
+	From string
+	TLS  string
+}
+
+// SMTPSender sends each email over its own connection to an SMTP server,
+// authenticating with PLAIN when a username is set
+type SMTPSender struct {
+	config SMTPConfig
+	from   *mail.Address
+	now    func() time.Time
+}
+
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if config.Port < 1 || config.Port > 65535 {
+		return nil, fmt.Errorf("invalid SMTP port %d", config.Port)
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP from address %q", config.From)
+	}
+	switch config.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("invalid SMTP TLS mode %q, must be one of: starttls, tls, none", config.TLS)
+	}
+
+	return &SMTPSender{config: config, from: from, now: time.Now}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, to, subject, body string) error {
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q", to)
+	}
+	message, err := s.message(recipient, subject, body)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("SMTP server refused the sender: %w", err)
+	}
+	if err := client.Rcpt(recipient.Address); err != nil {
+		return fmt.Errorf("SMTP server refused the recipient: %w", err)
+	}
+	data, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := data.Write(message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("SMTP server refused the email: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial connects and greets the server, upgrading to TLS as configured. The
+// connection gives up at the context's deadline.
+func (s *SMTPSender) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	tlsConfig := &tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if s.config.TLS == TLSImplicit {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to greet SMTP server: %w", err)
+	}
+	if s.config.TLS == TLSStartTLS {
+		// Never fall back to sending credentials and mail in the clear
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("SMTP server does not offer STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to start TLS with SMTP server: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// message builds a UTF-8 plain-text email with quoted-printable body
+func (s *SMTPSender) message(to *mail.Address, subject, body string) ([]byte, error) {
+	// Headers come from our own templates, but a line break would let a
+	// username or task title add headers
+	if strings.ContainsAny(subject, "\r\n") {
+		return nil, fmt.Errorf("email subject must be a single line")
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	domain := s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", s.now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
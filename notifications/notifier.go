@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is a notification rendered for every kind of channel: email uses
+// Subject and Body, chat channels the one-line Text
+type Message struct {
+	Subject string
+	Body    string
+	Text    string
+}
+
+// Notifier delivers messages to one destination, such as an email address or
+// a Slack channel. String names the destination in logs without secrets.
+type Notifier interface {
+	Notify(ctx context.Context, message *Message) error
+	String() string
+}
+
+// PermanentError is a failure that retrying cannot fix, such as a revoked
+// token or a deleted channel
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err is, or wraps, a PermanentError
+func IsPermanent(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// EmailNotifier sends messages to one address
+type EmailNotifier struct {
+	sender EmailSender
+	to     string
+}
+
+func NewEmailNotifier(sender EmailSender, to string) *EmailNotifier {
+	return &EmailNotifier{sender: sender, to: to}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, message *Message) error {
+	return n.sender.Send(ctx, n.to, message.Subject, message.Body)
+}
+
+func (n *EmailNotifier) String() string {
+	return "email to " + n.to
+}
@@ -0,0 +1,170 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// queueWorkers is how many messages are sent at once
+	queueWorkers = 2
+	// queueSendTimeout bounds one attempt at sending a message
+	queueSendTimeout = 30 * time.Second
+	// queueRetryDelay is the wait before the first retry; it doubles after
+	// every further failure
+	queueRetryDelay = 30 * time.Second
+	// queueDrainTimeout is how long shutdown waits for queued messages to go out
+	queueDrainTimeout = 5 * time.Second
+)
+
+// QueueConfig sets how many messages wait at most and how often each is
+// attempted before it is dropped
+type QueueConfig struct {
+	Size        int
+	MaxAttempts int
+}
+
+type queuedMessage struct {
+	notifier Notifier
+	message  *Message
+	attempts int
+}
+
+// Queue sends messages in the background so callers never wait on a mail
+// server or chat service. Failed sends are retried with backoff, unless the
+// failure is permanent. Messages are only kept in memory, so the links with
+// secrets some carry are never stored, and the ones still waiting when the
+// process stops are lost.
+type Queue struct {
+	sender   EmailSender
+	config   QueueConfig
+	messages chan *queuedMessage
+
+	// retrying counts the messages waiting to be retried, for shutdown to report
+	mu       sync.Mutex
+	retrying int
+}
+
+// NewQueue sends email with sender and other messages with their own notifiers
+func NewQueue(sender EmailSender, config QueueConfig) *Queue {
+	return &Queue{
+		sender:   sender,
+		config:   config,
+		messages: make(chan *queuedMessage, config.Size),
+	}
+}
+
+// Email returns a notifier for the address that sends through the queue's sender
+func (q *Queue) Email(to string) Notifier {
+	return NewEmailNotifier(q.sender, to)
+}
+
+// Send queues an email, so the queue is an EmailSender itself
+func (q *Queue) Send(ctx context.Context, to, subject, body string) error {
+	return q.Deliver(ctx, q.Email(to), &Message{Subject: subject, Body: body, Text: subject})
+}
+
+// Deliver queues the message for the notifier; it fails only when the queue is full
+func (q *Queue) Deliver(ctx context.Context, notifier Notifier, message *Message) error {
+	select {
+	case q.messages <- &queuedMessage{notifier: notifier, message: message}:
+		return nil
+	default:
+		return fmt.Errorf("notification queue is full")
+	}
+}
+
+// Start sends queued messages until ctx is cancelled, then gives the ones
+// already queued a few seconds to go out
+func (q *Queue) Start(ctx context.Context) {
+	log.Printf("Starting notification queue - %d senders, up to %d queued messages and %d attempts each", queueWorkers, q.config.Size, q.config.MaxAttempts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < queueWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), queueDrainTimeout)
+	defer cancel()
+drain:
+	for drainCtx.Err() == nil {
+		select {
+		case queued := <-q.messages:
+			q.send(drainCtx, queued)
+		default:
+			break drain
+		}
+	}
+
+	q.mu.Lock()
+	lost := len(q.messages) + q.retrying
+	q.mu.Unlock()
+	if lost > 0 {
+		log.Printf("Notification queue stopped with %d messages unsent", lost)
+	} else {
+		log.Println("Notification queue stopped")
+	}
+}
+
+func (q *Queue) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case queued := <-q.messages:
+			if !q.send(ctx, queued) && ctx.Err() == nil {
+				q.retry(queued)
+			}
+		}
+	}
+}
+
+// send makes one attempt, reporting whether the message is done with: sent,
+// failed for good or failed on its last attempt
+func (q *Queue) send(ctx context.Context, queued *queuedMessage) bool {
+	queued.attempts++
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), queueSendTimeout)
+	defer cancel()
+
+	err := queued.notifier.Notify(sendCtx, queued.message)
+	switch {
+	case err == nil:
+		return true
+	case IsPermanent(err):
+		log.Printf("Dropping message %q to %s: %v", queued.message.Subject, queued.notifier, err)
+		return true
+	case queued.attempts >= q.config.MaxAttempts:
+		log.Printf("Dropping message %q to %s after %d failed attempts: %v", queued.message.Subject, queued.notifier, queued.attempts, err)
+		return true
+	}
+	log.Printf("Sending message %q to %s failed on attempt %d: %v", queued.message.Subject, queued.notifier, queued.attempts, err)
+	return false
+}
+
+// retry queues the message again after the backoff, unless the queue is full then
+func (q *Queue) retry(queued *queuedMessage) {
+	q.mu.Lock()
+	q.retrying++
+	q.mu.Unlock()
+
+	delay := queueRetryDelay << (queued.attempts - 1)
+	time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		q.retrying--
+		q.mu.Unlock()
+
+		select {
+		case q.messages <- queued:
+		default:
+			log.Printf("Dropping message %q to %s: the notification queue is full", queued.message.Subject, queued.notifier)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"task-management-api/outbound"
+)
+
+const (
+	// SlackWebhookHost is the only host incoming-webhook URLs are accepted for
+	SlackWebhookHost = "hooks.slack.com"
+	// slackAPIURL is the Web API the bot notifier posts to
+	slackAPIURL = "https://slack.com/api"
+	// maxSlackResponseRead bounds how much of a response is read
+	maxSlackResponseRead = 64 << 10
+)
+
+// slackEscaper escapes the characters Slack reads as markup, so a task title
+// cannot mention @channel or add links
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// ValidSlackWebhookURL reports whether raw is an incoming-webhook URL, which
+// keeps channels from being pointed at arbitrary hosts
+func ValidSlackWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host == SlackWebhookHost && strings.HasPrefix(u.Path, "/services/") && u.User == nil
+}
+
+// SlackWebhookNotifier posts messages to the channel an incoming webhook was created for
+type SlackWebhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func NewSlackWebhookNotifier(client *outbound.Client, webhookURL string) *SlackWebhookNotifier {
+	return &SlackWebhookNotifier{client: client.HTTPClientNoRedirects(0), url: webhookURL}
+}
+
+func (n *SlackWebhookNotifier) Notify(ctx context.Context, message *Message) error {
+	resp, body, err := postSlack(ctx, n.client, n.url, "", "", message)
+	if err != nil {
+		return err
+	}
+
+	// Slack answers 404 or 410 once the webhook or its channel is gone and
+	// 403 when posting is no longer allowed; none of these recover
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests:
+		return permanent(fmt.Errorf("Slack responded with status %d: %s", resp.StatusCode, body))
+	default:
+		return fmt.Errorf("Slack responded with status %d", resp.StatusCode)
+	}
+}
+
+func (n *SlackWebhookNotifier) String() string {
+	return "Slack incoming webhook"
+}
+
+// SlackBotNotifier posts messages to a channel as a bot user with chat.postMessage
+type SlackBotNotifier struct {
+	client  *http.Client
+	token   string
+	channel string
+}
+
+func NewSlackBotNotifier(client *outbound.Client, token, channel string) *SlackBotNotifier {
+	return &SlackBotNotifier{client: client.HTTPClientNoRedirects(0), token: token, channel: channel}
+}
+
+// slackErrorsPermanent are chat.postMessage errors that retrying cannot fix
+var slackErrorsPermanent = map[string]bool{
+	"account_inactive":  true,
+	"channel_not_found": true,
+	"invalid_auth":      true,
+	"is_archived":       true,
+	"missing_scope":     true,
+	"not_authed":        true,
+	"not_in_channel":    true,
+	"token_revoked":     true,
+}
+
+func (n *SlackBotNotifier) Notify(ctx context.Context, message *Message) error {
+	resp, body, err := postSlack(ctx, n.client, slackAPIURL+"/chat.postMessage", n.token, n.channel, message)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Slack responded with status %d", resp.StatusCode)
+	}
+
+	// The Web API answers 200 for failures too and names them in the body
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to read Slack response: %w", err)
+	}
+	if !result.OK {
+		err := fmt.Errorf("Slack refused the message: %s", result.Error)
+		if slackErrorsPermanent[result.Error] {
+			return permanent(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (n *SlackBotNotifier) String() string {
+	return "Slack channel " + n.channel
+}
+
+// postSlack sends the message's text as JSON, authenticated with the bot
+// token if there is one, and returns the response with its body, already
+// read and closed
+func postSlack(ctx context.Context, client *http.Client, endpoint, token, channel string, message *Message) (*http.Response, []byte, error) {
+	payload := map[string]interface{}{
+		"text": slackEscaper.Replace(message.Text),
+		// Task titles never unfurl into previews
+		"unfurl_links": false,
+		"unfurl_media": false,
+	}
+	if channel != "" {
+		payload["channel"] = channel
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error names the URL, which for incoming webhooks is the secret
+		return nil, nil, fmt.Errorf("failed to reach Slack: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSlackResponseRead))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Slack response: %w", err)
+	}
+	return resp, bytes.TrimSpace(body), nil
+}
+
+// unwrapURLError drops the URL from a client error
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
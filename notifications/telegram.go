@@ -0,0 +1,132 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"task-management-api/outbound"
+)
+
+const (
+	// telegramAPIURL is the Bot API the bot sends through
+	telegramAPIURL = "https://api.telegram.org"
+	// maxTelegramResponseRead bounds how much of a response is read
+	maxTelegramResponseRead = 64 << 10
+)
+
+// TelegramBot sends messages as one bot, to the chats that started it
+type TelegramBot struct {
+	client   *http.Client
+	token    string
+	username string
+}
+
+func NewTelegramBot(client *outbound.Client, token, username string) *TelegramBot {
+	return &TelegramBot{client: client.HTTPClient(0), token: token, username: strings.TrimPrefix(username, "@")}
+}
+
+// StartLink is the deep link that opens a chat with the bot and sends it
+// "/start <payload>" once the user taps Start
+func (b *TelegramBot) StartLink(payload string) string {
+	return "https://t.me/" + b.username + "?start=" + url.QueryEscape(payload)
+}
+
+// Notifier returns a notifier for one chat
+func (b *TelegramBot) Notifier(chatID int64) Notifier {
+	return &TelegramNotifier{bot: b, chatID: chatID}
+}
+
+// SendMessage posts plain text to the chat with sendMessage
+func (b *TelegramBot) SendMessage(ctx context.Context, chatID int64, text string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":              chatID,
+		"text":                 text,
+		"link_preview_options": map[string]bool{"is_disabled": true},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPIURL+"/bot"+b.token+"/sendMessage", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// The error names the URL, which holds the bot token
+		return fmt.Errorf("failed to reach Telegram: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTelegramResponseRead))
+	if err != nil {
+		return fmt.Errorf("failed to read Telegram response: %w", err)
+	}
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("Telegram responded with status %d", resp.StatusCode)
+	}
+	if result.OK {
+		return nil
+	}
+
+	err = fmt.Errorf("Telegram refused the message: %s", result.Description)
+	// 400 is a chat that is gone, 401 a revoked bot token and 403 a user who
+	// blocked the bot; 429 and server errors are worth retrying
+	switch result.ErrorCode {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+		return permanent(err)
+	}
+	return err
+}
+
+// TelegramNotifier sends messages to one chat with a bot
+type TelegramNotifier struct {
+	bot    *TelegramBot
+	chatID int64
+}
+
+func (n *TelegramNotifier) Notify(ctx context.Context, message *Message) error {
+	return n.bot.SendMessage(ctx, n.chatID, message.Text)
+}
+
+func (n *TelegramNotifier) String() string {
+	return fmt.Sprintf("Telegram chat %d", n.chatID)
+}
+
+// TelegramUpdate is the part of a Bot API update the service reads
+type TelegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// StartPayload returns the chat and payload of a "/start <payload>" message,
+// which Telegram sends when a user opens a StartLink
+func (u *TelegramUpdate) StartPayload() (int64, string, bool) {
+	if u.Message == nil {
+		return 0, "", false
+	}
+	command, payload, _ := strings.Cut(strings.TrimSpace(u.Message.Text), " ")
+	// In groups the command may name the bot: /start@task_bot
+	command, _, _ = strings.Cut(command, "@")
+	if command != "/start" {
+		return 0, "", false
+	}
+	return u.Message.Chat.ID, strings.TrimSpace(payload), true
+}
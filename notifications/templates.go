@@ -0,0 +1,175 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Message templates; each renders a subject, a plain-text body and, for chat
+// channels, a one-line text from the data type named next to it. Templates
+// without a text of their own use the subject.
+const (
+	TemplateWelcome           = "welcome"             // WelcomeData
+	TemplateVerifyEmail       = "verify_email"        // VerifyEmailData
+	TemplatePasswordReset     = "password_reset"      // PasswordResetData
+	TemplateTaskCreated       = "task_created"        // TaskData
+	TemplateTaskCompleted     = "task_completed"      // TaskData
+	TemplateTaskAutoCompleted = "task_auto_completed" // TaskData
+	TemplateTaskDueSoon       = "task_due_soon"       // TaskData
+	TemplateTasksAssigned     = "tasks_assigned"      // TasksAssignedData
+	TemplateTest              = "test"                // nil
+)
+
+type WelcomeData struct {
+	Username string
+}
+
+type VerifyEmailData struct {
+	Username string
+	Link     string
+}
+
+// PasswordResetData tells the user how to set a new password after an
+// admin reset their credentials. ResetURL already carries the token.
+type PasswordResetData struct {
+	Username  string
+	Token     string
+	ResetURL  string
+	ExpiresAt time.Time
+}
+
+// TaskData describes one task; Title is "(private task)" for private tasks
+type TaskData struct {
+	Username string
+	Title    string
+	DueAt    *time.Time
+	// Minutes is the auto-complete delay, or how long until the task is due
+	Minutes int
+}
+
+// TasksAssignedData tells a user how many open tasks were reassigned to them
+type TasksAssignedData struct {
+	Username string
+	Count    int64
+}
+
+// TemplateNames lists every template, each of which can be replaced by a custom one
+var TemplateNames = []string{TemplateWelcome, TemplateVerifyEmail, TemplatePasswordReset, TemplateTaskCreated, TemplateTaskCompleted, TemplateTaskAutoCompleted, TemplateTaskDueSoon, TemplateTasksAssigned, TemplateTest}
+
+// templateFuncs are the functions templates, built-in or custom, may call
+var templateFuncs = template.FuncMap{
+	"utc": func(t time.Time) string { return t.UTC().Format("Mon, 2 Jan 2006 15:04 UTC") },
+}
+
+var templates = template.Must(template.New("").Funcs(templateFuncs).Parse(`
+{{define "welcome.subject"}}Welcome to Task Management{{end}}
+{{define "welcome.body"}}Hi {{.Username}},
+
+Your email address is confirmed and your account is ready. Create your first
+task with POST /api/v1/tasks, or connect a client with an API key from
+POST /api/v1/me/api-keys.
+{{end}}
+
+{{define "verify_email.subject"}}Verify your email address{{end}}
+{{define "verify_email.body"}}Hi {{.Username}},
+
+Confirm your email address by opening:
+{{.Link}}
+
+The link expires in 48 hours.{{end}}
+
+{{define "password_reset.subject"}}Your password was reset{{end}}
+{{define "password_reset.body"}}Hi {{.Username}},
+
+An administrator reset your credentials and signed you out everywhere. Choose
+a new password here:
+
+{{.ResetURL}}
+
+The link expires on {{utc .ExpiresAt}}. If you did not expect this, contact
+your administrator.
+{{end}}
+
+{{define "task_created.subject"}}New task: {{.Title}}{{end}}
+{{define "task_created.body"}}Hi {{.Username}},
+
+The task "{{.Title}}" was created{{with .DueAt}}, due {{utc .}}{{end}}.
+{{end}}
+{{define "task_created.text"}}New task: {{.Title}}{{with .DueAt}} (due {{utc .}}){{end}}{{end}}
+
+{{define "task_completed.subject"}}Completed: {{.Title}}{{end}}
+{{define "task_completed.body"}}Hi {{.Username}},
+
+The task "{{.Title}}" was marked completed.
+{{end}}
+
+{{define "task_auto_completed.subject"}}Completed automatically: {{.Title}}{{end}}
+{{define "task_auto_completed.body"}}Hi {{.Username}},
+
+Your task "{{.Title}}" was still open {{.Minutes}} minutes after you created it,
+so it was marked completed. Reopen it by setting its status back if it is not
+done; PATCH /api/v1/me/settings turns auto-completion off.
+{{end}}
+{{define "task_auto_completed.text"}}Completed automatically after {{.Minutes}} minutes: {{.Title}}{{end}}
+
+{{define "task_due_soon.subject"}}Due in {{.Minutes}} minutes: {{.Title}}{{end}}
+{{define "task_due_soon.body"}}Hi {{.Username}}, your task "{{.Title}}" is due at {{utc .DueAt}}.
+{{end}}
+{{define "task_due_soon.text"}}Due in {{.Minutes}} minutes: {{.Title}} ({{utc .DueAt}}){{end}}
+
+{{define "tasks_assigned.subject"}}{{if eq .Count 1}}A task was{{else}}{{.Count}} tasks were{{end}} assigned to you{{end}}
+{{define "tasks_assigned.body"}}Hi {{.Username}},
+
+An administrator assigned {{if eq .Count 1}}an open task{{else}}{{.Count}} open tasks{{end}} to you. {{if eq .Count 1}}It is{{else}}They are{{end}}
+listed with the rest of your tasks at GET /api/v1/tasks.
+{{end}}
+
+{{define "test.subject"}}Test notification{{end}}
+{{define "test.body"}}Notifications from Task Management reach you here.
+{{end}}
+{{define "test.text"}}Notifications from Task Management reach you here.{{end}}
+`))
+
+// Render fills in the named built-in template
+func Render(name string, data interface{}) (*Message, error) {
+	text := name + ".text"
+	if templates.Lookup(text) == nil {
+		text = name + ".subject"
+	}
+	return execute(name, templates.Lookup(name+".subject"), templates.Lookup(name+".body"), templates.Lookup(text), data)
+}
+
+// SendTemplate renders the named built-in template and sends it to one address
+func SendTemplate(ctx context.Context, sender EmailSender, to, name string, data interface{}) error {
+	message, err := Render(name, data)
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, to, message.Subject, message.Body)
+}
+
+func execute(name string, subjectTemplate, bodyTemplate, textTemplate *template.Template, data interface{}) (*Message, error) {
+	if subjectTemplate == nil || bodyTemplate == nil {
+		return nil, fmt.Errorf("unknown template %s", name)
+	}
+	var subject, body, text strings.Builder
+	if err := subjectTemplate.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s message: %w", name, err)
+	}
+	if err := bodyTemplate.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s message: %w", name, err)
+	}
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s message: %w", name, err)
+	}
+
+	// Titles may hold line breaks, which a subject or a one-line text cannot
+	return &Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Body:    body.String(),
+		Text:    strings.Join(strings.Fields(text.String()), " "),
+	}, nil
+}
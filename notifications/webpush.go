@@ -0,0 +1,301 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/outbound"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// pushTTL is how long a push service keeps a message for a browser that is offline
+	pushTTL = 12 * time.Hour
+	// vapidTokenLifetime is how long the signed VAPID token is valid; at most 24 hours
+	vapidTokenLifetime = 12 * time.Hour
+	// pushRecordSize is the record size declared in the encrypted body; the
+	// whole message is a single record
+	pushRecordSize = 4096
+	// maxPushPayload keeps the encrypted body within the 4096 bytes every push service accepts
+	maxPushPayload = 3800
+	// maxPushResponseRead bounds how much of a response is read
+	maxPushResponseRead = 64 << 10
+)
+
+// VAPID identifies this server to push services (RFC 8292). Browsers only
+// accept messages signed with the key they subscribed with.
+type VAPID struct {
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+}
+
+// NewVAPID takes the key pair as URL-safe base64, the public key an
+// uncompressed P-256 point and the private key its 32-byte scalar, as VAPID
+// key generators print them. Subject is a mailto: or https: URL push services
+// can reach the operator at.
+func NewVAPID(publicKey, privateKey, subject string) (*VAPID, error) {
+	if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https://") {
+		return nil, fmt.Errorf("VAPID subject must be a mailto: or https: URL")
+	}
+	scalar, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(scalar)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	point := ecdhKey.PublicKey().Bytes()
+	if publicKey != "" {
+		given, err := decodeBase64URL(publicKey)
+		if err != nil || !bytes.Equal(given, point) {
+			return nil, fmt.Errorf("VAPID public key does not match the private key")
+		}
+	}
+
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(scalar),
+	}
+	return &VAPID{key: key, publicKey: base64.RawURLEncoding.EncodeToString(point), subject: subject}, nil
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with
+func (v *VAPID) PublicKey() string {
+	return v.publicKey
+}
+
+// authorization signs a token for the push service that hosts endpoint
+func (v *VAPID) authorization(endpoint *url.URL, now time.Time) (string, error) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": now.Add(vapidTokenLifetime).Unix(),
+		"sub": v.subject,
+	}).SignedString(v.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	return "vapid t=" + token + ", k=" + v.publicKey, nil
+}
+
+// PushSubscription is what a browser's PushManager hands out: the push
+// service endpoint and the keys messages to it are encrypted with
+type PushSubscription struct {
+	Endpoint string
+	P256DH   string
+	Auth     string
+}
+
+// ValidatePushSubscription checks the endpoint is an https URL and the keys
+// decode to a P-256 point and a 16-byte secret
+func ValidatePushSubscription(sub PushSubscription) error {
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return fmt.Errorf("invalid endpoint, must be an https URL")
+	}
+	if _, err := subscriberKey(sub.P256DH); err != nil {
+		return fmt.Errorf("invalid keys.p256dh")
+	}
+	if auth, err := decodeBase64URL(sub.Auth); err != nil || len(auth) != 16 {
+		return fmt.Errorf("invalid keys.auth")
+	}
+	return nil
+}
+
+// WebPushNotifier sends messages to one browser subscription. Gone is called
+// when the push service reports the subscription expired or unsubscribed.
+type WebPushNotifier struct {
+	client       *http.Client
+	vapid        *VAPID
+	subscription PushSubscription
+	gone         func(ctx context.Context)
+	clock        clock.Clock
+}
+
+// NewWebPushNotifier signs each message with a VAPID token that expires
+// relative to clk
+func NewWebPushNotifier(client *outbound.Client, vapid *VAPID, subscription PushSubscription, gone func(ctx context.Context), clk clock.Clock) *WebPushNotifier {
+	return &WebPushNotifier{client: client.HTTPClientNoRedirects(0), vapid: vapid, subscription: subscription, gone: gone, clock: clk}
+}
+
+// pushPayload is the JSON the browser's service worker receives
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+}
+
+func (n *WebPushNotifier) Notify(ctx context.Context, message *Message) error {
+	endpoint, err := url.Parse(n.subscription.Endpoint)
+	if err != nil {
+		return permanent(fmt.Errorf("invalid push endpoint"))
+	}
+	push := pushPayload{Title: message.Subject, Body: message.Text}
+	// Templates without a text of their own would repeat the title
+	if push.Body == push.Title {
+		push.Body = ""
+	}
+	payload, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxPushPayload {
+		return permanent(fmt.Errorf("push message is too large"))
+	}
+	body, err := encryptPush(n.subscription, payload)
+	if err != nil {
+		return permanent(err)
+	}
+	authorization, err := n.vapid.authorization(endpoint, n.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(pushTTL/time.Second)))
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// Endpoints carry a per-browser token, kept out of logs
+		return fmt.Errorf("failed to reach push service: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxPushResponseRead))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		if n.gone != nil {
+			n.gone(ctx)
+		}
+		return permanent(fmt.Errorf("push subscription expired"))
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests:
+		return permanent(fmt.Errorf("push service responded with status %d", resp.StatusCode))
+	default:
+		return fmt.Errorf("push service responded with status %d", resp.StatusCode)
+	}
+}
+
+func (n *WebPushNotifier) String() string {
+	if endpoint, err := url.Parse(n.subscription.Endpoint); err == nil {
+		return "push subscription at " + endpoint.Host
+	}
+	return "push subscription"
+}
+
+// encryptPush encrypts the payload for the subscription with aes128gcm as
+// RFC 8291 describes: a fresh key pair per message, agreed with the browser's
+// key and mixed with its auth secret
+func encryptPush(sub PushSubscription, payload []byte) ([]byte, error) {
+	browserKey, err := subscriberKey(sub.P256DH)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push subscription key")
+	}
+	authSecret, err := decodeBase64URL(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid push subscription auth secret")
+	}
+
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate push key: %w", err)
+	}
+	shared, err := serverKey.ECDH(browserKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to agree on push key: %w", err)
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	keyInfo := append([]byte("WebPush: info\x00"), browserKey.Bytes()...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, err := hkdfRead(shared, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate push salt: %w", err)
+	}
+	contentKey, err := hkdfRead(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfRead(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key ID length and the server's public key
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+
+	// 0x02 marks the last, and only, record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+func hkdfRead(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, fmt.Errorf("failed to derive push key: %w", err)
+	}
+	return out, nil
+}
+
+func subscriberKey(p256dh string) (*ecdh.PublicKey, error) {
+	raw, err := decodeBase64URL(p256dh)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.P256().NewPublicKey(raw)
+}
+
+// decodeBase64URL accepts URL-safe base64 with or without padding, as
+// browsers and key generators differ
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(s), "="))
+}
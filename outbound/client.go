@@ -0,0 +1,255 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Config controls the shared client used for every call leaving the service.
+type Config struct {
+	Timeout      time.Duration
+	RatePerHost  float64
+	BurstPerHost int
+	// Hosts that may resolve to private or loopback addresses, e.g. an internal shadow deployment
+	AllowedPrivateHosts []string
+	// Hosts the operator configured, such as an OIDC issuer or S3 endpoint, that
+	// metrics name in addition to the known providers
+	MetricHosts []string
+}
+
+// OtherHost labels metrics of every host that is neither a known provider nor
+// configured. Webhook and integration URLs come from users, so naming their
+// hosts would publish them and let the label set grow without bound.
+const OtherHost = "other"
+
+// knownHosts are the providers whose hosts metrics name
+var knownHosts = []string{
+	"accounts.google.com", "oauth2.googleapis.com", "openidconnect.googleapis.com",
+	"github.com", "api.github.com",
+	"hooks.slack.com", "api.telegram.org", "api.todoist.com",
+	"fcm.googleapis.com", "updates.push.services.mozilla.com", "web.push.apple.com",
+}
+
+// Client is the single way integration code talks to other services. It pools
+// connections, limits the request rate per host, refuses to connect to private
+// networks unless the host is allowlisted, and counts requests per host.
+type Client struct {
+	config    Config
+	transport *http.Transport
+	allowed   map[string]bool
+	named     map[string]bool
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	stats   map[string]*hostStats
+}
+
+type hostStats struct {
+	requests    int64
+	errors      int64
+	blocked     int64
+	rateLimited int64
+	seconds     float64
+}
+
+type allowPrivateKey struct{}
+
+func New(config Config) *Client {
+	c := &Client{
+		config:  config,
+		allowed: make(map[string]bool),
+		named:   make(map[string]bool),
+		buckets: make(map[string]*bucket),
+		stats:   make(map[string]*hostStats),
+	}
+	for _, host := range config.AllowedPrivateHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			c.allowed[host] = true
+		}
+	}
+	for _, host := range append(knownHosts, config.MetricHosts...) {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			c.named[host] = true
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	c.transport = &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			// The check runs on the resolved address, so DNS tricks cannot bypass it
+			d := *dialer
+			if ctx.Value(allowPrivateKey{}) == nil {
+				d.Control = denyPrivate
+			}
+			return d.DialContext(ctx, network, addr)
+		},
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: config.Timeout,
+	}
+
+	return c
+}
+
+// HTTPClient returns an *http.Client sharing the pool, limits and metrics; a
+// zero timeout uses the configured default.
+func (c *Client) HTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = c.config.Timeout
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: c,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return fmt.Errorf("stopped after 5 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// HTTPClientNoRedirects is HTTPClient for calls to URLs users registered:
+// a redirect is returned as the response instead of being followed.
+func (c *Client) HTTPClientNoRedirects(timeout time.Duration) *http.Client {
+	client := c.HTTPClient(timeout)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}
+
+// RoundTrip lets Client act as the transport of the clients it hands out.
+func (c *Client) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("outbound: scheme %q is not allowed", req.URL.Scheme)
+	}
+
+	if err := c.wait(req.Context(), host); err != nil {
+		c.record(host, func(s *hostStats) { s.rateLimited++ })
+		return nil, err
+	}
+
+	if c.allowed[host] {
+		req = req.WithContext(context.WithValue(req.Context(), allowPrivateKey{}, true))
+	}
+
+	start := time.Now()
+	resp, err := c.transport.RoundTrip(req)
+	elapsed := time.Since(start).Seconds()
+
+	c.record(host, func(s *hostStats) {
+		s.requests++
+		s.seconds += elapsed
+		if err != nil {
+			s.errors++
+			if isBlocked(err) {
+				s.blocked++
+			}
+		}
+	})
+	return resp, err
+}
+
+func (c *Client) record(host string, update func(*hostStats)) {
+	if !c.named[host] {
+		host = OtherHost
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats, ok := c.stats[host]
+	if !ok {
+		stats = &hostStats{}
+		c.stats[host] = stats
+	}
+	update(stats)
+}
+
+// PrometheusMetrics renders per-host counters in the Prometheus text exposition format.
+func (c *Client) PrometheusMetrics() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hosts := make([]string, 0, len(c.stats))
+	for host := range c.stats {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var b strings.Builder
+	metrics := []struct {
+		name, help string
+		value      func(*hostStats) string
+	}{
+		{"outbound_requests_total", "Outbound HTTP requests by host.", func(s *hostStats) string { return fmt.Sprint(s.requests) }},
+		{"outbound_errors_total", "Outbound HTTP requests that failed without a response, by host.", func(s *hostStats) string { return fmt.Sprint(s.errors) }},
+		{"outbound_blocked_total", "Outbound connections refused by SSRF protection, by host.", func(s *hostStats) string { return fmt.Sprint(s.blocked) }},
+		{"outbound_rate_limited_total", "Outbound requests dropped by the per-host rate limit.", func(s *hostStats) string { return fmt.Sprint(s.rateLimited) }},
+		{"outbound_request_duration_seconds_sum", "Total time spent in outbound requests, by host.", func(s *hostStats) string { return fmt.Sprintf("%g", s.seconds) }},
+	}
+	for _, metric := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s counter\n", metric.name)
+		for _, host := range hosts {
+			fmt.Fprintf(&b, "%s{host=%q} %s\n", metric.name, host, metric.value(c.stats[host]))
+		}
+	}
+
+	return b.String()
+}
+
+var privateRanges = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7", "fe80::/10",
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+type blockedError struct {
+	ip net.IP
+}
+
+func (e *blockedError) Error() string {
+	return fmt.Sprintf("outbound: connection to private address %s is not allowed", e.ip)
+}
+
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("outbound: unresolved address %s", host)
+	}
+	for _, n := range privateRanges {
+		if n.Contains(ip) {
+			return &blockedError{ip: ip}
+		}
+	}
+	return nil
+}
+
+func isBlocked(err error) bool {
+	var blocked *blockedError
+	return errors.As(err, &blocked)
+}
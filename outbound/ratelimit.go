@@ -0,0 +1,72 @@
+package outbound
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// bucket is a token bucket refilled continuously at the per-host rate.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// wait blocks until host has a token, or fails when the wait would outlast ctx.
+func (c *Client) wait(ctx context.Context, host string) error {
+	if c.config.RatePerHost <= 0 {
+		return nil
+	}
+
+	burst := float64(c.config.BurstPerHost)
+	if burst < 1 {
+		burst = 1
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	b, ok := c.buckets[host]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		c.buckets[host] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * c.config.RatePerHost
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+
+	// Reserve the token now so concurrent callers queue up behind each other
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / c.config.RatePerHost * float64(time.Second))
+	}
+	c.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		c.refund(host)
+		return fmt.Errorf("outbound: rate limit for %s exceeded", host)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		c.refund(host)
+		return ctx.Err()
+	}
+}
+
+func (c *Client) refund(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b, ok := c.buckets[host]; ok {
+		b.tokens++
+	}
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryQueue keeps jobs in memory. They are lost on restart, so it suits
+// development and single instances that can rebuild their queue.
+type MemoryQueue struct {
+	mu     sync.Mutex
+	nextID int
+	jobs   map[string]*memoryJob
+}
+
+type memoryJob struct {
+	Job
+	leasedUntil time.Time
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{jobs: make(map[string]*memoryJob)}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, key string, now time.Time) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, queued := q.jobs[key]; queued {
+		return false, nil
+	}
+	q.nextID++
+	q.jobs[key] = &memoryJob{Job: Job{ID: strconv.Itoa(q.nextID), Key: key, EnqueuedAt: now, AvailableAt: now}}
+	return true, nil
+}
+
+func (q *MemoryQueue) Dequeue(ctx context.Context, now time.Time, lease time.Duration) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var next *memoryJob
+	for _, job := range q.jobs {
+		if job.AvailableAt.After(now) || job.leasedUntil.After(now) {
+			continue
+		}
+		if next == nil || job.AvailableAt.Before(next.AvailableAt) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Attempts++
+	next.leasedUntil = now.Add(lease)
+	job := next.Job
+	return &job, nil
+}
+
+func (q *MemoryQueue) Ack(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if current, ok := q.jobs[job.Key]; ok && current.ID == job.ID {
+		delete(q.jobs, job.Key)
+	}
+	return nil
+}
+
+func (q *MemoryQueue) Retry(ctx context.Context, job *Job, at time.Time, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if current, ok := q.jobs[job.Key]; ok && current.ID == job.ID {
+		current.AvailableAt = at
+		current.leasedUntil = time.Time{}
+		current.LastError = reason
+	}
+	return nil
+}
+
+func (q *MemoryQueue) Depth(ctx context.Context, now time.Time) (Depth, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var depth Depth
+	for _, job := range q.jobs {
+		switch {
+		case job.leasedUntil.After(now):
+			depth.Leased++
+		case job.AvailableAt.After(now):
+			depth.Waiting++
+		default:
+			depth.Ready++
+		}
+	}
+	return depth, nil
+}
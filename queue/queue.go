@@ -0,0 +1,48 @@
+// Package queue holds the jobs background workers process. A job is leased
+// to one consumer at a time and stays queued until it is acknowledged, so a
+// consumer that stops mid-job only delays it until the lease runs out.
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Job is one queued unit of work, named by its key
+type Job struct {
+	ID  string
+	Key string
+	// Attempts counts the leases so far, including the current one
+	Attempts    int
+	EnqueuedAt  time.Time
+	AvailableAt time.Time
+	// LastError is why the previous attempt failed
+	LastError string
+}
+
+// Depth counts a queue's jobs by state
+type Depth struct {
+	// Ready jobs are available to the next Dequeue
+	Ready int64
+	// Leased jobs are being worked on
+	Leased int64
+	// Waiting jobs become available later, such as failed jobs awaiting a retry
+	Waiting int64
+}
+
+// Queue holds jobs until they are acknowledged. Times are passed in so the
+// queue follows the clock of the workers using it.
+type Queue interface {
+	// Enqueue adds a job unless one with the key is already queued; it
+	// reports whether the job was added
+	Enqueue(ctx context.Context, key string, now time.Time) (bool, error)
+	// Dequeue leases the job available longest for lease, or returns nil
+	// when no job is available
+	Dequeue(ctx context.Context, now time.Time, lease time.Duration) (*Job, error)
+	// Ack removes a finished job
+	Ack(ctx context.Context, job *Job) error
+	// Retry makes a failed job available again at the given time
+	Retry(ctx context.Context, job *Job, at time.Time, reason string) error
+	// Depth counts the queued jobs as of now
+	Depth(ctx context.Context, now time.Time) (Depth, error)
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AccountRepository struct {
+	client   *mongo.Client
+	database *mongo.Database
+}
+
+func NewAccountRepository(db *database.MongoDB) *AccountRepository {
+	return &AccountRepository{
+		client:   db.Client,
+		database: db.Database,
+	}
+}
+
+// DeleteUser removes a user, their sessions and their comments, and either
+// reassigns their tasks to reassignTo or deletes them with their comments and
+// history. Everything runs in one transaction, which needs a replica set.
+func (r *AccountRepository) DeleteUser(ctx context.Context, userID primitive.ObjectID, reassignTo *primitive.ObjectID, now time.Time) (*models.DeleteAccountResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	tasks := r.database.Collection("tasks")
+	comments := r.database.Collection("comments")
+
+	result, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		response := &models.DeleteAccountResponse{}
+
+		if reassignTo != nil {
+			update := bson.M{"$set": bson.M{"user_id": *reassignTo, "updated_at": now}, "$inc": bson.M{"version": 1}}
+			reassigned, err := tasks.UpdateMany(sc, bson.M{"user_id": userID}, update)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reassign tasks: %w", err)
+			}
+			response.TasksReassigned = models.Int64(reassigned.ModifiedCount)
+		} else {
+			held, err := tasks.CountDocuments(sc, bson.M{"user_id": userID, "legal_hold": true})
+			if err != nil {
+				return nil, fmt.Errorf("failed to check legal holds: %w", err)
+			}
+			if held > 0 {
+				return nil, fmt.Errorf("account is under legal hold")
+			}
+
+			taskIDs, err := tasks.Distinct(sc, "_id", bson.M{"user_id": userID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to find tasks: %w", err)
+			}
+			if len(taskIDs) > 0 {
+				byTask := bson.M{"task_id": bson.M{"$in": taskIDs}}
+				deletedComments, err := comments.DeleteMany(sc, byTask)
+				if err != nil {
+					return nil, fmt.Errorf("failed to delete comments: %w", err)
+				}
+				response.CommentsDeleted += models.Int64(deletedComments.DeletedCount)
+				if _, err := r.database.Collection("task_history").DeleteMany(sc, byTask); err != nil {
+					return nil, fmt.Errorf("failed to delete task history: %w", err)
+				}
+			}
+
+			deleted, err := tasks.DeleteMany(sc, bson.M{"user_id": userID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete tasks: %w", err)
+			}
+			response.TasksDeleted = models.Int64(deleted.DeletedCount)
+		}
+
+		deletedComments, err := comments.DeleteMany(sc, bson.M{"author_id": userID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete comments: %w", err)
+		}
+		response.CommentsDeleted += models.Int64(deletedComments.DeletedCount)
+
+		if _, err := r.database.Collection("refresh_tokens").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete refresh tokens: %w", err)
+		}
+
+		if _, err := r.database.Collection("api_keys").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete api keys: %w", err)
+		}
+
+		if _, err := r.database.Collection("sessions").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete sessions: %w", err)
+		}
+
+		if _, err := r.database.Collection("my_day_items").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete my day items: %w", err)
+		}
+
+		if _, err := r.database.Collection("focus_sessions").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete focus sessions: %w", err)
+		}
+
+		if _, err := r.database.Collection("user_achievements").DeleteOne(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete achievements: %w", err)
+		}
+
+		if _, err := r.database.Collection("task_summaries").DeleteOne(sc, bson.M{"_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete task summary: %w", err)
+		}
+
+		if _, err := r.database.Collection("webhooks").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete webhooks: %w", err)
+		}
+
+		if _, err := r.database.Collection("webhook_deliveries").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		if _, err := r.database.Collection("notification_channels").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete notification channels: %w", err)
+		}
+		if _, err := r.database.Collection("push_subscriptions").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete push subscriptions: %w", err)
+		}
+		if _, err := r.database.Collection("notification_preferences").DeleteOne(sc, bson.M{"_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete notification preferences: %w", err)
+		}
+		if _, err := r.database.Collection("import_jobs").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete import jobs: %w", err)
+		}
+		if _, err := r.database.Collection("export_jobs").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete export jobs: %w", err)
+		}
+		if _, err := r.database.Collection("export_chunks").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete exports: %w", err)
+		}
+		if _, err := r.database.Collection("usage_buckets").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete usage: %w", err)
+		}
+		if _, err := r.database.Collection("deprecation_usage").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete deprecation usage: %w", err)
+		}
+		if _, err := r.database.Collection("subscriptions").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete subscription: %w", err)
+		}
+		if _, err := r.database.Collection("request_traces").DeleteMany(sc, bson.M{"user_id": userID}); err != nil {
+			return nil, fmt.Errorf("failed to delete request traces: %w", err)
+		}
+
+		deletedUser, err := r.database.Collection("users").DeleteOne(sc, bson.M{"_id": userID, "legal_hold": bson.M{"$ne": true}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete user: %w", err)
+		}
+		if deletedUser.DeletedCount == 0 {
+			return nil, fmt.Errorf("account is under legal hold")
+		}
+
+		return response, nil
+	})
+	if err != nil {
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == 20 {
+			return nil, fmt.Errorf("account deletion requires a MongoDB replica set")
+		}
+		return nil, err
+	}
+
+	return result.(*models.DeleteAccountResponse), nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AchievementRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAchievementRepository(db *database.MongoDB) *AchievementRepository {
+	return &AchievementRepository{
+		collection: db.Database.Collection("user_achievements"),
+	}
+}
+
+func (r *AchievementRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.UserAchievements, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var achievements models.UserAchievements
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&achievements)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("achievements not found")
+		}
+		return nil, fmt.Errorf("failed to find achievements: %w", err)
+	}
+
+	return &achievements, nil
+}
+
+// Save stores the user's achievements if nobody recorded a completion since
+// they were read with previousTotal completions. A concurrent write either
+// changed the total or inserted the first record, which the unique user_id
+// index rejects, and is reported as a conflict so the caller can re-read.
+func (r *AchievementRepository) Save(ctx context.Context, achievements *models.UserAchievements, previousTotal int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"user_id": achievements.UserID, "total_completed": previousTotal}
+	update := bson.M{"$set": bson.M{
+		"total_completed":     achievements.TotalCompleted,
+		"current_streak":      achievements.CurrentStreak,
+		"longest_streak":      achievements.LongestStreak,
+		"last_completion_day": achievements.LastCompletionDay,
+		"badges":              achievements.Badges,
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, query, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("achievements changed concurrently")
+		}
+		return fmt.Errorf("failed to save achievements: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *database.MongoDB) *APIKeyRepository {
+	return &APIKeyRepository{
+		collection: db.Database.Collection("api_keys"),
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	key.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindActiveByHash returns the unrevoked key with the given hash.
+func (r *APIKeyRepository) FindActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"key_hash": keyHash, "revoked_at": bson.M{"$exists": false}}
+
+	var key models.APIKey
+	err := r.collection.FindOne(ctx, query).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("api key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find api keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := []*models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) CountActiveByUserID(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count api keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// Revoke revokes one of the user's active keys.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id, userID primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, query, bson.M{"$set": bson.M{"revoked_at": revokedAt}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("api key not found")
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	if _, err := r.collection.UpdateMany(ctx, query, bson.M{"$set": bson.M{"revoked_at": revokedAt}}); err != nil {
+		return fmt.Errorf("failed to revoke api keys: %w", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": usedAt}}); err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditArchiveRepository records which archive objects exist; the objects
+// themselves live in the archive store.
+type AuditArchiveRepository struct {
+	collection *mongo.Collection
+}
+
+type AuditArchiveFilter struct {
+	// From and To limit the archived days, both inclusive
+	From  *time.Time
+	To    *time.Time
+	Page  int
+	Limit int
+}
+
+func NewAuditArchiveRepository(db *database.MongoDB) *AuditArchiveRepository {
+	return &AuditArchiveRepository{
+		collection: db.Database.Collection("audit_archives"),
+	}
+}
+
+func (r *AuditArchiveRepository) Create(ctx context.Context, archive *models.AuditArchive) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, archive); err != nil {
+		return fmt.Errorf("failed to create audit archive: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditArchiveRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.AuditArchive, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var archive models.AuditArchive
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&archive)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("audit archive not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit archive: %w", err)
+	}
+
+	return &archive, nil
+}
+
+func (r *AuditArchiveRepository) FindAll(ctx context.Context, filter AuditArchiveFilter) ([]*models.AuditArchive, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.From != nil || filter.To != nil {
+		day := bson.M{}
+		if filter.From != nil {
+			day["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			day["$lte"] = *filter.To
+		}
+		query["day"] = day
+	}
+
+	// Count total documents
+	totalCount, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit archives: %w", err)
+	}
+
+	// Set pagination defaults
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit)).
+		SetSort(bson.D{{Key: "day", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find audit archives: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var archives []*models.AuditArchive
+	if err := cursor.All(ctx, &archives); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode audit archives: %w", err)
+	}
+
+	return archives, totalCount, nil
+}
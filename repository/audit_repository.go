@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(db *database.MongoDB) *AuditRepository {
+	return &AuditRepository{
+		collection: db.Database.Collection("audit_logs"),
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// MarkPurge schedules entries created before cutoff for deletion at purgeAt,
+// except entries about targets under legal hold.
+func (r *AuditRepository) MarkPurge(ctx context.Context, cutoff, purgeAt time.Time, heldTargetIDs []primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"created_at": bson.M{"$lt": cutoff},
+		"purge_at":   bson.M{"$exists": false},
+		"target_id":  bson.M{"$nin": heldTargetIDs},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, query, bson.M{"$set": bson.M{"purge_at": purgeAt}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark audit logs for purge: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *AuditRepository) DeletePurgeDue(ctx context.Context, now time.Time, heldTargetIDs []primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"purge_at":  bson.M{"$lte": now},
+		"target_id": bson.M{"$nin": heldTargetIDs},
+	}
+
+	result, err := r.collection.DeleteMany(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit logs: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (r *AuditRepository) CancelPurgeByTargetID(ctx context.Context, targetID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"target_id": targetID, "purge_at": bson.M{"$exists": true}}
+	if _, err := r.collection.UpdateMany(ctx, query, bson.M{"$unset": bson.M{"purge_at": ""}}); err != nil {
+		return fmt.Errorf("failed to cancel audit log purges: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditRepository) CountPendingPurge(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"purge_at": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit logs pending purge: %w", err)
+	}
+
+	return count, nil
+}
+
+// archivable matches entries created in [from, to) that are not already
+// scheduled for purge; entries about to be deleted are not worth archiving
+func archivable(from, to time.Time) bson.M {
+	createdAt := bson.M{"$lt": to}
+	if !from.IsZero() {
+		createdAt["$gte"] = from
+	}
+	return bson.M{"created_at": createdAt, "purge_at": bson.M{"$exists": false}}
+}
+
+// FindOldestArchivable returns the creation time of the oldest entry before
+// cutoff that can be archived, or nil when there is none
+func (r *AuditRepository) FindOldestArchivable(ctx context.Context, cutoff time.Time) (*time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetProjection(bson.M{"created_at": 1})
+	var entry models.AuditLog
+	err := r.collection.FindOne(ctx, archivable(time.Time{}, cutoff), opts).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find oldest audit log: %w", err)
+	}
+
+	return &entry.CreatedAt, nil
+}
+
+// FindArchivable returns the entries created in [from, to), oldest first
+func (r *AuditRepository) FindArchivable(ctx context.Context, from, to time.Time) ([]*models.AuditLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, archivable(from, to), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit logs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.AuditLog
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode audit logs: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *AuditRepository) DeleteByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit logs: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentRepository struct {
+	collection     *mongo.Collection
+	listCollection *mongo.Collection
+}
+
+type CommentFilter struct {
+	Page  int
+	Limit int
+}
+
+func NewCommentRepository(db *database.MongoDB) *CommentRepository {
+	return &CommentRepository{
+		collection:     db.Database.Collection("comments"),
+		listCollection: db.ListDatabase.Collection("comments"),
+	}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, comment)
+	if err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	comment.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *CommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var comment models.Comment
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find comment: %w", err)
+	}
+
+	return &comment, nil
+}
+
+func (r *CommentRepository) FindByTaskID(ctx context.Context, taskID primitive.ObjectID, filter CommentFilter) ([]*models.Comment, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"task_id": taskID}
+
+	// Count total documents
+	totalCount, err := r.listCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	// Set pagination defaults
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	// Comments read as a conversation, oldest first
+	findOptions := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit)).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.listCollection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find comments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode comments: %w", err)
+	}
+
+	return comments, totalCount, nil
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("comment not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeadLetterRepository keeps the jobs of every queue that exhausted their
+// attempts. Entries expire after 90 days through a TTL index on failed_at.
+type DeadLetterRepository struct {
+	collection *mongo.Collection
+}
+
+type DeadLetterFilter struct {
+	Queue string
+	Page  int
+	Limit int
+}
+
+func NewDeadLetterRepository(db *database.MongoDB) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		collection: db.Database.Collection("dead_letter"),
+	}
+}
+
+func (r *DeadLetterRepository) Create(ctx context.Context, deadLetter *models.DeadLetter) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, deadLetter)
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter: %w", err)
+	}
+
+	deadLetter.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *DeadLetterRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.DeadLetter, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var deadLetter models.DeadLetter
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&deadLetter)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("dead letter not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dead letter: %w", err)
+	}
+
+	return &deadLetter, nil
+}
+
+// FindAll lists dead letters, most recently failed first
+func (r *DeadLetterRepository) FindAll(ctx context.Context, filter DeadLetterFilter) ([]*models.DeadLetter, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.Queue != "" {
+		query["queue"] = filter.Queue
+	}
+
+	totalCount, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit)).
+		SetSort(bson.D{{Key: "failed_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find dead letters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var deadLetters []*models.DeadLetter
+	if err := cursor.All(ctx, &deadLetters); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode dead letters: %w", err)
+	}
+
+	return deadLetters, totalCount, nil
+}
+
+// Delete removes a dead letter; it fails with "dead letter not found" when
+// another request removed it first
+func (r *DeadLetterRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("dead letter not found")
+	}
+
+	return nil
+}
+
+// Count counts the dead letters of the queue
+func (r *DeadLetterRepository) Count(ctx context.Context, queue string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"queue": queue})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	return count, nil
+}
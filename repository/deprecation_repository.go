@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeprecationRepository counts requests using deprecated parts of the API,
+// one record per deprecation, route and client. Records expire 180 days after
+// they were last seen, so clients that migrated drop out of the report.
+type DeprecationRepository struct {
+	collection *mongo.Collection
+}
+
+// DeprecationIncrement adds requests to one record
+type DeprecationIncrement struct {
+	Deprecation string
+	Route       string
+	UserID      *primitive.ObjectID
+	APIKeyID    *primitive.ObjectID
+	UserAgent   string
+	Requests    int64
+	FirstSeen   time.Time
+	LastSeen    time.Time
+}
+
+func NewDeprecationRepository(db *database.MongoDB) *DeprecationRepository {
+	return &DeprecationRepository{
+		collection: db.Database.Collection("deprecation_usage"),
+	}
+}
+
+// Increment adds the requests to their records, creating records as needed
+func (r *DeprecationRepository) Increment(ctx context.Context, increments []DeprecationIncrement) error {
+	if len(increments) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, len(increments))
+	for i, inc := range increments {
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"deprecation": inc.Deprecation,
+				"route":       inc.Route,
+				"user_id":     inc.UserID,
+				"api_key_id":  inc.APIKeyID,
+				"user_agent":  inc.UserAgent,
+			}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"requests": inc.Requests},
+				"$min": bson.M{"first_seen": inc.FirstSeen},
+				"$max": bson.M{"last_seen": inc.LastSeen},
+			}).
+			SetUpsert(true)
+	}
+	if _, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to record deprecation usage: %w", err)
+	}
+
+	return nil
+}
+
+// FindClients returns the clients that used the deprecation, most recently
+// seen first, with their routes merged
+func (r *DeprecationRepository) FindClients(ctx context.Context, deprecation string, limit int64) ([]*models.DeprecationClient, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deprecation": deprecation}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        bson.M{"user_id": "$user_id", "api_key_id": "$api_key_id", "user_agent": "$user_agent"},
+			"user_id":    bson.M{"$first": "$user_id"},
+			"api_key_id": bson.M{"$first": "$api_key_id"},
+			"user_agent": bson.M{"$first": "$user_agent"},
+			"requests":   bson.M{"$sum": "$requests"},
+			"routes":     bson.M{"$addToSet": "$route"},
+			"first_seen": bson.M{"$min": "$first_seen"},
+			"last_seen":  bson.M{"$max": "$last_seen"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "last_seen", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deprecation usage: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	clients := []*models.DeprecationClient{}
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, fmt.Errorf("failed to decode deprecation usage: %w", err)
+	}
+
+	return clients, nil
+}
+
+// CountRequests sums the requests that used the deprecation
+func (r *DeprecationRepository) CountRequests(ctx context.Context, deprecation string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deprecation": deprecation}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "requests": bson.M{"$sum": "$requests"}}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deprecation usage: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		Requests int64 `bson:"requests"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return 0, fmt.Errorf("failed to decode deprecation usage: %w", err)
+	}
+	if len(totals) == 0 {
+		return 0, nil
+	}
+	return totals[0].Requests, nil
+}
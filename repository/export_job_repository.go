@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// exportChunkBytes keeps each stored piece of a file well under MongoDB's
+// 16MB document limit
+const exportChunkBytes = 8 << 20
+
+// ExportJobRepository stores background export jobs and their files, split
+// into chunks. Both expire through TTL indexes on expires_at.
+type ExportJobRepository struct {
+	jobs   *mongo.Collection
+	chunks *mongo.Collection
+}
+
+func NewExportJobRepository(db *database.MongoDB) *ExportJobRepository {
+	return &ExportJobRepository{
+		jobs:   db.Database.Collection("export_jobs"),
+		chunks: db.Database.Collection("export_chunks"),
+	}
+}
+
+func (r *ExportJobRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.jobs.InsertOne(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ExportJobRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var job models.ExportJob
+	err := r.jobs.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("export job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find export job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// FindActive returns the user's queued or running job that moved after
+// since, or nil when there is none
+func (r *ExportJobRepository) FindActive(ctx context.Context, userID primitive.ObjectID, since time.Time) (*models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":    userID,
+		"status":     bson.M{"$in": []models.ExportJobStatus{models.ExportJobStatusQueued, models.ExportJobStatusRunning}},
+		"updated_at": bson.M{"$gt": since},
+	}
+	var job models.ExportJob
+	err := r.jobs.FindOne(ctx, query, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find export job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Update saves the job's progress. A job that has finished is not changed again.
+func (r *ExportJobRepository) Update(ctx context.Context, job *models.ExportJob) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"_id":    job.ID,
+		"status": bson.M{"$in": []models.ExportJobStatus{models.ExportJobStatusQueued, models.ExportJobStatusRunning}},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":               job.Status,
+		"task_count":           job.TaskCount,
+		"hidden_private_count": job.HiddenPrivateCount,
+		"size_bytes":           job.SizeBytes,
+		"error":                job.Error,
+		"updated_at":           job.UpdatedAt,
+		"finished_at":          job.FinishedAt,
+	}}
+	if _, err := r.jobs.UpdateOne(ctx, query, update); err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+
+	return nil
+}
+
+// SaveFile stores the job's file; it expires with the job
+func (r *ExportJobRepository) SaveFile(ctx context.Context, job *models.ExportJob, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var chunks []interface{}
+	for n, start := 0, 0; start < len(data) || n == 0; n, start = n+1, start+exportChunkBytes {
+		chunks = append(chunks, bson.M{
+			"export_id":  job.ID,
+			"user_id":    job.UserID,
+			"n":          n,
+			"data":       data[start:min(start+exportChunkBytes, len(data))],
+			"expires_at": job.ExpiresAt,
+		})
+	}
+	if _, err := r.chunks.InsertMany(ctx, chunks); err != nil {
+		return fmt.Errorf("failed to save export file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadFile returns the job's file, reassembled from its chunks
+func (r *ExportJobRepository) ReadFile(ctx context.Context, jobID primitive.ObjectID) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cursor, err := r.chunks.Find(ctx, bson.M{"export_id": jobID}, options.Find().SetSort(bson.D{{Key: "n", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export file: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var data []byte
+	found := false
+	for cursor.Next(ctx) {
+		var chunk struct {
+			Data []byte `bson:"data"`
+		}
+		if err := cursor.Decode(&chunk); err != nil {
+			return nil, fmt.Errorf("failed to read export file: %w", err)
+		}
+		data = append(data, chunk.Data...)
+		found = true
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read export file: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("export file not found")
+	}
+
+	return data, nil
+}
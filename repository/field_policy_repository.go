@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const taskFieldPolicyID = "task"
+
+type FieldPolicyRepository struct {
+	policies *mongo.Collection
+}
+
+func NewFieldPolicyRepository(db *database.MongoDB) *FieldPolicyRepository {
+	return &FieldPolicyRepository{
+		policies: db.Database.Collection("field_policies"),
+	}
+}
+
+func (r *FieldPolicyRepository) GetTaskPolicy(ctx context.Context) (*models.TaskFieldPolicy, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var policy models.TaskFieldPolicy
+	err := r.policies.FindOne(ctx, bson.M{"_id": taskFieldPolicyID}).Decode(&policy)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("field policy not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find field policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (r *FieldPolicyRepository) SaveTaskPolicy(ctx context.Context, policy *models.TaskFieldPolicy) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	policy.ID = taskFieldPolicyID
+	_, err := r.policies.ReplaceOne(ctx, bson.M{"_id": taskFieldPolicyID}, policy, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save field policy: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FocusSessionRepository struct {
+	collection     *mongo.Collection
+	listCollection *mongo.Collection
+}
+
+type FocusSessionFilter struct {
+	Page  int
+	Limit int
+}
+
+func NewFocusSessionRepository(db *database.MongoDB) *FocusSessionRepository {
+	return &FocusSessionRepository{
+		collection:     db.Database.Collection("focus_sessions"),
+		listCollection: db.ListDatabase.Collection("focus_sessions"),
+	}
+}
+
+func (r *FocusSessionRepository) Create(ctx context.Context, session *models.FocusSession) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to create focus session: %w", err)
+	}
+
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindRunning returns the user's session that is neither stopped nor over at now
+func (r *FocusSessionRepository) FindRunning(ctx context.Context, userID primitive.ObjectID, now time.Time) (*models.FocusSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":  userID,
+		"ended_at": bson.M{"$exists": false},
+		"ends_at":  bson.M{"$gt": now},
+	}
+
+	var session models.FocusSession
+	err := r.collection.FindOne(ctx, query).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("no pomodoro session is running")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find focus session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// Stop ends a running session at endedAt; it fails if the session already ended
+func (r *FocusSessionRepository) Stop(ctx context.Context, id primitive.ObjectID, endedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"_id":      id,
+		"ended_at": bson.M{"$exists": false},
+		"ends_at":  bson.M{"$gt": endedAt},
+	}
+	result, err := r.collection.UpdateOne(ctx, query, bson.M{"$set": bson.M{"ended_at": endedAt}})
+	if err != nil {
+		return fmt.Errorf("failed to stop focus session: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no pomodoro session is running")
+	}
+
+	return nil
+}
+
+func (r *FocusSessionRepository) FindByTaskID(ctx context.Context, taskID primitive.ObjectID, filter FocusSessionFilter) ([]*models.FocusSession, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"task_id": taskID}
+
+	totalCount, err := r.listCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count focus sessions: %w", err)
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit)).
+		SetSort(bson.D{{Key: "started_at", Value: -1}})
+
+	cursor, err := r.listCollection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find focus sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*models.FocusSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode focus sessions: %w", err)
+	}
+
+	return sessions, totalCount, nil
+}
+
+// FindStartedBetween returns up to limit of the user's sessions started in [from, to)
+func (r *FocusSessionRepository) FindStartedBetween(ctx context.Context, userID primitive.ObjectID, from, to time.Time, limit int64) ([]*models.FocusSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":    userID,
+		"started_at": bson.M{"$gte": from, "$lt": to},
+	}
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "started_at", Value: 1}})
+
+	cursor, err := r.listCollection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find focus sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []*models.FocusSession{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode focus sessions: %w", err)
+	}
+
+	return sessions, nil
+}
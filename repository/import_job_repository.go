@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImportJobRepository stores background import jobs; finished jobs expire
+// after 30 days through a TTL index on created_at.
+type ImportJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewImportJobRepository(db *database.MongoDB) *ImportJobRepository {
+	return &ImportJobRepository{
+		collection: db.Database.Collection("import_jobs"),
+	}
+}
+
+func (r *ImportJobRepository) Create(ctx context.Context, job *models.ImportJob) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ImportJobRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var job models.ImportJob
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("import job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find import job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// FindActive returns the user's queued or running job that moved after
+// since, or nil when there is none
+func (r *ImportJobRepository) FindActive(ctx context.Context, userID primitive.ObjectID, since time.Time) (*models.ImportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":    userID,
+		"status":     bson.M{"$in": []models.ImportJobStatus{models.ImportJobStatusQueued, models.ImportJobStatusRunning}},
+		"updated_at": bson.M{"$gt": since},
+	}
+	var job models.ImportJob
+	err := r.collection.FindOne(ctx, query, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find import job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Update saves the job's progress. A job that has finished is not changed again.
+func (r *ImportJobRepository) Update(ctx context.Context, job *models.ImportJob) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"_id":    job.ID,
+		"status": bson.M{"$in": []models.ImportJobStatus{models.ImportJobStatusQueued, models.ImportJobStatusRunning}},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":         job.Status,
+		"imported_count": job.ImportedCount,
+		"failed_count":   job.FailedCount,
+		"failures":       job.Failures,
+		"error":          job.Error,
+		"updated_at":     job.UpdatedAt,
+		"finished_at":    job.FinishedAt,
+	}}
+	if _, err := r.collection.UpdateOne(ctx, query, update); err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	return nil
+}
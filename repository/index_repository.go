@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IndexRepository struct {
+	database *mongo.Database
+}
+
+func NewIndexRepository(db *database.MongoDB) *IndexRepository {
+	return &IndexRepository{
+		database: db.Database,
+	}
+}
+
+type indexDocument struct {
+	Name               string `bson:"name"`
+	Key                bson.D `bson:"key"`
+	Unique             bool   `bson:"unique"`
+	Sparse             bool   `bson:"sparse"`
+	ExpireAfterSeconds *int32 `bson:"expireAfterSeconds"`
+}
+
+// List returns the collection's indexes; a collection that does not exist yet has none
+func (r *IndexRepository) List(ctx context.Context, collection string) ([]models.IndexInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.database.Collection(collection).Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s indexes: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []indexDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode %s indexes: %w", collection, err)
+	}
+
+	indexes := make([]models.IndexInfo, len(docs))
+	for i, doc := range docs {
+		indexes[i] = models.IndexInfo{
+			Name:               doc.Name,
+			Key:                database.IndexKeySignature(doc.Key),
+			Unique:             doc.Unique,
+			Sparse:             doc.Sparse,
+			ExpireAfterSeconds: doc.ExpireAfterSeconds,
+		}
+	}
+
+	return indexes, nil
+}
+
+// IndexUsage is the number of operations that used an index since Since
+type IndexUsage struct {
+	Ops   int64
+	Since time.Time
+}
+
+// Usage reads $indexStats for the collection, summing operations across replica set members
+func (r *IndexRepository) Usage(ctx context.Context, collection string) (map[string]IndexUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.database.Collection(collection).Aggregate(ctx, mongo.Pipeline{{{Key: "$indexStats", Value: bson.M{}}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s index stats: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var stats []struct {
+		Name     string `bson:"name"`
+		Accesses struct {
+			Ops   int64     `bson:"ops"`
+			Since time.Time `bson:"since"`
+		} `bson:"accesses"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("failed to decode %s index stats: %w", collection, err)
+	}
+
+	usage := make(map[string]IndexUsage, len(stats))
+	for _, stat := range stats {
+		current, seen := usage[stat.Name]
+		current.Ops += stat.Accesses.Ops
+		if !seen || stat.Accesses.Since.Before(current.Since) {
+			current.Since = stat.Accesses.Since
+		}
+		usage[stat.Name] = current
+	}
+
+	return usage, nil
+}
+
+// Create builds one index. Builds on large collections can take a long time,
+// so the caller's context bounds it rather than the usual short timeout.
+func (r *IndexRepository) Create(ctx context.Context, spec database.IndexSpec) (string, error) {
+	name, err := r.database.Collection(spec.Collection).Indexes().CreateOne(ctx, spec.Model())
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s index %s: %w", spec.Collection, spec.Name(), err)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeaseRepository keeps named leases, each held by one instance until it
+// expires. A lease is one document named by its ID, so the ID's unique index
+// decides races between instances.
+type LeaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLeaseRepository(db *database.MongoDB) *LeaseRepository {
+	return &LeaseRepository{
+		collection: db.Database.Collection("leases"),
+	}
+}
+
+// Acquire takes or renews the lease for holder until now+ttl. It reports
+// false while another holder's lease has not expired.
+func (r *LeaseRepository) Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"holder": holder},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	// An update pipeline, so acquired_at is only set when the lease changes hands
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"holder":      holder,
+		"expires_at":  now.Add(ttl),
+		"renewed_at":  now,
+		"acquired_at": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$holder", holder}}, "$acquired_at", now}},
+	}}}}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// The filter missed an unexpired lease of another holder, so the
+		// upsert collided with it
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	return true, nil
+}
+
+// Release gives up the lease if holder still has it, so another instance
+// can take it over without waiting for it to expire
+func (r *LeaseRepository) Release(ctx context.Context, name, holder string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": name, "holder": holder}); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxListedLoginAttempts = 1000
+
+// LoginAttemptRepository is the shared login attempt store; expired windows
+// are removed by a TTL index on reset_at.
+type LoginAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLoginAttemptRepository(db *database.MongoDB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		collection: db.Database.Collection("login_attempts"),
+	}
+}
+
+func (r *LoginAttemptRepository) Get(ctx context.Context, key string, now time.Time) (*models.LoginAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var attempt models.LoginAttempt
+	err := r.collection.FindOne(ctx, bson.M{"_id": key, "reset_at": bson.M{"$gt": now}}).Decode(&attempt)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find login attempts: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+// RecordFailure increments the counter atomically, starting a new window
+// when the current one has ended.
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration, now time.Time) (*models.LoginAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	active := bson.M{"$gt": bson.A{"$reset_at", now}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures":     bson.M{"$cond": bson.A{active, bson.M{"$add": bson.A{"$failures", 1}}, 1}},
+			"window_start": bson.M{"$cond": bson.A{active, "$window_start", now}},
+			"reset_at":     bson.M{"$cond": bson.A{active, "$reset_at", now.Add(window)}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempt models.LoginAttempt
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&attempt); err != nil {
+		return nil, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+func (r *LoginAttemptRepository) Clear(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("failed to clear login attempts: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LoginAttemptRepository) List(ctx context.Context, now time.Time) ([]*models.LoginAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "failures", Value: -1}}).
+		SetLimit(maxListedLoginAttempts)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"reset_at": bson.M{"$gt": now}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find login attempts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	attempts := []*models.LoginAttempt{}
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, fmt.Errorf("failed to decode login attempts: %w", err)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MyDayRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMyDayRepository(db *database.MongoDB) *MyDayRepository {
+	return &MyDayRepository{
+		collection: db.Database.Collection("my_day_items"),
+	}
+}
+
+// Add puts a task on the user's list for day. Adding a task that is already
+// listed keeps its original entry, rollover count included.
+func (r *MyDayRepository) Add(ctx context.Context, item *models.MyDayItem) (*models.MyDayItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"user_id": item.UserID, "task_id": item.TaskID}
+	update := bson.M{"$setOnInsert": bson.M{
+		"day":            item.Day,
+		"added_at":       item.AddedAt,
+		"rollover_count": item.RolloverCount,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.MyDayItem
+	if err := r.collection.FindOneAndUpdate(ctx, query, update, opts).Decode(&stored); err != nil {
+		return nil, fmt.Errorf("failed to add task to my day: %w", err)
+	}
+
+	return &stored, nil
+}
+
+func (r *MyDayRepository) Remove(ctx context.Context, userID, taskID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID, "task_id": taskID})
+	if err != nil {
+		return fmt.Errorf("failed to remove task from my day: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("task is not in my day")
+	}
+
+	return nil
+}
+
+// FindByUserID returns the user's list in the order tasks were added
+func (r *MyDayRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.MyDayItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "added_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find my day items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	items := []*models.MyDayItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode my day items: %w", err)
+	}
+
+	return items, nil
+}
+
+// FindStale returns up to limit items from days before day, optionally for one user only.
+func (r *MyDayRepository) FindStale(ctx context.Context, day string, userID *primitive.ObjectID, limit int64) ([]*models.MyDayItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// YYYY-MM-DD strings sort chronologically
+	query := bson.M{"day": bson.M{"$lt": day}}
+	if userID != nil {
+		query["user_id"] = *userID
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stale my day items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	items := []*models.MyDayItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode my day items: %w", err)
+	}
+
+	return items, nil
+}
+
+// RollOver moves an item to day. The day condition makes concurrent rollovers
+// of the same item, e.g. the job and a list request, count it only once.
+func (r *MyDayRepository) RollOver(ctx context.Context, item *models.MyDayItem, day string, days int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"_id": item.ID, "day": item.Day}
+	update := bson.M{
+		"$set": bson.M{"day": day},
+		"$inc": bson.M{"rollover_count": days},
+	}
+	if _, err := r.collection.UpdateOne(ctx, query, update); err != nil {
+		return fmt.Errorf("failed to roll over my day item: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MyDayRepository) DeleteByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete my day items: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationChannelRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationChannelRepository(db *database.MongoDB) *NotificationChannelRepository {
+	return &NotificationChannelRepository{
+		collection: db.Database.Collection("notification_channels"),
+	}
+}
+
+func (r *NotificationChannelRepository) Create(ctx context.Context, channel *models.NotificationChannel) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, channel)
+	if err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+
+	channel.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByID returns one of the user's channels
+func (r *NotificationChannelRepository) FindByID(ctx context.Context, id, userID primitive.ObjectID) (*models.NotificationChannel, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var channel models.NotificationChannel
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&channel)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("notification channel not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification channel: %w", err)
+	}
+
+	return &channel, nil
+}
+
+func (r *NotificationChannelRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.NotificationChannel, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification channels: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	channels := []*models.NotificationChannel{}
+	if err := cursor.All(ctx, &channels); err != nil {
+		return nil, fmt.Errorf("failed to decode notification channels: %w", err)
+	}
+
+	return channels, nil
+}
+
+// FindSubscribed returns the user's channels that subscribe to event, leaving
+// out Telegram chats that are not linked yet
+func (r *NotificationChannelRepository) FindSubscribed(ctx context.Context, userID primitive.ObjectID, event string) ([]*models.NotificationChannel, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID, "events": event, "telegram.link_expires_at": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification channels: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	channels := []*models.NotificationChannel{}
+	if err := cursor.All(ctx, &channels); err != nil {
+		return nil, fmt.Errorf("failed to decode notification channels: %w", err)
+	}
+
+	return channels, nil
+}
+
+// LinkTelegram connects the pending Telegram channel whose link token hashes
+// to tokenHash to the chat, unless the link expired. It returns nil when no
+// channel matches.
+func (r *NotificationChannelRepository) LinkTelegram(ctx context.Context, tokenHash string, chatID int64, now time.Time) (*models.NotificationChannel, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"telegram.link_token_hash": tokenHash,
+		"telegram.link_expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{
+		"$set":   bson.M{"telegram.chat_id": chatID, "telegram.linked_at": now},
+		"$unset": bson.M{"telegram.link_token_hash": "", "telegram.link_expires_at": ""},
+	}
+
+	var channel models.NotificationChannel
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&channel)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to link notification channel: %w", err)
+	}
+
+	return &channel, nil
+}
+
+func (r *NotificationChannelRepository) CountByUserID(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notification channels: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *NotificationChannelRepository) Delete(ctx context.Context, id, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("notification channel not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationPreferenceRepository stores one preferences document per user,
+// keyed by the user's ID
+type NotificationPreferenceRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationPreferenceRepository(db *database.MongoDB) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{
+		collection: db.Database.Collection("notification_preferences"),
+	}
+}
+
+// FindByUserID returns the user's preferences, or nil when they never changed them
+func (r *NotificationPreferenceRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var preferences models.NotificationPreferences
+	err := r.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&preferences)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification preferences: %w", err)
+	}
+
+	return &preferences, nil
+}
+
+// SetChannels replaces the events of the given channels, leaving the others as stored
+func (r *NotificationPreferenceRepository) SetChannels(ctx context.Context, userID primitive.ObjectID, channels map[string][]string, now time.Time) (*models.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"updated_at": now}
+	for channel, events := range channels {
+		set["channels."+channel] = events
+	}
+
+	var preferences models.NotificationPreferences
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": userID}, bson.M{"$set": set}, opts).Decode(&preferences); err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	return &preferences, nil
+}
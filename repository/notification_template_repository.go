@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationTemplateRepository stores custom templates, keyed by the name
+// of the built-in template each replaces
+type NotificationTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationTemplateRepository(db *database.MongoDB) *NotificationTemplateRepository {
+	return &NotificationTemplateRepository{
+		collection: db.Database.Collection("notification_templates"),
+	}
+}
+
+func (r *NotificationTemplateRepository) FindAll(ctx context.Context) ([]*models.NotificationTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification templates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*models.NotificationTemplate{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, fmt.Errorf("failed to decode notification templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (r *NotificationTemplateRepository) FindByName(ctx context.Context, name string) (*models.NotificationTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var template models.NotificationTemplate
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&template)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("custom notification template not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification template: %w", err)
+	}
+
+	return &template, nil
+}
+
+func (r *NotificationTemplateRepository) Save(ctx context.Context, template *models.NotificationTemplate) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": template.Name}, template, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save notification template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NotificationTemplateRepository) Delete(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return fmt.Errorf("failed to delete notification template: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("custom notification template not found")
+	}
+
+	return nil
+}
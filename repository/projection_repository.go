@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProjectionRepository stores read models derived from other collections.
+// They can always be rebuilt from their source and are never the only copy.
+type ProjectionRepository struct {
+	taskSummaries *mongo.Collection
+}
+
+func NewProjectionRepository(db *database.MongoDB) *ProjectionRepository {
+	return &ProjectionRepository{
+		taskSummaries: db.Database.Collection("task_summaries"),
+	}
+}
+
+func (r *ProjectionRepository) FindTaskSummary(ctx context.Context, userID primitive.ObjectID) (*models.TaskSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var summary models.TaskSummary
+	err := r.taskSummaries.FindOne(ctx, bson.M{"_id": userID}).Decode(&summary)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("task summary not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// FindAllTaskSummaries returns every stored summary; there is one per user with tasks
+func (r *ProjectionRepository) FindAllTaskSummaries(ctx context.Context) ([]*models.TaskSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	cursor, err := r.taskSummaries.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task summaries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	summaries := []*models.TaskSummary{}
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, fmt.Errorf("failed to decode task summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// SaveTaskSummary replaces the stored summary unless a newer one is already
+// stored; summaries computed concurrently may finish in either order.
+func (r *ProjectionRepository) SaveTaskSummary(ctx context.Context, summary *models.TaskSummary) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"_id": summary.UserID, "updated_at": bson.M{"$lte": summary.UpdatedAt}}
+	_, err := r.taskSummaries.ReplaceOne(ctx, query, summary, options.Replace().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to save task summary: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTaskSummariesBefore removes summaries not refreshed since cutoff,
+// which after a full rebuild are those of users without tasks.
+func (r *ProjectionRepository) DeleteTaskSummariesBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := r.taskSummaries.DeleteMany(ctx, bson.M{"updated_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete task summaries: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PushSubscriptionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPushSubscriptionRepository(db *database.MongoDB) *PushSubscriptionRepository {
+	return &PushSubscriptionRepository{
+		collection: db.Database.Collection("push_subscriptions"),
+	}
+}
+
+// Upsert saves the subscription, replacing the keys and expiry of the user's
+// subscription for the same endpoint, since browsers resubscribe with the
+// endpoint they already have. The stored ID and creation time are kept.
+func (r *PushSubscriptionRepository) Upsert(ctx context.Context, subscription *models.PushSubscription) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"p256dh":     subscription.P256DH,
+		"auth":       subscription.Auth,
+		"device":     subscription.Device,
+		"expires_at": subscription.ExpiresAt,
+	}
+	unset := bson.M{}
+	if subscription.ExpiresAt == nil {
+		delete(set, "expires_at")
+		unset["expires_at"] = ""
+	}
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"created_at": subscription.CreatedAt},
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var saved models.PushSubscription
+	filter := bson.M{"user_id": subscription.UserID, "endpoint": subscription.Endpoint}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return fmt.Errorf("failed to save push subscription: %w", err)
+	}
+
+	*subscription = saved
+	return nil
+}
+
+func (r *PushSubscriptionRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.PushSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find push subscriptions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := []*models.PushSubscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to decode push subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// CountByUserID counts the user's subscriptions other than the one for
+// endpoint, which a resubscribe replaces
+func (r *PushSubscriptionRepository) CountByUserID(ctx context.Context, userID primitive.ObjectID, endpoint string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "endpoint": bson.M{"$ne": endpoint}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count push subscriptions: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *PushSubscriptionRepository) Delete(ctx context.Context, id, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("push subscription not found")
+	}
+
+	return nil
+}
+
+// DeleteByID removes a subscription the push service reported gone. A
+// subscription that is already gone is not an error.
+func (r *PushSubscriptionRepository) DeleteByID(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+
+	return nil
+}
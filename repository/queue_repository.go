@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/queue"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QueueRepository is a queue.Queue kept in the queue_jobs collection, so
+// jobs survive restarts and are shared by every instance. Each queue is
+// named; a unique index on queue and key keeps a key queued only once.
+type QueueRepository struct {
+	collection *mongo.Collection
+	name       string
+}
+
+type queueJob struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Queue       string             `bson:"queue"`
+	Key         string             `bson:"key"`
+	Attempts    int                `bson:"attempts"`
+	EnqueuedAt  time.Time          `bson:"enqueued_at"`
+	AvailableAt time.Time          `bson:"available_at"`
+	LeasedUntil *time.Time         `bson:"leased_until"`
+	LastError   string             `bson:"last_error,omitempty"`
+}
+
+func NewQueueRepository(db *database.MongoDB, name string) *QueueRepository {
+	return &QueueRepository{
+		collection: db.Database.Collection("queue_jobs"),
+		name:       name,
+	}
+}
+
+func (r *QueueRepository) Enqueue(ctx context.Context, key string, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"queue": r.name, "key": key},
+		bson.M{"$setOnInsert": bson.M{
+			"attempts":     0,
+			"enqueued_at":  now,
+			"available_at": now,
+			"leased_until": nil,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		// Two instances queueing the same key at once race on the unique index
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return result.UpsertedCount > 0, nil
+}
+
+func (r *QueueRepository) Dequeue(ctx context.Context, now time.Time, lease time.Duration) (*queue.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"queue":        r.name,
+		"available_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"leased_until": nil},
+			bson.M{"leased_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"leased_until": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "available_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job queueJob
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+
+	return &queue.Job{
+		ID:          job.ID.Hex(),
+		Key:         job.Key,
+		Attempts:    job.Attempts,
+		EnqueuedAt:  job.EnqueuedAt,
+		AvailableAt: job.AvailableAt,
+		LastError:   job.LastError,
+	}, nil
+}
+
+func (r *QueueRepository) Ack(ctx context.Context, job *queue.Job) error {
+	id, err := primitive.ObjectIDFromHex(job.ID)
+	if err != nil {
+		return fmt.Errorf("invalid job ID %q", job.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to acknowledge job: %w", err)
+	}
+	return nil
+}
+
+func (r *QueueRepository) Retry(ctx context.Context, job *queue.Job, at time.Time, reason string) error {
+	id, err := primitive.ObjectIDFromHex(job.ID)
+	if err != nil {
+		return fmt.Errorf("invalid job ID %q", job.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"available_at": at, "leased_until": nil, "last_error": reason}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	return nil
+}
+
+func (r *QueueRepository) Depth(ctx context.Context, now time.Time) (queue.Depth, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	leased := bson.M{"queue": r.name, "leased_until": bson.M{"$gt": now}}
+	notLeased := bson.A{bson.M{"leased_until": nil}, bson.M{"leased_until": bson.M{"$lte": now}}}
+	waiting := bson.M{"queue": r.name, "available_at": bson.M{"$gt": now}, "$or": notLeased}
+	ready := bson.M{"queue": r.name, "available_at": bson.M{"$lte": now}, "$or": notLeased}
+
+	var depth queue.Depth
+	for _, count := range []struct {
+		filter bson.M
+		target *int64
+	}{{ready, &depth.Ready}, {leased, &depth.Leased}, {waiting, &depth.Waiting}} {
+		n, err := r.collection.CountDocuments(ctx, count.filter)
+		if err != nil {
+			return queue.Depth{}, fmt.Errorf("failed to count jobs: %w", err)
+		}
+		*count.target = n
+	}
+	return depth, nil
+}
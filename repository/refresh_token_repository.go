@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RefreshTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRefreshTokenRepository(db *database.MongoDB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		collection: db.Database.Collection("refresh_tokens"),
+	}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	token.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var token models.RefreshToken
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("refresh token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Revoke marks a still-active token as revoked and reports whether this call did it,
+// so concurrent rotations of the same token cannot both succeed.
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id primitive.ObjectID, replacedBy *primitive.ObjectID, revokedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"revoked_at": revokedAt}
+	if replacedBy != nil {
+		set["replaced_by"] = *replacedBy
+	}
+
+	query := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, query, bson.M{"$set": set})
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForSession(ctx context.Context, sessionID primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"session_id": sessionID, "revoked_at": bson.M{"$exists": false}}
+	if _, err := r.collection.UpdateMany(ctx, query, bson.M{"$set": bson.M{"revoked_at": revokedAt}}); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	if _, err := r.collection.UpdateMany(ctx, query, bson.M{"$set": bson.M{"revoked_at": revokedAt}}); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
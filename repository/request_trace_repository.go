@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RequestTraceRepository keeps traces of failed requests; they expire after
+// seven days through a TTL index on started_at.
+type RequestTraceRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRequestTraceRepository(db *database.MongoDB) *RequestTraceRepository {
+	return &RequestTraceRepository{
+		collection: db.Database.Collection("request_traces"),
+	}
+}
+
+func (r *RequestTraceRepository) Create(ctx context.Context, trace *models.RequestTrace) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, trace); err != nil {
+		return fmt.Errorf("failed to create request trace: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RequestTraceRepository) FindByID(ctx context.Context, id string) (*models.RequestTrace, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var trace models.RequestTrace
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&trace)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("request trace not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find request trace: %w", err)
+	}
+
+	return &trace, nil
+}
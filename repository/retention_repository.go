@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const retentionPolicyID = "retention"
+
+type RetentionRepository struct {
+	policies *mongo.Collection
+	reports  *mongo.Collection
+}
+
+type RetentionReportFilter struct {
+	Page  int
+	Limit int
+}
+
+func NewRetentionRepository(db *database.MongoDB) *RetentionRepository {
+	return &RetentionRepository{
+		policies: db.Database.Collection("retention_policies"),
+		reports:  db.Database.Collection("retention_reports"),
+	}
+}
+
+func (r *RetentionRepository) GetPolicy(ctx context.Context) (*models.RetentionPolicy, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var policy models.RetentionPolicy
+	err := r.policies.FindOne(ctx, bson.M{"_id": retentionPolicyID}).Decode(&policy)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("retention policy not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find retention policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (r *RetentionRepository) SavePolicy(ctx context.Context, policy *models.RetentionPolicy) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	policy.ID = retentionPolicyID
+	_, err := r.policies.ReplaceOne(ctx, bson.M{"_id": retentionPolicyID}, policy, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save retention policy: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RetentionRepository) CreateReport(ctx context.Context, report *models.RetentionReport) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.reports.InsertOne(ctx, report)
+	if err != nil {
+		return fmt.Errorf("failed to create retention report: %w", err)
+	}
+
+	report.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *RetentionRepository) FindReports(ctx context.Context, filter RetentionReportFilter) ([]*models.RetentionReport, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Count total documents
+	totalCount, err := r.reports.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count retention reports: %w", err)
+	}
+
+	// Set pagination defaults
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit)).
+		SetSort(bson.D{{Key: "ran_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.reports.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find retention reports: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.RetentionReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode retention reports: %w", err)
+	}
+
+	return reports, totalCount, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Collections wiped by a sandbox reset
+var sandboxCollections = []string{"users", "tasks", "comments", "task_history", "audit_logs", "refresh_tokens", "sessions", "api_keys", "login_attempts", "retention_policies", "retention_reports", "my_day_items", "focus_sessions", "user_achievements", "task_summaries", "webhooks", "webhook_deliveries", "field_policies", "audit_archives", "request_traces", "import_jobs", "usage_buckets", "subscriptions", "export_jobs", "export_chunks", "deprecation_usage", "queue_jobs", "scheduled_jobs", "dead_letter", "leases", "worker_state", "notification_channels", "push_subscriptions", "notification_preferences", "notification_templates"}
+
+type SandboxRepository struct {
+	database *mongo.Database
+}
+
+func NewSandboxRepository(db *database.MongoDB) *SandboxRepository {
+	return &SandboxRepository{
+		database: db.Database,
+	}
+}
+
+func (r *SandboxRepository) Reset(ctx context.Context, users []*models.User, tasks []*models.Task) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Delete documents rather than dropping collections so indexes survive
+	for _, name := range sandboxCollections {
+		if _, err := r.database.Collection(name).DeleteMany(ctx, bson.M{}); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", name, err)
+		}
+	}
+
+	userDocs := make([]interface{}, len(users))
+	for i, user := range users {
+		userDocs[i] = user
+	}
+	if _, err := r.database.Collection("users").InsertMany(ctx, userDocs); err != nil {
+		return fmt.Errorf("failed to seed users: %w", err)
+	}
+
+	taskDocs := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		taskDocs[i] = task
+	}
+	if _, err := r.database.Collection("tasks").InsertMany(ctx, taskDocs); err != nil {
+		return fmt.Errorf("failed to seed tasks: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ScheduledJobRepository keeps one record per scheduled job, named by its ID,
+// with its last run and when it runs next
+type ScheduledJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewScheduledJobRepository(db *database.MongoDB) *ScheduledJobRepository {
+	return &ScheduledJobRepository{
+		collection: db.Database.Collection("scheduled_jobs"),
+	}
+}
+
+// RecordStart marks the job as running, creating its record on its first run
+func (r *ScheduledJobRepository) RecordStart(ctx context.Context, name, schedule string, startedAt, nextRunAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"schedule":        schedule,
+			"last_status":     models.JobStatusRunning,
+			"last_started_at": startedAt,
+			"next_run_at":     nextRunAt,
+		},
+		"$unset": bson.M{"last_error": ""},
+		"$inc":   bson.M{"runs": 1},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": name}, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to record job start: %w", err)
+	}
+	return nil
+}
+
+// RecordFinish stores the outcome of the run; runErr is nil when it succeeded
+func (r *ScheduledJobRepository) RecordFinish(ctx context.Context, name string, finishedAt time.Time, duration time.Duration, runErr error) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"last_status":      models.JobStatusSucceeded,
+		"last_finished_at": finishedAt,
+		"last_duration_ms": duration.Milliseconds(),
+	}
+	update := bson.M{"$set": set}
+	if runErr != nil {
+		set["last_status"] = models.JobStatusFailed
+		set["last_error"] = runErr.Error()
+		update["$inc"] = bson.M{"failures": 1}
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": name}, update); err != nil {
+		return fmt.Errorf("failed to record job finish: %w", err)
+	}
+	return nil
+}
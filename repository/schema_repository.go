@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const schemaDocumentID = "schema"
+
+type SchemaRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSchemaRepository(db *database.MongoDB) *SchemaRepository {
+	return &SchemaRepository{
+		collection: db.Database.Collection("schema_meta"),
+	}
+}
+
+func (r *SchemaRepository) Get(ctx context.Context) (*models.SchemaRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var record models.SchemaRecord
+	err := r.collection.FindOne(ctx, bson.M{"_id": schemaDocumentID}).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("schema version not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Upgrade records version only if the stored version is lower, so an older
+// instance starting during a rolling deploy can never downgrade the record.
+func (r *SchemaRepository) Upgrade(ctx context.Context, version int, updatedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"_id": schemaDocumentID,
+		"$or": bson.A{
+			bson.M{"version": bson.M{"$lt": version}},
+			bson.M{"version": bson.M{"$exists": false}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"version": version, "updated_at": updatedAt}}
+
+	_, err := r.collection.UpdateOne(ctx, query, update, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(db *database.MongoDB) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Database.Collection("sessions"),
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *SessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var session models.Session
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("session not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// FindActiveByUserID returns the user's unrevoked, unexpired sessions started
+// after startedAfter, most recently used first.
+func (r *SessionRepository) FindActiveByUserID(ctx context.Context, userID primitive.ObjectID, startedAfter *time.Time, now time.Time) ([]*models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	if startedAfter != nil {
+		query["created_at"] = bson.M{"$gt": *startedAfter}
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []*models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Touch records a refresh: where it came from and the new expiry
+func (r *SessionRepository) Touch(ctx context.Context, id primitive.ObjectID, client models.ClientInfo, expiresAt, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"ip":           client.IP,
+		"user_agent":   client.UserAgent,
+		"last_used_at": usedAt,
+		"expires_at":   expiresAt,
+	}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke ends one of the user's active sessions.
+func (r *SessionRepository) Revoke(ctx context.Context, id, userID primitive.ObjectID, revokedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, query, bson.M{"$set": bson.M{"revoked_at": revokedAt}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Documents read and inserted per round trip while copying to staging
+const stagingBatchSize = 500
+
+// StagingRepository copies collections from the production database into a
+// staging database.
+type StagingRepository struct {
+	source *mongo.Database
+	target *mongo.Database
+}
+
+// NewStagingRepository reads the source with the list read preference, so a
+// secondary can carry the copy instead of the primary.
+func NewStagingRepository(source, target *database.MongoDB) *StagingRepository {
+	return &StagingRepository{
+		source: source.ListDatabase,
+		target: target.Database,
+	}
+}
+
+// CopyCollection replaces the documents of the staging collection with those
+// of the source collection, passing each through transform when it is set.
+// Documents are deleted rather than the collection dropped so staging indexes
+// survive.
+func (r *StagingRepository) CopyCollection(ctx context.Context, name string, transform func(doc bson.M) error) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	target := r.target.Collection(name)
+	if _, err := target.DeleteMany(ctx, bson.M{}); err != nil {
+		return 0, fmt.Errorf("failed to clear staging %s: %w", name, err)
+	}
+
+	cursor, err := r.source.Collection(name).Find(ctx, bson.M{}, options.Find().SetBatchSize(stagingBatchSize))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer cursor.Close(ctx)
+
+	var copied int64
+	batch := make([]interface{}, 0, stagingBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := target.InsertMany(ctx, batch); err != nil {
+			return fmt.Errorf("failed to write staging %s: %w", name, err)
+		}
+		copied += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return copied, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		if transform != nil {
+			if err := transform(doc); err != nil {
+				return copied, fmt.Errorf("failed to anonymize %s %v: %w", name, doc["_id"], err)
+			}
+		}
+		batch = append(batch, doc)
+		if len(batch) == stagingBatchSize {
+			if err := flush(); err != nil {
+				return copied, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return copied, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := flush(); err != nil {
+		return copied, err
+	}
+
+	return copied, nil
+}
+
+// ClearCollection deletes every document of the staging collection
+func (r *StagingRepository) ClearCollection(ctx context.Context, name string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	result, err := r.target.Collection(name).DeleteMany(ctx, bson.M{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear staging %s: %w", name, err)
+	}
+
+	return result.DeletedCount, nil
+}
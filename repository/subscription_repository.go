@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SubscriptionRepository keeps one subscription per account, unique on user_id
+type SubscriptionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSubscriptionRepository(db *database.MongoDB) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		collection: db.Database.Collection("subscriptions"),
+	}
+}
+
+func (r *SubscriptionRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var subscription models.Subscription
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&subscription)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("subscription not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+func (r *SubscriptionRepository) FindByCustomerID(ctx context.Context, provider, customerID string) (*models.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var subscription models.Subscription
+	err := r.collection.FindOne(ctx, bson.M{"provider": provider, "customer_id": customerID}).Decode(&subscription)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("subscription not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+// Save replaces the account's subscription unless the stored one came from a
+// newer event. It reports whether the subscription was saved.
+func (r *SubscriptionRepository) Save(ctx context.Context, subscription *models.Subscription) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": subscription.UserID, "event_at": bson.M{"$lte": subscription.EventAt}}
+	update := bson.M{"$set": bson.M{
+		"provider":           subscription.Provider,
+		"customer_id":        subscription.CustomerID,
+		"subscription_id":    subscription.SubscriptionID,
+		"plan":               subscription.Plan,
+		"status":             subscription.Status,
+		"current_period_end": subscription.CurrentPeriodEnd,
+		"event_at":           subscription.EventAt,
+		"updated_at":         subscription.UpdatedAt,
+	}}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	// A newer stored event fails the filter, and the upsert then collides
+	// with it on the unique user_id index
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save subscription: %w", err)
+	}
+
+	return true, nil
+}
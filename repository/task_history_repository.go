@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TaskHistoryRepository struct {
+	collection     *mongo.Collection
+	listCollection *mongo.Collection
+}
+
+type HistoryFilter struct {
+	Page  int
+	Limit int
+}
+
+func NewTaskHistoryRepository(db *database.MongoDB) *TaskHistoryRepository {
+	return &TaskHistoryRepository{
+		collection:     db.Database.Collection("task_history"),
+		listCollection: db.ListDatabase.Collection("task_history"),
+	}
+}
+
+func (r *TaskHistoryRepository) CreateMany(ctx context.Context, entries []*models.TaskHistory) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docs := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		docs[i] = entry
+	}
+
+	result, err := r.collection.InsertMany(ctx, docs)
+	if err != nil {
+		return fmt.Errorf("failed to create task history: %w", err)
+	}
+
+	for i, id := range result.InsertedIDs {
+		entries[i].ID = id.(primitive.ObjectID)
+	}
+	return nil
+}
+
+func (r *TaskHistoryRepository) FindByTaskID(ctx context.Context, taskID primitive.ObjectID, filter HistoryFilter) ([]*models.TaskHistory, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"task_id": taskID}
+
+	// Count total documents
+	totalCount, err := r.listCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count task history: %w", err)
+	}
+
+	// Set pagination defaults
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.listCollection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find task history: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.TaskHistory
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode task history: %w", err)
+	}
+
+	return entries, totalCount, nil
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"task-management-api/clock"
 	"task-management-api/database"
 	"task-management-api/models"
 	"time"
@@ -15,19 +17,41 @@ import (
 )
 
 type TaskRepository struct {
-	collection *mongo.Collection
-	mu         sync.RWMutex
+	collection     *mongo.Collection
+	listCollection *mongo.Collection
+	clock          clock.Clock
+	mu             sync.RWMutex
 }
 
 type TaskFilter struct {
-	Status *models.TaskStatus
-	Page   int
-	Limit  int
+	Status          *models.TaskStatus
+	IncludeArchived bool
+	Page            int
+	Limit           int
+	// Fields limits the fields read to these of models.TaskFields; all are read when empty
+	Fields []string
 }
 
-func NewTaskRepository(db *database.MongoDB) *TaskRepository {
+type TaskBulkFilter struct {
+	UserID         *primitive.ObjectID
+	IDs            []primitive.ObjectID
+	Status         *models.TaskStatus
+	ExcludeUserIDs []primitive.ObjectID
+}
+
+// TaskReportFilter selects a user's tasks for a report; From and To bound
+// created_at, From inclusive and To exclusive
+type TaskReportFilter struct {
+	UserID primitive.ObjectID
+	From   *time.Time
+	To     *time.Time
+}
+
+func NewTaskRepository(db *database.MongoDB, clk clock.Clock) *TaskRepository {
 	return &TaskRepository{
-		collection: db.Database.Collection("tasks"),
+		collection:     db.Database.Collection("tasks"),
+		listCollection: db.ListDatabase.Collection("tasks"),
+		clock:          clk,
 	}
 }
 
@@ -47,6 +71,34 @@ func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
 	return nil
 }
 
+// CreateMany inserts tasks in order. When it fails, some of the tasks may have been inserted.
+func (r *TaskRepository) CreateMany(ctx context.Context, tasks []*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	docs := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		docs[i] = task
+	}
+
+	result, err := r.collection.InsertMany(ctx, docs)
+	if err != nil {
+		return fmt.Errorf("failed to create tasks: %w", err)
+	}
+
+	for i, id := range result.InsertedIDs {
+		tasks[i].ID = id.(primitive.ObjectID)
+	}
+	return nil
+}
+
 func (r *TaskRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -66,6 +118,27 @@ func (r *TaskRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 	return &task, nil
 }
 
+func (r *TaskRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
 func (r *TaskRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID, filter TaskFilter) ([]*models.Task, int64, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -79,8 +152,51 @@ func (r *TaskRepository) FindByUserID(ctx context.Context, userID primitive.Obje
 		query["status"] = *filter.Status
 	}
 
+	return r.findPage(ctx, query, filter)
+}
+
+func (r *TaskRepository) FindAll(ctx context.Context, filter TaskFilter) ([]*models.Task, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Private tasks are left out of listings across users, even for operators
+	query := bson.M{"private": bson.M{"$ne": true}}
+	if filter.Status != nil {
+		query["status"] = *filter.Status
+	}
+
+	return r.findPage(ctx, query, filter)
+}
+
+func (r *TaskRepository) FindByParentID(ctx context.Context, parentID primitive.ObjectID, filter TaskFilter) ([]*models.Task, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Build query
+	query := bson.M{"parent_id": parentID}
+	if filter.Status != nil {
+		query["status"] = *filter.Status
+	}
+
+	return r.findPage(ctx, query, filter)
+}
+
+// findPage runs a paginated, newest-first query; callers hold the lock and own the context timeout.
+// It serves the list endpoints, so it reads with the list read preference.
+func (r *TaskRepository) findPage(ctx context.Context, query bson.M, filter TaskFilter) ([]*models.Task, int64, error) {
+	// Tasks created before archiving existed have no archived field
+	if !filter.IncludeArchived {
+		query["archived"] = bson.M{"$ne": true}
+	}
+
 	// Count total documents
-	totalCount, err := r.collection.CountDocuments(ctx, query)
+	totalCount, err := r.listCollection.CountDocuments(ctx, query)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count tasks: %w", err)
 	}
@@ -102,7 +218,11 @@ func (r *TaskRepository) FindByUserID(ctx context.Context, userID primitive.Obje
 		SetLimit(int64(filter.Limit)).
 		SetSort(bson.D{{Key: "created_at", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if len(filter.Fields) > 0 {
+		findOptions.SetProjection(taskProjection(filter.Fields))
+	}
+
+	cursor, err := r.listCollection.Find(ctx, query, findOptions)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to find tasks: %w", err)
 	}
@@ -116,54 +236,162 @@ func (r *TaskRepository) FindByUserID(ctx context.Context, userID primitive.Obje
 	return tasks, totalCount, nil
 }
 
-func (r *TaskRepository) FindAll(ctx context.Context, filter TaskFilter) ([]*models.Task, int64, error) {
+// taskProjection reads the selected fields, plus what decrypting a private
+// task's title and description needs
+func taskProjection(fields []string) bson.M {
+	projection := bson.M{"_id": 1}
+	for _, field := range fields {
+		switch field {
+		case "id":
+		case "title", "description":
+			projection[field] = 1
+			projection["user_id"] = 1
+			projection["private"] = 1
+			projection["sealed"] = 1
+		default:
+			projection[field] = 1
+		}
+	}
+	return projection
+}
+
+func (r *TaskRepository) FindSubtaskIDs(ctx context.Context, parentIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// Build query
-	query := bson.M{}
-	if filter.Status != nil {
-		query["status"] = *filter.Status
+	query := bson.M{"parent_id": bson.M{"$in": parentIDs}}
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subtasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode subtask: %w", err)
+		}
+		ids = append(ids, doc.ID)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate subtasks: %w", err)
 	}
 
-	// Count total documents
-	totalCount, err := r.collection.CountDocuments(ctx, query)
+	return ids, nil
+}
+
+func (r *TaskRepository) CountOpenSubtasks(ctx context.Context, parentID primitive.ObjectID) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"parent_id": parentID,
+		"status":    bson.M{"$ne": models.TaskStatusCompleted},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, query)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count tasks: %w", err)
+		return 0, fmt.Errorf("failed to count subtasks: %w", err)
 	}
 
-	// Set pagination defaults
-	if filter.Page < 1 {
-		filter.Page = 1
+	return count, nil
+}
+
+func (r *TaskRepository) OrphanSubtasks(ctx context.Context, parentIDs ...primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$unset": bson.M{"parent_id": ""},
+		"$set":   bson.M{"updated_at": r.clock.Now()},
+		"$inc":   bson.M{"version": 1},
 	}
-	if filter.Limit < 1 {
-		filter.Limit = 10
+
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"parent_id": bson.M{"$in": parentIDs}}, update); err != nil {
+		return fmt.Errorf("failed to orphan subtasks: %w", err)
 	}
 
-	// Calculate skip
-	skip := (filter.Page - 1) * filter.Limit
+	return nil
+}
 
-	// Find options with pagination and sorting
-	findOptions := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(filter.Limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
+func (r *TaskRepository) Update(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	task.UpdatedAt = r.clock.Now()
+	set := bson.M{
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      task.Status,
+		"priority":    task.Priority,
+		"blocked_by":  task.BlockedBy,
+		"due_date":    task.DueDate,
+		"recurrence":  task.Recurrence,
+		"private":     task.Private,
+		"updated_at":  task.UpdatedAt,
+	}
+	unset := bson.M{}
+	if task.Private {
+		set["sealed"] = task.Sealed
+	} else {
+		unset["sealed"] = ""
+	}
+	if task.RemindedAt == nil {
+		unset["reminded_at"] = ""
+	}
+
+	// Touching a task pending purge cancels the purge
+	if task.PurgeAt != nil {
+		task.PurgeAt = nil
+		unset["purge_at"] = ""
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 
-	cursor, err := r.collection.Find(ctx, query, findOptions)
+	// Only the version that was read is updated, so concurrent writes cannot overwrite each other
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": task.ID, "version": versionQuery(task.Version)}, update)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to find tasks: %w", err)
+		return fmt.Errorf("failed to update task: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var tasks []*models.Task
-	if err := cursor.All(ctx, &tasks); err != nil {
-		return nil, 0, fmt.Errorf("failed to decode tasks: %w", err)
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": task.ID})
+		if err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("task not found")
+		}
+		return fmt.Errorf("task has been modified")
 	}
 
-	return tasks, totalCount, nil
+	task.Version++
+	return nil
+}
+
+// versionQuery matches a task version. Version 0 is never stored, see models.Task.
+func versionQuery(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$exists": false}
+	}
+	return version
 }
 
 func (r *TaskRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
@@ -173,7 +401,7 @@ func (r *TaskRepository) Delete(ctx context.Context, id primitive.ObjectID) erro
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "legal_hold": bson.M{"$ne": true}})
 	if err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
@@ -185,7 +413,183 @@ func (r *TaskRepository) Delete(ctx context.Context, id primitive.ObjectID) erro
 	return nil
 }
 
-func (r *TaskRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status models.TaskStatus) error {
+// bulkQuery matches the tasks a bulk delete with filter removes; tasks under
+// legal hold never match
+func bulkQuery(filter TaskBulkFilter) (bson.M, error) {
+	// Never turn an empty filter into a delete of the whole collection
+	if filter.UserID == nil && len(filter.IDs) == 0 && filter.Status == nil {
+		return nil, fmt.Errorf("bulk delete requires a filter")
+	}
+
+	query := bson.M{"legal_hold": bson.M{"$ne": true}}
+	userQuery := bson.M{}
+	if filter.UserID != nil {
+		userQuery["$eq"] = *filter.UserID
+	}
+	if len(filter.ExcludeUserIDs) > 0 {
+		userQuery["$nin"] = filter.ExcludeUserIDs
+	}
+	if len(userQuery) > 0 {
+		query["user_id"] = userQuery
+	}
+	if len(filter.IDs) > 0 {
+		query["_id"] = bson.M{"$in": filter.IDs}
+	}
+	if filter.Status != nil {
+		query["status"] = *filter.Status
+	}
+	return query, nil
+}
+
+// FindBulkIDs returns the IDs of up to limit tasks a bulk delete with filter
+// would remove, oldest first
+func (r *TaskRepository) FindBulkIDs(ctx context.Context, filter TaskBulkFilter, limit int64) ([]primitive.ObjectID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query, err := bulkQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode task: %w", err)
+		}
+		ids = append(ids, doc.ID)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate tasks: %w", err)
+	}
+
+	return ids, nil
+}
+
+// DeleteMany deletes the tasks matching filter and returns them as they were
+// just before deletion, so callers can report each one
+func (r *TaskRepository) DeleteMany(ctx context.Context, filter TaskBulkFilter) ([]*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query, err := bulkQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Find(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	// Delete exactly what was read; tasks that start matching meanwhile are left alone
+	ids := make([]primitive.ObjectID, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	query["_id"] = bson.M{"$in": ids}
+	if _, err := r.collection.DeleteMany(ctx, query); err != nil {
+		return nil, fmt.Errorf("failed to delete tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// FindOpenIDsByUserID returns up to limit IDs of a user's pending or in-progress tasks, oldest first.
+func (r *TaskRepository) FindOpenIDsByUserID(ctx context.Context, userID primitive.ObjectID, limit int64) ([]primitive.ObjectID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id": userID,
+		"status": bson.M{
+			"$in": []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress},
+		},
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find open tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+
+	return ids, nil
+}
+
+// Reassign moves the given tasks from one owner to another in a single update.
+// Tasks that changed owner or were completed in the meantime are left alone.
+func (r *TaskRepository) Reassign(ctx context.Context, ids []primitive.ObjectID, from, to primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"_id":     bson.M{"$in": ids},
+		"user_id": from,
+		"status":  bson.M{"$ne": models.TaskStatusCompleted},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":    to,
+			"updated_at": r.clock.Now(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, query, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reassign tasks: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *TaskRepository) SetArchived(ctx context.Context, id primitive.ObjectID, archived bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -194,14 +598,15 @@ func (r *TaskRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID
 
 	update := bson.M{
 		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
+			"archived":   archived,
+			"updated_at": r.clock.Now(),
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
 	if err != nil {
-		return fmt.Errorf("failed to update task status: %w", err)
+		return fmt.Errorf("failed to update task: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
@@ -211,27 +616,661 @@ func (r *TaskRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID
 	return nil
 }
 
-func (r *TaskRepository) FindPendingTasks(ctx context.Context, olderThan time.Time) ([]*models.Task, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// UpdateStatus sets the status of the task as it was read. It fails with
+// "task has been modified" when the task changed since, leaving it as it is.
+func (r *TaskRepository) UpdateStatus(ctx context.Context, task *models.Task, status models.TaskStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	query := bson.M{
-		"status": bson.M{
-			"$in": []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress},
+	now := r.clock.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": now,
 		},
-		"created_at": bson.M{"$lt": olderThan},
+		"$inc": bson.M{"version": 1},
 	}
 
-	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": task.ID, "status": task.Status, "version": versionQuery(task.Version)}, update)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find pending tasks: %w", err)
+		return fmt.Errorf("failed to update task status: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var tasks []*models.Task
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": task.ID})
+		if err != nil {
+			return fmt.Errorf("failed to update task status: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("task not found")
+		}
+		return fmt.Errorf("task has been modified")
+	}
+
+	task.Status = status
+	task.UpdatedAt = now
+	task.Version++
+	return nil
+}
+
+// SetNextOccurrence links a recurring task to its successor, only if none was linked yet.
+func (r *TaskRepository) SetNextOccurrence(ctx context.Context, id, nextID primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"_id":                id,
+		"next_occurrence_id": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_occurrence_id": nextID},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, query, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to link next occurrence: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// MarkPurge schedules completed tasks untouched since cutoff for deletion at purgeAt,
+// skipping tasks under legal hold and tasks of held users.
+func (r *TaskRepository) MarkPurge(ctx context.Context, cutoff, purgeAt time.Time, heldUserIDs []primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"status":     models.TaskStatusCompleted,
+		"updated_at": bson.M{"$lt": cutoff},
+		"purge_at":   bson.M{"$exists": false},
+		"legal_hold": bson.M{"$ne": true},
+		"user_id":    bson.M{"$nin": heldUserIDs},
+	}
+
+	update := bson.M{
+		"$set": bson.M{"purge_at": purgeAt},
+		"$inc": bson.M{"version": 1},
+	}
+	result, err := r.collection.UpdateMany(ctx, query, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark tasks for purge: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// PurgeDue deletes tasks whose grace period has ended, detaching their subtasks first.
+func (r *TaskRepository) PurgeDue(ctx context.Context, now time.Time, heldUserIDs []primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"status":     models.TaskStatusCompleted,
+		"purge_at":   bson.M{"$lte": now},
+		"legal_hold": bson.M{"$ne": true},
+		"user_id":    bson.M{"$nin": heldUserIDs},
+	}
+
+	ids, err := r.collection.Distinct(ctx, "_id", query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find tasks due for purge: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	orphan := bson.M{
+		"$unset": bson.M{"parent_id": ""},
+		"$set":   bson.M{"updated_at": r.clock.Now()},
+		"$inc":   bson.M{"version": 1},
+	}
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"parent_id": bson.M{"$in": ids}}, orphan); err != nil {
+		return 0, fmt.Errorf("failed to orphan subtasks: %w", err)
+	}
+
+	query["_id"] = bson.M{"$in": ids}
+	result, err := r.collection.DeleteMany(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// SetLegalHold places or releases a hold; placing one also cancels a pending purge.
+func (r *TaskRepository) SetLegalHold(ctx context.Context, id primitive.ObjectID, hold bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$unset": bson.M{"legal_hold": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	if hold {
+		update = bson.M{
+			"$set":   bson.M{"legal_hold": true},
+			"$unset": bson.M{"purge_at": ""},
+			"$inc":   bson.M{"version": 1},
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update legal hold: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("task not found")
+	}
+
+	return nil
+}
+
+func (r *TaskRepository) CancelPurgeByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"user_id": userID, "purge_at": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"purge_at": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	if _, err := r.collection.UpdateMany(ctx, query, update); err != nil {
+		return fmt.Errorf("failed to cancel task purges: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TaskRepository) FindLegalHoldIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return distinctIDs(ctx, r.collection, bson.M{"legal_hold": true})
+}
+
+// CountByUserID counts all of the user's tasks, archived ones included
+func (r *TaskRepository) CountByUserID(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *TaskRepository) CountPendingPurge(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"purge_at": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tasks pending purge: %w", err)
+	}
+
+	return count, nil
+}
+
+// FindPendingTasks returns up to limit open, unarchived tasks created before
+// olderThan, oldest first. The tasks of users in exceptions are due when created
+// before their own cutoff instead, or never when it is nil.
+func (r *TaskRepository) FindPendingTasks(ctx context.Context, olderThan time.Time, exceptions map[primitive.ObjectID]*time.Time, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// One branch for everyone else and one per distinct cutoff
+	except := make([]primitive.ObjectID, 0, len(exceptions))
+	byCutoff := make(map[time.Time][]primitive.ObjectID)
+	for userID, cutoff := range exceptions {
+		except = append(except, userID)
+		if cutoff != nil {
+			byCutoff[*cutoff] = append(byCutoff[*cutoff], userID)
+		}
+	}
+	branches := bson.A{bson.M{"user_id": bson.M{"$nin": except}, "created_at": bson.M{"$lt": olderThan}}}
+	for cutoff, userIDs := range byCutoff {
+		branches = append(branches, bson.M{"user_id": bson.M{"$in": userIDs}, "created_at": bson.M{"$lt": cutoff}})
+	}
+
+	query := bson.M{
+		"status": bson.M{
+			"$in": []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress},
+		},
+		"archived": bson.M{"$ne": true},
+		"$or":      branches,
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// FindDueBefore returns up to limit open, unarchived tasks due before the
+// given time, grouped by owner and soonest due first.
+func (r *TaskRepository) FindDueBefore(ctx context.Context, before time.Time, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"status": bson.M{
+			"$in": []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress},
+		},
+		"due_date": bson.M{"$lt": before},
+		"archived": bson.M{"$ne": true},
+	}
+	sort := bson.D{{Key: "user_id", Value: 1}, {Key: "due_date", Value: 1}}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(sort).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// FindOpenCreatedSince returns up to limit open, unarchived tasks created
+// at or after since, oldest first.
+func (r *TaskRepository) FindOpenCreatedSince(ctx context.Context, since time.Time, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"status": bson.M{
+			"$in": []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress},
+		},
+		"created_at": bson.M{"$gte": since},
+		"archived":   bson.M{"$ne": true},
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find open tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// ErrReplicaSetRequired is returned by WatchTasks when MongoDB runs without a
+// replica set, where change streams are not available
+var ErrReplicaSetRequired = errors.New("change streams require a MongoDB replica set")
+
+// TaskChangeStream follows the tasks that are created and the tasks whose
+// status, archived flag or owner changes
+type TaskChangeStream struct {
+	stream *mongo.ChangeStream
+}
+
+// WatchTasks opens a change stream on the tasks, resuming after resumeAfter
+// or, without a token, starting at startAt. Change streams need a replica set.
+func (r *TaskRepository) WatchTasks(ctx context.Context, resumeAfter bson.Raw, startAt time.Time) (*TaskChangeStream, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"operationType": bson.M{"$in": bson.A{"insert", "replace"}}},
+			bson.M{"operationType": "update", "updateDescription.updatedFields.status": bson.M{"$exists": true}},
+			bson.M{"operationType": "update", "updateDescription.updatedFields.archived": bson.M{"$exists": true}},
+			bson.M{"operationType": "update", "updateDescription.updatedFields.user_id": bson.M{"$exists": true}},
+		}}}},
+		// Tasks can be large; only what decides auto-completion is needed
+		{{Key: "$project", Value: bson.M{
+			"fullDocument._id":        1,
+			"fullDocument.user_id":    1,
+			"fullDocument.status":     1,
+			"fullDocument.archived":   1,
+			"fullDocument.created_at": 1,
+		}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeAfter != nil {
+		opts.SetResumeAfter(resumeAfter)
+	} else {
+		opts.SetStartAtOperationTime(&primitive.Timestamp{T: uint32(startAt.Unix())})
+	}
+
+	stream, err := r.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == 40573 {
+			return nil, ErrReplicaSetRequired
+		}
+		return nil, fmt.Errorf("failed to watch tasks: %w", err)
+	}
+
+	return &TaskChangeStream{stream: stream}, nil
+}
+
+// Next waits for the next changed task. Tasks deleted before their change
+// was read are skipped.
+func (s *TaskChangeStream) Next(ctx context.Context) (*models.Task, error) {
+	for s.stream.Next(ctx) {
+		var event struct {
+			FullDocument *models.Task `bson:"fullDocument"`
+		}
+		if err := s.stream.Decode(&event); err != nil {
+			return nil, fmt.Errorf("failed to decode task change: %w", err)
+		}
+		if event.FullDocument != nil {
+			return event.FullDocument, nil
+		}
+	}
+	if err := s.stream.Err(); err != nil {
+		return nil, fmt.Errorf("task change stream failed: %w", err)
+	}
+	return nil, fmt.Errorf("task change stream closed")
+}
+
+// ResumeToken marks how far the stream was read, for WatchTasks to resume after
+func (s *TaskChangeStream) ResumeToken() bson.Raw {
+	return s.stream.ResumeToken()
+}
+
+func (s *TaskChangeStream) Close(ctx context.Context) error {
+	return s.stream.Close(ctx)
+}
+
+// FindUnreminded returns up to limit open, unarchived tasks due in
+// [from, to) whose owners were not reminded yet, soonest due first.
+func (r *TaskRepository) FindUnreminded(ctx context.Context, from, to time.Time, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"status": bson.M{
+			"$in": []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress},
+		},
+		"due_date":    bson.M{"$gte": from, "$lt": to},
+		"archived":    bson.M{"$ne": true},
+		"reminded_at": bson.M{"$exists": false},
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks to remind: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// MarkReminded records the reminder of the task's due date. It reports false
+// when the task was already reminded, so each due date is reminded once.
+func (r *TaskRepository) MarkReminded(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "reminded_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"reminded_at": at}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark task reminded: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// FindExportByUserID returns up to limit of the user's unarchived tasks, oldest first.
+func (r *TaskRepository) FindExportByUserID(ctx context.Context, userID primitive.ObjectID, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":  userID,
+		"archived": bson.M{"$ne": true},
+	}
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	return r.findList(ctx, query, findOptions)
+}
+
+// FindExportBatch returns the next limit of all the user's tasks, archived
+// ones included, in ID order after after. Pass nil to start.
+func (r *TaskRepository) FindExportBatch(ctx context.Context, userID primitive.ObjectID, after *primitive.ObjectID, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"user_id": userID}
+	if after != nil {
+		query["_id"] = bson.M{"$gt": *after}
+	}
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	return r.findList(ctx, query, findOptions)
+}
+
+// FindForReport returns up to limit of the user's unarchived tasks in the
+// filter's range, oldest first
+func (r *TaskRepository) FindForReport(ctx context.Context, filter TaskReportFilter, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":  filter.UserID,
+		"archived": bson.M{"$ne": true},
+	}
+	if filter.From != nil || filter.To != nil {
+		created := bson.M{}
+		if filter.From != nil {
+			created["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			created["$lt"] = *filter.To
+		}
+		query["created_at"] = created
+	}
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	return r.findList(ctx, query, findOptions)
+}
+
+// FindDueBetween returns up to limit of the user's unarchived tasks due in [from, to), earliest first.
+func (r *TaskRepository) FindDueBetween(ctx context.Context, userID primitive.ObjectID, from, to time.Time, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"user_id":  userID,
+		"due_date": bson.M{"$gte": from, "$lt": to},
+		"archived": bson.M{"$ne": true},
+	}
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "due_date", Value: 1}, {Key: "created_at", Value: 1}})
+
+	return r.findList(ctx, query, findOptions)
+}
+
+// FindUnscheduled returns up to limit of the user's open, unarchived tasks without a due date, oldest first.
+func (r *TaskRepository) FindUnscheduled(ctx context.Context, userID primitive.ObjectID, limit int64) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Tasks without a due date have no due_date field at all
+	query := bson.M{
+		"user_id":  userID,
+		"due_date": bson.M{"$exists": false},
+		"status": bson.M{
+			"$in": []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress},
+		},
+		"archived": bson.M{"$ne": true},
+	}
+	findOptions := options.Find().
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	return r.findList(ctx, query, findOptions)
+}
+
+// SummarizeByUser counts tasks per owner from the primary, so a summary
+// computed right after a write includes it. A nil userID summarizes every
+// owner; unassigned tasks are left out.
+func (r *TaskRepository) SummarizeByUser(ctx context.Context, userID *primitive.ObjectID, now time.Time) ([]*models.TaskSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	timeout := 10 * time.Second
+	match := bson.M{"user_id": bson.M{"$ne": primitive.NilObjectID}}
+	if userID != nil {
+		match = bson.M{"user_id": *userID}
+	} else {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"user_id": "$user_id", "status": "$status", "archived": bson.M{"$eq": bson.A{"$archived", true}}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		ID struct {
+			UserID   primitive.ObjectID `bson:"user_id"`
+			Status   models.TaskStatus  `bson:"status"`
+			Archived bool               `bson:"archived"`
+		} `bson:"_id"`
+		Count models.Int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode task summaries: %w", err)
+	}
+
+	byUser := make(map[primitive.ObjectID]*models.TaskSummary)
+	summaries := []*models.TaskSummary{}
+	for _, group := range groups {
+		summary, ok := byUser[group.ID.UserID]
+		if !ok {
+			summary = &models.TaskSummary{UserID: group.ID.UserID, UpdatedAt: now}
+			byUser[group.ID.UserID] = summary
+			summaries = append(summaries, summary)
+		}
+
+		summary.Total += group.Count
+		switch {
+		case group.ID.Archived:
+			summary.Archived += group.Count
+		case group.ID.Status == models.TaskStatusPending:
+			summary.Pending += group.Count
+		case group.ID.Status == models.TaskStatusInProgress:
+			summary.InProgress += group.Count
+		case group.ID.Status == models.TaskStatusCompleted:
+			summary.Completed += group.Count
+		}
+	}
+
+	return summaries, nil
+}
+
+// findList runs an unpaginated list query; callers hold the lock and own the context timeout.
+func (r *TaskRepository) findList(ctx context.Context, query bson.M, findOptions *options.FindOptions) ([]*models.Task, error) {
+	cursor, err := r.listCollection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []*models.Task{}
 	if err := cursor.All(ctx, &tasks); err != nil {
 		return nil, fmt.Errorf("failed to decode tasks: %w", err)
 	}
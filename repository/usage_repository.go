@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UsageRepository keeps API usage rolled up into one bucket per user, API
+// key, route and hour. Buckets are only ever incremented, so every instance
+// can add to them; they expire after 400 days through a TTL index on hour.
+type UsageRepository struct {
+	collection *mongo.Collection
+}
+
+// UsageIncrement adds to one usage bucket
+type UsageIncrement struct {
+	UserID   primitive.ObjectID
+	APIKeyID *primitive.ObjectID
+	Route    string
+	Hour     time.Time
+	Counts   models.UsageCounts
+}
+
+func NewUsageRepository(db *database.MongoDB) *UsageRepository {
+	return &UsageRepository{
+		collection: db.Database.Collection("usage_buckets"),
+	}
+}
+
+// Increment adds the counts to their buckets, creating buckets as needed
+func (r *UsageRepository) Increment(ctx context.Context, increments []UsageIncrement) error {
+	if len(increments) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, len(increments))
+	for i, inc := range increments {
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": inc.UserID, "api_key_id": inc.APIKeyID, "route": inc.Route, "hour": inc.Hour}).
+			SetUpdate(bson.M{"$inc": bson.M{
+				"requests":      inc.Counts.Requests,
+				"client_errors": inc.Counts.ClientErrors,
+				"server_errors": inc.Counts.ServerErrors,
+			}}).
+			SetUpsert(true)
+	}
+	if _, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+
+	return nil
+}
+
+// Summarize sums the user's buckets with hours in [from, to): in total, by
+// route (the top routes only), by API key and by UTC day
+func (r *UsageRepository) Summarize(ctx context.Context, userID primitive.ObjectID, from, to time.Time, topRoutes int) (*models.UsageReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sums := bson.M{
+		"requests":      bson.M{"$sum": "$requests"},
+		"client_errors": bson.M{"$sum": "$client_errors"},
+		"server_errors": bson.M{"$sum": "$server_errors"},
+	}
+	group := func(id interface{}) bson.M {
+		stage := bson.M{"_id": id}
+		for field, sum := range sums {
+			stage[field] = sum
+		}
+		return bson.M{"$group": stage}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "hour": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$facet", Value: bson.M{
+			"total":     bson.A{group(nil)},
+			"endpoints": bson.A{group("$route"), bson.M{"$sort": bson.D{{Key: "requests", Value: -1}, {Key: "_id", Value: 1}}}, bson.M{"$limit": topRoutes}},
+			"api_keys":  bson.A{group("$api_key_id"), bson.M{"$sort": bson.D{{Key: "requests", Value: -1}}}},
+			"days": bson.A{
+				group(bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$hour"}}),
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize usage: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Total     []models.UsageCounts    `bson:"total"`
+		Endpoints []*models.UsageEndpoint `bson:"endpoints"`
+		APIKeys   []*models.UsageAPIKey   `bson:"api_keys"`
+		Days      []*models.UsageDay      `bson:"days"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, fmt.Errorf("failed to decode usage: %w", err)
+	}
+
+	report := &models.UsageReport{
+		UserID:       userID,
+		TopEndpoints: []*models.UsageEndpoint{},
+		APIKeys:      []*models.UsageAPIKey{},
+		Days:         []*models.UsageDay{},
+	}
+	if len(facets) == 1 {
+		if len(facets[0].Total) == 1 {
+			report.UsageCounts = facets[0].Total[0]
+		}
+		report.TopEndpoints = append(report.TopEndpoints, facets[0].Endpoints...)
+		report.APIKeys = append(report.APIKeys, facets[0].APIKeys...)
+		report.Days = append(report.Days, facets[0].Days...)
+	}
+
+	return report, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"task-management-api/database"
 	"task-management-api/models"
@@ -10,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -69,3 +71,290 @@ func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 
 	return &user, nil
 }
+
+func (r *UserRepository) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}},
+	}
+
+	var user models.User
+	err := r.collection.FindOne(ctx, query).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.Identity) error {
+	return r.updateByID(ctx, id, bson.M{
+		"$push": bson.M{"identities": identity},
+	})
+}
+
+func (r *UserRepository) FindByPasswordResetTokenHash(ctx context.Context, tokenHash string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"password_reset_token_hash": tokenHash}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) FindByEmailVerificationTokenHash(ctx context.Context, tokenHash string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"email_verification_token_hash": tokenHash}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// UpdateProfile saves username and email. A non-empty verification token hash
+// marks the email as changed and restarts its verification.
+func (r *UserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, username, email, verificationTokenHash string, verificationExpiresAt time.Time) error {
+	set := bson.M{
+		"username": username,
+		"email":    email,
+	}
+	update := bson.M{"$set": set}
+	if verificationTokenHash != "" {
+		set["email_verification_token_hash"] = verificationTokenHash
+		set["email_verification_expires_at"] = verificationExpiresAt
+		update["$unset"] = bson.M{"email_verified_at": ""}
+	}
+
+	err := r.updateByID(ctx, id, update)
+	if err != nil && mongo.IsDuplicateKeyError(errors.Unwrap(err)) {
+		return fmt.Errorf("user with this email already exists")
+	}
+	return err
+}
+
+func (r *UserRepository) SetPreferences(ctx context.Context, id primitive.ObjectID, preferences models.UserPreferences) error {
+	return r.updateByID(ctx, id, bson.M{"$set": bson.M{"preferences": preferences}})
+}
+
+func (r *UserRepository) SetSettings(ctx context.Context, id primitive.ObjectID, settings models.UserSettings) error {
+	return r.updateByID(ctx, id, bson.M{"$set": bson.M{"settings": settings}})
+}
+
+// FindAutoCompleteOverrides returns the users who disabled auto-completion or
+// chose their own threshold, with only their IDs and settings
+func (r *UserRepository) FindAutoCompleteOverrides(ctx context.Context) ([]*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"$or": bson.A{
+		bson.M{"settings.auto_complete_disabled": true},
+		bson.M{"settings.auto_complete_minutes": bson.M{"$gt": 0}},
+	}}
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetProjection(bson.M{"settings": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find auto-complete settings: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) SetEmailVerification(ctx context.Context, id primitive.ObjectID, tokenHash string, expiresAt time.Time) error {
+	return r.updateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"email_verification_token_hash": tokenHash,
+			"email_verification_expires_at": expiresAt,
+		},
+	})
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, verifiedAt time.Time) error {
+	return r.updateByID(ctx, id, bson.M{
+		"$set": bson.M{"email_verified_at": verifiedAt},
+		"$unset": bson.M{
+			"email_verification_token_hash": "",
+			"email_verification_expires_at": "",
+		},
+	})
+}
+
+func (r *UserRepository) SetLegalHold(ctx context.Context, id primitive.ObjectID, hold bool) error {
+	update := bson.M{"$unset": bson.M{"legal_hold": ""}}
+	if hold {
+		update = bson.M{"$set": bson.M{"legal_hold": true}}
+	}
+	return r.updateByID(ctx, id, update)
+}
+
+func (r *UserRepository) SetPermissions(ctx context.Context, id primitive.ObjectID, permissions []models.Permission) error {
+	return r.updateByID(ctx, id, bson.M{"$set": bson.M{"permissions": permissions}})
+}
+
+// SetRole changes the user's role and resets their permissions to the role's defaults
+func (r *UserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role models.UserRole, permissions []models.Permission) error {
+	return r.updateByID(ctx, id, bson.M{"$set": bson.M{"role": role, "permissions": permissions}})
+}
+
+// BackfillPermissions stores the given permissions on every user of the role
+// that has none stored yet, returning how many users were updated.
+func (r *UserRepository) BackfillPermissions(ctx context.Context, role models.UserRole, permissions []models.Permission) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"role": role, "permissions": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, query, bson.M{"$set": bson.M{"permissions": permissions}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill permissions: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// SetPrivateKey stores the salt and check value for the user's private task key.
+// It never replaces existing ones, since tasks sealed with the old key could not be opened.
+func (r *UserRepository) SetPrivateKey(ctx context.Context, id primitive.ObjectID, salt, check []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"_id": id, "private_key_salt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, query, bson.M{"$set": bson.M{"private_key_salt": salt, "private_key_check": check}})
+	if err != nil {
+		return fmt.Errorf("failed to set private key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("private passphrase is already set")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) FindLegalHoldIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return distinctIDs(ctx, r.collection, bson.M{"legal_hold": true})
+}
+
+func (r *UserRepository) RevokeTokens(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error {
+	return r.updateByID(ctx, id, bson.M{
+		"$set": bson.M{"tokens_revoked_at": revokedAt},
+	})
+}
+
+func (r *UserRepository) SetPasswordReset(ctx context.Context, id primitive.ObjectID, hashedPassword, tokenHash string, expiresAt, revokedAt time.Time) error {
+	return r.updateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"password":                  hashedPassword,
+			"password_reset_token_hash": tokenHash,
+			"password_reset_expires_at": expiresAt,
+			"tokens_revoked_at":         revokedAt,
+		},
+	})
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, hashedPassword string, revokedAt time.Time) error {
+	return r.updateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"password":          hashedPassword,
+			"tokens_revoked_at": revokedAt,
+		},
+		"$unset": bson.M{
+			"password_reset_token_hash": "",
+			"password_reset_expires_at": "",
+			"failed_login_count":        "",
+			"locked_until":              "",
+		},
+	})
+}
+
+// RecordLoginFailure counts a wrong password and, on reaching maxFailures,
+// locks the account until lockedUntil and restarts the count. It returns the
+// updated user so callers can tell whether this failure caused the lock.
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id primitive.ObjectID, maxFailures int, lockedUntil time.Time) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	failures := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failed_login_count", 0}}, 1}}
+	reachedLimit := bson.M{"$gte": bson.A{failures, maxFailures}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failed_login_count": bson.M{"$cond": bson.A{reachedLimit, 0, failures}},
+			"locked_until":       bson.M{"$cond": bson.A{reachedLimit, lockedUntil, "$locked_until"}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return &user, nil
+}
+
+// ClearLoginFailures resets the failure count and lifts any lock
+func (r *UserRepository) ClearLoginFailures(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateByID(ctx, id, bson.M{
+		"$unset": bson.M{"failed_login_count": "", "locked_until": ""},
+	})
+}
+
+func (r *UserRepository) updateByID(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func distinctIDs(ctx context.Context, collection *mongo.Collection, query bson.M) ([]primitive.ObjectID, error) {
+	values, err := collection.Distinct(ctx, "_id", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", collection.Name(), err)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
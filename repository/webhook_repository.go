@@ -0,0 +1,369 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepository struct {
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+type WebhookDeliveryFilter struct {
+	Page  int
+	Limit int
+}
+
+// WebhookAttempt is the outcome of one delivery attempt
+type WebhookAttempt struct {
+	Status         models.WebhookDeliveryStatus
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	At             time.Time
+	// When the next attempt is due, for deliveries that stay pending
+	NextAttemptAt time.Time
+}
+
+func NewWebhookRepository(db *database.MongoDB) *WebhookRepository {
+	return &WebhookRepository{
+		webhooks:   db.Database.Collection("webhooks"),
+		deliveries: db.Database.Collection("webhook_deliveries"),
+	}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.webhooks.InsertOne(ctx, webhook)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	webhook.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *WebhookRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var webhook models.Webhook
+	err := r.webhooks.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.webhooks.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhooks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// FindSubscribed returns the user's enabled webhooks that subscribe to event
+func (r *WebhookRepository) FindSubscribed(ctx context.Context, userID primitive.ObjectID, event string) ([]*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.webhooks.Find(ctx, bson.M{"user_id": userID, "events": event, "disabled_at": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhooks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) CountByUserID(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.webhooks.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *WebhookRepository) SetSchemaVersion(ctx context.Context, id, userID primitive.ObjectID, version int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.webhooks.UpdateOne(ctx, bson.M{"_id": id, "user_id": userID}, bson.M{"$set": bson.M{"schema_version": version}})
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+// SetEnabled turns one of the user's webhooks on or off. Turning it on also
+// clears its failure streak.
+func (r *WebhookRepository) SetEnabled(ctx context.Context, id, userID primitive.ObjectID, enabled bool, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"disabled_at": "", "failing_since": "", "consecutive_failures": ""}}
+	if !enabled {
+		update = bson.M{"$set": bson.M{"disabled_at": now}}
+	}
+	result, err := r.webhooks.UpdateOne(ctx, bson.M{"_id": id, "user_id": userID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the webhook's failure streak
+func (r *WebhookRepository) RecordSuccess(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "consecutive_failures": bson.M{"$gt": 0}}
+	update := bson.M{"$unset": bson.M{"consecutive_failures": "", "failing_since": ""}}
+	if _, err := r.webhooks.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// RecordFailure adds a failed attempt to the webhook's streak, which starts
+// at now unless one is running, and returns the webhook as updated
+func (r *WebhookRepository) RecordFailure(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$inc": bson.M{"consecutive_failures": 1},
+		"$min": bson.M{"failing_since": now},
+	}
+
+	var webhook models.Webhook
+	err := r.webhooks.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+// Disable turns off an enabled webhook. It reports false when the webhook
+// was already disabled, so only one caller acts on it.
+func (r *WebhookRepository) Disable(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.webhooks.UpdateOne(ctx, bson.M{"_id": id, "disabled_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"disabled_at": now}})
+	if err != nil {
+		return false, fmt.Errorf("failed to disable webhook: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// FailPendingDeliveries marks the webhook's pending deliveries failed with reason
+func (r *WebhookRepository) FailPendingDeliveries(ctx context.Context, webhookID primitive.ObjectID, reason string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"webhook_id": webhookID, "status": models.WebhookDeliveryPending}
+	update := bson.M{
+		"$set":   bson.M{"status": models.WebhookDeliveryFailed, "last_error": reason},
+		"$unset": bson.M{"next_attempt_at": ""},
+	}
+	result, err := r.deliveries.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update webhook deliveries: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// Delete removes one of the user's webhooks together with its delivery log
+func (r *WebhookRepository) Delete(ctx context.Context, id, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.webhooks.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	if _, err := r.deliveries.DeleteMany(ctx, bson.M{"webhook_id": id}); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docs := make([]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		docs[i] = delivery
+	}
+	if _, err := r.deliveries.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDueDelivery takes the oldest pending delivery whose attempt is due and
+// pushes its next attempt to leaseUntil, so no other worker or replica picks
+// it up while it is in flight. An attempt that crashes is retried after the lease.
+func (r *WebhookRepository) ClaimDueDelivery(ctx context.Context, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := bson.M{"status": models.WebhookDeliveryPending, "next_attempt_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"next_attempt_at": leaseUntil}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err := r.deliveries.FindOneAndUpdate(ctx, query, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// RecordAttempt stores the outcome of an attempt on a claimed delivery
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt WebhookAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"status":          attempt.Status,
+		"last_attempt_at": attempt.At,
+	}
+	unset := bson.M{}
+	if attempt.ResponseStatus != 0 {
+		set["response_status"] = attempt.ResponseStatus
+	} else {
+		unset["response_status"] = ""
+	}
+	if attempt.ResponseBody != "" {
+		set["response_body"] = attempt.ResponseBody
+	} else {
+		unset["response_body"] = ""
+	}
+	if attempt.Error != "" {
+		set["last_error"] = attempt.Error
+	} else {
+		unset["last_error"] = ""
+	}
+	switch attempt.Status {
+	case models.WebhookDeliveryPending:
+		set["next_attempt_at"] = attempt.NextAttemptAt
+	case models.WebhookDeliveryDelivered:
+		set["delivered_at"] = attempt.At
+		unset["next_attempt_at"] = ""
+	default:
+		unset["next_attempt_at"] = ""
+	}
+
+	update := bson.M{"$set": set, "$inc": bson.M{"attempts": 1}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := r.deliveries.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+
+	return nil
+}
+
+// FindDeliveries lists a webhook's deliveries, newest first
+func (r *WebhookRepository) FindDeliveries(ctx context.Context, webhookID primitive.ObjectID, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := bson.M{"webhook_id": webhookID}
+
+	totalCount, err := r.deliveries.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 10
+	}
+
+	findOptions := options.Find().
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.deliveries.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find webhook deliveries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []*models.WebhookDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode webhook deliveries: %w", err)
+	}
+
+	return deliveries, totalCount, nil
+}
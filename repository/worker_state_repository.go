@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/database"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WorkerStateRepository keeps one state document per worker, named by its
+// ID, so pausing a worker reaches every instance
+type WorkerStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWorkerStateRepository(db *database.MongoDB) *WorkerStateRepository {
+	return &WorkerStateRepository{
+		collection: db.Database.Collection("worker_state"),
+	}
+}
+
+// Get returns the worker's state; a worker without one is running and has done nothing yet
+func (r *WorkerStateRepository) Get(ctx context.Context, name string) (*models.WorkerState, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var state models.WorkerState
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return &models.WorkerState{Name: name}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find worker state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// SetPaused pauses the worker on behalf of actor, or resumes it
+func (r *WorkerStateRepository) SetPaused(ctx context.Context, name string, paused bool, actor primitive.ObjectID, at time.Time) error {
+	update := bson.M{"$set": bson.M{"paused": false}, "$unset": bson.M{"paused_by": "", "paused_at": ""}}
+	if paused {
+		update = bson.M{"$set": bson.M{"paused": true, "paused_by": actor, "paused_at": at}}
+	}
+	return r.update(ctx, name, update, "failed to set worker pause")
+}
+
+func (r *WorkerStateRepository) RecordScan(ctx context.Context, name string, at time.Time) error {
+	return r.update(ctx, name, bson.M{"$set": bson.M{"last_scan_at": at}}, "failed to record worker scan")
+}
+
+// Count adds to the worker's counters of processed, failed and dead-lettered jobs
+func (r *WorkerStateRepository) Count(ctx context.Context, name string, processed, failed, deadLettered int64) error {
+	update := bson.M{"$inc": bson.M{"processed": processed, "failed": failed, "dead_lettered": deadLettered}}
+	return r.update(ctx, name, update, "failed to count worker jobs")
+}
+
+func (r *WorkerStateRepository) update(ctx context.Context, name string, update bson.M, failure string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": name}, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("%s: %w", failure, err)
+	}
+	return nil
+}
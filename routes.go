@@ -0,0 +1,219 @@
+package main
+
+import (
+	"net/http"
+	"task-management-api/billing"
+	"task-management-api/handler"
+	"task-management-api/models"
+	"task-management-api/service"
+
+	"github.com/gorilla/mux"
+)
+
+// apiV1 holds the handlers behind version 1 of the API. A new version gets its
+// own struct and mount method and is mounted next to it under /api/v2, reusing
+// whichever v1 handlers did not change.
+type apiV1 struct {
+	authService         *service.AuthService
+	apiKeyService       *service.APIKeyService
+	billingService      *service.BillingService
+	oauthProviders      []service.OAuthProvider
+	authHandler         *handler.AuthHandler
+	oauthHandler        *handler.OAuthHandler
+	userHandler         *handler.UserHandler
+	settingsHandler     *handler.SettingsHandler
+	apiKeyHandler       *handler.APIKeyHandler
+	sessionHandler      *handler.SessionHandler
+	achievementHandler  *handler.AchievementHandler
+	webhookHandler      *handler.WebhookHandler
+	notificationHandler *handler.NotificationHandler
+	taskHandler         *handler.TaskHandler
+	myDayHandler        *handler.MyDayHandler
+	pomodoroHandler     *handler.PomodoroHandler
+	projectionHandler   *handler.ProjectionHandler
+	commentHandler      *handler.CommentHandler
+	adminHandler        *handler.AdminHandler
+	metricsHandler      *handler.MetricsHandler
+	workerHandler       *handler.WorkerHandler
+	deadLetterHandler   *handler.DeadLetterHandler
+	retentionHandler    *handler.RetentionHandler
+	auditArchiveHandler *handler.AuditArchiveHandler
+	requestTraceHandler *handler.RequestTraceHandler
+	importJobHandler    *handler.ImportJobHandler
+	reportHandler       *handler.ReportHandler
+	usageHandler        *handler.UsageHandler
+	deprecationHandler  *handler.DeprecationHandler
+	billingHandler      *handler.BillingHandler
+	exportJobHandler    *handler.ExportJobHandler
+	fieldPolicyHandler  *handler.FieldPolicyHandler
+	templateHandler     *handler.NotificationTemplateHandler
+	graphqlHandler      *handler.GraphQLHandler
+}
+
+// mount registers the v1 routes on r, which is the /api/v1 subrouter or the
+// deprecated unprefixed alias.
+func (a *apiV1) mount(r *mux.Router) {
+	authService := a.authService
+	// premium routes answer 402 to users whose plan lacks the feature
+	premium := func(feature string, h http.HandlerFunc) http.Handler {
+		return a.billingService.RequireFeature(feature)(h)
+	}
+
+	// Public routes
+	r.HandleFunc("/register", a.authHandler.Register).Methods("POST")
+	r.HandleFunc("/login", a.authHandler.Login).Methods("POST")
+	r.HandleFunc("/auth/refresh", a.authHandler.Refresh).Methods("POST")
+	r.HandleFunc("/auth/reset-password", a.authHandler.ResetPassword).Methods("POST")
+	r.Handle("/auth/change-password", authService.AuthMiddleware(http.HandlerFunc(a.authHandler.ChangePassword))).Methods("POST")
+	r.HandleFunc("/auth/verify", a.authHandler.VerifyEmail).Methods("GET")
+	r.HandleFunc("/auth/verify/resend", a.authHandler.ResendVerification).Methods("POST")
+	r.HandleFunc("/webhooks/schemas", a.webhookHandler.ListSchemas).Methods("GET")
+	r.HandleFunc("/billing/{provider}/webhook", a.billingHandler.Webhook).Methods("POST")
+	r.HandleFunc("/telegram/webhook", a.notificationHandler.TelegramWebhook).Methods("POST")
+
+	// External login providers
+	for _, provider := range a.oauthProviders {
+		r.HandleFunc("/auth/"+provider.Name(), a.oauthHandler.Login(provider)).Methods("GET")
+		r.HandleFunc("/auth/"+provider.Name()+"/callback", a.oauthHandler.Callback(provider)).Methods("GET")
+	}
+
+	// Own account
+	me := r.PathPrefix("/me").Subrouter()
+	me.Use(authService.AuthMiddleware)
+	me.HandleFunc("", a.userHandler.GetMe).Methods("GET")
+	me.HandleFunc("", a.userHandler.UpdateMe).Methods("PUT")
+	me.HandleFunc("", a.userHandler.DeleteMe).Methods("DELETE")
+	me.HandleFunc("/private-passphrase", a.userHandler.SetPrivatePassphrase).Methods("PUT")
+	me.HandleFunc("/settings", a.settingsHandler.GetSettings).Methods("GET")
+	me.HandleFunc("/settings", a.settingsHandler.UpdateSettings).Methods("PATCH")
+	me.HandleFunc("/api-keys", a.apiKeyHandler.CreateKey).Methods("POST")
+	me.HandleFunc("/api-keys", a.apiKeyHandler.ListKeys).Methods("GET")
+	me.HandleFunc("/api-keys/{id}", a.apiKeyHandler.RevokeKey).Methods("DELETE")
+	me.HandleFunc("/sessions", a.sessionHandler.ListSessions).Methods("GET")
+	me.HandleFunc("/tokens", a.authHandler.IssueScopedToken).Methods("POST")
+	me.HandleFunc("/sessions/{id}", a.sessionHandler.RevokeSession).Methods("DELETE")
+	me.HandleFunc("/achievements", a.achievementHandler.GetAchievements).Methods("GET")
+	me.HandleFunc("/usage", a.usageHandler.GetMyUsage).Methods("GET")
+	me.HandleFunc("/subscription", a.billingHandler.GetSubscription).Methods("GET")
+	me.Handle("/webhooks", premium(billing.FeatureWebhooks, a.webhookHandler.CreateWebhook)).Methods("POST")
+	me.HandleFunc("/webhooks", a.webhookHandler.ListWebhooks).Methods("GET")
+	me.Handle("/webhooks/{id}", premium(billing.FeatureWebhooks, a.webhookHandler.UpdateWebhook)).Methods("PATCH")
+	me.HandleFunc("/webhooks/{id}", a.webhookHandler.DeleteWebhook).Methods("DELETE")
+	me.HandleFunc("/webhooks/{id}/deliveries", a.webhookHandler.ListDeliveries).Methods("GET")
+	me.Handle("/notification-channels", premium(billing.FeatureIntegrations, a.notificationHandler.CreateChannel)).Methods("POST")
+	me.HandleFunc("/notification-channels", a.notificationHandler.ListChannels).Methods("GET")
+	me.HandleFunc("/notification-channels/{id}", a.notificationHandler.DeleteChannel).Methods("DELETE")
+	me.HandleFunc("/notification-channels/{id}/test", a.notificationHandler.TestChannel).Methods("POST")
+	me.HandleFunc("/notification-preferences", a.notificationHandler.GetPreferences).Methods("GET")
+	me.HandleFunc("/notification-preferences", a.notificationHandler.UpdatePreferences).Methods("PATCH")
+	me.HandleFunc("/push-subscriptions", a.notificationHandler.CreatePushSubscription).Methods("POST")
+	me.HandleFunc("/push-subscriptions", a.notificationHandler.ListPushSubscriptions).Methods("GET")
+	me.HandleFunc("/push-subscriptions/public-key", a.notificationHandler.PushPublicKey).Methods("GET")
+	me.HandleFunc("/push-subscriptions/{id}", a.notificationHandler.DeletePushSubscription).Methods("DELETE")
+
+	// Protected routes; scripts may authenticate with X-API-Key or a scoped token, and
+	// every route declares the scope such credentials need
+	api := r.PathPrefix("/tasks").Subrouter()
+	api.Use(a.apiKeyService.Middleware(authService))
+	api.Use(service.PrivatePassphraseMiddleware)
+	scoped := func(scope string, h http.Handler) http.Handler {
+		return service.RequireScope(scope)(h)
+	}
+	read, write := service.ScopeTasksRead, service.ScopeTasksWrite
+	taskHandler, myDayHandler, pomodoroHandler, commentHandler := a.taskHandler, a.myDayHandler, a.pomodoroHandler, a.commentHandler
+	api.Handle("", scoped(write, authService.RequireVerifiedEmail(http.HandlerFunc(taskHandler.CreateTask)))).Methods("POST")
+	api.Handle("", scoped(read, http.HandlerFunc(taskHandler.ListTasks))).Methods("GET")
+	api.Handle("", scoped(write, http.HandlerFunc(taskHandler.BulkDeleteTasks))).Methods("DELETE")
+	api.Handle("/summary", scoped(read, http.HandlerFunc(taskHandler.TaskSummary))).Methods("GET")
+	api.Handle("/my-day", scoped(read, http.HandlerFunc(myDayHandler.ListMyDay))).Methods("GET")
+	api.Handle("/focus-stats", scoped(read, http.HandlerFunc(pomodoroHandler.FocusStats))).Methods("GET")
+	api.Handle("/plan", scoped(read, http.HandlerFunc(taskHandler.GetWeeklyPlan))).Methods("GET")
+	api.Handle("/export", scoped(read, http.HandlerFunc(taskHandler.ExportTasks))).Methods("GET")
+	api.Handle("/import", scoped(write, authService.RequireVerifiedEmail(http.HandlerFunc(taskHandler.ImportTasks)))).Methods("POST")
+	api.Handle("/import/todoist", scoped(write, authService.RequireVerifiedEmail(premium(billing.FeatureIntegrations, a.importJobHandler.StartTodoistImport)))).Methods("POST")
+	api.Handle("/import/jobs/{id}", scoped(read, http.HandlerFunc(a.importJobHandler.GetJob))).Methods("GET")
+	api.Handle("/{id}", scoped(read, http.HandlerFunc(taskHandler.GetTask))).Methods("GET")
+	api.Handle("/{id}", scoped(write, http.HandlerFunc(taskHandler.UpdateTask))).Methods("PATCH")
+	api.Handle("/{id}/subtasks", scoped(read, http.HandlerFunc(taskHandler.ListSubtasks))).Methods("GET")
+	api.Handle("/{id}/schedule", scoped(write, http.HandlerFunc(taskHandler.ScheduleTask))).Methods("POST")
+	api.Handle("/{id}/my-day", scoped(write, http.HandlerFunc(myDayHandler.AddToMyDay))).Methods("POST")
+	api.Handle("/{id}/my-day", scoped(write, http.HandlerFunc(myDayHandler.RemoveFromMyDay))).Methods("DELETE")
+	api.Handle("/{id}/pomodoros", scoped(write, http.HandlerFunc(pomodoroHandler.StartSession))).Methods("POST")
+	api.Handle("/{id}/pomodoros", scoped(read, http.HandlerFunc(pomodoroHandler.ListSessions))).Methods("GET")
+	api.Handle("/{id}/pomodoros/stop", scoped(write, http.HandlerFunc(pomodoroHandler.StopSession))).Methods("POST")
+	api.Handle("/{id}/archive", scoped(write, http.HandlerFunc(taskHandler.ArchiveTask))).Methods("POST")
+	api.Handle("/{id}/unarchive", scoped(write, http.HandlerFunc(taskHandler.UnarchiveTask))).Methods("POST")
+	api.Handle("/{id}/history", scoped(read, http.HandlerFunc(taskHandler.GetTaskHistory))).Methods("GET")
+	api.Handle("/{id}/comments", scoped(write, http.HandlerFunc(commentHandler.CreateComment))).Methods("POST")
+	api.Handle("/{id}/comments", scoped(read, http.HandlerFunc(commentHandler.ListComments))).Methods("GET")
+	api.Handle("/{id}/comments/{commentId}", scoped(write, http.HandlerFunc(commentHandler.DeleteComment))).Methods("DELETE")
+	api.Handle("/{id}", scoped(write, http.HandlerFunc(taskHandler.DeleteTask))).Methods("DELETE")
+
+	// Reports render tasks as documents and take the same credentials as /tasks
+	reports := r.PathPrefix("/reports").Subrouter()
+	reports.Use(a.apiKeyService.Middleware(authService))
+	reports.Use(service.PrivatePassphraseMiddleware)
+	reports.Handle("/tasks.pdf", scoped(read, http.HandlerFunc(a.reportHandler.TasksPDF))).Methods("GET")
+
+	// Background exports of all of a user's tasks; they only read tasks
+	exports := r.PathPrefix("/exports").Subrouter()
+	exports.Use(a.apiKeyService.Middleware(authService))
+	exports.Use(service.PrivatePassphraseMiddleware)
+	exports.Handle("", scoped(read, http.HandlerFunc(a.exportJobHandler.StartExport))).Methods("POST")
+	exports.Handle("/{id}", scoped(read, http.HandlerFunc(a.exportJobHandler.GetJob))).Methods("GET")
+	exports.Handle("/{id}/download", scoped(read, http.HandlerFunc(a.exportJobHandler.Download))).Methods("GET")
+
+	// GraphQL takes the same credentials as /tasks; each field checks the
+	// scope its REST route needs
+	gql := r.PathPrefix("/graphql").Subrouter()
+	gql.Use(a.apiKeyService.Middleware(authService))
+	gql.Use(service.PrivatePassphraseMiddleware)
+	gql.HandleFunc("", a.graphqlHandler.Serve).Methods("POST")
+
+	// Admin routes, each gated on a single permission
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(authService.AuthMiddleware)
+	requires := func(permission models.Permission, h http.HandlerFunc) http.Handler {
+		return authService.RequirePermission(permission)(h)
+	}
+	adminHandler, retentionHandler := a.adminHandler, a.retentionHandler
+	admin.Handle("/slo", requires(models.PermissionSystemRead, a.metricsHandler.SLOReport)).Methods("GET")
+	admin.Handle("/schema", requires(models.PermissionSystemRead, adminHandler.SchemaStatus)).Methods("GET")
+	admin.Handle("/indexes", requires(models.PermissionSystemRead, adminHandler.Indexes)).Methods("GET")
+	admin.Handle("/deprecations", requires(models.PermissionSystemRead, a.deprecationHandler.Report)).Methods("GET")
+	admin.Handle("/config", requires(models.PermissionSystemRead, adminHandler.Config)).Methods("GET")
+	admin.Handle("/requests/{id}", requires(models.PermissionSystemRead, a.requestTraceHandler.GetTrace)).Methods("GET")
+	admin.Handle("/projections/consistency", requires(models.PermissionSystemRead, a.projectionHandler.CheckConsistency)).Methods("GET")
+	admin.Handle("/worker", requires(models.PermissionSystemRead, a.workerHandler.Status)).Methods("GET")
+	admin.Handle("/worker/pause", requires(models.PermissionTasksUpdateAny, a.workerHandler.Pause)).Methods("POST")
+	admin.Handle("/worker/resume", requires(models.PermissionTasksUpdateAny, a.workerHandler.Resume)).Methods("POST")
+	admin.Handle("/worker/trigger", requires(models.PermissionTasksUpdateAny, a.workerHandler.Trigger)).Methods("POST")
+	admin.Handle("/worker/simulate", requires(models.PermissionTasksReadAll, a.workerHandler.Simulate)).Methods("POST")
+	admin.Handle("/dead-letters", requires(models.PermissionSystemRead, a.deadLetterHandler.List)).Methods("GET")
+	admin.Handle("/dead-letters/{id}", requires(models.PermissionSystemRead, a.deadLetterHandler.Get)).Methods("GET")
+	admin.Handle("/dead-letters/{id}/requeue", requires(models.PermissionTasksUpdateAny, a.deadLetterHandler.Requeue)).Methods("POST")
+	admin.Handle("/retention", requires(models.PermissionComplianceManage, retentionHandler.GetStatus)).Methods("GET")
+	admin.Handle("/retention", requires(models.PermissionComplianceManage, retentionHandler.UpdatePolicy)).Methods("PUT")
+	admin.Handle("/retention/run", requires(models.PermissionComplianceManage, retentionHandler.Run)).Methods("POST")
+	admin.Handle("/retention/reports", requires(models.PermissionComplianceManage, retentionHandler.ListReports)).Methods("GET")
+	admin.Handle("/audit-archives", requires(models.PermissionComplianceManage, a.auditArchiveHandler.ListArchives)).Methods("GET")
+	admin.Handle("/audit-archives/run", requires(models.PermissionComplianceManage, a.auditArchiveHandler.Run)).Methods("POST")
+	admin.Handle("/audit-archives/{id}/download", requires(models.PermissionComplianceManage, a.auditArchiveHandler.Download)).Methods("GET")
+	admin.Handle("/users/{id}/force-logout", requires(models.PermissionUsersManage, adminHandler.ForceLogout)).Methods("POST")
+	admin.Handle("/users/{id}/reset-credentials", requires(models.PermissionUsersManage, adminHandler.ResetCredentials)).Methods("POST")
+	admin.Handle("/users/{id}/unlock", requires(models.PermissionUsersManage, adminHandler.UnlockAccount)).Methods("POST")
+	admin.Handle("/users/{id}/usage", requires(models.PermissionSystemRead, a.usageHandler.GetUserUsage)).Methods("GET")
+	admin.Handle("/users/{id}/reassign-tasks", requires(models.PermissionUsersManage, adminHandler.ReassignTasks)).Methods("POST")
+	admin.Handle("/login-attempts", requires(models.PermissionUsersManage, a.authHandler.ListLoginAttempts)).Methods("GET")
+	admin.Handle("/login-attempts", requires(models.PermissionUsersManage, a.authHandler.ClearLoginAttempts)).Methods("DELETE")
+	admin.Handle("/users/{id}/permissions", requires(models.PermissionUsersManage, adminHandler.SetPermissions)).Methods("PUT")
+	admin.Handle("/field-policy/tasks", requires(models.PermissionUsersManage, a.fieldPolicyHandler.GetTaskPolicy)).Methods("GET")
+	admin.Handle("/field-policy/tasks", requires(models.PermissionUsersManage, a.fieldPolicyHandler.UpdateTaskPolicy)).Methods("PUT")
+	admin.Handle("/notification-templates", requires(models.PermissionUsersManage, a.templateHandler.List)).Methods("GET")
+	admin.Handle("/notification-templates/{name}", requires(models.PermissionUsersManage, a.templateHandler.Get)).Methods("GET")
+	admin.Handle("/notification-templates/{name}", requires(models.PermissionUsersManage, a.templateHandler.Update)).Methods("PUT")
+	admin.Handle("/notification-templates/{name}", requires(models.PermissionUsersManage, a.templateHandler.Delete)).Methods("DELETE")
+	admin.Handle("/notification-templates/{name}/preview", requires(models.PermissionUsersManage, a.templateHandler.Preview)).Methods("POST")
+	admin.Handle("/users/{id}/legal-hold", requires(models.PermissionComplianceManage, adminHandler.SetUserLegalHold)).Methods("PUT")
+	admin.Handle("/tasks/{id}/legal-hold", requires(models.PermissionComplianceManage, adminHandler.SetTaskLegalHold)).Methods("PUT")
+}
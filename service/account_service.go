@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Task handling when an account is deleted
+const (
+	AccountTasksDelete   = "delete"
+	AccountTasksReassign = "reassign"
+)
+
+type AccountService struct {
+	userRepo    *repository.UserRepository
+	accountRepo *repository.AccountRepository
+	auditRepo   *repository.AuditRepository
+	clock       clock.Clock
+}
+
+func NewAccountService(userRepo *repository.UserRepository, accountRepo *repository.AccountRepository, auditRepo *repository.AuditRepository, clk clock.Clock) *AccountService {
+	return &AccountService{
+		userRepo:    userRepo,
+		accountRepo: accountRepo,
+		auditRepo:   auditRepo,
+		clock:       clk,
+	}
+}
+
+func (s *AccountService) DeleteAccount(ctx context.Context, user *models.User, req *models.DeleteAccountRequest) (*models.DeleteAccountResponse, error) {
+	// Validate input
+	if req.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, fmt.Errorf("password is incorrect")
+	}
+
+	if user.LegalHold {
+		return nil, fmt.Errorf("account is under legal hold")
+	}
+
+	var reassignTo *primitive.ObjectID
+	switch req.Tasks {
+	case AccountTasksDelete:
+	case AccountTasksReassign:
+		// "unassigned" moves the tasks into the pool, anything else must be an existing user
+		to := primitive.NilObjectID
+		if req.ReassignTo != models.ReassignUnassigned {
+			id, err := primitive.ObjectIDFromHex(req.ReassignTo)
+			if err != nil || id == user.ID {
+				return nil, fmt.Errorf("invalid reassign_to")
+			}
+			if _, err := s.userRepo.FindByID(ctx, id); err != nil {
+				if err.Error() == "user not found" {
+					return nil, fmt.Errorf("invalid reassign_to")
+				}
+				return nil, err
+			}
+			to = id
+		}
+		reassignTo = &to
+	default:
+		return nil, fmt.Errorf("tasks must be one of: delete, reassign")
+	}
+
+	now := s.clock.Now()
+	response, err := s.accountRepo.DeleteUser(ctx, user.ID, reassignTo, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// The audit trail keeps only the ID of the deleted account
+	entry := models.NewAuditLog(user.ID, "user.delete_account", "user", user.ID, map[string]interface{}{
+		"tasks":            req.Tasks,
+		"tasks_deleted":    response.TasksDeleted,
+		"tasks_reassigned": response.TasksReassigned,
+	}, now)
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, user.ID.Hex(), err)
+	}
+
+	return response, nil
+}
+
+// SetPrivatePassphrase sets up the key private tasks are encrypted with. Only a
+// salt and a check value are stored, so a forgotten passphrase cannot be recovered
+// and it can be set only once.
+func (s *AccountService) SetPrivatePassphrase(ctx context.Context, user *models.User, req *models.SetPrivatePassphraseRequest) (*models.User, error) {
+	if len(user.PrivateKeySalt) > 0 {
+		return nil, fmt.Errorf("private passphrase is already set")
+	}
+
+	salt, check, err := newPrivateKey(req.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetPrivateKey(ctx, user.ID, salt, check); err != nil {
+		return nil, err
+	}
+
+	entry := models.NewAuditLog(user.ID, "user.set_private_passphrase", "user", user.ID, nil, s.clock.Now())
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, user.ID.Hex(), err)
+	}
+
+	updated := *user
+	updated.PrivateKeySalt = salt
+	updated.PrivateKeyCheck = check
+	return &updated, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"task-management-api/clock"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/repository"
+)
+
+// Attempts at recording a completion when other completions by the same user race it
+const achievementSaveAttempts = 3
+
+// badgeRule awards a badge once its condition holds after a completion
+type badgeRule struct {
+	code        string
+	name        string
+	description string
+	earned      func(a *models.UserAchievements) bool
+}
+
+var badgeRules = []badgeRule{
+	{"first_completion", "First Step", "Complete your first task", completedAtLeast(1)},
+	{"completed_10", "Getting Things Done", "Complete 10 tasks", completedAtLeast(10)},
+	{"completed_100", "Centurion", "Complete 100 tasks", completedAtLeast(100)},
+	{"completed_1000", "Unstoppable", "Complete 1000 tasks", completedAtLeast(1000)},
+	{"streak_3", "On a Roll", "Complete tasks on 3 days in a row", streakAtLeast(3)},
+	{"streak_7", "Week Streak", "Complete tasks on 7 days in a row", streakAtLeast(7)},
+	{"streak_30", "Month Streak", "Complete tasks on 30 days in a row", streakAtLeast(30)},
+	{"streak_100", "Habit Formed", "Complete tasks on 100 days in a row", streakAtLeast(100)},
+}
+
+func completedAtLeast(n int64) func(a *models.UserAchievements) bool {
+	return func(a *models.UserAchievements) bool { return a.TotalCompleted >= n }
+}
+
+func streakAtLeast(n int) func(a *models.UserAchievements) bool {
+	return func(a *models.UserAchievements) bool { return a.CurrentStreak >= n }
+}
+
+// AchievementService tracks daily completion streaks and awards badges. It
+// consumes the task service's completion events; completions by the
+// auto-complete worker are not the user's doing and do not count, and neither
+// does reopening a task take a completion back.
+type AchievementService struct {
+	achievementRepo *repository.AchievementRepository
+	clock           clock.Clock
+}
+
+func NewAchievementService(achievementRepo *repository.AchievementRepository, clk clock.Clock) *AchievementService {
+	return &AchievementService{
+		achievementRepo: achievementRepo,
+		clock:           clk,
+	}
+}
+
+// Subscribe credits users with the tasks they complete. Call during startup.
+func (s *AchievementService) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, s.taskCompleted)
+}
+
+// taskCompleted credits the completion to the user who made it; the worker's
+// auto-completions earn nothing. Failures are logged rather than returned.
+func (s *AchievementService) taskCompleted(ctx context.Context, e events.TaskCompleted) {
+	task, by := e.Task, e.By
+	if e.AutoCompleted() || by.Preferences.AchievementsOptOut {
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.recordCompletion(ctx, by)
+		if err == nil {
+			return
+		}
+		if err.Error() != "achievements changed concurrently" || attempt == achievementSaveAttempts {
+			logf(ctx, "Failed to record completion of task %s for user %s: %v", task.ID.Hex(), by.ID.Hex(), err)
+			return
+		}
+	}
+}
+
+func (s *AchievementService) recordCompletion(ctx context.Context, user *models.User) error {
+	achievements, err := s.find(ctx, user)
+	if err != nil {
+		return err
+	}
+	previousTotal := achievements.TotalCompleted
+
+	now := s.clock.Now()
+	today := now.UTC().Format(planDateLayout)
+	yesterday := now.UTC().AddDate(0, 0, -1).Format(planDateLayout)
+
+	achievements.TotalCompleted++
+	switch achievements.LastCompletionDay {
+	case today:
+		// The streak already counts today
+	case yesterday:
+		achievements.CurrentStreak++
+	default:
+		achievements.CurrentStreak = 1
+	}
+	achievements.LastCompletionDay = today
+	if achievements.CurrentStreak > achievements.LongestStreak {
+		achievements.LongestStreak = achievements.CurrentStreak
+	}
+
+	held := make(map[string]bool, len(achievements.Badges))
+	for _, badge := range achievements.Badges {
+		held[badge.Code] = true
+	}
+	for _, rule := range badgeRules {
+		if !held[rule.code] && rule.earned(achievements) {
+			achievements.Badges = append(achievements.Badges, &models.Badge{Code: rule.code, AwardedAt: now})
+		}
+	}
+
+	return s.achievementRepo.Save(ctx, achievements, previousTotal)
+}
+
+// Get returns the user's streaks and badges. A streak without a completion
+// today or yesterday has lapsed and reads as zero.
+func (s *AchievementService) Get(ctx context.Context, user *models.User) (*models.AchievementsResponse, error) {
+	achievements, err := s.find(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	currentStreak := achievements.CurrentStreak
+	switch achievements.LastCompletionDay {
+	case now.Format(planDateLayout), now.AddDate(0, 0, -1).Format(planDateLayout):
+	default:
+		currentStreak = 0
+	}
+
+	response := &models.AchievementsResponse{
+		OptedOut:       user.Preferences.AchievementsOptOut,
+		TotalCompleted: models.Int64(achievements.TotalCompleted),
+		CurrentStreak:  currentStreak,
+		LongestStreak:  achievements.LongestStreak,
+		Badges:         achievements.Badges,
+	}
+	if achievements.LastCompletionDay != "" {
+		response.LastCompletionDate = &achievements.LastCompletionDay
+	}
+
+	names := make(map[string]badgeRule, len(badgeRules))
+	for _, rule := range badgeRules {
+		names[rule.code] = rule
+	}
+	for _, badge := range response.Badges {
+		badge.Name = names[badge.Code].name
+		badge.Description = names[badge.Code].description
+	}
+
+	return response, nil
+}
+
+// find returns the stored achievements, or an empty record for users without any yet
+func (s *AchievementService) find(ctx context.Context, user *models.User) (*models.UserAchievements, error) {
+	achievements, err := s.achievementRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		if err.Error() != "achievements not found" {
+			return nil, err
+		}
+		achievements = &models.UserAchievements{UserID: user.ID}
+	}
+	if achievements.Badges == nil {
+		achievements.Badges = []*models.Badge{}
+	}
+	return achievements, nil
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/notifications"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordResetTTL = 24 * time.Hour
+
+type AdminService struct {
+	userRepo    *repository.UserRepository
+	refreshRepo *repository.RefreshTokenRepository
+	apiKeyRepo  *repository.APIKeyRepository
+	auditRepo   *repository.AuditRepository
+	taskService *TaskService
+	mailer      notifications.EmailSender
+	templates   *notifications.Renderer
+	resetURL    string
+	clock       clock.Clock
+}
+
+func NewAdminService(userRepo *repository.UserRepository, refreshRepo *repository.RefreshTokenRepository, apiKeyRepo *repository.APIKeyRepository, auditRepo *repository.AuditRepository, taskService *TaskService, mailer notifications.EmailSender, templates *notifications.Renderer, resetURL string, clk clock.Clock) *AdminService {
+	return &AdminService{
+		userRepo:    userRepo,
+		refreshRepo: refreshRepo,
+		apiKeyRepo:  apiKeyRepo,
+		auditRepo:   auditRepo,
+		taskService: taskService,
+		mailer:      mailer,
+		templates:   templates,
+		resetURL:    resetURL,
+		clock:       clk,
+	}
+}
+
+func (s *AdminService) ForceLogout(ctx context.Context, actor *models.User, userID primitive.ObjectID) error {
+	now := s.clock.Now()
+	if err := s.userRepo.RevokeTokens(ctx, userID, now); err != nil {
+		return err
+	}
+	if err := s.refreshRepo.RevokeAllForUser(ctx, userID, now); err != nil {
+		return err
+	}
+	if err := s.apiKeyRepo.RevokeAllForUser(ctx, userID, now); err != nil {
+		return err
+	}
+
+	s.audit(ctx, models.NewAuditLog(actor.ID, "user.force_logout", "user", userID, nil, now))
+	return nil
+}
+
+// ResetCredentials signs the user out everywhere, replaces their password and
+// emails them a link to choose a new one. The token is never returned to the
+// admin. Users without a verified address are refused, since the link could
+// go to someone else.
+func (s *AdminService) ResetCredentials(ctx context.Context, actor *models.User, userID primitive.ObjectID) (*models.ResetCredentialsResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsEmailVerified() {
+		return nil, fmt.Errorf("user email address is not verified")
+	}
+
+	// Replace the password with an unguessable one so the old credentials stop working immediately
+	unusablePassword, _, err := newSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(unusablePassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	resetToken, resetTokenHash, err := newSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	expiresAt := now.Add(passwordResetTTL)
+	if err := s.userRepo.SetPasswordReset(ctx, userID, string(hashedPassword), resetTokenHash, expiresAt, now); err != nil {
+		return nil, err
+	}
+	if err := s.refreshRepo.RevokeAllForUser(ctx, userID, now); err != nil {
+		return nil, err
+	}
+	if err := s.apiKeyRepo.RevokeAllForUser(ctx, userID, now); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, models.NewAuditLog(actor.ID, "user.reset_credentials", "user", userID, nil, now))
+
+	data := notifications.PasswordResetData{
+		Username:  user.Username,
+		Token:     resetToken,
+		ResetURL:  s.resetLink(resetToken),
+		ExpiresAt: expiresAt,
+	}
+	// The credentials stay reset either way; another reset sends a new link
+	emailSent := true
+	if err := s.templates.SendTemplate(ctx, s.mailer, user.Email, notifications.TemplatePasswordReset, data); err != nil {
+		logf(ctx, "Failed to send password reset mail to user %s: %v", userID.Hex(), err)
+		emailSent = false
+	}
+
+	return &models.ResetCredentialsResponse{
+		ExpiresAt: expiresAt,
+		EmailSent: emailSent,
+	}, nil
+}
+
+// resetLink adds the token to the page where users choose a new password
+func (s *AdminService) resetLink(token string) string {
+	link, err := url.Parse(s.resetURL)
+	if err != nil {
+		return s.resetURL
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// UnlockAccount lifts a login lockout and resets the failure count
+func (s *AdminService) UnlockAccount(ctx context.Context, actor *models.User, userID primitive.ObjectID) error {
+	if err := s.userRepo.ClearLoginFailures(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, models.NewAuditLog(actor.ID, "user.unlock", "user", userID, nil, s.clock.Now()))
+	return nil
+}
+
+func (s *AdminService) ReassignTasks(ctx context.Context, actor *models.User, userID primitive.ObjectID, req *models.ReassignTasksRequest) (*models.ReassignTasksResponse, error) {
+	if req.To == "" {
+		return nil, fmt.Errorf("to is required")
+	}
+
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// "unassigned" moves the tasks into the pool, anything else must be an existing user
+	to := primitive.NilObjectID
+	if req.To != models.ReassignUnassigned {
+		id, err := primitive.ObjectIDFromHex(req.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target user ID")
+		}
+		if id == userID {
+			return nil, fmt.Errorf("target user must differ from the source user")
+		}
+		if _, err := s.userRepo.FindByID(ctx, id); err != nil {
+			if err.Error() == "user not found" {
+				return nil, fmt.Errorf("target user not found")
+			}
+			return nil, err
+		}
+		to = id
+	}
+
+	moved, err := s.taskService.ReassignOpenTasks(ctx, userID, to, actor.ID)
+	// Record whatever was moved, even when a later batch failed
+	s.audit(ctx, models.NewAuditLog(actor.ID, "user.reassign_tasks", "user", userID, map[string]interface{}{
+		"to":       req.To,
+		"moved":    moved,
+		"complete": err == nil,
+	}, s.clock.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReassignTasksResponse{
+		ReassignedCount: models.Int64(moved),
+		To:              req.To,
+	}, nil
+}
+
+// SetPermissions replaces a user's permissions. Actors can only grant
+// permissions they hold themselves and cannot drop their own users:manage.
+func (s *AdminService) SetPermissions(ctx context.Context, actor *models.User, userID primitive.ObjectID, req *models.SetPermissionsRequest) (*models.User, error) {
+	if req.Permissions == nil {
+		return nil, fmt.Errorf("permissions is required")
+	}
+
+	permissions := []models.Permission{}
+	seen := make(map[models.Permission]bool)
+	for _, permission := range req.Permissions {
+		if !models.IsValidPermission(permission) {
+			return nil, fmt.Errorf("invalid permission: %s", permission)
+		}
+		if !actor.HasPermission(permission) {
+			return nil, fmt.Errorf("cannot grant a permission you do not hold: %s", permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	if userID == actor.ID && !seen[models.PermissionUsersManage] {
+		return nil, fmt.Errorf("cannot remove users:manage from yourself")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetPermissions(ctx, userID, permissions); err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{"before": user.EffectivePermissions(), "after": permissions}
+	s.audit(ctx, models.NewAuditLog(actor.ID, "user.permissions", "user", userID, details, s.clock.Now()))
+
+	user.Permissions = permissions
+	return user, nil
+}
+
+// MigrateRolePermissions stores the role defaults on users that predate
+// per-user permissions. It is idempotent and runs at startup.
+func (s *AdminService) MigrateRolePermissions(ctx context.Context) (int64, error) {
+	var migrated int64
+	for role, permissions := range models.RolePermissions {
+		count, err := s.userRepo.BackfillPermissions(ctx, role, permissions)
+		if err != nil {
+			return migrated, err
+		}
+		migrated += count
+	}
+	return migrated, nil
+}
+
+func (s *AdminService) SetUserLegalHold(ctx context.Context, actor *models.User, userID primitive.ObjectID, req *models.SetLegalHoldRequest) (*models.LegalHoldResponse, error) {
+	if req.Enabled == nil {
+		return nil, fmt.Errorf("enabled is required")
+	}
+
+	if err := s.userRepo.SetLegalHold(ctx, userID, *req.Enabled); err != nil {
+		return nil, err
+	}
+	if *req.Enabled {
+		if err := s.taskService.CancelUserPurges(ctx, userID); err != nil {
+			return nil, err
+		}
+		if err := s.auditRepo.CancelPurgeByTargetID(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.audit(ctx, models.NewAuditLog(actor.ID, legalHoldAction(*req.Enabled), "user", userID, map[string]interface{}{"reason": req.Reason}, s.clock.Now()))
+
+	return &models.LegalHoldResponse{TargetType: "user", TargetID: userID, LegalHold: *req.Enabled}, nil
+}
+
+func (s *AdminService) SetTaskLegalHold(ctx context.Context, actor *models.User, taskID primitive.ObjectID, req *models.SetLegalHoldRequest) (*models.LegalHoldResponse, error) {
+	if req.Enabled == nil {
+		return nil, fmt.Errorf("enabled is required")
+	}
+
+	if err := s.taskService.SetLegalHold(ctx, taskID, *req.Enabled); err != nil {
+		return nil, err
+	}
+	if *req.Enabled {
+		if err := s.auditRepo.CancelPurgeByTargetID(ctx, taskID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.audit(ctx, models.NewAuditLog(actor.ID, legalHoldAction(*req.Enabled), "task", taskID, map[string]interface{}{"reason": req.Reason}, s.clock.Now()))
+
+	return &models.LegalHoldResponse{TargetType: "task", TargetID: taskID, LegalHold: *req.Enabled}, nil
+}
+
+func legalHoldAction(enabled bool) string {
+	if enabled {
+		return "legal_hold.place"
+	}
+	return "legal_hold.release"
+}
+
+func (s *AdminService) audit(ctx context.Context, entry *models.AuditLog) {
+	// The admin action already happened, so a failed audit write is logged rather than returned
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, entry.TargetID.Hex(), err)
+	}
+}
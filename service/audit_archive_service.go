@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"task-management-api/archive"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxAuditArchiveDaysPerRun bounds a run so an admin request finishes in time;
+// the scheduled job keeps running until the backlog is gone
+const maxAuditArchiveDaysPerRun = 31
+
+// AuditArchiveService moves audit logs older than a number of days out of
+// MongoDB into one gzipped NDJSON object per UTC day, so the hot collection
+// stays small while the history stays retrievable. Lockout and other login
+// events are audit log entries and are archived with the rest.
+type AuditArchiveService struct {
+	auditRepo   *repository.AuditRepository
+	archiveRepo *repository.AuditArchiveRepository
+	store       archive.Store
+	afterDays   int
+	clock       clock.Clock
+
+	// running stops the scheduled job and an admin run from archiving the
+	// same day twice
+	running sync.Mutex
+}
+
+// NewAuditArchiveService returns a service that archives entries older than
+// afterDays; a nil store disables archiving.
+func NewAuditArchiveService(auditRepo *repository.AuditRepository, archiveRepo *repository.AuditArchiveRepository, store archive.Store, afterDays int, clk clock.Clock) *AuditArchiveService {
+	return &AuditArchiveService{
+		auditRepo:   auditRepo,
+		archiveRepo: archiveRepo,
+		store:       store,
+		afterDays:   afterDays,
+		clock:       clk,
+	}
+}
+
+// Run archives every whole UTC day older than olderThanDays, oldest first, up
+// to maxAuditArchiveDaysPerRun days. Zero uses the configured number of days.
+// actor is nil for scheduled runs.
+func (s *AuditArchiveService) Run(ctx context.Context, actor *models.User, olderThanDays int) (*models.AuditArchiveRunReport, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("audit archiving is not configured")
+	}
+	if olderThanDays == 0 {
+		olderThanDays = s.afterDays
+	}
+	if olderThanDays < 1 {
+		return nil, fmt.Errorf("older_than_days must be at least 1")
+	}
+	if !s.running.TryLock() {
+		return nil, fmt.Errorf("audit archive run already in progress")
+	}
+	defer s.running.Unlock()
+
+	now := s.clock.Now().UTC()
+	report := &models.AuditArchiveRunReport{
+		Cutoff:   startOfUTCDay(now.AddDate(0, 0, -olderThanDays)),
+		Archives: []*models.AuditArchive{},
+	}
+	var createdBy *primitive.ObjectID
+	if actor != nil {
+		createdBy = &actor.ID
+	}
+
+	for {
+		oldest, err := s.auditRepo.FindOldestArchivable(ctx, report.Cutoff)
+		if err != nil {
+			return nil, err
+		}
+		if oldest == nil {
+			break
+		}
+		if len(report.Archives) == maxAuditArchiveDaysPerRun {
+			report.More = true
+			break
+		}
+
+		day := startOfUTCDay(*oldest)
+		archived, err := s.archiveDay(ctx, day, createdBy)
+		if err != nil {
+			return nil, err
+		}
+		if archived == nil {
+			// Purged between the two queries
+			continue
+		}
+		report.Archives = append(report.Archives, archived)
+		report.ArchivesCreated++
+		report.EntriesArchived += archived.EntryCount
+	}
+
+	if actor != nil && report.ArchivesCreated > 0 {
+		entry := models.NewAuditLog(actor.ID, "audit_log.archive", "audit_archive", report.Archives[0].ID, map[string]interface{}{
+			"archives": report.ArchivesCreated,
+			"entries":  report.EntriesArchived,
+			"cutoff":   report.Cutoff,
+		}, now)
+		if err := s.auditRepo.Create(ctx, entry); err != nil {
+			logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, actor.ID.Hex(), err)
+		}
+	}
+
+	return report, nil
+}
+
+// archiveDay writes the day's entries to the store, records the archive and
+// only then deletes the entries, so a failure part way leaves them in MongoDB
+// to be archived again by the next run
+func (s *AuditArchiveService) archiveDay(ctx context.Context, day time.Time, createdBy *primitive.ObjectID) (*models.AuditArchive, error) {
+	entries, err := s.auditRepo.FindArchivable(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	ids := make([]primitive.ObjectID, len(entries))
+	for i, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return nil, fmt.Errorf("failed to encode audit log: %w", err)
+		}
+		ids[i] = entry.ID
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress audit logs: %w", err)
+	}
+
+	id := primitive.NewObjectID()
+	sum := sha256.Sum256(buf.Bytes())
+	archived := &models.AuditArchive{
+		ID:           id,
+		Day:          day,
+		Key:          fmt.Sprintf("audit_logs/%s/%s.ndjson.gz", day.Format("2006/01/02"), id.Hex()),
+		EntryCount:   len(entries),
+		FirstEntryAt: entries[0].CreatedAt,
+		LastEntryAt:  entries[len(entries)-1].CreatedAt,
+		Bytes:        buf.Len(),
+		SHA256:       hex.EncodeToString(sum[:]),
+		CreatedBy:    createdBy,
+		CreatedAt:    s.clock.Now(),
+	}
+
+	if err := s.store.Put(ctx, archived.Key, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := s.archiveRepo.Create(ctx, archived); err != nil {
+		return nil, err
+	}
+	if _, err := s.auditRepo.DeleteByIDs(ctx, ids); err != nil {
+		return nil, err
+	}
+
+	return archived, nil
+}
+
+func (s *AuditArchiveService) ListArchives(ctx context.Context, filter repository.AuditArchiveFilter) (*models.AuditArchiveListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 10
+	}
+
+	archives, totalCount, err := s.archiveRepo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewAuditArchiveListResponse(archives, filter.Page, filter.Limit, totalCount), nil
+}
+
+// Download returns an archive and its gzipped NDJSON content, checked against
+// the digest recorded when it was written
+func (s *AuditArchiveService) Download(ctx context.Context, id primitive.ObjectID) (*models.AuditArchive, []byte, error) {
+	if s.store == nil {
+		return nil, nil, fmt.Errorf("audit archiving is not configured")
+	}
+
+	archived, err := s.archiveRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := s.store.Get(ctx, archived.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != archived.SHA256 {
+		logf(ctx, "Audit archive %s does not match its recorded SHA-256", archived.Key)
+		return nil, nil, fmt.Errorf("failed to verify audit archive %s: checksum mismatch", archived.Key)
+	}
+
+	return archived, body, nil
+}
+
+// RunScheduled is the scheduled archive job; it archives batches until
+// nothing is left to archive
+func (s *AuditArchiveService) RunScheduled(ctx context.Context) error {
+	for {
+		report, err := s.Run(ctx, nil, 0)
+		if err != nil {
+			return err
+		}
+		if report.ArchivesCreated > 0 {
+			log.Printf("Audit archive run: archived %d audit logs into %d archives", report.EntriesArchived, report.ArchivesCreated)
+		}
+		if !report.More {
+			return nil
+		}
+	}
+}
+
+func startOfUTCDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"task-management-api/clock"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/repository"
+)
+
+// AuditService records events in the audit log that no admin action
+// accounts for, such as sign-ups
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+	clock     clock.Clock
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository, clk clock.Clock) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		clock:     clk,
+	}
+}
+
+// Subscribe records new accounts. Call during startup.
+func (s *AuditService) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, s.userRegistered)
+}
+
+// userRegistered records a sign-up as user.register, naming the login
+// provider for accounts created through one
+func (s *AuditService) userRegistered(ctx context.Context, e events.UserRegistered) {
+	details := map[string]interface{}{"provider": "password"}
+	if e.Provider != "" {
+		details["provider"] = e.Provider
+	}
+	entry := models.NewAuditLog(e.User.ID, "user.register", "user", e.User.ID, details, s.clock.Now())
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, e.User.ID.Hex(), err)
+	}
+}
@@ -2,10 +2,18 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"net/http"
 	"strings"
+	"task-management-api/clock"
+	"task-management-api/events"
 	"task-management-api/models"
+	"task-management-api/notifications"
 	"task-management-api/repository"
 	"task-management-api/utils"
 	"time"
@@ -18,16 +26,62 @@ import (
 type contextKey string
 
 const userContextKey contextKey = "user"
+const sessionContextKey contextKey = "session"
+
+const emailVerificationTTL = 48 * time.Hour
+
+const (
+	defaultScopedTokenTTL = 24 * time.Hour
+	maxScopedTokenTTL     = 30 * 24 * time.Hour
+)
+
+// Email verification gates
+const (
+	VerificationGateNone  = "none"
+	VerificationGateLogin = "login"
+	VerificationGateTasks = "tasks"
+)
+
+type EmailVerificationConfig struct {
+	Gate      string
+	BaseURL   string
+	Mailer    notifications.EmailSender
+	Templates *notifications.Renderer
+}
+
+// LockoutConfig locks an account for Duration after MaxFailures consecutive
+// wrong passwords. A zero MaxFailures disables lockout.
+type LockoutConfig struct {
+	MaxFailures int
+	Duration    time.Duration
+}
 
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret []byte
+	userRepo        *repository.UserRepository
+	refreshRepo     *repository.RefreshTokenRepository
+	sessionRepo     *repository.SessionRepository
+	auditRepo       *repository.AuditRepository
+	jwtKeys         *JWTKeys
+	refreshTokenTTL time.Duration
+	verification    EmailVerificationConfig
+	lockout         LockoutConfig
+	bus             *events.Bus
+	clock           clock.Clock
 }
 
-func NewAuthService(userRepo *repository.UserRepository, secret string) *AuthService {
+// NewAuthService publishes new accounts to bus
+func NewAuthService(userRepo *repository.UserRepository, refreshRepo *repository.RefreshTokenRepository, sessionRepo *repository.SessionRepository, auditRepo *repository.AuditRepository, jwtKeys *JWTKeys, refreshTokenTTL time.Duration, verification EmailVerificationConfig, lockout LockoutConfig, bus *events.Bus, clk clock.Clock) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: []byte(secret),
+		userRepo:        userRepo,
+		refreshRepo:     refreshRepo,
+		sessionRepo:     sessionRepo,
+		auditRepo:       auditRepo,
+		jwtKeys:         jwtKeys,
+		refreshTokenTTL: refreshTokenTTL,
+		verification:    verification,
+		lockout:         lockout,
+		bus:             bus,
+		clock:           clk,
 	}
 }
 
@@ -37,8 +91,8 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 		return nil, fmt.Errorf("email, username, and password are required")
 	}
 
-	if len(req.Password) < 6 {
-		return nil, fmt.Errorf("password must be at least 6 characters")
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
 	}
 
 	// Check if user exists
@@ -52,16 +106,88 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// Create user
-	user := models.NewUser(req.Email, req.Username, string(hashedPassword), models.UserRoleUser)
+	verificationToken, verificationTokenHash, err := newSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create user, unverified until the emailed token comes back
+	now := s.clock.Now()
+	expiresAt := now.Add(emailVerificationTTL)
+	user := models.NewUser(req.Email, req.Username, string(hashedPassword), models.UserRoleUser, now)
+	user.EmailVerificationTokenHash = verificationTokenHash
+	user.EmailVerificationExpiresAt = &expiresAt
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.bus.Publish(ctx, events.UserRegistered{User: user})
 
+	s.sendVerification(ctx, user, verificationToken)
 	return user, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.LoginResponse, error) {
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return fmt.Errorf("token is required")
+	}
+
+	user, err := s.userRepo.FindByEmailVerificationTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return fmt.Errorf("invalid or expired verification token")
+	}
+
+	now := s.clock.Now()
+	if user.EmailVerificationExpiresAt == nil || now.After(*user.EmailVerificationExpiresAt) {
+		return fmt.Errorf("invalid or expired verification token")
+	}
+
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID, now); err != nil {
+		return err
+	}
+
+	// Welcome users once their address is known to be theirs
+	data := notifications.WelcomeData{Username: user.Username}
+	if err := s.verification.Templates.SendTemplate(ctx, s.verification.Mailer, user.Email, notifications.TemplateWelcome, data); err != nil {
+		logf(ctx, "Failed to send welcome mail to user %s: %v", user.ID.Hex(), err)
+	}
+	return nil
+}
+
+// ResendVerification issues a fresh token, replacing any earlier one. Unknown or
+// already verified addresses are ignored so the response does not reveal accounts.
+func (s *AuthService) ResendVerification(ctx context.Context, req *models.ResendVerificationRequest) error {
+	if req.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil || user.IsEmailVerified() {
+		return nil
+	}
+
+	token, tokenHash, err := newSecureToken()
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.SetEmailVerification(ctx, user.ID, tokenHash, s.clock.Now().Add(emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	s.sendVerification(ctx, user, token)
+	return nil
+}
+
+func (s *AuthService) sendVerification(ctx context.Context, user *models.User, token string) {
+	link := fmt.Sprintf("%s/api/v1/auth/verify?token=%s", strings.TrimRight(s.verification.BaseURL, "/"), token)
+
+	// The account exists either way; a lost mail can be re-sent
+	data := notifications.VerifyEmailData{Username: user.Username, Link: link}
+	if err := s.verification.Templates.SendTemplate(ctx, s.verification.Mailer, user.Email, notifications.TemplateVerifyEmail, data); err != nil {
+		logf(ctx, "Failed to send verification mail to user %s: %v", user.ID.Hex(), err)
+	}
+}
+
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, client models.ClientInfo) (*models.LoginResponse, error) {
 	// Validate input
 	if req.Email == "" || req.Password == "" {
 		return nil, fmt.Errorf("email and password are required")
@@ -73,75 +199,626 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
+	// Locked accounts are refused before the password is checked
+	now := s.clock.Now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		s.audit(ctx, models.NewAuditLog(user.ID, "user.login_locked", "user", user.ID, map[string]interface{}{"locked_until": *user.LockedUntil}, now))
+		return nil, fmt.Errorf("account is temporarily locked")
+	}
+
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		s.recordLoginFailure(ctx, user, now)
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
+	if user.FailedLoginCount > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ClearLoginFailures(ctx, user.ID); err != nil {
+			logf(ctx, "Failed to clear login failures for user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	if s.verification.Gate == VerificationGateLogin && !user.IsEmailVerified() {
+		return nil, fmt.Errorf("email not verified")
+	}
+
+	return s.issueTokens(ctx, user, client)
+}
+
+func (s *AuthService) recordLoginFailure(ctx context.Context, user *models.User, now time.Time) {
+	if s.lockout.MaxFailures <= 0 {
+		return
+	}
+
+	lockedUntil := now.Add(s.lockout.Duration)
+	updated, err := s.userRepo.RecordLoginFailure(ctx, user.ID, s.lockout.MaxFailures, lockedUntil)
+	if err != nil {
+		logf(ctx, "Failed to record login failure for user %s: %v", user.ID.Hex(), err)
+		return
+	}
+	// The count only drops back to zero when this failure locked the account
+	if updated.FailedLoginCount == 0 {
+		details := map[string]interface{}{"failures": s.lockout.MaxFailures, "locked_until": lockedUntil}
+		s.audit(ctx, models.NewAuditLog(user.ID, "user.locked", "user", user.ID, details, now))
+	}
+}
+
+func (s *AuthService) audit(ctx context.Context, entry *models.AuditLog) {
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, entry.TargetID.Hex(), err)
+	}
+}
+
+// LoginWithOAuth signs in the user linked to an external identity. Unknown
+// identities are linked to the account with the same email when the provider
+// vouches for that email, and otherwise get a new account without a password.
+func (s *AuthService) LoginWithOAuth(ctx context.Context, profile *OAuthProfile, client models.ClientInfo) (*models.LoginResponse, error) {
+	user, err := s.userRepo.FindByIdentity(ctx, profile.Provider, profile.Subject)
+	if err == nil {
+		if s.verification.Gate == VerificationGateLogin && !user.IsEmailVerified() {
+			return nil, fmt.Errorf("email not verified")
+		}
+		if err := s.syncRole(ctx, user, profile); err != nil {
+			return nil, err
+		}
+		return s.issueTokens(ctx, user, client)
+	}
+	if err.Error() != "user not found" {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	identity := models.Identity{
+		Provider: profile.Provider,
+		Subject:  profile.Subject,
+		Email:    profile.Email,
+		LinkedAt: now,
+	}
+
+	existing, err := s.userRepo.FindByEmail(ctx, profile.Email)
+	if err == nil {
+		if !profile.EmailVerified {
+			return nil, fmt.Errorf("user with this email already exists")
+		}
+		if err := s.userRepo.AddIdentity(ctx, existing.ID, identity); err != nil {
+			return nil, err
+		}
+		existing.Identities = append(existing.Identities, identity)
+		if err := s.syncRole(ctx, existing, profile); err != nil {
+			return nil, err
+		}
+		return s.issueTokens(ctx, existing, client)
+	}
+	if err.Error() != "user not found" {
+		return nil, err
+	}
+
+	// Password login stays impossible until the user sets one through a reset
+	unusablePassword, _, err := newSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(unusablePassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	username := profile.Name
+	if username == "" {
+		username = strings.SplitN(profile.Email, "@", 2)[0]
+	}
+
+	role := models.UserRoleUser
+	if profile.Role != "" {
+		role = profile.Role
+	}
+	user = models.NewUser(profile.Email, username, string(hashedPassword), role, now)
+	user.Identities = []models.Identity{identity}
+
+	var verificationToken string
+	if profile.EmailVerified {
+		user.EmailVerifiedAt = &now
+	} else {
+		token, tokenHash, err := newSecureToken()
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := now.Add(emailVerificationTTL)
+		verificationToken = token
+		user.EmailVerificationTokenHash = tokenHash
+		user.EmailVerificationExpiresAt = &expiresAt
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	s.bus.Publish(ctx, events.UserRegistered{User: user, Provider: profile.Provider})
+
+	if verificationToken != "" {
+		s.sendVerification(ctx, user, verificationToken)
+		if s.verification.Gate == VerificationGateLogin {
+			return nil, fmt.Errorf("email not verified")
+		}
+	}
+	return s.issueTokens(ctx, user, client)
+}
+
+// syncRole applies the role a provider maps the user's claims to. A change
+// replaces any custom permissions with the new role's defaults.
+func (s *AuthService) syncRole(ctx context.Context, user *models.User, profile *OAuthProfile) error {
+	if profile.Role == "" || profile.Role == user.Role {
+		return nil
+	}
+
+	permissions := models.RolePermissions[profile.Role]
+	if err := s.userRepo.SetRole(ctx, user.ID, profile.Role, permissions); err != nil {
+		return err
+	}
+	s.audit(ctx, models.NewAuditLog(user.ID, "user.role_sync", "user", user.ID, map[string]interface{}{
+		"provider": profile.Provider,
+		"from":     user.Role,
+		"to":       profile.Role,
+	}, s.clock.Now()))
+
+	user.Role = profile.Role
+	user.Permissions = permissions
+	return nil
+}
+
+func (s *AuthService) Refresh(ctx context.Context, req *models.RefreshRequest, client models.ClientInfo) (*models.LoginResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, fmt.Errorf("refresh_token is required")
+	}
+
+	current, err := s.refreshRepo.FindByHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	now := s.clock.Now()
+
+	// A rotated token being presented again means it leaked; end every session of the user
+	if current.RevokedAt != nil {
+		if current.ReplacedBy != nil {
+			if err := s.RevokeSessions(ctx, current.UserID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	if now.After(current.ExpiresAt) {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, current.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	// Tokens issued before sessions were tracked start one on their first refresh
+	var session *models.Session
+	if current.SessionID.IsZero() {
+		session, err = s.startSession(ctx, user, client)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		session, err = s.sessionRepo.FindByID(ctx, current.SessionID)
+		if err != nil || session.RevokedAt != nil {
+			return nil, fmt.Errorf("invalid refresh token")
+		}
+	}
+
+	response, next, err := s.issueTokensWithRefresh(ctx, user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.Touch(ctx, session.ID, client, next.ExpiresAt, now); err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.refreshRepo.Revoke(ctx, current.ID, &next.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		// Lost a race with a concurrent rotation of the same token
+		if _, err := s.refreshRepo.Revoke(ctx, next.ID, nil, now); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	return response, nil
+}
+
+// RevokeSessions invalidates all access and refresh tokens issued to the user so far.
+func (s *AuthService) RevokeSessions(ctx context.Context, userID primitive.ObjectID) error {
+	now := s.clock.Now()
+	if err := s.userRepo.RevokeTokens(ctx, userID, now); err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeAllForUser(ctx, userID, now)
+}
+
+// ListSessions returns the user's active sessions, flagging the one the request came from
+func (s *AuthService) ListSessions(ctx context.Context, user *models.User, currentID primitive.ObjectID) ([]*models.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(ctx, user.ID, user.TokensRevokedAt, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions, invalidating its access and refresh tokens
+func (s *AuthService) RevokeSession(ctx context.Context, user *models.User, sessionID primitive.ObjectID) error {
+	now := s.clock.Now()
+	if err := s.sessionRepo.Revoke(ctx, sessionID, user.ID, now); err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeAllForSession(ctx, sessionID, now)
+}
+
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User, client models.ClientInfo) (*models.LoginResponse, error) {
+	session, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	response, refreshToken, err := s.issueTokensWithRefresh(ctx, user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.Touch(ctx, session.ID, client, refreshToken.ExpiresAt, session.CreatedAt); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (s *AuthService) startSession(ctx context.Context, user *models.User, client models.ClientInfo) (*models.Session, error) {
+	now := s.clock.Now()
+	session := &models.Session{
+		UserID:     user.ID,
+		Device:     describeDevice(client.UserAgent),
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.refreshTokenTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *AuthService) issueTokensWithRefresh(ctx context.Context, user *models.User, sessionID primitive.ObjectID) (*models.LoginResponse, *models.RefreshToken, error) {
 	// Generate JWT token
-	token, err := s.generateToken(user)
+	token, err := s.generateToken(user, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	rawRefreshToken, refreshTokenHash, err := newSecureToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.clock.Now()
+	refreshToken := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: refreshTokenHash,
+		ExpiresAt: now.Add(s.refreshTokenTTL),
+		CreatedAt: now,
+		SessionID: sessionID,
+	}
+	if err := s.refreshRepo.Create(ctx, refreshToken); err != nil {
+		return nil, nil, err
 	}
 
 	return &models.LoginResponse{
-		Token: token,
-		User:  user,
-	}, nil
+		Token:        token,
+		RefreshToken: rawRefreshToken,
+		User:         user,
+	}, refreshToken, nil
 }
 
-func (s *AuthService) generateToken(user *models.User) (string, error) {
-	claims := jwt.MapClaims{
+// IssueScopedToken mints an access token limited to the requested scopes, e.g. a
+// read-only token for a dashboard. It has no refresh token and no session, so it
+// lasts until it expires or all of the user's sessions are revoked.
+func (s *AuthService) IssueScopedToken(ctx context.Context, user *models.User, req *models.CreateScopedTokenRequest) (*models.ScopedTokenResponse, error) {
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("scopes is required")
+	}
+	if err := validateScopes(req.Scopes); err != nil {
+		return nil, err
+	}
+
+	ttl := defaultScopedTokenTTL
+	if req.ExpiresInHours != 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+		if ttl < time.Hour || ttl > maxScopedTokenTTL {
+			return nil, fmt.Errorf("expires_in_hours must be between 1 and %d", int(maxScopedTokenTTL.Hours()))
+		}
+	}
+
+	now := s.clock.Now()
+	expiresAt := now.Add(ttl)
+	token, err := s.jwtKeys.sign(jwt.MapClaims{
 		"user_id": user.ID.Hex(),
 		"email":   user.Email,
 		"role":    user.Role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"scopes":  req.Scopes,
+		"iat":     issuedAtClaim(now),
+		"exp":     expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	details := map[string]interface{}{"scopes": req.Scopes, "expires_at": expiresAt}
+	s.audit(ctx, models.NewAuditLog(user.ID, "token.scoped_issue", "user", user.ID, details, now))
+
+	return &models.ScopedTokenResponse{
+		Token:     token,
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+	}, nil
 }
 
-func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*models.User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.jwtSecret, nil
-	})
+func (s *AuthService) generateToken(user *models.User, sessionID primitive.ObjectID) (string, error) {
+	now := s.clock.Now()
+	claims := jwt.MapClaims{
+		"user_id":     user.ID.Hex(),
+		"email":       user.Email,
+		"role":        user.Role,
+		"permissions": user.EffectivePermissions(),
+		"sid":         sessionID.Hex(),
+		"iat":         issuedAtClaim(now),
+		"exp":         now.Add(24 * time.Hour).Unix(),
+	}
+
+	return s.jwtKeys.sign(claims)
+}
+
+// issuedAtClaim is the iat claim for now: seconds with a millisecond fraction,
+// the precision MongoDB keeps TokensRevokedAt at, so a token issued right after
+// a revocation in the same second is not taken for one issued before it
+func issuedAtClaim(now time.Time) float64 {
+	return float64(now.UnixMilli()) / 1000
+}
+
+// tokenIssuedAt reads the iat claim to the millisecond; the jwt package's own
+// accessor truncates it to whole seconds
+func tokenIssuedAt(claims jwt.MapClaims) (time.Time, bool) {
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(int64(math.Round(iat * 1000))), true
+}
+
+// JWKS returns the public keys that verify access tokens
+func (s *AuthService) JWKS() *models.JWKSet {
+	return s.jwtKeys.JWKS()
+}
+
+// AccessToken is what a validated access token carries besides its user.
+// Scopes is nil for regular login tokens, which are not limited by scopes.
+type AccessToken struct {
+	SessionID primitive.ObjectID
+	Scopes    []string
+}
+
+// ValidateToken returns the token's user, session and scopes
+func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*models.User, *AccessToken, error) {
+	token, err := jwt.Parse(tokenString, s.jwtKeys.keyFunc, jwt.WithTimeFunc(s.clock.Now))
 
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		return nil, nil, fmt.Errorf("invalid token: %w", err)
 	}
 
 	if !token.Valid {
-		return nil, fmt.Errorf("token is not valid")
+		return nil, nil, fmt.Errorf("token is not valid")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, fmt.Errorf("invalid token claims")
+		return nil, nil, fmt.Errorf("invalid token claims")
 	}
 
 	userIDStr, ok := claims["user_id"].(string)
 	if !ok {
-		return nil, fmt.Errorf("invalid user_id in token")
+		return nil, nil, fmt.Errorf("invalid user_id in token")
 	}
 
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user_id format: %w", err)
+		return nil, nil, fmt.Errorf("invalid user_id format: %w", err)
 	}
 
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, nil, fmt.Errorf("user not found: %w", err)
 	}
 
-	return user, nil
+	// Reject tokens issued before the user's sessions were revoked
+	if user.TokensRevokedAt != nil {
+		issuedAt, ok := tokenIssuedAt(claims)
+		if !ok || issuedAt.Before(*user.TokensRevokedAt) {
+			return nil, nil, fmt.Errorf("token has been revoked")
+		}
+	}
+
+	// Tokens issued before sessions were tracked carry no session ID
+	var sessionID primitive.ObjectID
+	if sid, ok := claims["sid"].(string); ok {
+		sessionID, err = primitive.ObjectIDFromHex(sid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid sid format: %w", err)
+		}
+		session, err := s.sessionRepo.FindByID(ctx, sessionID)
+		if err != nil || session.RevokedAt != nil {
+			return nil, nil, fmt.Errorf("token has been revoked")
+		}
+	}
+
+	access := &AccessToken{SessionID: sessionID}
+	if raw, ok := claims["scopes"].([]interface{}); ok {
+		access.Scopes = []string{}
+		for _, scope := range raw {
+			if scope, ok := scope.(string); ok {
+				access.Scopes = append(access.Scopes, scope)
+			}
+		}
+	}
+
+	return user, access, nil
+}
+
+func (s *AuthService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	// Validate input
+	if req.Token == "" || req.NewPassword == "" {
+		return fmt.Errorf("token and new_password are required")
+	}
+
+	if err := validatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByPasswordResetTokenHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return fmt.Errorf("invalid or expired reset token")
+	}
+
+	if user.PasswordResetExpiresAt == nil || s.clock.Now().After(*user.PasswordResetExpiresAt) {
+		return fmt.Errorf("invalid or expired reset token")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := s.clock.Now()
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword), now); err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeAllForUser(ctx, user.ID, now)
+}
+
+// UpdateProfile changes username, email and preferences. A new email starts unverified and gets
+// a fresh verification mail; the unique index catches addresses already in use.
+func (s *AuthService) UpdateProfile(ctx context.Context, user *models.User, req *models.UpdateProfileRequest) (*models.User, error) {
+	// Validate input
+	if req.Username == nil && req.Email == nil && req.Preferences == nil {
+		return nil, fmt.Errorf("username, email or preferences is required")
+	}
+
+	updated := *user
+	if req.Username != nil {
+		if *req.Username == "" {
+			return nil, fmt.Errorf("username must not be empty")
+		}
+		updated.Username = *req.Username
+	}
+
+	var verificationToken, verificationTokenHash string
+	var verificationExpiresAt time.Time
+	if req.Email != nil && *req.Email != user.Email {
+		if *req.Email == "" {
+			return nil, fmt.Errorf("email must not be empty")
+		}
+
+		var err error
+		verificationToken, verificationTokenHash, err = newSecureToken()
+		if err != nil {
+			return nil, err
+		}
+		verificationExpiresAt = s.clock.Now().Add(emailVerificationTTL)
+
+		updated.Email = *req.Email
+		updated.EmailVerifiedAt = nil
+		updated.EmailVerificationTokenHash = verificationTokenHash
+		updated.EmailVerificationExpiresAt = &verificationExpiresAt
+	}
+
+	if err := s.userRepo.UpdateProfile(ctx, user.ID, updated.Username, updated.Email, verificationTokenHash, verificationExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if req.Preferences != nil {
+		if req.Preferences.AchievementsOptOut != nil {
+			updated.Preferences.AchievementsOptOut = *req.Preferences.AchievementsOptOut
+		}
+		if err := s.userRepo.SetPreferences(ctx, user.ID, updated.Preferences); err != nil {
+			return nil, err
+		}
+	}
+
+	if verificationToken != "" {
+		s.sendVerification(ctx, &updated, verificationToken)
+	}
+	return &updated, nil
+}
+
+// ChangePassword replaces the password after re-checking the current one and ends
+// every existing session, including the one used to make the request.
+func (s *AuthService) ChangePassword(ctx context.Context, user *models.User, req *models.ChangePasswordRequest) error {
+	// Validate input
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return fmt.Errorf("current_password and new_password are required")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return fmt.Errorf("current password is incorrect")
+	}
+
+	if err := validatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return fmt.Errorf("new password must differ from the current password")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := s.clock.Now()
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword), now); err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeAllForUser(ctx, user.ID, now)
+}
+
+func validatePassword(password string) error {
+	if len(password) < 6 {
+		return fmt.Errorf("password must be at least 6 characters")
+	}
+	return nil
 }
 
+// AuthMiddleware requires a regular login token; scoped tokens are refused so
+// they can never reach account or admin routes.
 func (s *AuthService) AuthMiddleware(next http.Handler) http.Handler {
+	return s.authenticate(next, false)
+}
+
+// ScopedAuthMiddleware also accepts scoped tokens, limiting the request to
+// their scopes; routes behind it must enforce them with RequireScope.
+func (s *AuthService) ScopedAuthMiddleware(next http.Handler) http.Handler {
+	return s.authenticate(next, true)
+}
+
+func (s *AuthService) authenticate(next http.Handler, allowScoped bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -155,17 +832,74 @@ func (s *AuthService) AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		user, err := s.ValidateToken(r.Context(), parts[1])
+		user, access, err := s.ValidateToken(r.Context(), parts[1])
 		if err != nil {
 			utils.RespondError(w, http.StatusUnauthorized, "invalid or expired token")
 			return
 		}
+		if access.Scopes != nil && !allowScoped {
+			utils.RespondError(w, http.StatusForbidden, "scoped tokens are not accepted here")
+			return
+		}
 
-		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx := withUser(r.Context(), user)
+		ctx = context.WithValue(ctx, sessionContextKey, access.SessionID)
+		if access.Scopes != nil {
+			ctx = withScopes(ctx, "token", access.Scopes)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequirePermission rejects users without the permission. It checks the user
+// loaded by AuthMiddleware, so revoked permissions apply before the token expires.
+func (s *AuthService) RequirePermission(permission models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := GetUserFromContext(r.Context())
+			if err != nil {
+				utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			if !user.HasPermission(permission) {
+				utils.RespondError(w, http.StatusForbidden, "permission "+string(permission)+" required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireVerifiedEmail rejects unverified users when task creation is gated on verification.
+func (s *AuthService) RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.verification.Gate == VerificationGateTasks {
+			user, err := GetUserFromContext(r.Context())
+			if err != nil {
+				utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			if err := s.CheckVerifiedEmail(user); err != nil {
+				utils.RespondError(w, http.StatusForbidden, err.Error())
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CheckVerifiedEmail fails when the verification gate keeps the user from
+// creating tasks
+func (s *AuthService) CheckVerifiedEmail(user *models.User) error {
+	if s.verification.Gate == VerificationGateTasks && !user.IsEmailVerified() {
+		return fmt.Errorf("email not verified")
+	}
+	return nil
+}
+
 func GetUserFromContext(ctx context.Context) (*models.User, error) {
 	user, ok := ctx.Value(userContextKey).(*models.User)
 	if !ok {
@@ -173,3 +907,25 @@ func GetUserFromContext(ctx context.Context) (*models.User, error) {
 	}
 	return user, nil
 }
+
+// GetSessionIDFromContext returns the session of the request's access token;
+// it is the zero ID for API keys and tokens issued before sessions were tracked.
+func GetSessionIDFromContext(ctx context.Context) primitive.ObjectID {
+	sessionID, _ := ctx.Value(sessionContextKey).(primitive.ObjectID)
+	return sessionID
+}
+
+// newSecureToken returns a random URL-safe token and the hash stored in its place.
+func newSecureToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
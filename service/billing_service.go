@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"task-management-api/billing"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuotaCheck is asked before records are added for an account, and fails
+// when adding them would take the account over its limit
+type QuotaCheck func(ctx context.Context, userID primitive.ObjectID, adding int) error
+
+type BillingConfig struct {
+	// Enabled turns on enforcement; self-hosted deployments leave it off
+	Enabled     bool
+	Plans       map[string]billing.Limits
+	DefaultPlan string
+	// TrialPlan, if set, is the plan of accounts without a subscription for
+	// their first TrialDays days
+	TrialPlan string
+	TrialDays int
+	// UpgradeURL is where 402 responses send users to change their plan
+	UpgradeURL string
+	// Prices maps provider price IDs or lookup keys to plans
+	Prices map[string]string
+}
+
+// PlanLimitError is returned when an account's plan lacks a feature or would
+// go over one of its limits
+type PlanLimitError struct {
+	Plan string
+	// Feature is the premium feature the plan lacks; when it is empty, the
+	// plan allows at most Max of Limit
+	Feature    string
+	Limit      string
+	Max        int
+	UpgradeURL string
+}
+
+func (e *PlanLimitError) Error() string {
+	if e.Feature != "" {
+		return fmt.Sprintf("the %s plan does not include %s", e.Plan, e.Feature)
+	}
+	return fmt.Sprintf("plan limit reached: the %s plan allows %d %s", e.Plan, e.Max, e.Limit)
+}
+
+// Details are the error's fields for 402 responses
+func (e *PlanLimitError) Details() map[string]interface{} {
+	details := map[string]interface{}{"plan": e.Plan}
+	if e.Feature != "" {
+		details["feature"] = e.Feature
+	} else {
+		details["limit"] = e.Limit
+		details["max"] = e.Max
+	}
+	if e.UpgradeURL != "" {
+		details["upgrade_url"] = e.UpgradeURL
+	}
+	return details
+}
+
+// accountPlan is the plan in effect for an account and what grants it
+type accountPlan struct {
+	name         string
+	subscription *models.Subscription
+	trialEndsAt  *time.Time
+}
+
+// BillingService applies subscription changes reported by payment providers
+// and enforces the limits of each account's plan.
+type BillingService struct {
+	subscriptionRepo *repository.SubscriptionRepository
+	userRepo         *repository.UserRepository
+	taskRepo         *repository.TaskRepository
+	webhookRepo      *repository.WebhookRepository
+	auditRepo        *repository.AuditRepository
+	providers        map[string]billing.Provider
+	config           BillingConfig
+	clock            clock.Clock
+}
+
+func NewBillingService(subscriptionRepo *repository.SubscriptionRepository, userRepo *repository.UserRepository, taskRepo *repository.TaskRepository, webhookRepo *repository.WebhookRepository, auditRepo *repository.AuditRepository, config BillingConfig, clk clock.Clock, providers ...billing.Provider) *BillingService {
+	byName := make(map[string]billing.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return &BillingService{
+		subscriptionRepo: subscriptionRepo,
+		userRepo:         userRepo,
+		taskRepo:         taskRepo,
+		webhookRepo:      webhookRepo,
+		auditRepo:        auditRepo,
+		providers:        byName,
+		config:           config,
+		clock:            clk,
+	}
+}
+
+// HandleEvent applies a provider's webhook event. Events that cannot be tied
+// to an account are logged and acknowledged, so the provider stops resending them.
+func (s *BillingService) HandleEvent(ctx context.Context, providerName string, header http.Header, body []byte) error {
+	provider, ok := s.providers[providerName]
+	if !ok || !s.config.Enabled {
+		return fmt.Errorf("billing provider not found")
+	}
+
+	event, err := provider.ParseEvent(header, body)
+	if err != nil || event == nil {
+		return err
+	}
+
+	userID, err := s.eventUser(ctx, provider.Name(), event)
+	if err != nil {
+		logf(ctx, "Ignoring %s event %s for subscription %s: %v", provider.Name(), event.ID, event.SubscriptionID, err)
+		return nil
+	}
+
+	plan, ok := s.config.Prices[event.Price]
+	if !ok {
+		plan, ok = s.config.Prices[event.LookupKey]
+	}
+	if !ok {
+		if _, named := s.config.Plans[event.LookupKey]; named {
+			plan = event.LookupKey
+		} else {
+			logf(ctx, "Subscription %s has unmapped price %s, using the %s plan", event.SubscriptionID, event.Price, s.config.DefaultPlan)
+			plan = s.config.DefaultPlan
+		}
+	}
+
+	subscription := &models.Subscription{
+		UserID:           userID,
+		Provider:         provider.Name(),
+		CustomerID:       event.CustomerID,
+		SubscriptionID:   event.SubscriptionID,
+		Plan:             plan,
+		Status:           event.Status,
+		CurrentPeriodEnd: event.CurrentPeriodEnd,
+		EventAt:          event.CreatedAt,
+		UpdatedAt:        s.clock.Now(),
+	}
+	saved, err := s.subscriptionRepo.Save(ctx, subscription)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		logf(ctx, "Skipping %s event %s, a newer one was already applied", provider.Name(), event.ID)
+		return nil
+	}
+
+	logf(ctx, "Subscription of user %s is now %s on the %s plan", userID.Hex(), event.Status, plan)
+	return nil
+}
+
+// eventUser finds the account by the metadata set at checkout, or by the
+// customer of an earlier event
+func (s *BillingService) eventUser(ctx context.Context, provider string, event *billing.Event) (primitive.ObjectID, error) {
+	if event.UserID != "" {
+		userID, err := primitive.ObjectIDFromHex(event.UserID)
+		if err != nil {
+			return primitive.NilObjectID, fmt.Errorf("invalid user_id metadata %q", event.UserID)
+		}
+		if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+			return primitive.NilObjectID, err
+		}
+		return userID, nil
+	}
+
+	existing, err := s.subscriptionRepo.FindByCustomerID(ctx, provider, event.CustomerID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return existing.UserID, nil
+}
+
+// planFor returns the account's plan: the one its subscription grants, else
+// the trial plan while the trial lasts, else the default plan
+func (s *BillingService) planFor(ctx context.Context, userID primitive.ObjectID) (*accountPlan, error) {
+	subscription, err := s.subscriptionRepo.FindByUserID(ctx, userID)
+	if err != nil && err.Error() != "subscription not found" {
+		return nil, err
+	}
+	if subscription != nil {
+		if _, known := s.config.Plans[subscription.Plan]; known && billing.GrantsPlan(subscription.Status) {
+			return &accountPlan{name: subscription.Plan, subscription: subscription}, nil
+		}
+	}
+
+	if s.config.TrialPlan != "" {
+		user, err := s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		trialEndsAt := user.CreatedAt.AddDate(0, 0, s.config.TrialDays)
+		if s.clock.Now().Before(trialEndsAt) {
+			return &accountPlan{name: s.config.TrialPlan, subscription: subscription, trialEndsAt: &trialEndsAt}, nil
+		}
+	}
+	return &accountPlan{name: s.config.DefaultPlan, subscription: subscription}, nil
+}
+
+// RequireFeature answers 402 Payment Required to users whose plan lacks the
+// feature, and passes other requests, or all of them without billing, to next
+func (s *BillingService) RequireFeature(feature string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.config.Enabled {
+				user, err := GetUserFromContext(r.Context())
+				if err != nil {
+					utils.RespondError(w, http.StatusUnauthorized, "unauthorized")
+					return
+				}
+				plan, err := s.planFor(r.Context(), user.ID)
+				if err != nil {
+					utils.RespondError(w, http.StatusInternalServerError, "failed to check plan")
+					return
+				}
+				if !s.config.Plans[plan.name].Includes(feature) {
+					limitErr := &PlanLimitError{Plan: plan.name, Feature: feature, UpgradeURL: s.config.UpgradeURL}
+					s.limitReached(r.Context(), user.ID, limitErr)
+					utils.RespondErrorDetails(w, http.StatusPaymentRequired, limitErr.Error(), limitErr.Details())
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitReached records that the user was refused by their plan, so operators
+// can see who outgrows theirs
+func (s *BillingService) limitReached(ctx context.Context, userID primitive.ObjectID, limitErr *PlanLimitError) {
+	details := limitErr.Details()
+	delete(details, "upgrade_url")
+	entry := models.NewAuditLog(userID, "billing.plan_limit_reached", "user", userID, details, s.clock.Now())
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, userID.Hex(), err)
+	}
+}
+
+// CheckTasks is the QuotaCheck for tasks
+func (s *BillingService) CheckTasks(ctx context.Context, userID primitive.ObjectID, adding int) error {
+	return s.check(ctx, userID, adding, "tasks", func(limits billing.Limits) int { return limits.Tasks }, s.taskRepo.CountByUserID)
+}
+
+// CheckWebhooks is the QuotaCheck for webhooks
+func (s *BillingService) CheckWebhooks(ctx context.Context, userID primitive.ObjectID, adding int) error {
+	return s.check(ctx, userID, adding, "webhooks", func(limits billing.Limits) int { return limits.Webhooks }, s.webhookRepo.CountByUserID)
+}
+
+func (s *BillingService) check(ctx context.Context, userID primitive.ObjectID, adding int, what string, limit func(billing.Limits) int, count func(context.Context, primitive.ObjectID) (int64, error)) error {
+	if !s.config.Enabled {
+		return nil
+	}
+
+	plan, err := s.planFor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	max := limit(s.config.Plans[plan.name])
+	if max == 0 {
+		return nil
+	}
+
+	current, err := count(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if current+int64(adding) > int64(max) {
+		limitErr := &PlanLimitError{Plan: plan.name, Limit: what, Max: max, UpgradeURL: s.config.UpgradeURL}
+		s.limitReached(ctx, userID, limitErr)
+		return limitErr
+	}
+	return nil
+}
+
+// GetSubscription reports the account's plan, its limits and how much of them is used
+func (s *BillingService) GetSubscription(ctx context.Context, user *models.User) (*models.SubscriptionResponse, error) {
+	if !s.config.Enabled {
+		return nil, fmt.Errorf("billing is not enabled")
+	}
+
+	plan, err := s.planFor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.taskRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := s.webhookRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := func(n int) *models.Int64 {
+		if n == 0 {
+			return nil
+		}
+		value := models.Int64(n)
+		return &value
+	}
+	limits := s.config.Plans[plan.name]
+	response := &models.SubscriptionResponse{
+		Plan:        plan.name,
+		Status:      "none",
+		Features:    append([]string{}, limits.Features...),
+		Limits:      models.PlanLimits{Tasks: limit(limits.Tasks), Webhooks: limit(limits.Webhooks)},
+		Usage:       models.PlanUsage{Tasks: models.Int64(tasks), Webhooks: models.Int64(webhooks)},
+		TrialEndsAt: plan.trialEndsAt,
+		UpgradeURL:  s.config.UpgradeURL,
+	}
+	if plan.subscription != nil {
+		response.Status = plan.subscription.Status
+		response.CurrentPeriodEnd = plan.subscription.CurrentPeriodEnd
+	}
+	if plan.trialEndsAt != nil {
+		response.Status = billing.StatusTrialing
+	}
+
+	return response, nil
+}
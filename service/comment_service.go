@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxCommentLength = 5000
+
+type CommentService struct {
+	commentRepo *repository.CommentRepository
+	taskQueries *TaskQueryService
+	clock       clock.Clock
+}
+
+func NewCommentService(commentRepo *repository.CommentRepository, taskQueries *TaskQueryService, clk clock.Clock) *CommentService {
+	return &CommentService{
+		commentRepo: commentRepo,
+		taskQueries: taskQueries,
+		clock:       clk,
+	}
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, taskID primitive.ObjectID, user *models.User, req *models.CreateCommentRequest) (*models.Comment, error) {
+	// Comments follow the task's authorization rules
+	if _, err := s.taskQueries.GetTask(ctx, taskID, user); err != nil {
+		return nil, err
+	}
+
+	// Validate input
+	if req.Body == "" {
+		return nil, fmt.Errorf("body is required")
+	}
+
+	if len(req.Body) > maxCommentLength {
+		return nil, fmt.Errorf("body must be at most %d characters", maxCommentLength)
+	}
+
+	comment := models.NewComment(taskID, user.ID, req.Body, s.clock.Now())
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) ListComments(ctx context.Context, taskID primitive.ObjectID, user *models.User, filter repository.CommentFilter) (*models.CommentListResponse, error) {
+	if _, err := s.taskQueries.GetTask(ctx, taskID, user); err != nil {
+		return nil, err
+	}
+
+	comments, totalCount, err := s.commentRepo.FindByTaskID(ctx, taskID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewCommentListResponse(comments, filter.Page, filter.Limit, totalCount), nil
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, taskID, commentID primitive.ObjectID, user *models.User) error {
+	// Anyone who can access the task (its owner or an admin) can moderate its comments
+	if _, err := s.taskQueries.GetTask(ctx, taskID, user); err != nil {
+		return err
+	}
+
+	comment, err := s.commentRepo.FindByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.TaskID != taskID {
+		return fmt.Errorf("comment not found")
+	}
+
+	return s.commentRepo.Delete(ctx, commentID)
+}
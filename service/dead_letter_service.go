@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/queue"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeadLetterService lets admins inspect jobs that failed on every attempt and
+// put them back on their queue
+type DeadLetterService struct {
+	deadLetterRepo *repository.DeadLetterRepository
+	auditRepo      *repository.AuditRepository
+	queues         map[string]queue.Queue
+	clock          clock.Clock
+}
+
+// NewDeadLetterService takes the queues dead letters can be requeued to, by name
+func NewDeadLetterService(deadLetterRepo *repository.DeadLetterRepository, auditRepo *repository.AuditRepository, queues map[string]queue.Queue, clk clock.Clock) *DeadLetterService {
+	return &DeadLetterService{
+		deadLetterRepo: deadLetterRepo,
+		auditRepo:      auditRepo,
+		queues:         queues,
+		clock:          clk,
+	}
+}
+
+func (s *DeadLetterService) List(ctx context.Context, filter repository.DeadLetterFilter) (*models.DeadLetterListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 10
+	}
+
+	deadLetters, totalCount, err := s.deadLetterRepo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewDeadLetterListResponse(deadLetters, filter.Page, filter.Limit, totalCount), nil
+}
+
+func (s *DeadLetterService) Get(ctx context.Context, id primitive.ObjectID) (*models.DeadLetter, error) {
+	return s.deadLetterRepo.FindByID(ctx, id)
+}
+
+// Requeue queues the job again with fresh attempts and removes the dead letter
+func (s *DeadLetterService) Requeue(ctx context.Context, actor *models.User, id primitive.ObjectID) (*models.DeadLetterRequeueResult, error) {
+	deadLetter, err := s.deadLetterRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	jobs, ok := s.queues[deadLetter.Queue]
+	if !ok {
+		return nil, fmt.Errorf("queue %s no longer exists", deadLetter.Queue)
+	}
+
+	now := s.clock.Now()
+	added, err := jobs.Enqueue(ctx, deadLetter.Key, now)
+	if err != nil {
+		return nil, err
+	}
+	// Enqueueing is idempotent, so losing a race to another requeue only
+	// reports the dead letter as gone
+	if err := s.deadLetterRepo.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{"queue": deadLetter.Queue, "key": deadLetter.Key}
+	if err := s.auditRepo.Create(ctx, models.NewAuditLog(actor.ID, "dead_letter.requeued", "dead_letter", id, details, now)); err != nil {
+		logf(ctx, "Failed to record audit log dead_letter.requeued for %s: %v", id.Hex(), err)
+	}
+
+	return &models.DeadLetterRequeueResult{DeadLetter: deadLetter, AlreadyQueued: !added}, nil
+}
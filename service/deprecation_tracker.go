@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	deprecationContextKey contextKey = "deprecations"
+	// deprecationFlushInterval is how long counts stay in memory before they are stored
+	deprecationFlushInterval = time.Minute
+	maxDeprecationClients    = 100
+	maxUserAgentLength       = 256
+)
+
+// deprecationRequest collects the deprecations a request used
+type deprecationRequest struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+type deprecationKey struct {
+	deprecation string
+	route       string
+	userID      primitive.ObjectID
+	apiKeyID    primitive.ObjectID
+	userAgent   string
+}
+
+type deprecationCount struct {
+	requests  int64
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// DeprecationTracker counts requests using deprecated routes and fields per
+// client, so maintainers can see who still has to migrate before a removal.
+// Routes are annotated with Track; handlers report deprecated fields with
+// RecordDeprecation.
+type DeprecationTracker struct {
+	deprecationRepo *repository.DeprecationRepository
+	userRepo        *repository.UserRepository
+	apiKeyRepo      *repository.APIKeyRepository
+	deprecations    []models.Deprecation
+	clock           clock.Clock
+
+	mu      sync.Mutex
+	pending map[deprecationKey]*deprecationCount
+}
+
+func NewDeprecationTracker(deprecationRepo *repository.DeprecationRepository, userRepo *repository.UserRepository, apiKeyRepo *repository.APIKeyRepository, clk clock.Clock, deprecations ...models.Deprecation) *DeprecationTracker {
+	return &DeprecationTracker{
+		deprecationRepo: deprecationRepo,
+		userRepo:        userRepo,
+		apiKeyRepo:      apiKeyRepo,
+		deprecations:    deprecations,
+		clock:           clk,
+		pending:         make(map[deprecationKey]*deprecationCount),
+	}
+}
+
+// Middleware records the deprecations each request used once it is served.
+// It must run inside UsageTracker.Middleware, which learns who made the request.
+func (t *DeprecationTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		used := &deprecationRequest{}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), deprecationContextKey, used)))
+
+		used.mu.Lock()
+		ids := used.ids
+		used.mu.Unlock()
+		if len(ids) == 0 {
+			return
+		}
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		key := deprecationKey{route: r.Method + " " + route, userAgent: r.UserAgent()}
+		if len(key.userAgent) > maxUserAgentLength {
+			key.userAgent = key.userAgent[:maxUserAgentLength]
+		}
+		if usage, ok := r.Context().Value(usageContextKey).(*usageRequest); ok {
+			if usage.userID != nil {
+				key.userID = *usage.userID
+			}
+			if usage.apiKeyID != nil {
+				key.apiKeyID = *usage.apiKeyID
+			}
+		}
+		t.record(key, ids)
+	})
+}
+
+// Track annotates routes as using the deprecation
+func (t *DeprecationTracker) Track(id string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			RecordDeprecation(r.Context(), id)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RecordDeprecation notes that the request used the deprecation, such as a
+// deprecated field it sent. Each deprecation counts once per request.
+func RecordDeprecation(ctx context.Context, id string) {
+	used, ok := ctx.Value(deprecationContextKey).(*deprecationRequest)
+	if !ok {
+		return
+	}
+	used.mu.Lock()
+	defer used.mu.Unlock()
+	for _, existing := range used.ids {
+		if existing == id {
+			return
+		}
+	}
+	used.ids = append(used.ids, id)
+}
+
+func (t *DeprecationTracker) record(key deprecationKey, ids []string) {
+	now := t.clock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, id := range ids {
+		key.deprecation = id
+		count, ok := t.pending[key]
+		if !ok {
+			count = &deprecationCount{firstSeen: now}
+			t.pending[key] = count
+		}
+		count.requests++
+		count.lastSeen = now
+	}
+}
+
+// Start stores the counts every minute, and once more on shutdown
+func (t *DeprecationTracker) Start(ctx context.Context) {
+	log.Printf("Starting deprecation tracker - %d deprecations, flushing every %v", len(t.deprecations), deprecationFlushInterval)
+
+	ticker := time.NewTicker(deprecationFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.flush(context.WithoutCancel(ctx))
+			log.Println("Deprecation tracker stopped")
+			return
+		case <-ticker.C:
+			t.flush(ctx)
+		}
+	}
+}
+
+// flush stores the pending counts; when that fails they are kept for the next flush
+func (t *DeprecationTracker) flush(ctx context.Context) {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[deprecationKey]*deprecationCount)
+	t.mu.Unlock()
+
+	increments := make([]repository.DeprecationIncrement, 0, len(pending))
+	for key, count := range pending {
+		inc := repository.DeprecationIncrement{
+			Deprecation: key.deprecation,
+			Route:       key.route,
+			UserAgent:   key.userAgent,
+			Requests:    count.requests,
+			FirstSeen:   count.firstSeen,
+			LastSeen:    count.lastSeen,
+		}
+		if !key.userID.IsZero() {
+			userID := key.userID
+			inc.UserID = &userID
+		}
+		if !key.apiKeyID.IsZero() {
+			apiKeyID := key.apiKeyID
+			inc.APIKeyID = &apiKeyID
+		}
+		increments = append(increments, inc)
+	}
+
+	if err := t.deprecationRepo.Increment(ctx, increments); err != nil {
+		log.Printf("Failed to flush deprecation usage, keeping %d records for the next flush: %v", len(pending), err)
+		t.mu.Lock()
+		for key, count := range pending {
+			if current, ok := t.pending[key]; ok {
+				current.requests += count.requests
+				current.firstSeen = count.firstSeen
+			} else {
+				t.pending[key] = count
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Report lists every deprecation with the clients that used it, most recently
+// seen first. Counts lag up to a minute behind.
+func (t *DeprecationTracker) Report(ctx context.Context) (*models.DeprecationReport, error) {
+	report := &models.DeprecationReport{Deprecations: []*models.DeprecationUsage{}}
+	users := make(map[primitive.ObjectID]*models.User)
+	keyNames := make(map[primitive.ObjectID]map[primitive.ObjectID]string)
+
+	for _, deprecation := range t.deprecations {
+		requests, err := t.deprecationRepo.CountRequests(ctx, deprecation.ID)
+		if err != nil {
+			return nil, err
+		}
+		clients, err := t.deprecationRepo.FindClients(ctx, deprecation.ID, maxDeprecationClients)
+		if err != nil {
+			return nil, err
+		}
+
+		// Name the accounts and keys; deleted ones keep only their IDs
+		for _, client := range clients {
+			if client.UserID == nil {
+				continue
+			}
+			user, ok := users[*client.UserID]
+			if !ok {
+				if user, err = t.userRepo.FindByID(ctx, *client.UserID); err != nil && err.Error() != "user not found" {
+					return nil, err
+				}
+				users[*client.UserID] = user
+			}
+			if user != nil {
+				client.Email = user.Email
+			}
+			if client.APIKeyID == nil {
+				continue
+			}
+			names, ok := keyNames[*client.UserID]
+			if !ok {
+				keys, err := t.apiKeyRepo.FindByUserID(ctx, *client.UserID)
+				if err != nil {
+					return nil, err
+				}
+				names = make(map[primitive.ObjectID]string, len(keys))
+				for _, key := range keys {
+					names[key.ID] = key.Name
+				}
+				keyNames[*client.UserID] = names
+			}
+			client.APIKeyName = names[*client.APIKeyID]
+		}
+
+		report.Deprecations = append(report.Deprecations, &models.DeprecationUsage{
+			Deprecation: deprecation,
+			Requests:    models.Int64(requests),
+			Clients:     clients,
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import "strings"
+
+// describeDevice turns a User-Agent into a short label such as "Chrome on Windows"
+// so users can recognise their sessions. Unrecognised agents are labelled as such.
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	var platform string
+	switch {
+	case strings.Contains(userAgent, "iPhone"):
+		platform = "iPhone"
+	case strings.Contains(userAgent, "iPad"):
+		platform = "iPad"
+	case strings.Contains(userAgent, "Android"):
+		platform = "Android"
+	case strings.Contains(userAgent, "Windows"):
+		platform = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"), strings.Contains(userAgent, "Macintosh"):
+		platform = "macOS"
+	case strings.Contains(userAgent, "CrOS"):
+		platform = "ChromeOS"
+	case strings.Contains(userAgent, "Linux"):
+		platform = "Linux"
+	}
+
+	// Order matters: Edge and Opera also claim Chrome, and Chrome also claims Safari
+	var browser string
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+
+	// Non-browser clients such as "curl/8.4.0" are named by their product token
+	product := strings.SplitN(userAgent, " ", 2)[0]
+	return strings.SplitN(product, "/", 2)[0]
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/notifications"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// digestMaxTasks bounds the tasks one digest run reads
+const digestMaxTasks = 5000
+
+// DigestService emails each verified user a list of their open tasks that
+// are due today or overdue
+type DigestService struct {
+	taskRepo *repository.TaskRepository
+	userRepo *repository.UserRepository
+	mailer   notifications.EmailSender
+	clock    clock.Clock
+}
+
+func NewDigestService(taskRepo *repository.TaskRepository, userRepo *repository.UserRepository, mailer notifications.EmailSender, clk clock.Clock) *DigestService {
+	return &DigestService{
+		taskRepo: taskRepo,
+		userRepo: userRepo,
+		mailer:   mailer,
+		clock:    clk,
+	}
+}
+
+// SendDigests is the scheduled digest job. A failed email is logged and the
+// other users still get theirs.
+func (s *DigestService) SendDigests(ctx context.Context) error {
+	today := startOfUTCDay(s.clock.Now())
+	tasks, err := s.taskRepo.FindDueBefore(ctx, today.AddDate(0, 0, 1), digestMaxTasks)
+	if err != nil {
+		return err
+	}
+
+	// Tasks come grouped by owner
+	sent := 0
+	for start := 0; start < len(tasks); {
+		end := start + 1
+		for end < len(tasks) && tasks[end].UserID == tasks[start].UserID {
+			end++
+		}
+		ok, err := s.sendDigest(ctx, tasks[start].UserID, tasks[start:end])
+		if err != nil {
+			log.Printf("Failed to send digest to user %s: %v", tasks[start].UserID.Hex(), err)
+		} else if ok {
+			sent++
+		}
+		start = end
+	}
+	if sent > 0 {
+		log.Printf("Digest run: emailed %d users", sent)
+	}
+	return nil
+}
+
+// sendDigest emails the user their tasks, unless the user is gone or has no verified email
+func (s *DigestService) sendDigest(ctx context.Context, userID primitive.ObjectID, tasks []*models.Task) (bool, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return false, nil
+		}
+		return false, err
+	}
+	if !user.IsEmailVerified() {
+		return false, nil
+	}
+
+	today := startOfUTCDay(s.clock.Now())
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s, these tasks are due today or overdue:\n\n", user.Username)
+	for _, task := range tasks {
+		due := "today"
+		if task.DueDate.Before(today) {
+			due = "overdue since " + task.DueDate.Format("2006-01-02")
+		}
+		fmt.Fprintf(&body, "- %s (%s)\n", emailTitle(task), due)
+	}
+
+	subject := fmt.Sprintf("%d tasks due today", len(tasks))
+	if len(tasks) == 1 {
+		subject = "1 task due today"
+	}
+	if err := s.mailer.Send(ctx, user.Email, subject, body.String()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// emailTitle is the task's title as emails show it; private titles are sealed
+// and stay out of mailboxes
+func emailTitle(task *models.Task) string {
+	if task.Private {
+		return "(private task)"
+	}
+	return task.Title
+}
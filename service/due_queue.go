@@ -0,0 +1,81 @@
+package service
+
+import (
+	"container/heap"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type dueTask struct {
+	id    primitive.ObjectID
+	dueAt time.Time
+	index int
+}
+
+// dueQueue orders tasks by when they become due, soonest first. Each task
+// is in it at most once.
+type dueQueue struct {
+	tasks []*dueTask
+	byID  map[primitive.ObjectID]*dueTask
+}
+
+func newDueQueue() *dueQueue {
+	return &dueQueue{byID: make(map[primitive.ObjectID]*dueTask)}
+}
+
+// set adds the task, or moves it when it is already queued
+func (q *dueQueue) set(id primitive.ObjectID, dueAt time.Time) {
+	if task, ok := q.byID[id]; ok {
+		task.dueAt = dueAt
+		heap.Fix(q, task.index)
+		return
+	}
+	heap.Push(q, &dueTask{id: id, dueAt: dueAt})
+}
+
+func (q *dueQueue) remove(id primitive.ObjectID) {
+	if task, ok := q.byID[id]; ok {
+		heap.Remove(q, task.index)
+	}
+}
+
+// peek returns the task due soonest, or nil when the queue is empty
+func (q *dueQueue) peek() *dueTask {
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	return q.tasks[0]
+}
+
+func (q *dueQueue) pop() *dueTask {
+	return heap.Pop(q).(*dueTask)
+}
+
+// heap.Interface; use set, remove, peek and pop instead
+
+func (q *dueQueue) Len() int { return len(q.tasks) }
+
+func (q *dueQueue) Less(i, j int) bool { return q.tasks[i].dueAt.Before(q.tasks[j].dueAt) }
+
+func (q *dueQueue) Swap(i, j int) {
+	q.tasks[i], q.tasks[j] = q.tasks[j], q.tasks[i]
+	q.tasks[i].index = i
+	q.tasks[j].index = j
+}
+
+func (q *dueQueue) Push(x interface{}) {
+	task := x.(*dueTask)
+	task.index = len(q.tasks)
+	q.tasks = append(q.tasks, task)
+	q.byID[task.id] = task
+}
+
+func (q *dueQueue) Pop() interface{} {
+	last := len(q.tasks) - 1
+	task := q.tasks[last]
+	q.tasks[last] = nil
+	q.tasks = q.tasks[:last]
+	delete(q.byID, task.id)
+	return task
+}
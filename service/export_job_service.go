@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+
+	exportBatchSize = 1000
+	// exportJobStaleAfter is how long a job may go without progress before
+	// it counts as interrupted
+	exportJobStaleAfter = 15 * time.Minute
+	exportJobTimeout    = 10 * time.Minute
+	// exportRetention is how long finished exports can be downloaded
+	exportRetention = 7 * 24 * time.Hour
+)
+
+// ExportJobService builds exports of all of a user's tasks in the background,
+// for accounts too large to export within a request. Each user runs at most
+// one export at a time.
+type ExportJobService struct {
+	jobRepo     *repository.ExportJobRepository
+	taskRepo    *repository.TaskRepository
+	taskQueries *TaskQueryService
+	clock       clock.Clock
+}
+
+func NewExportJobService(jobRepo *repository.ExportJobRepository, taskRepo *repository.TaskRepository, taskQueries *TaskQueryService, clk clock.Clock) *ExportJobService {
+	return &ExportJobService{
+		jobRepo:     jobRepo,
+		taskRepo:    taskRepo,
+		taskQueries: taskQueries,
+		clock:       clk,
+	}
+}
+
+// StartExport queues an export. Private tasks are included when the request
+// carries the private passphrase, which the job holds in memory while it runs.
+func (s *ExportJobService) StartExport(ctx context.Context, user *models.User, req *models.CreateExportRequest) (*models.ExportJob, error) {
+	if req.Format != exportFormatCSV && req.Format != exportFormatJSON {
+		return nil, fmt.Errorf("invalid format, must be one of: csv, json")
+	}
+	// Fail now rather than in the job when the passphrase is wrong
+	if _, err := privateKey(ctx, user); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	active, err := s.jobRepo.FindActive(ctx, user.ID, now.Add(-exportJobStaleAfter))
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, fmt.Errorf("an export is already running")
+	}
+
+	job := &models.ExportJob{
+		UserID:    user.ID,
+		Format:    req.Format,
+		Status:    models.ExportJobStatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(exportRetention),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// The job outlives the request, but keeps its values, the passphrase among them
+	go s.runExport(context.WithoutCancel(ctx), job, user)
+
+	return job, nil
+}
+
+func (s *ExportJobService) runExport(ctx context.Context, job *models.ExportJob, user *models.User) {
+	ctx, cancel := context.WithTimeout(ctx, exportJobTimeout)
+	defer cancel()
+
+	job.Status = models.ExportJobStatusRunning
+	s.saveProgress(ctx, job)
+
+	var file exportFile = &csvExport{}
+	if job.Format == exportFormatJSON {
+		file = &jsonExport{}
+	}
+
+	var after *primitive.ObjectID
+	for {
+		tasks, err := s.taskRepo.FindExportBatch(ctx, user.ID, after, exportBatchSize)
+		if err != nil {
+			s.finish(ctx, job, err)
+			return
+		}
+		if len(tasks) == 0 {
+			break
+		}
+		after = &tasks[len(tasks)-1].ID
+
+		if err := s.taskQueries.RevealPrivate(ctx, user, tasks...); err != nil {
+			s.finish(ctx, job, err)
+			return
+		}
+		for _, task := range tasks {
+			if task.Private && task.Sealed != nil {
+				job.HiddenPrivateCount++
+				continue
+			}
+			if err := file.add(task); err != nil {
+				s.finish(ctx, job, fmt.Errorf("failed to write export: %w", err))
+				return
+			}
+			job.TaskCount++
+		}
+		s.saveProgress(ctx, job)
+	}
+
+	data, err := file.bytes()
+	if err == nil {
+		job.SizeBytes = models.Int64(len(data))
+		err = s.jobRepo.SaveFile(ctx, job, data)
+	}
+	s.finish(ctx, job, err)
+}
+
+func (s *ExportJobService) saveProgress(ctx context.Context, job *models.ExportJob) {
+	job.UpdatedAt = s.clock.Now()
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		logf(ctx, "Failed to update export job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// finish records the outcome; the job fails as a whole when err is set
+func (s *ExportJobService) finish(ctx context.Context, job *models.ExportJob, err error) {
+	now := s.clock.Now()
+	job.Status = models.ExportJobStatusCompleted
+	if err != nil {
+		job.Status = models.ExportJobStatusFailed
+		job.Error = err.Error()
+		logf(ctx, "Export job %s failed: %v", job.ID.Hex(), err)
+	}
+	job.FinishedAt = &now
+	s.saveProgress(context.WithoutCancel(ctx), job)
+}
+
+// GetJob returns one of the user's jobs. A job that stopped making progress
+// is reported, and stored, as failed.
+func (s *ExportJobService) GetJob(ctx context.Context, user *models.User, jobID primitive.ObjectID) (*models.ExportJob, error) {
+	job, err := s.jobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != user.ID {
+		return nil, fmt.Errorf("export job not found")
+	}
+
+	active := job.Status == models.ExportJobStatusQueued || job.Status == models.ExportJobStatusRunning
+	if active && s.clock.Now().Sub(job.UpdatedAt) > exportJobStaleAfter {
+		s.finish(ctx, job, fmt.Errorf("export was interrupted, start it again"))
+	}
+	return job, nil
+}
+
+// Download returns a completed job and its file
+func (s *ExportJobService) Download(ctx context.Context, user *models.User, jobID primitive.ObjectID) (*models.ExportJob, []byte, error) {
+	job, err := s.GetJob(ctx, user, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.ExportJobStatusCompleted {
+		return nil, nil, fmt.Errorf("export is not ready")
+	}
+
+	data, err := s.jobRepo.ReadFile(ctx, job.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, data, nil
+}
+
+// exportFile collects the tasks of an export in its format
+type exportFile interface {
+	add(task *models.Task) error
+	bytes() ([]byte, error)
+}
+
+var exportCSVHeader = []string{"id", "parent_id", "title", "description", "status", "priority", "due_date", "recurrence", "archived", "private", "created_at", "updated_at"}
+
+type csvExport struct {
+	buf    bytes.Buffer
+	writer *csv.Writer
+}
+
+func (e *csvExport) add(task *models.Task) error {
+	if e.writer == nil {
+		e.writer = csv.NewWriter(&e.buf)
+		if err := e.writer.Write(exportCSVHeader); err != nil {
+			return err
+		}
+	}
+
+	var parentID, dueDate string
+	if task.ParentID != nil {
+		parentID = task.ParentID.Hex()
+	}
+	if task.DueDate != nil {
+		dueDate = models.FormatTime(*task.DueDate)
+	}
+	return e.writer.Write([]string{
+		task.ID.Hex(),
+		parentID,
+		csvText(task.Title),
+		csvText(task.Description),
+		string(task.Status),
+		string(task.Priority),
+		dueDate,
+		task.Recurrence,
+		strconv.FormatBool(task.Archived),
+		strconv.FormatBool(task.Private),
+		models.FormatTime(task.CreatedAt),
+		models.FormatTime(task.UpdatedAt),
+	})
+}
+
+func (e *csvExport) bytes() ([]byte, error) {
+	if e.writer == nil {
+		e.writer = csv.NewWriter(&e.buf)
+		e.writer.Write(exportCSVHeader)
+	}
+	e.writer.Flush()
+	return e.buf.Bytes(), e.writer.Error()
+}
+
+// csvText keeps spreadsheets from running user text as a formula by
+// prefixing the characters that start one with an apostrophe
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// jsonExport writes a JSON array with one task per line
+type jsonExport struct {
+	buf bytes.Buffer
+}
+
+func (e *jsonExport) add(task *models.Task) error {
+	encoded, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	if e.buf.Len() == 0 {
+		e.buf.WriteString("[\n")
+	} else {
+		e.buf.WriteString(",\n")
+	}
+	e.buf.Write(encoded)
+	return nil
+}
+
+func (e *jsonExport) bytes() ([]byte, error) {
+	if e.buf.Len() == 0 {
+		return []byte("[]\n"), nil
+	}
+	e.buf.WriteString("\n]\n")
+	return e.buf.Bytes(), nil
+}
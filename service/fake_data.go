@@ -0,0 +1,126 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	fakeFirstNames = []string{
+		"Olivia", "Liam", "Emma", "Noah", "Amelia", "Oliver", "Sophia", "Elijah", "Mia", "Lucas",
+		"Isabella", "Mateo", "Ava", "Levi", "Harper", "Ethan", "Aisha", "Hiroshi", "Priya", "Diego",
+		"Fatima", "Chen", "Nadia", "Kwame", "Ingrid", "Tomás", "Leila", "Ravi", "Sofia", "Jonas",
+	}
+	fakeLastNames = []string{
+		"Smith", "Johnson", "Garcia", "Martinez", "Brown", "Nguyen", "Kim", "Patel", "Müller", "Rossi",
+		"Silva", "Kowalski", "Andersson", "Okafor", "Tanaka", "Haddad", "Novak", "Dubois", "Cohen", "Walker",
+		"Hughes", "Fischer", "Moreau", "Jensen", "Ivanova", "Santos", "Lopez", "Wright", "Park", "Mensah",
+	}
+	fakeTaskVerbs = []string{
+		"Review", "Draft", "Update", "Fix", "Prepare", "Schedule", "Send", "Plan", "Refactor", "Call about",
+		"Organize", "Finalize", "Test", "Book", "Clean up", "Research", "Write", "Submit", "Follow up on", "Migrate",
+	}
+	fakeTaskObjects = []string{
+		"quarterly budget", "onboarding checklist", "release notes", "team offsite", "vendor contract",
+		"login page", "database backup", "sprint retrospective", "customer feedback", "expense report",
+		"marketing plan", "dentist appointment", "API documentation", "holiday schedule", "performance review",
+		"grocery list", "project proposal", "server migration", "invoice batch", "training slides",
+	}
+	fakeSentences = []string{
+		"Check the latest numbers before sending anything out.",
+		"Waiting on feedback from the rest of the team.",
+		"Keep it short, one page at most.",
+		"The previous version had a few gaps that need filling.",
+		"Loop in finance once the draft is ready.",
+		"Blocked until the vendor gets back to us.",
+		"Use last year's template as a starting point.",
+		"Needs a second pair of eyes before Friday.",
+		"Split this up if it takes longer than a day.",
+		"Notes from the last meeting are in the shared folder.",
+		"Double-check the dates with everyone involved.",
+		"Low priority, but it keeps coming up.",
+		"Ask for an estimate before committing to anything.",
+		"This was requested by a customer last week.",
+		"Remember to update the tracker when done.",
+		"Good enough is fine here, no need to polish.",
+	}
+)
+
+// fakeSource derives a stream of choices from a record ID, so the same record
+// always gets the same fake value and relationships between records keep
+// their shape from one staging refresh to the next.
+type fakeSource struct {
+	seed [sha256.Size]byte
+	next int
+}
+
+func newFakeSource(id primitive.ObjectID, field string) *fakeSource {
+	return &fakeSource{seed: sha256.Sum256([]byte(field + ":" + id.Hex()))}
+}
+
+// intn returns a number in [0, n), rehashing the seed when it runs out
+func (f *fakeSource) intn(n int) int {
+	if f.next+2 > len(f.seed) {
+		f.seed = sha256.Sum256(f.seed[:])
+		f.next = 0
+	}
+	v := int(binary.BigEndian.Uint16(f.seed[f.next:]))
+	f.next += 2
+	return v % n
+}
+
+func (f *fakeSource) pick(values []string) string {
+	return values[f.intn(len(values))]
+}
+
+// fakePerson is a made-up identity for a user. The email embeds the user ID,
+// which keeps it unique under the users email index.
+type fakePerson struct {
+	email    string
+	username string
+}
+
+func newFakePerson(userID primitive.ObjectID) fakePerson {
+	f := newFakeSource(userID, "person")
+	first := f.pick(fakeFirstNames)
+	last := f.pick(fakeLastNames)
+	number := f.intn(100)
+
+	return fakePerson{
+		email:    fmt.Sprintf("%s.%s.%s@example.com", asciiLower(first), asciiLower(last), userID.Hex()),
+		username: fmt.Sprintf("%s%s%d", asciiLower(first), asciiLower(last[:1]), number),
+	}
+}
+
+// fakeSubject stands in for an external login's subject; it only has to be
+// unique per provider
+func fakeSubject(userID primitive.ObjectID, provider string) string {
+	sum := sha256.Sum256([]byte("subject:" + provider + ":" + userID.Hex()))
+	return hex.EncodeToString(sum[:12])
+}
+
+func fakeTitle(id primitive.ObjectID, field string) string {
+	f := newFakeSource(id, field)
+	return f.pick(fakeTaskVerbs) + " " + f.pick(fakeTaskObjects)
+}
+
+// fakeText returns one to maxSentences sentences
+func fakeText(id primitive.ObjectID, field string, maxSentences int) string {
+	f := newFakeSource(id, field)
+	sentences := make([]string, 1+f.intn(maxSentences))
+	for i := range sentences {
+		sentences[i] = f.pick(fakeSentences)
+	}
+	return strings.Join(sentences, " ")
+}
+
+// asciiLower lowercases a name and drops accents, for use in emails and usernames
+func asciiLower(name string) string {
+	replacer := strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u")
+	return replacer.Replace(strings.ToLower(name))
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+)
+
+// FieldPolicyError names the fields an update changed that the user's role may not change
+type FieldPolicyError struct {
+	Fields []string
+}
+
+func (e *FieldPolicyError) Error() string {
+	return "not allowed to change fields: " + strings.Join(e.Fields, ", ")
+}
+
+type FieldPolicyService struct {
+	policyRepo *repository.FieldPolicyRepository
+	auditRepo  *repository.AuditRepository
+	clock      clock.Clock
+}
+
+func NewFieldPolicyService(policyRepo *repository.FieldPolicyRepository, auditRepo *repository.AuditRepository, clk clock.Clock) *FieldPolicyService {
+	return &FieldPolicyService{
+		policyRepo: policyRepo,
+		auditRepo:  auditRepo,
+		clock:      clk,
+	}
+}
+
+// GetTaskPolicy returns the stored policy, or an empty one that restricts nothing
+func (s *FieldPolicyService) GetTaskPolicy(ctx context.Context) (*models.TaskFieldPolicy, error) {
+	policy, err := s.policyRepo.GetTaskPolicy(ctx)
+	if err != nil {
+		if err.Error() == "field policy not found" {
+			return &models.TaskFieldPolicy{Fields: map[string][]models.UserRole{}}, nil
+		}
+		return nil, err
+	}
+	if policy.Fields == nil {
+		policy.Fields = map[string][]models.UserRole{}
+	}
+	return policy, nil
+}
+
+func (s *FieldPolicyService) UpdateTaskPolicy(ctx context.Context, actor *models.User, req *models.UpdateTaskFieldPolicyRequest) (*models.TaskFieldPolicy, error) {
+	fields := map[string][]models.UserRole{}
+	for field, roles := range req.Fields {
+		if !slices.Contains(models.TaskPolicyFields, field) {
+			return nil, fmt.Errorf("invalid field %s, must be one of: %s", field, strings.Join(models.TaskPolicyFields, ", "))
+		}
+		allowed := []models.UserRole{}
+		for _, role := range roles {
+			if _, ok := models.RolePermissions[role]; !ok {
+				return nil, fmt.Errorf("invalid role %s, must be one of: user, admin", role)
+			}
+			if !slices.Contains(allowed, role) {
+				allowed = append(allowed, role)
+			}
+		}
+		fields[field] = allowed
+	}
+
+	now := s.clock.Now()
+	policy := &models.TaskFieldPolicy{
+		Fields:    fields,
+		UpdatedBy: &actor.ID,
+		UpdatedAt: &now,
+	}
+	if err := s.policyRepo.SaveTaskPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{}
+	for field, roles := range fields {
+		details[field] = roles
+	}
+	entry := models.NewAuditLog(actor.ID, "field_policy.update", "field_policy", actor.ID, details, now)
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s: %v", entry.Action, err)
+	}
+
+	return policy, nil
+}
+
+// CheckTaskUpdate returns a *FieldPolicyError when user changed, between before
+// and after, a field the policy does not let their role change. Fields sent with
+// their current value are not changes.
+func (s *FieldPolicyService) CheckTaskUpdate(ctx context.Context, user *models.User, before, after *models.Task) error {
+	changed := changedTaskFields(before, after)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	policy, err := s.GetTaskPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	var blocked []string
+	for _, field := range changed {
+		roles, restricted := policy.Fields[field]
+		if restricted && !slices.Contains(roles, user.Role) {
+			blocked = append(blocked, field)
+		}
+	}
+	if len(blocked) > 0 {
+		return &FieldPolicyError{Fields: blocked}
+	}
+	return nil
+}
+
+// changedTaskFields lists the TaskPolicyFields that differ between two versions of a task
+func changedTaskFields(before, after *models.Task) []string {
+	var changed []string
+	if before.Title != after.Title {
+		changed = append(changed, "title")
+	}
+	if before.Description != after.Description {
+		changed = append(changed, "description")
+	}
+	if before.Status != after.Status {
+		changed = append(changed, "status")
+	}
+	if before.Priority != after.Priority {
+		changed = append(changed, "priority")
+	}
+	if (before.DueDate == nil) != (after.DueDate == nil) || (before.DueDate != nil && !before.DueDate.Equal(*after.DueDate)) {
+		changed = append(changed, "due_date")
+	}
+	if before.Recurrence != after.Recurrence {
+		changed = append(changed, "recurrence")
+	}
+
+	beforeBlockers := make([]string, len(before.BlockedBy))
+	for i, id := range before.BlockedBy {
+		beforeBlockers[i] = id.Hex()
+	}
+	afterBlockers := make([]string, len(after.BlockedBy))
+	for i, id := range after.BlockedBy {
+		afterBlockers[i] = id.Hex()
+	}
+	sort.Strings(beforeBlockers)
+	sort.Strings(afterBlockers)
+	if !slices.Equal(beforeBlockers, afterBlockers) {
+		changed = append(changed, "blocked_by")
+	}
+
+	return changed
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	githubAuthURL   = "https://github.com/login/oauth/authorize"
+	githubTokenURL  = "https://github.com/login/oauth/access_token"
+	githubUserURL   = "https://api.github.com/user"
+	githubEmailsURL = "https://api.github.com/user/emails"
+)
+
+type GitHubOAuth struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+}
+
+func NewGitHubOAuth(clientID, clientSecret, redirectURL string, client *http.Client) *GitHubOAuth {
+	return &GitHubOAuth{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       client,
+	}
+}
+
+func (g *GitHubOAuth) Name() string {
+	return "github"
+}
+
+func (g *GitHubOAuth) AuthCodeURL(state string) string {
+	params := url.Values{
+		"client_id":    {g.clientID},
+		"redirect_uri": {g.redirectURL},
+		"scope":        {"read:user user:email"},
+		"state":        {state},
+	}
+	return githubAuthURL + "?" + params.Encode()
+}
+
+// Exchange trades an authorization code for an access token, then reads the
+// account and its primary email, which GitHub only reports as verified via /user/emails.
+func (g *GitHubOAuth) Exchange(ctx context.Context, code string) (*OAuthProfile, error) {
+	accessToken, err := exchangeOAuthCode(ctx, g.client, githubTokenURL, url.Values{
+		"client_id":     {g.clientID},
+		"client_secret": {g.clientSecret},
+		"redirect_uri":  {g.redirectURL},
+		"code":          {code},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var account struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := oauthGetJSON(ctx, g.client, githubUserURL, accessToken, &account); err != nil {
+		return nil, fmt.Errorf("failed to fetch profile: %w", err)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := oauthGetJSON(ctx, g.client, githubEmailsURL, accessToken, &emails); err != nil {
+		return nil, fmt.Errorf("failed to fetch emails: %w", err)
+	}
+
+	profile := &OAuthProfile{
+		Provider: g.Name(),
+		Subject:  strconv.FormatInt(account.ID, 10),
+		Name:     account.Name,
+	}
+	if profile.Name == "" {
+		profile.Name = account.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+		}
+	}
+	if account.ID == 0 || profile.Email == "" {
+		return nil, fmt.Errorf("failed to fetch profile: missing subject or email")
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+type GoogleOAuth struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+}
+
+func NewGoogleOAuth(clientID, clientSecret, redirectURL string, client *http.Client) *GoogleOAuth {
+	return &GoogleOAuth{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       client,
+	}
+}
+
+func (g *GoogleOAuth) Name() string {
+	return "google"
+}
+
+func (g *GoogleOAuth) AuthCodeURL(state string) string {
+	params := url.Values{
+		"client_id":     {g.clientID},
+		"redirect_uri":  {g.redirectURL},
+		"response_type": {"code"},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+	}
+	return googleAuthURL + "?" + params.Encode()
+}
+
+// Exchange trades an authorization code for an access token and fetches the user's profile with it.
+func (g *GoogleOAuth) Exchange(ctx context.Context, code string) (*OAuthProfile, error) {
+	accessToken, err := exchangeOAuthCode(ctx, g.client, googleTokenURL, url.Values{
+		"client_id":     {g.clientID},
+		"client_secret": {g.clientSecret},
+		"redirect_uri":  {g.redirectURL},
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := oauthGetJSON(ctx, g.client, googleUserInfoURL, accessToken, &info); err != nil {
+		return nil, fmt.Errorf("failed to fetch profile: %w", err)
+	}
+	if info.Sub == "" || info.Email == "" {
+		return nil, fmt.Errorf("failed to fetch profile: missing subject or email")
+	}
+
+	return &OAuthProfile{
+		Provider:      g.Name(),
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+	}, nil
+}
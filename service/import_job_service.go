@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	importSourceTodoist = "todoist"
+	// importJobStaleAfter is how long a job may go without progress before it
+	// counts as interrupted, e.g. by a restart of the instance running it
+	importJobStaleAfter = 15 * time.Minute
+	// importJobTimeout bounds a whole import, fetching included
+	importJobTimeout = 10 * time.Minute
+)
+
+// ImportJobService runs imports from other services in the background. Each
+// user runs at most one at a time; its progress is read back by job ID.
+type ImportJobService struct {
+	jobRepo     *repository.ImportJobRepository
+	taskService *TaskService
+	todoist     *TodoistClient
+	clock       clock.Clock
+}
+
+func NewImportJobService(jobRepo *repository.ImportJobRepository, taskService *TaskService, todoist *TodoistClient, clk clock.Clock) *ImportJobService {
+	return &ImportJobService{
+		jobRepo:     jobRepo,
+		taskService: taskService,
+		todoist:     todoist,
+		clock:       clk,
+	}
+}
+
+// StartTodoistImport checks the token against Todoist and queues a job that
+// imports the user's active Todoist tasks. The token is only held in memory
+// while the job runs.
+func (s *ImportJobService) StartTodoistImport(ctx context.Context, user *models.User, req *models.StartTodoistImportRequest) (*models.ImportJob, error) {
+	token := strings.TrimSpace(req.APIToken)
+	if token == "" {
+		return nil, fmt.Errorf("api_token is required")
+	}
+
+	now := s.clock.Now()
+	active, err := s.jobRepo.FindActive(ctx, user.ID, now.Add(-importJobStaleAfter))
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, fmt.Errorf("an import is already running")
+	}
+
+	// Fetching the projects checks the token before the job is accepted
+	projects, err := s.todoist.Projects(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	projectNames := make(map[string]string, len(projects))
+	for _, project := range projects {
+		projectNames[project.ID] = project.Name
+	}
+
+	job := &models.ImportJob{
+		UserID:    user.ID,
+		Source:    importSourceTodoist,
+		Status:    models.ImportJobStatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// The job outlives the request, but keeps its values for tracing
+	go s.runTodoistImport(context.WithoutCancel(ctx), job, user, token, projectNames)
+
+	return job, nil
+}
+
+func (s *ImportJobService) runTodoistImport(ctx context.Context, job *models.ImportJob, user *models.User, token string, projectNames map[string]string) {
+	ctx, cancel := context.WithTimeout(ctx, importJobTimeout)
+	defer cancel()
+
+	job.Status = models.ImportJobStatusRunning
+	s.saveProgress(ctx, job)
+
+	items, err := s.todoist.Tasks(ctx, token, maxJSONImportTasks)
+	if err != nil {
+		s.finish(ctx, job, err)
+		return
+	}
+
+	// IDs are assigned up front so subtasks can point at parents in any batch
+	var tasks []*models.Task
+	taskIDs := make(map[string]primitive.ObjectID, len(items))
+	parents := make(map[*models.Task]string)
+	for _, item := range items {
+		task, err := s.todoistTask(ctx, user, item, projectNames[item.ProjectID])
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "failed to") {
+				s.finish(ctx, job, err)
+				return
+			}
+			job.Failures = append(job.Failures, models.ImportJobFailure{SourceID: item.ID, Title: item.Content, Error: err.Error()})
+			continue
+		}
+		task.ID = primitive.NewObjectID()
+		taskIDs[item.ID] = task.ID
+		if item.ParentID != nil {
+			parents[task] = *item.ParentID
+		}
+		tasks = append(tasks, task)
+	}
+	// A subtask whose parent failed to import becomes a top-level task
+	for task, parentID := range parents {
+		if id, ok := taskIDs[parentID]; ok {
+			task.ParentID = &id
+		}
+	}
+	job.FailedCount = len(job.Failures)
+	s.saveProgress(ctx, job)
+
+	job.ImportedCount, err = s.taskService.saveImported(ctx, tasks)
+	s.finish(ctx, job, err)
+}
+
+func (s *ImportJobService) todoistTask(ctx context.Context, user *models.User, item todoistTask, projectName string) (*models.Task, error) {
+	req, err := todoistTaskRequest(item, projectName)
+	if err != nil {
+		return nil, err
+	}
+	return s.taskService.newTask(ctx, user, req)
+}
+
+func (s *ImportJobService) saveProgress(ctx context.Context, job *models.ImportJob) {
+	job.UpdatedAt = s.clock.Now()
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		logf(ctx, "Failed to update import job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// finish records the outcome; the job fails as a whole when err is set
+func (s *ImportJobService) finish(ctx context.Context, job *models.ImportJob, err error) {
+	now := s.clock.Now()
+	job.Status = models.ImportJobStatusCompleted
+	if err != nil {
+		job.Status = models.ImportJobStatusFailed
+		job.Error = err.Error()
+		logf(ctx, "Import job %s failed: %v", job.ID.Hex(), err)
+	}
+	job.FinishedAt = &now
+	// Saved even when the import ran out of time
+	s.saveProgress(context.WithoutCancel(ctx), job)
+}
+
+// GetJob returns one of the user's jobs. A job that stopped making progress
+// is reported, and stored, as failed.
+func (s *ImportJobService) GetJob(ctx context.Context, user *models.User, jobID primitive.ObjectID) (*models.ImportJob, error) {
+	job, err := s.jobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != user.ID {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	active := job.Status == models.ImportJobStatusQueued || job.Status == models.ImportJobStatusRunning
+	if active && s.clock.Now().Sub(job.UpdatedAt) > importJobStaleAfter {
+		s.finish(ctx, job, fmt.Errorf("import was interrupted, start it again"))
+	}
+	return job, nil
+}
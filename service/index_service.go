@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"task-management-api/clock"
+	"task-management-api/database"
+	"task-management-api/models"
+	"task-management-api/repository"
+)
+
+// IndexService builds the indexes in database.IndexSpecs and reports how the
+// database has drifted from them.
+type IndexService struct {
+	indexRepo *repository.IndexRepository
+	specs     []database.IndexSpec
+	clock     clock.Clock
+
+	mu    sync.Mutex
+	build models.IndexBuildStatus
+}
+
+func NewIndexService(indexRepo *repository.IndexRepository, specs []database.IndexSpec, clk clock.Clock) *IndexService {
+	return &IndexService{
+		indexRepo: indexRepo,
+		specs:     specs,
+		clock:     clk,
+		build: models.IndexBuildStatus{
+			State:   models.IndexBuildPending,
+			Created: []string{},
+			Errors:  []string{},
+		},
+	}
+}
+
+// Ensure creates every missing index one at a time. It is meant to run in its
+// own goroutine so long builds never hold up startup. Indexes whose key exists
+// with different options are left alone and only reported; indexes that fail
+// to build (e.g. a unique index over duplicate data) are logged and skipped.
+func (s *IndexService) Ensure(ctx context.Context) {
+	started := s.clock.Now()
+	s.mu.Lock()
+	s.build = models.IndexBuildStatus{
+		State:     models.IndexBuildRunning,
+		StartedAt: &started,
+		Created:   []string{},
+		Errors:    []string{},
+	}
+	s.mu.Unlock()
+
+	existing := make(map[string][]models.IndexInfo)
+	for _, spec := range s.specs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		indexes, loaded := existing[spec.Collection]
+		if !loaded {
+			var err error
+			indexes, err = s.indexRepo.List(ctx, spec.Collection)
+			if err != nil {
+				s.recordBuildError(err)
+				continue
+			}
+			existing[spec.Collection] = indexes
+		}
+		if findIndex(indexes, spec.Name()) != nil {
+			continue
+		}
+
+		name, err := s.indexRepo.Create(ctx, spec)
+		if err != nil {
+			s.recordBuildError(err)
+			continue
+		}
+		log.Printf("Created index %s.%s", spec.Collection, name)
+		s.mu.Lock()
+		s.build.Created = append(s.build.Created, spec.Collection+"."+name)
+		s.mu.Unlock()
+	}
+
+	finished := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.build.FinishedAt = &finished
+	s.build.State = models.IndexBuildCompleted
+	if len(s.build.Errors) > 0 || ctx.Err() != nil {
+		s.build.State = models.IndexBuildFailed
+	}
+	log.Printf("Index build %s: %d created, %d failed", s.build.State, len(s.build.Created), len(s.build.Errors))
+}
+
+func (s *IndexService) recordBuildError(err error) {
+	log.Printf("Index build error: %v", err)
+	s.mu.Lock()
+	s.build.Errors = append(s.build.Errors, err.Error())
+	s.mu.Unlock()
+}
+
+// Report compares the desired indexes with what exists in each managed collection
+func (s *IndexService) Report(ctx context.Context) (*models.IndexReport, error) {
+	s.mu.Lock()
+	build := s.build
+	build.Created = append([]string{}, s.build.Created...)
+	build.Errors = append([]string{}, s.build.Errors...)
+	s.mu.Unlock()
+
+	report := &models.IndexReport{Build: build, Collections: []*models.CollectionIndexReport{}}
+	byCollection := make(map[string]*models.CollectionIndexReport)
+	desired := make(map[string]bool)
+
+	for _, spec := range s.specs {
+		collection, ok := byCollection[spec.Collection]
+		if !ok {
+			indexes, err := s.indexRepo.List(ctx, spec.Collection)
+			if err != nil {
+				return nil, err
+			}
+			s.attachUsage(ctx, spec.Collection, indexes)
+
+			collection = &models.CollectionIndexReport{
+				Collection: spec.Collection,
+				Indexes:    indexes,
+				Missing:    []string{},
+				Drifted:    []models.IndexDrift{},
+				Unexpected: []string{},
+				Unused:     []string{},
+			}
+			byCollection[spec.Collection] = collection
+			report.Collections = append(report.Collections, collection)
+		}
+		desired[spec.Collection+"."+spec.Name()] = true
+
+		actual := findIndex(collection.Indexes, spec.Name())
+		if actual == nil {
+			collection.Missing = append(collection.Missing, spec.Name())
+			continue
+		}
+		expected := models.IndexInfo{Unique: spec.Unique, Sparse: spec.Sparse, ExpireAfterSeconds: spec.ExpireAfterSeconds}
+		if describeIndexOptions(expected) != describeIndexOptions(*actual) {
+			collection.Drifted = append(collection.Drifted, models.IndexDrift{
+				Name:     actual.Name,
+				Expected: describeIndexOptions(expected),
+				Actual:   describeIndexOptions(*actual),
+			})
+		}
+	}
+
+	for _, collection := range report.Collections {
+		for _, index := range collection.Indexes {
+			if index.Name == "_id_" {
+				continue
+			}
+			if !desired[collection.Collection+"."+index.Key] {
+				collection.Unexpected = append(collection.Unexpected, index.Name)
+			}
+			if index.Ops != nil && *index.Ops == 0 {
+				collection.Unused = append(collection.Unused, index.Name)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// attachUsage fills in $indexStats counters; without the privilege to read them they stay nil
+func (s *IndexService) attachUsage(ctx context.Context, collection string, indexes []models.IndexInfo) {
+	usage, err := s.indexRepo.Usage(ctx, collection)
+	if err != nil {
+		log.Printf("Index usage unavailable: %v", err)
+		return
+	}
+
+	for i := range indexes {
+		if u, ok := usage[indexes[i].Name]; ok {
+			ops, since := models.Int64(u.Ops), u.Since
+			indexes[i].Ops = &ops
+			indexes[i].Since = &since
+		}
+	}
+}
+
+// findIndex matches on the key signature, so an index created under a custom name still counts
+func findIndex(indexes []models.IndexInfo, key string) *models.IndexInfo {
+	for i := range indexes {
+		if indexes[i].Key == key {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+func describeIndexOptions(index models.IndexInfo) string {
+	ttl := "none"
+	if index.ExpireAfterSeconds != nil {
+		ttl = fmt.Sprintf("%ds", *index.ExpireAfterSeconds)
+	}
+	return fmt.Sprintf("unique=%t sparse=%t ttl=%s", index.Unique, index.Sparse, ttl)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"task-management-api/models"
+	"time"
+)
+
+// jsonImportRecord is one task of a JSON import, or the reason it could not be read
+type jsonImportRecord struct {
+	line int
+	req  *models.CreateTaskRequest
+	err  error
+}
+
+// parseJSONImport reads a JSON array of create requests, or one request per
+// line when ndjson is set. Records that are not task objects are returned with
+// an error so the rest of the import can go ahead; only a document that cannot
+// be read at all fails as a whole. Records are numbered by line for NDJSON and
+// by position in the array otherwise.
+func parseJSONImport(body []byte, ndjson bool, maxTasks int) ([]jsonImportRecord, error) {
+	var records []jsonImportRecord
+	add := func(line int, raw []byte) error {
+		if len(records) == maxTasks {
+			return fmt.Errorf("at most %d tasks can be imported at once", maxTasks)
+		}
+		var req models.CreateTaskRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			records = append(records, jsonImportRecord{line: line, err: jsonImportError(err)})
+			return nil
+		}
+		records = append(records, jsonImportRecord{line: line, req: &req})
+		return nil
+	}
+
+	if ndjson {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+		line := 0
+		for scanner.Scan() {
+			line++
+			raw := bytes.TrimSpace(scanner.Bytes())
+			if len(raw) == 0 {
+				continue
+			}
+			if err := add(line, raw); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read import: %w", err)
+		}
+	} else {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(body, &raws); err != nil {
+			return nil, fmt.Errorf("invalid JSON, expected an array of tasks")
+		}
+		for i, raw := range raws {
+			if err := add(i+1, raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no tasks found")
+	}
+
+	return records, nil
+}
+
+// jsonImportError names the field a record could not be decoded at, where possible
+func jsonImportError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fmt.Errorf("invalid %s", typeErr.Field)
+	}
+	var timeErr *time.ParseError
+	if errors.As(err, &timeErr) {
+		return fmt.Errorf("invalid due_date, use RFC 3339")
+	}
+	return fmt.Errorf("invalid record, expected a task object")
+}
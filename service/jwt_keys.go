@@ -0,0 +1,174 @@
+package service
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"task-management-api/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT signing methods
+const (
+	SigningMethodHS256 = "HS256"
+	SigningMethodRS256 = "RS256"
+)
+
+const minRSAKeyBits = 2048
+
+// JWTKeys signs access tokens and resolves the key that verifies them. With
+// RS256 the current private key signs and every configured public key, the
+// current one included, verifies; tokens name their key in the "kid" header.
+type JWTKeys struct {
+	method     string
+	secret     []byte
+	signingKey *rsa.PrivateKey
+	signingKID string
+	publicKeys map[string]*rsa.PublicKey
+	// kids keeps the publication order of the JWKS stable
+	kids []string
+}
+
+// NewHMACKeys signs and verifies with a shared secret
+func NewHMACKeys(secret string) *JWTKeys {
+	return &JWTKeys{method: SigningMethodHS256, secret: []byte(secret)}
+}
+
+// LoadRSAKeys reads the PEM private key used for signing and any extra PEM
+// public (or private) keys that are still accepted, e.g. the previous key
+// during a rotation or the next one published ahead of it.
+func LoadRSAKeys(privateKeyFile string, publicKeyFiles []string) (*JWTKeys, error) {
+	if privateKeyFile == "" {
+		return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for RS256")
+	}
+
+	pemBytes, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", privateKeyFile, err)
+	}
+	signingKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", privateKeyFile, err)
+	}
+
+	keys := &JWTKeys{
+		method:     SigningMethodRS256,
+		signingKey: signingKey,
+		publicKeys: make(map[string]*rsa.PublicKey),
+	}
+	if err := keys.addPublicKey(&signingKey.PublicKey, privateKeyFile); err != nil {
+		return nil, err
+	}
+	keys.signingKID = keys.kids[0]
+
+	for _, file := range publicKeyFiles {
+		pemBytes, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		if err != nil {
+			privateKey, privateErr := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+			if privateErr != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			publicKey = &privateKey.PublicKey
+		}
+		if err := keys.addPublicKey(publicKey, file); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+func (k *JWTKeys) addPublicKey(key *rsa.PublicKey, source string) error {
+	if key.N.BitLen() < minRSAKeyBits {
+		return fmt.Errorf("%s: RSA keys must be at least %d bits", source, minRSAKeyBits)
+	}
+
+	kid := thumbprint(key)
+	if _, ok := k.publicKeys[kid]; ok {
+		return nil
+	}
+	k.publicKeys[kid] = key
+	k.kids = append(k.kids, kid)
+	return nil
+}
+
+// Method is the algorithm new tokens are signed with
+func (k *JWTKeys) Method() string {
+	return k.method
+}
+
+func (k *JWTKeys) sign(claims jwt.Claims) (string, error) {
+	if k.method == SigningMethodRS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = k.signingKID
+		return token.SignedString(k.signingKey)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(k.secret)
+}
+
+// keyFunc only accepts the configured method, so an RS256 deployment never
+// falls back to the shared secret and an HS256 one never trusts a header's key.
+func (k *JWTKeys) keyFunc(token *jwt.Token) (interface{}, error) {
+	if k.method == SigningMethodRS256 {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := k.publicKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return k.secret, nil
+}
+
+// JWKS publishes the public keys; it is empty for HS256, whose secret is never published
+func (k *JWTKeys) JWKS() *models.JWKSet {
+	set := &models.JWKSet{Keys: []models.JWK{}}
+	for _, kid := range k.kids {
+		set.Keys = append(set.Keys, publicJWK(kid, k.publicKeys[kid]))
+	}
+	return set
+}
+
+func publicJWK(kid string, key *rsa.PublicKey) models.JWK {
+	return models.JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: SigningMethodRS256,
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// thumbprint is the RFC 7638 JWK thumbprint, so a key's ID never needs configuring
+func thumbprint(key *rsa.PublicKey) string {
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
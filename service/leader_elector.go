@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"task-management-api/clock"
+	"task-management-api/repository"
+	"time"
+)
+
+// LeaderElector keeps one instance of a deployment the leader, which alone
+// runs the scheduled jobs. The leader renews its lease three times per TTL;
+// when it dies, its lease expires and another instance takes over at its
+// next attempt, within a TTL and a third.
+type LeaderElector struct {
+	leaseRepo *repository.LeaseRepository
+	name      string
+	holder    string
+	ttl       time.Duration
+	clock     clock.Clock
+
+	mu sync.Mutex
+	// until is when the lease this instance last acquired runs out
+	until time.Time
+}
+
+// NewLeaderElector elects a leader among the instances sharing leaseRepo;
+// holder identifies this instance and must differ between instances
+func NewLeaderElector(leaseRepo *repository.LeaseRepository, name, holder string, ttl time.Duration, clk clock.Clock) *LeaderElector {
+	return &LeaderElector{
+		leaseRepo: leaseRepo,
+		name:      name,
+		holder:    holder,
+		ttl:       ttl,
+		clock:     clk,
+	}
+}
+
+// IsLeader reports whether this instance holds an unexpired lease. A leader
+// that cannot reach MongoDB stops leading once its lease runs out, before
+// another instance can take over.
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.clock.Now().Before(e.until)
+}
+
+// Start campaigns for the lease until ctx is cancelled, then releases it
+func (e *LeaderElector) Start(ctx context.Context) {
+	log.Printf("Starting leader election as %s - lease %s held for %v", e.holder, e.name, e.ttl)
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	e.campaign(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			if e.IsLeader() {
+				if err := e.leaseRepo.Release(context.WithoutCancel(ctx), e.name, e.holder); err != nil {
+					log.Printf("Failed to release lease %s: %v", e.name, err)
+				}
+			}
+			log.Println("Leader election stopped")
+			return
+		case <-ticker.C:
+			e.campaign(ctx)
+		}
+	}
+}
+
+// campaign acquires or renews the lease, logging when leadership changes
+func (e *LeaderElector) campaign(ctx context.Context) {
+	now := e.clock.Now()
+	acquired, err := e.leaseRepo.Acquire(ctx, e.name, e.holder, now, e.ttl)
+	if err != nil {
+		// Keep leading until the lease runs out; no one else can take it sooner
+		log.Printf("Failed to renew lease %s: %v", e.name, err)
+		return
+	}
+
+	wasLeader := e.IsLeader()
+	e.mu.Lock()
+	if acquired {
+		e.until = now.Add(e.ttl)
+	} else {
+		e.until = time.Time{}
+	}
+	e.mu.Unlock()
+
+	switch {
+	case acquired && !wasLeader:
+		log.Printf("Became leader of %s", e.name)
+	case !acquired && wasLeader:
+		log.Printf("Lost leadership of %s to another instance", e.name)
+	}
+}
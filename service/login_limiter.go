@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"time"
+)
+
+// Login attempt store backends
+const (
+	LoginAttemptStoreMemory = "memory"
+	LoginAttemptStoreMongo  = "mongo"
+)
+
+// LoginAttemptStore counts failed logins per key in fixed windows. Entries
+// whose window has ended are treated as absent.
+type LoginAttemptStore interface {
+	Get(ctx context.Context, key string, now time.Time) (*models.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration, now time.Time) (*models.LoginAttempt, error)
+	Clear(ctx context.Context, key string) error
+	List(ctx context.Context, now time.Time) ([]*models.LoginAttempt, error)
+}
+
+// MemoryLoginAttemptStore keeps counters in process; each instance counts separately.
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: make(map[string]models.LoginAttempt),
+	}
+}
+
+func (s *MemoryLoginAttemptStore) Get(ctx context.Context, key string, now time.Time) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok || !now.Before(attempt.ResetAt) {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (s *MemoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration, now time.Time) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok || !now.Before(attempt.ResetAt) {
+		attempt = models.LoginAttempt{Key: key, WindowStart: now, ResetAt: now.Add(window)}
+	}
+	attempt.Failures++
+	s.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (s *MemoryLoginAttemptStore) Clear(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) List(ctx context.Context, now time.Time) ([]*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := []*models.LoginAttempt{}
+	for key, attempt := range s.attempts {
+		if !now.Before(attempt.ResetAt) {
+			delete(s.attempts, key)
+			continue
+		}
+		attempt := attempt
+		attempts = append(attempts, &attempt)
+	}
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].Failures > attempts[j].Failures })
+	return attempts, nil
+}
+
+type LoginLimitConfig struct {
+	MaxFailuresPerEmail int
+	MaxFailuresPerIP    int
+	Window              time.Duration
+}
+
+// LoginLimiter locks out an email or client IP after too many failed logins
+// within the window. A zero limit disables that dimension.
+type LoginLimiter struct {
+	store  LoginAttemptStore
+	config LoginLimitConfig
+	clock  clock.Clock
+}
+
+func NewLoginLimiter(store LoginAttemptStore, config LoginLimitConfig, clk clock.Clock) *LoginLimiter {
+	return &LoginLimiter{
+		store:  store,
+		config: config,
+		clock:  clk,
+	}
+}
+
+func emailAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long the caller must wait, or zero when the login may proceed.
+// Store errors are logged and let the login through.
+func (l *LoginLimiter) Check(ctx context.Context, email, ip string) time.Duration {
+	now := l.clock.Now()
+	var retryAfter time.Duration
+	for _, check := range l.checks(email, ip) {
+		attempt, err := l.store.Get(ctx, check.key, now)
+		if err != nil {
+			logf(ctx, "Failed to read login attempts for %s: %v", check.key, err)
+			continue
+		}
+		if attempt != nil && attempt.Failures >= check.limit {
+			if wait := attempt.ResetAt.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+	return retryAfter
+}
+
+func (l *LoginLimiter) RecordFailure(ctx context.Context, email, ip string) {
+	now := l.clock.Now()
+	for _, check := range l.checks(email, ip) {
+		attempt, err := l.store.RecordFailure(ctx, check.key, l.config.Window, now)
+		if err != nil {
+			logf(ctx, "Failed to record login attempt for %s: %v", check.key, err)
+			continue
+		}
+		if attempt.Failures == check.limit {
+			logf(ctx, "Login locked for %s until %s after %d failures", check.key, models.FormatTime(attempt.ResetAt), attempt.Failures)
+		}
+	}
+}
+
+// RecordSuccess clears the email's counter; the IP's is kept so one valid
+// account cannot be used to reset guessing against others.
+func (l *LoginLimiter) RecordSuccess(ctx context.Context, email string) {
+	if l.config.MaxFailuresPerEmail <= 0 {
+		return
+	}
+	if err := l.store.Clear(ctx, emailAttemptKey(email)); err != nil {
+		logf(ctx, "Failed to clear login attempts for %s: %v", email, err)
+	}
+}
+
+func (l *LoginLimiter) List(ctx context.Context) ([]*models.LoginAttempt, error) {
+	attempts, err := l.store.List(ctx, l.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, attempt := range attempts {
+		attempt.Locked = l.isLocked(attempt)
+	}
+	return attempts, nil
+}
+
+// Clear removes the counters for an email and/or IP, lifting any lockout.
+func (l *LoginLimiter) Clear(ctx context.Context, email, ip string) error {
+	if email == "" && ip == "" {
+		return fmt.Errorf("email or ip is required")
+	}
+	if email != "" {
+		if err := l.store.Clear(ctx, emailAttemptKey(email)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if err := l.store.Clear(ctx, ipAttemptKey(ip)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type attemptCheck struct {
+	key   string
+	limit int
+}
+
+func (l *LoginLimiter) checks(email, ip string) []attemptCheck {
+	var checks []attemptCheck
+	if l.config.MaxFailuresPerEmail > 0 && email != "" {
+		checks = append(checks, attemptCheck{key: emailAttemptKey(email), limit: l.config.MaxFailuresPerEmail})
+	}
+	if l.config.MaxFailuresPerIP > 0 && ip != "" {
+		checks = append(checks, attemptCheck{key: ipAttemptKey(ip), limit: l.config.MaxFailuresPerIP})
+	}
+	return checks
+}
+
+func (l *LoginLimiter) isLocked(attempt *models.LoginAttempt) bool {
+	limit := l.config.MaxFailuresPerIP
+	if strings.HasPrefix(attempt.Key, "email:") {
+		limit = l.config.MaxFailuresPerEmail
+	}
+	return limit > 0 && attempt.Failures >= limit
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"task-management-api/clock"
+	"testing"
+	"time"
+)
+
+func TestLoginLimiterLockoutExpires(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewControllable()
+	clk.Freeze(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	limiter := NewLoginLimiter(NewMemoryLoginAttemptStore(), LoginLimitConfig{
+		MaxFailuresPerEmail: 3,
+		MaxFailuresPerIP:    10,
+		Window:              15 * time.Minute,
+	}, clk)
+
+	for i := 0; i < 3; i++ {
+		if wait := limiter.Check(ctx, "user@example.com", "10.0.0.1"); wait != 0 {
+			t.Fatalf("Check after %d failures = %v, want 0", i, wait)
+		}
+		limiter.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    time.Duration
+	}{
+		{name: "locked at the last failure", advance: 0, want: 15 * time.Minute},
+		{name: "still locked within the window", advance: 10 * time.Minute, want: 5 * time.Minute},
+		{name: "locked one second before the window ends", advance: 5*time.Minute - time.Second, want: time.Second},
+		{name: "unlocked once the window ends", advance: time.Second, want: 0},
+	}
+
+	for _, tt := range tests {
+		clk.Advance(tt.advance)
+		if got := limiter.Check(ctx, "user@example.com", "10.0.0.1"); got != tt.want {
+			t.Errorf("%s: Check = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A failure after the window starts a new count instead of locking again
+	limiter.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	if wait := limiter.Check(ctx, "user@example.com", "10.0.0.1"); wait != 0 {
+		t.Errorf("Check after one failure in a new window = %v, want 0", wait)
+	}
+}
+
+func TestLoginLimiterListDropsExpiredAttempts(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewControllable()
+	clk.Freeze(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	limiter := NewLoginLimiter(NewMemoryLoginAttemptStore(), LoginLimitConfig{
+		MaxFailuresPerEmail: 1,
+		Window:              time.Minute,
+	}, clk)
+	limiter.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+
+	attempts, err := limiter.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(attempts) != 1 || !attempts[0].Locked {
+		t.Fatalf("List = %+v, want one locked attempt", attempts)
+	}
+
+	clk.Advance(time.Minute)
+	attempts, err = limiter.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(attempts) != 0 {
+		t.Errorf("List after the window = %+v, want none", attempts)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"task-management-api/models"
+	"time"
+)
+
+// markdownSections lists the export headings in output order
+var markdownSections = []struct {
+	status  models.TaskStatus
+	heading string
+}{
+	{models.TaskStatusPending, "Pending"},
+	{models.TaskStatusInProgress, "In progress"},
+	{models.TaskStatusCompleted, "Completed"},
+}
+
+// markdownHeadings maps lower-cased headings to statuses on import; common
+// aliases are accepted so hand-written notes import without editing.
+var markdownHeadings = map[string]models.TaskStatus{
+	"pending":     models.TaskStatusPending,
+	"todo":        models.TaskStatusPending,
+	"to do":       models.TaskStatusPending,
+	"in progress": models.TaskStatusInProgress,
+	"in_progress": models.TaskStatusInProgress,
+	"doing":       models.TaskStatusInProgress,
+	"completed":   models.TaskStatusCompleted,
+	"done":        models.TaskStatusCompleted,
+}
+
+var (
+	markdownItemPattern = regexp.MustCompile(`^[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	markdownDuePattern  = regexp.MustCompile(`\s*\(due ([^()]+)\)$`)
+)
+
+// renderMarkdown writes tasks as a checklist grouped under one heading per status.
+// Empty sections are left out.
+func renderMarkdown(tasks []*models.Task) string {
+	var b strings.Builder
+	b.WriteString("# Tasks\n")
+
+	for _, section := range markdownSections {
+		var items []string
+		for _, task := range tasks {
+			if task.Status == section.status {
+				items = append(items, markdownItem(task))
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n\n", section.heading)
+		for _, item := range items {
+			b.WriteString(item)
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+func markdownItem(task *models.Task) string {
+	box := "[ ]"
+	if task.Status == models.TaskStatusCompleted {
+		box = "[x]"
+	}
+
+	// A checklist item is a single line
+	title := strings.Join(strings.Fields(task.Title), " ")
+	item := "- " + box + " " + title
+	if task.DueDate != nil {
+		item += " (due " + formatMarkdownDue(*task.DueDate) + ")"
+	}
+	return item
+}
+
+// formatMarkdownDue writes midnight UTC as a plain date, which is how most
+// due dates are set, and anything else as RFC 3339 so the time round-trips.
+func formatMarkdownDue(due time.Time) string {
+	due = due.UTC()
+	if due.Equal(due.Truncate(24 * time.Hour)) {
+		return due.Format("2006-01-02")
+	}
+	return models.FormatTime(due)
+}
+
+func parseMarkdownDue(value string) (*time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if due, err := time.Parse(layout, value); err == nil {
+			due = due.UTC()
+			return &due, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid due date %s, use YYYY-MM-DD or RFC 3339", value)
+}
+
+// parseMarkdown reads checklist items back into create requests. A checked box
+// always means completed; an unchecked one takes its heading's status, except
+// under a completed heading, where it is pending. Lines that are not checklist
+// items are ignored, so notes can be imported as they are.
+func parseMarkdown(text string, maxTasks int) ([]*models.CreateTaskRequest, error) {
+	var requests []*models.CreateTaskRequest
+	status := models.TaskStatusPending
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(text)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		trimmed := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(trimmed, "#") {
+			heading := strings.ToLower(strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			if s, ok := markdownHeadings[heading]; ok {
+				status = s
+			} else {
+				status = models.TaskStatusPending
+			}
+			continue
+		}
+
+		match := markdownItemPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+
+		req := &models.CreateTaskRequest{Status: status}
+		switch {
+		case match[1] != " ":
+			req.Status = models.TaskStatusCompleted
+		case status == models.TaskStatusCompleted:
+			req.Status = models.TaskStatusPending
+		}
+
+		title := match[2]
+		if due := markdownDuePattern.FindStringSubmatch(title); due != nil {
+			dueDate, err := parseMarkdownDue(strings.TrimSpace(due[1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			req.DueDate = dueDate
+			title = title[:len(title)-len(due[0])]
+		}
+
+		req.Title = strings.TrimSpace(title)
+		if req.Title == "" {
+			return nil, fmt.Errorf("line %d: title is required", line)
+		}
+
+		if len(requests) == maxTasks {
+			return nil, fmt.Errorf("at most %d tasks can be imported at once", maxTasks)
+		}
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read markdown: %w", err)
+	}
+
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no checklist items found")
+	}
+
+	return requests, nil
+}
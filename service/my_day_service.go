@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"log"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const myDayRolloverBatch = 500
+
+// MyDayService keeps each user's "my day" focus list. Days are UTC dates; at
+// the start of a day unfinished items roll over to it and finished, archived
+// or deleted tasks drop off.
+type MyDayService struct {
+	myDayRepo   *repository.MyDayRepository
+	taskRepo    *repository.TaskRepository
+	taskQueries *TaskQueryService
+	clock       clock.Clock
+}
+
+func NewMyDayService(myDayRepo *repository.MyDayRepository, taskRepo *repository.TaskRepository, taskQueries *TaskQueryService, clk clock.Clock) *MyDayService {
+	return &MyDayService{
+		myDayRepo:   myDayRepo,
+		taskRepo:    taskRepo,
+		taskQueries: taskQueries,
+		clock:       clk,
+	}
+}
+
+func (s *MyDayService) today() string {
+	return s.clock.Now().UTC().Format(planDateLayout)
+}
+
+// Add puts a task the user can read on their list for today
+func (s *MyDayService) Add(ctx context.Context, user *models.User, taskID primitive.ObjectID) (*models.MyDayEntry, error) {
+	task, err := s.taskQueries.GetTask(ctx, taskID, user)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.myDayRepo.Add(ctx, &models.MyDayItem{
+		UserID:  user.ID,
+		TaskID:  task.ID,
+		Day:     s.today(),
+		AddedAt: s.clock.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MyDayEntry{Task: task, AddedAt: item.AddedAt, RolloverCount: item.RolloverCount}, nil
+}
+
+func (s *MyDayService) Remove(ctx context.Context, user *models.User, taskID primitive.ObjectID) error {
+	return s.myDayRepo.Remove(ctx, user.ID, taskID)
+}
+
+// List returns today's list, rolling the user's items over first so the list
+// is right even when the daily job has not run yet.
+func (s *MyDayService) List(ctx context.Context, user *models.User) (*models.MyDayResponse, error) {
+	if _, _, err := s.RollOver(ctx, &user.ID); err != nil {
+		return nil, err
+	}
+
+	items, err := s.myDayRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs := make([]primitive.ObjectID, len(items))
+	for i, item := range items {
+		taskIDs[i] = item.TaskID
+	}
+	tasks, err := s.taskRepo.FindByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	response := &models.MyDayResponse{Date: s.today(), Tasks: []*models.MyDayEntry{}}
+	for _, item := range items {
+		// Tasks deleted today disappear now rather than at the next rollover
+		task, ok := byID[item.TaskID]
+		if !ok {
+			continue
+		}
+		response.Tasks = append(response.Tasks, &models.MyDayEntry{Task: task, AddedAt: item.AddedAt, RolloverCount: item.RolloverCount})
+	}
+
+	return response, nil
+}
+
+// RollOver moves items from earlier days to today, adding the days skipped to
+// their rollover count, and removes items whose task is completed, archived or
+// gone. A nil userID rolls over every user's list.
+func (s *MyDayService) RollOver(ctx context.Context, userID *primitive.ObjectID) (rolled, removed int64, err error) {
+	today := s.today()
+	todayDate, _ := time.Parse(planDateLayout, today)
+
+	// Every processed item leaves the stale set, so each batch makes progress
+	for {
+		items, err := s.myDayRepo.FindStale(ctx, today, userID, myDayRolloverBatch)
+		if err != nil {
+			return rolled, removed, err
+		}
+		if len(items) == 0 {
+			return rolled, removed, nil
+		}
+
+		taskIDs := make([]primitive.ObjectID, len(items))
+		for i, item := range items {
+			taskIDs[i] = item.TaskID
+		}
+		tasks, err := s.taskRepo.FindByIDs(ctx, taskIDs)
+		if err != nil {
+			return rolled, removed, err
+		}
+		open := make(map[primitive.ObjectID]bool, len(tasks))
+		for _, task := range tasks {
+			open[task.ID] = task.Status != models.TaskStatusCompleted && !task.Archived
+		}
+
+		var finished []primitive.ObjectID
+		for _, item := range items {
+			if !open[item.TaskID] {
+				finished = append(finished, item.ID)
+				continue
+			}
+
+			days := 1
+			if day, err := time.Parse(planDateLayout, item.Day); err == nil {
+				days = int(todayDate.Sub(day) / (24 * time.Hour))
+			}
+			if err := s.myDayRepo.RollOver(ctx, item, today, days); err != nil {
+				return rolled, removed, err
+			}
+			rolled++
+		}
+
+		if len(finished) > 0 {
+			deleted, err := s.myDayRepo.DeleteByIDs(ctx, finished)
+			if err != nil {
+				return rolled, removed, err
+			}
+			removed += deleted
+		}
+	}
+}
+
+// RunScheduled is the scheduled rollover job. Run hourly, as by default, lists
+// reset within an hour of midnight UTC.
+func (s *MyDayService) RunScheduled(ctx context.Context) error {
+	rolled, removed, err := s.RollOver(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if rolled > 0 || removed > 0 {
+		log.Printf("My day rollover: rolled over %d items, removed %d finished items", rolled, removed)
+	}
+	return nil
+}
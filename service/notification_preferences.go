@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"task-management-api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultNotificationPreferences are the events each way of reaching a user
+// gets until they choose otherwise. Slack and Telegram get every event, so
+// what each channel subscribes to decides.
+var defaultNotificationPreferences = map[string][]string{
+	models.NotificationChannelEmail:    {models.NotificationEventAutoCompleted, models.TaskEventDueSoon},
+	models.NotificationChannelSlack:    models.NotificationEvents,
+	models.NotificationChannelTelegram: models.NotificationEvents,
+	models.NotificationChannelPush:     {models.NotificationEventAssigned, models.TaskEventDueSoon},
+}
+
+// GetPreferences returns the user's preferences with defaults filled in for
+// channels they never changed
+func (s *NotificationService) GetPreferences(ctx context.Context, user *models.User) (*models.NotificationPreferences, error) {
+	stored, err := s.preferenceRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return withDefaultPreferences(user.ID, stored), nil
+}
+
+// UpdatePreferences replaces the events of the channels named in the request
+func (s *NotificationService) UpdatePreferences(ctx context.Context, user *models.User, req *models.UpdateNotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+	if len(req.Channels) == 0 {
+		return nil, fmt.Errorf("channels is required")
+	}
+
+	channels := make(map[string][]string, len(req.Channels))
+	for channel, requested := range req.Channels {
+		if _, ok := defaultNotificationPreferences[channel]; !ok {
+			return nil, fmt.Errorf("invalid notification channel, must be one of: %s", strings.Join(models.NotificationPreferenceChannels, ", "))
+		}
+		events := []string{}
+		if len(requested) > 0 {
+			var err error
+			if events, err = notificationEvents(channel, requested); err != nil {
+				return nil, err
+			}
+		}
+		channels[channel] = events
+	}
+
+	stored, err := s.preferenceRepo.SetChannels(ctx, user.ID, channels, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return withDefaultPreferences(user.ID, stored), nil
+}
+
+// preferredChannels reports, for each way of reaching the user, whether
+// their preferences let event through
+func (s *NotificationService) preferredChannels(ctx context.Context, userID primitive.ObjectID, event string) (map[string]bool, error) {
+	stored, err := s.preferenceRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferred := make(map[string]bool, len(models.NotificationPreferenceChannels))
+	for channel, events := range withDefaultPreferences(userID, stored).Channels {
+		for _, allowed := range events {
+			preferred[channel] = preferred[channel] || allowed == event
+		}
+	}
+	return preferred, nil
+}
+
+func withDefaultPreferences(userID primitive.ObjectID, stored *models.NotificationPreferences) *models.NotificationPreferences {
+	preferences := &models.NotificationPreferences{UserID: userID, Channels: make(map[string][]string, len(defaultNotificationPreferences))}
+	if stored != nil {
+		preferences.UpdatedAt = stored.UpdatedAt
+	}
+	for channel, events := range defaultNotificationPreferences {
+		if stored != nil && stored.Channels[channel] != nil {
+			events = stored.Channels[channel]
+		}
+		preferences.Channels[channel] = events
+	}
+	return preferences
+}
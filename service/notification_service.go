@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/notifications"
+	"task-management-api/outbound"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxNotificationChannelsPerUser = 10
+	maxSlackChannelChars           = 80
+	// telegramLinkTTL is how long a Telegram link can be opened
+	telegramLinkTTL = time.Hour
+)
+
+// defaultNotificationEvents are the events a channel of each type gets when
+// none are chosen
+var defaultNotificationEvents = map[string][]string{
+	models.NotificationChannelSlack:    {models.TaskEventCreated, models.TaskEventCompleted, models.NotificationEventAutoCompleted},
+	models.NotificationChannelTelegram: {models.TaskEventDueSoon, models.NotificationEventAutoCompleted},
+}
+
+// NotificationService tells task owners about their tasks by email, through
+// the chat channels they connect and with push messages to their browsers,
+// as far as their notification preferences allow. Every message goes out
+// through the notification queue, so a slow mail server or chat service
+// never delays a request.
+type NotificationService struct {
+	channelRepo    *repository.NotificationChannelRepository
+	pushRepo       *repository.PushSubscriptionRepository
+	preferenceRepo *repository.NotificationPreferenceRepository
+	userRepo       *repository.UserRepository
+	queue          *notifications.Queue
+	templates      *notifications.Renderer
+	client         *outbound.Client
+	clock          clock.Clock
+
+	// telegram is nil unless a bot is configured
+	telegram       *notifications.TelegramBot
+	telegramSecret string
+	// vapid is nil unless push keys are configured
+	vapid *notifications.VAPID
+}
+
+func NewNotificationService(channelRepo *repository.NotificationChannelRepository, pushRepo *repository.PushSubscriptionRepository, preferenceRepo *repository.NotificationPreferenceRepository, userRepo *repository.UserRepository, queue *notifications.Queue, templates *notifications.Renderer, client *outbound.Client, clk clock.Clock) *NotificationService {
+	return &NotificationService{
+		channelRepo:    channelRepo,
+		pushRepo:       pushRepo,
+		preferenceRepo: preferenceRepo,
+		userRepo:       userRepo,
+		queue:          queue,
+		templates:      templates,
+		client:         client,
+		clock:          clk,
+	}
+}
+
+// EnableTelegram lets users link Telegram chats with the bot. Updates for
+// the bot must carry secret. Set during startup.
+func (s *NotificationService) EnableTelegram(bot *notifications.TelegramBot, secret string) {
+	s.telegram = bot
+	s.telegramSecret = secret
+}
+
+// EnablePush lets browsers subscribe to push messages signed with vapid. Set
+// during startup.
+func (s *NotificationService) EnablePush(vapid *notifications.VAPID) {
+	s.vapid = vapid
+}
+
+// Subscribe sends the messages about task events and reassignments. Call
+// during startup.
+func (s *NotificationService) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.TaskCreated) {
+		s.taskEvent(ctx, e.Name(), e.Task, 0)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.TaskCompleted) {
+		if e.AutoCompleted() {
+			s.taskEvent(ctx, models.NotificationEventAutoCompleted, e.Task, e.AutoCompleteMinutes)
+			return
+		}
+		s.taskEvent(ctx, e.Name(), e.Task, 0)
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.TaskDueSoon) {
+		s.taskEvent(ctx, e.Name(), e.Task, int(e.Task.DueDate.Sub(s.clock.Now()).Round(time.Minute)/time.Minute))
+	})
+	events.Subscribe(bus, s.tasksAssigned)
+}
+
+// CreateChannel connects a Slack channel right away. A Telegram chat is
+// created pending, and is linked once the user opens the returned link.
+func (s *NotificationService) CreateChannel(ctx context.Context, user *models.User, req *models.CreateNotificationChannelRequest) (*models.CreateNotificationChannelResponse, error) {
+	if req.Type == "" {
+		return nil, fmt.Errorf("type is required")
+	}
+	if _, ok := defaultNotificationEvents[req.Type]; !ok {
+		return nil, fmt.Errorf("invalid type, must be one of: %s", strings.Join(models.NotificationChannelTypes, ", "))
+	}
+	events, err := notificationEvents(req.Type, req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	channel := models.NewNotificationChannel(user.ID, req.Type, events, now)
+	response := &models.CreateNotificationChannelResponse{NotificationChannel: channel}
+	switch req.Type {
+	case models.NotificationChannelSlack:
+		if channel.Slack, err = slackTarget(req.Slack); err != nil {
+			return nil, err
+		}
+	case models.NotificationChannelTelegram:
+		if s.telegram == nil {
+			return nil, fmt.Errorf("telegram notifications are not configured")
+		}
+		token, tokenHash, err := newSecureToken()
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := now.Add(telegramLinkTTL)
+		channel.Telegram = &models.TelegramTarget{LinkTokenHash: tokenHash, LinkExpiresAt: &expiresAt}
+		response.LinkURL = s.telegram.StartLink(token)
+	}
+
+	count, err := s.channelRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxNotificationChannelsPerUser {
+		return nil, fmt.Errorf("at most %d notification channels are allowed", maxNotificationChannelsPerUser)
+	}
+
+	if err := s.channelRepo.Create(ctx, channel); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// notificationEvents validates requested events, defaulting to the channel type's defaultNotificationEvents
+func notificationEvents(channelType string, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return defaultNotificationEvents[channelType], nil
+	}
+
+	var events []string
+	seen := make(map[string]bool, len(requested))
+	for _, event := range requested {
+		valid := false
+		for _, known := range models.NotificationEvents {
+			valid = valid || event == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid notification event, must be one of: %s", strings.Join(models.NotificationEvents, ", "))
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// slackTarget validates the Slack part of a new channel: an incoming webhook,
+// or a bot token and the channel to post to
+func slackTarget(req *models.SlackTargetRequest) (*models.SlackTarget, error) {
+	if req == nil {
+		return nil, fmt.Errorf("slack is required")
+	}
+	webhookURL := strings.TrimSpace(req.WebhookURL)
+	botToken := strings.TrimSpace(req.BotToken)
+	channel := strings.TrimSpace(req.Channel)
+
+	switch {
+	case webhookURL != "" && botToken == "" && channel == "":
+		if !notifications.ValidSlackWebhookURL(webhookURL) {
+			return nil, fmt.Errorf("invalid slack webhook_url, must start with https://%s/services/", notifications.SlackWebhookHost)
+		}
+		return &models.SlackTarget{WebhookURL: webhookURL}, nil
+	case webhookURL == "" && botToken != "" && channel != "" && len(channel) <= maxSlackChannelChars:
+		return &models.SlackTarget{BotToken: botToken, Channel: channel}, nil
+	default:
+		return nil, fmt.Errorf("slack needs either webhook_url, or bot_token and channel")
+	}
+}
+
+func (s *NotificationService) ListChannels(ctx context.Context, user *models.User) ([]*models.NotificationChannel, error) {
+	return s.channelRepo.FindByUserID(ctx, user.ID)
+}
+
+func (s *NotificationService) DeleteChannel(ctx context.Context, user *models.User, channelID primitive.ObjectID) error {
+	return s.channelRepo.Delete(ctx, channelID, user.ID)
+}
+
+// TestChannel sends a test message right away, so the caller learns whether
+// the channel works
+func (s *NotificationService) TestChannel(ctx context.Context, user *models.User, channelID primitive.ObjectID) error {
+	channel, err := s.channelRepo.FindByID(ctx, channelID, user.ID)
+	if err != nil {
+		return err
+	}
+	if channel.Telegram != nil && channel.Telegram.LinkedAt == nil {
+		return fmt.Errorf("notification channel is not linked yet")
+	}
+	notifier := s.notifier(channel)
+	if notifier == nil {
+		return fmt.Errorf("telegram notifications are not configured")
+	}
+	message, err := s.templates.Render(ctx, notifications.TemplateTest, nil)
+	if err != nil {
+		return err
+	}
+	if err := notifier.Notify(ctx, message); err != nil {
+		return fmt.Errorf("test message failed: %v", err)
+	}
+	return nil
+}
+
+// notifier sends to the channel's target. It is nil for Telegram chats while
+// no bot is configured.
+func (s *NotificationService) notifier(channel *models.NotificationChannel) notifications.Notifier {
+	switch {
+	case channel.Slack != nil && channel.Slack.WebhookURL != "":
+		return notifications.NewSlackWebhookNotifier(s.client, channel.Slack.WebhookURL)
+	case channel.Slack != nil:
+		return notifications.NewSlackBotNotifier(s.client, channel.Slack.BotToken, channel.Slack.Channel)
+	case channel.Telegram != nil && s.telegram != nil:
+		return s.telegram.Notifier(channel.Telegram.ChatID)
+	}
+	return nil
+}
+
+// HandleTelegramUpdate links the chat when the update is a /start from a
+// channel's link, and ignores every other update. The bot answers in the
+// chat either way.
+func (s *NotificationService) HandleTelegramUpdate(ctx context.Context, secret string, body []byte) error {
+	if s.telegram == nil {
+		return fmt.Errorf("telegram notifications are not configured")
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(s.telegramSecret)) != 1 {
+		return fmt.Errorf("invalid secret token")
+	}
+	var update notifications.TelegramUpdate
+	if err := json.Unmarshal(body, &update); err != nil {
+		return fmt.Errorf("invalid update body")
+	}
+
+	chatID, token, ok := update.StartPayload()
+	if !ok || token == "" {
+		return nil
+	}
+	channel, err := s.channelRepo.LinkTelegram(ctx, hashToken(token), chatID, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	reply := "Connected. Your task notifications will arrive in this chat."
+	if channel == nil {
+		reply = "This link has expired or was already used. Connect Telegram again to get a new one."
+	} else {
+		logf(ctx, "Linked Telegram chat to notification channel %s", channel.ID.Hex())
+	}
+	message := &notifications.Message{Subject: "Telegram link", Text: reply}
+	if err := s.queue.Deliver(ctx, s.telegram.Notifier(chatID), message); err != nil {
+		logf(ctx, "Failed to queue Telegram link reply: %v", err)
+	}
+	return nil
+}
+
+// notificationTemplates holds the message for each task event
+var notificationTemplates = map[string]string{
+	models.TaskEventCreated:               notifications.TemplateTaskCreated,
+	models.TaskEventCompleted:             notifications.TemplateTaskCompleted,
+	models.NotificationEventAutoCompleted: notifications.TemplateTaskAutoCompleted,
+	models.TaskEventDueSoon:               notifications.TemplateTaskDueSoon,
+}
+
+// taskEvent queues the messages about the event for the task's owner.
+// Minutes is the auto-complete delay, or how long until the task is due.
+// Failures are logged rather than returned.
+func (s *NotificationService) taskEvent(ctx context.Context, event string, task *models.Task, minutes int) {
+	if task.UserID.IsZero() {
+		return
+	}
+	template, ok := notificationTemplates[event]
+	if !ok {
+		return
+	}
+
+	data := notifications.TaskData{Title: emailTitle(task), DueAt: task.DueDate, Minutes: minutes}
+
+	s.dispatch(ctx, task.UserID, event, fmt.Sprintf("%s of task %s", event, task.ID.Hex()), func(username string) (*notifications.Message, error) {
+		data.Username = username
+		return s.templates.Render(ctx, template, data)
+	})
+}
+
+// tasksAssigned tells the user how many open tasks an admin reassigned to
+// them. Failures are logged rather than returned.
+func (s *NotificationService) tasksAssigned(ctx context.Context, e events.TasksAssigned) {
+	s.dispatch(ctx, e.UserID, e.Name(), fmt.Sprintf("%s of %d tasks", e.Name(), e.Count), func(username string) (*notifications.Message, error) {
+		return s.templates.Render(ctx, notifications.TemplateTasksAssigned, notifications.TasksAssignedData{Username: username, Count: e.Count})
+	})
+}
+
+// dispatch queues the message about event to every way of reaching the user
+// their preferences allow: their verified email address, their Slack and
+// Telegram channels subscribed to the event and their browsers. Every
+// notification to a user goes through it. Subject names the message in logs.
+func (s *NotificationService) dispatch(ctx context.Context, userID primitive.ObjectID, event, subject string, render func(username string) (*notifications.Message, error)) {
+	preferred, err := s.preferredChannels(ctx, userID, event)
+	if err != nil {
+		logf(ctx, "Failed to find notification preferences for %s: %v", subject, err)
+		return
+	}
+
+	var notifiers []notifications.Notifier
+	if preferred[models.NotificationChannelSlack] || preferred[models.NotificationChannelTelegram] {
+		channels, err := s.channelRepo.FindSubscribed(ctx, userID, event)
+		if err != nil {
+			logf(ctx, "Failed to find notification channels for %s: %v", subject, err)
+			return
+		}
+		for _, channel := range channels {
+			if !preferred[channel.Type] {
+				continue
+			}
+			if notifier := s.notifier(channel); notifier != nil {
+				notifiers = append(notifiers, notifier)
+			}
+		}
+	}
+	if preferred[models.NotificationChannelPush] {
+		notifiers = append(notifiers, s.pushNotifiers(ctx, userID)...)
+	}
+	if len(notifiers) == 0 && !preferred[models.NotificationChannelEmail] {
+		return
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if err.Error() != "user not found" {
+			logf(ctx, "Failed to find recipient of %s: %v", subject, err)
+		}
+		return
+	}
+	if preferred[models.NotificationChannelEmail] && user.IsEmailVerified() {
+		notifiers = append(notifiers, s.queue.Email(user.Email))
+	}
+	if len(notifiers) == 0 {
+		return
+	}
+	message, err := render(user.Username)
+	if err != nil {
+		logf(ctx, "Failed to render %s: %v", subject, err)
+		return
+	}
+
+	for _, notifier := range notifiers {
+		if err := s.queue.Deliver(ctx, notifier, message); err != nil {
+			logf(ctx, "Failed to queue %s to %s: %v", subject, notifier, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/notifications"
+	"task-management-api/repository"
+)
+
+// NotificationTemplateService lets admins replace the built-in message
+// templates, so a deployment can brand its emails and chat messages without
+// a new build. Custom templates are read on every render, so a change
+// reaches every instance at once.
+type NotificationTemplateService struct {
+	templateRepo *repository.NotificationTemplateRepository
+	auditRepo    *repository.AuditRepository
+	clock        clock.Clock
+}
+
+func NewNotificationTemplateService(templateRepo *repository.NotificationTemplateRepository, auditRepo *repository.AuditRepository, clk clock.Clock) *NotificationTemplateService {
+	return &NotificationTemplateService{
+		templateRepo: templateRepo,
+		auditRepo:    auditRepo,
+		clock:        clk,
+	}
+}
+
+// List returns every template, custom where one is stored and built-in otherwise
+func (s *NotificationTemplateService) List(ctx context.Context) ([]*models.NotificationTemplate, error) {
+	stored, err := s.templateRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	custom := make(map[string]*models.NotificationTemplate, len(stored))
+	for _, template := range stored {
+		custom[template.Name] = template
+	}
+
+	templates := make([]*models.NotificationTemplate, 0, len(notifications.TemplateNames))
+	for _, name := range notifications.TemplateNames {
+		if template, ok := custom[name]; ok {
+			template.Custom = true
+			templates = append(templates, template)
+			continue
+		}
+		templates = append(templates, builtInTemplate(name))
+	}
+	return templates, nil
+}
+
+func (s *NotificationTemplateService) Get(ctx context.Context, name string) (*models.NotificationTemplate, error) {
+	if !slices.Contains(notifications.TemplateNames, name) {
+		return nil, fmt.Errorf("notification template not found")
+	}
+	template, err := s.templateRepo.FindByName(ctx, name)
+	if err != nil {
+		if err.Error() == "custom notification template not found" {
+			return builtInTemplate(name), nil
+		}
+		return nil, err
+	}
+	template.Custom = true
+	return template, nil
+}
+
+// Update stores a custom template in place of the built-in one. It must
+// render with the template's example data.
+func (s *NotificationTemplateService) Update(ctx context.Context, actor *models.User, name string, req *models.UpdateNotificationTemplateRequest) (*models.NotificationTemplate, error) {
+	if !slices.Contains(notifications.TemplateNames, name) {
+		return nil, fmt.Errorf("notification template not found")
+	}
+	if err := notifications.ValidateCustom(name, customTemplate(req)); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	template := &models.NotificationTemplate{
+		Name:      name,
+		Subject:   req.Subject,
+		Body:      req.Body,
+		Text:      req.Text,
+		Custom:    true,
+		UpdatedBy: &actor.ID,
+		UpdatedAt: &now,
+	}
+	if err := s.templateRepo.Save(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actor, "notification_template.update", name)
+	return template, nil
+}
+
+// Delete removes the custom template, so the built-in one applies again
+func (s *NotificationTemplateService) Delete(ctx context.Context, actor *models.User, name string) error {
+	if !slices.Contains(notifications.TemplateNames, name) {
+		return fmt.Errorf("notification template not found")
+	}
+	if err := s.templateRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actor, "notification_template.delete", name)
+	return nil
+}
+
+// Preview renders a template, without storing it, with the example data
+func (s *NotificationTemplateService) Preview(name string, req *models.UpdateNotificationTemplateRequest) (*models.NotificationTemplatePreview, error) {
+	if !slices.Contains(notifications.TemplateNames, name) {
+		return nil, fmt.Errorf("notification template not found")
+	}
+	message, err := notifications.PreviewCustom(name, customTemplate(req))
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationTemplatePreview{Subject: message.Subject, Body: message.Body, Text: message.Text}, nil
+}
+
+// CustomTemplate makes the service the notifications.TemplateStore that
+// messages are rendered from
+func (s *NotificationTemplateService) CustomTemplate(ctx context.Context, name string) (*notifications.Custom, error) {
+	template, err := s.templateRepo.FindByName(ctx, name)
+	if err != nil {
+		if err.Error() == "custom notification template not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &notifications.Custom{Subject: template.Subject, Body: template.Body, Text: template.Text}, nil
+}
+
+func (s *NotificationTemplateService) audit(ctx context.Context, actor *models.User, action, name string) {
+	entry := models.NewAuditLog(actor.ID, action, "notification_template", actor.ID, map[string]interface{}{"name": name}, s.clock.Now())
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s: %v", entry.Action, err)
+	}
+}
+
+func builtInTemplate(name string) *models.NotificationTemplate {
+	source, _ := notifications.BuiltIn(name)
+	return &models.NotificationTemplate{Name: name, Subject: source.Subject, Body: source.Body, Text: source.Text}
+}
+
+func customTemplate(req *models.UpdateNotificationTemplateRequest) *notifications.Custom {
+	return &notifications.Custom{Subject: req.Subject, Body: req.Body, Text: req.Text}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"task-management-api/models"
+)
+
+// OAuthProvider is an external login provider using the authorization code flow.
+// Each provider is a small adapter that turns a code into an OAuthProfile.
+type OAuthProvider interface {
+	Name() string
+	AuthCodeURL(state string) string
+	Exchange(ctx context.Context, code string) (*OAuthProfile, error)
+}
+
+// OAuthProfile is the part of a provider profile used to find or create a user.
+type OAuthProfile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// Role is set by providers that map claims to roles and is applied at every login
+	Role models.UserRole
+}
+
+// exchangeOAuthCode performs the standard token request and returns the access token.
+func exchangeOAuthCode(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := oauthDoJSON(client, req, &token); err != nil {
+		return "", fmt.Errorf("failed to exchange code: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("failed to exchange code: no access token")
+	}
+
+	return token.AccessToken, nil
+}
+
+// oauthGetJSON fetches a provider API resource with the user's access token.
+func oauthGetJSON(ctx context.Context, client *http.Client, resourceURL, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build profile request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	return oauthDoJSON(client, req, out)
+}
+
+func oauthDoJSON(client *http.Client, req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
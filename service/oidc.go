@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCConfig configures a generic OpenID Connect issuer such as Okta, Keycloak or Azure AD.
+type OIDCConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// RoleClaim is a claim name, or a dotted path such as realm_access.roles,
+	// holding a string or a list of strings
+	RoleClaim string
+	// RoleMapping entries look like "task-admins=admin"
+	RoleMapping []string
+}
+
+// OIDCProvider is an OAuthProvider for any issuer that supports discovery.
+type OIDCProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	authURL      string
+	tokenURL     string
+	userInfoURL  string
+	roleClaim    string
+	roleMapping  map[string]models.UserRole
+	client       *http.Client
+	clock        clock.Clock
+}
+
+// DiscoverOIDC reads the issuer's discovery document and validates the role mapping.
+func DiscoverOIDC(ctx context.Context, cfg OIDCConfig, client *http.Client, clk clock.Clock) (*OIDCProvider, error) {
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("OIDC_CLIENT_ID is required")
+	}
+	roleMapping, err := parseRoleMapping(cfg.RoleMapping)
+	if err != nil {
+		return nil, err
+	}
+	if len(roleMapping) > 0 && cfg.RoleClaim == "" {
+		return nil, fmt.Errorf("OIDC_ROLE_CLAIM is required with OIDC_ROLE_MAPPING")
+	}
+
+	issuer := strings.TrimRight(cfg.IssuerURL, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	var discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := oauthDoJSON(client, req, &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover %s: %w", issuer, err)
+	}
+	// Some issuers publish a trailing slash; anything else means a misconfigured URL
+	if strings.TrimRight(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("issuer mismatch: configured %s, discovered %s", issuer, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" {
+		return nil, fmt.Errorf("discovery document of %s lacks authorization or token endpoint", issuer)
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &OIDCProvider{
+		name:         cfg.Name,
+		issuer:       discovery.Issuer,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		scopes:       scopes,
+		authURL:      discovery.AuthorizationEndpoint,
+		tokenURL:     discovery.TokenEndpoint,
+		userInfoURL:  discovery.UserInfoEndpoint,
+		roleClaim:    cfg.RoleClaim,
+		roleMapping:  roleMapping,
+		client:       client,
+		clock:        clk,
+	}, nil
+}
+
+func parseRoleMapping(entries []string) (map[string]models.UserRole, error) {
+	mapping := make(map[string]models.UserRole, len(entries))
+	for _, entry := range entries {
+		value, role, ok := strings.Cut(entry, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid OIDC_ROLE_MAPPING entry %q, use value=role", entry)
+		}
+		if _, known := models.RolePermissions[models.UserRole(role)]; !known {
+			return nil, fmt.Errorf("invalid OIDC_ROLE_MAPPING entry %q, role must be user or admin", entry)
+		}
+		mapping[value] = models.UserRole(role)
+	}
+	return mapping, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) AuthCodeURL(state string) string {
+	params := url.Values{
+		"client_id":     {p.clientID},
+		"redirect_uri":  {p.redirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(p.scopes, " ")},
+		"state":         {state},
+	}
+	separator := "?"
+	if strings.Contains(p.authURL, "?") {
+		separator = "&"
+	}
+	return p.authURL + separator + params.Encode()
+}
+
+// Exchange trades the code for tokens and builds the profile from the ID token's
+// claims, topped up from the userinfo endpoint when the email is missing.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string) (*OAuthProfile, error) {
+	form := url.Values{
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"redirect_uri":  {p.redirectURL},
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+	}
+	if err := oauthDoJSON(p.client, req, &token); err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("failed to exchange code: no id token")
+	}
+
+	claims, err := p.verifyIDToken(token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := claims["email"].(string); !ok && p.userInfoURL != "" && token.AccessToken != "" {
+		var info jwt.MapClaims
+		if err := oauthGetJSON(ctx, p.client, p.userInfoURL, token.AccessToken, &info); err != nil {
+			return nil, fmt.Errorf("failed to fetch profile: %w", err)
+		}
+		// The ID token's subject wins; userinfo only fills gaps
+		for key, value := range info {
+			if _, ok := claims[key]; !ok {
+				claims[key] = value
+			}
+		}
+	}
+
+	profile := &OAuthProfile{Provider: p.name}
+	profile.Subject, _ = claims["sub"].(string)
+	profile.Email, _ = claims["email"].(string)
+	profile.Name, _ = claims["name"].(string)
+	// Azure AD sends no email_verified; such emails are treated as unverified
+	profile.EmailVerified, _ = claims["email_verified"].(bool)
+	if profile.Subject == "" || profile.Email == "" {
+		return nil, fmt.Errorf("failed to fetch profile: missing subject or email")
+	}
+	if len(p.roleMapping) > 0 {
+		profile.Role = p.mapRole(claims)
+	}
+
+	return profile, nil
+}
+
+// verifyIDToken checks the ID token's issuer, audience and expiry. The
+// signature is not checked: the token comes straight from the token endpoint
+// over TLS, which OpenID Connect Core 3.1.3.7 accepts in place of a signature.
+func (p *OIDCProvider) verifyIDToken(idToken string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, claims); err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if issuer, _ := claims.GetIssuer(); issuer != p.issuer {
+		return nil, fmt.Errorf("invalid id token: unexpected issuer %q", issuer)
+	}
+	audience, _ := claims.GetAudience()
+	issuedForClient := false
+	for _, aud := range audience {
+		if aud == p.clientID {
+			issuedForClient = true
+		}
+	}
+	if !issuedForClient {
+		return nil, fmt.Errorf("invalid id token: not issued for this client")
+	}
+	expiresAt, _ := claims.GetExpirationTime()
+	if expiresAt == nil || !p.clock.Now().Before(expiresAt.Time) {
+		return nil, fmt.Errorf("invalid id token: expired")
+	}
+
+	return claims, nil
+}
+
+// mapRole grants the most privileged role any of the user's claim values maps
+// to; users matching no entry get the default user role, so removing someone
+// from a group demotes them at their next login.
+func (p *OIDCProvider) mapRole(claims jwt.MapClaims) models.UserRole {
+	role := models.UserRoleUser
+	for _, value := range claimValues(claims, p.roleClaim) {
+		if mapped, ok := p.roleMapping[value]; ok && mapped == models.UserRoleAdmin {
+			role = models.UserRoleAdmin
+		}
+	}
+	return role
+}
+
+// claimValues follows a dotted path into the claims and returns the string or strings found there
+func claimValues(claims map[string]interface{}, path string) []string {
+	var current interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[key]
+	}
+
+	switch value := current.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"task-management-api/models"
+	"time"
+	"unicode/utf16"
+)
+
+// Page geometry in points: A4 portrait
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 36.0
+)
+
+// pdfFont is one of the standard fonts every PDF reader has, so no font is
+// embedded; text is written in their WinAnsi encoding
+type pdfFont int
+
+const (
+	pdfRegular pdfFont = iota
+	pdfBold
+)
+
+var pdfFontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// pdfFontWidths are the advance widths of the printable ASCII characters, in
+// thousandths of the font size, from the fonts' Adobe metrics
+var pdfFontWidths = [][95]int{
+	pdfRegular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	pdfBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// pdfDefaultWidth is used for the non-ASCII characters, which are rare enough
+// in titles that an average width keeps truncation close
+const pdfDefaultWidth = 556
+
+// winAnsiSpecials are the characters WinAnsi places in 0x80-0x9F; 0xA0-0xFF
+// match Latin-1
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// winAnsi encodes s for the standard fonts. Characters they cannot show become
+// '?', and control characters become spaces.
+func winAnsi(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x20:
+			encoded = append(encoded, ' ')
+		case r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		default:
+			if b, ok := winAnsiSpecials[r]; ok {
+				encoded = append(encoded, b)
+			} else {
+				encoded = append(encoded, '?')
+			}
+		}
+	}
+	return encoded
+}
+
+func pdfTextWidth(font pdfFont, size float64, s string) float64 {
+	total := 0
+	for _, b := range winAnsi(s) {
+		if b >= 0x20 && b < 0x7F {
+			total += pdfFontWidths[font][b-0x20]
+		} else {
+			total += pdfDefaultWidth
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfFit shortens s with an ellipsis until it fits in width
+func pdfFit(font pdfFont, size, width float64, s string) string {
+	if pdfTextWidth(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(font, size, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ") + "…"
+}
+
+// pdfColor is an RGB color with components from 0 to 1
+type pdfColor struct {
+	r, g, b float64
+}
+
+var (
+	pdfBlack     = pdfColor{0, 0, 0}
+	pdfGray      = pdfColor{0.4, 0.4, 0.4}
+	pdfRuleColor = pdfColor{0.75, 0.75, 0.75}
+	// pdfHeaderFill and the status fills match the Excel export
+	pdfHeaderFill = pdfColor{0.851, 0.851, 0.851}
+)
+
+// pdfPage collects the content stream of one page. Coordinates start at the
+// bottom left corner, as in PDF.
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+func (p *pdfPage) text(font pdfFont, size, x, y float64, color pdfColor, s string) {
+	fmt.Fprintf(&p.content, "BT %s rg /F%d %s Tf %s %s Td (", color, font+1, pdfNumber(size), pdfNumber(x), pdfNumber(y))
+	for _, b := range winAnsi(s) {
+		if b == '(' || b == ')' || b == '\\' {
+			p.content.WriteByte('\\')
+		}
+		p.content.WriteByte(b)
+	}
+	p.content.WriteString(") Tj ET\n")
+}
+
+// textRight writes s so that it ends at x
+func (p *pdfPage) textRight(font pdfFont, size, x, y float64, color pdfColor, s string) {
+	p.text(font, size, x-pdfTextWidth(font, size, s), y, color, s)
+}
+
+func (p *pdfPage) rect(x, y, width, height float64, color pdfColor) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n", color, pdfNumber(x), pdfNumber(y), pdfNumber(width), pdfNumber(height))
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2 float64, color pdfColor) {
+	fmt.Fprintf(&p.content, "%s RG 0.5 w %s %s m %s %s l S\n", color, pdfNumber(x1), pdfNumber(y1), pdfNumber(x2), pdfNumber(y2))
+}
+
+func (c pdfColor) String() string {
+	return pdfNumber(c.r) + " " + pdfNumber(c.g) + " " + pdfNumber(c.b)
+}
+
+// pdfNumber writes n with at most two decimals, which is finer than print needs
+func pdfNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+}
+
+// pdfTextString encodes a document information string as UTF-16 with a byte
+// order mark, which readers show in any script
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// writePDF assembles the pages into a PDF 1.4 file with compressed content streams
+func writePDF(pages []*pdfPage, title string, createdAt time.Time) ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-5 are fixed; each page then takes a page object and its content
+	const firstPageObject = 6
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObject+2*i)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	for _, name := range pdfFontNames {
+		object("<< /Type /Font /Subtype /Type1 /BaseFont /" + name + " /Encoding /WinAnsiEncoding >>")
+	}
+	object(fmt.Sprintf("<< /Title %s /Producer (task-management-api) /CreationDate (D:%s) >>",
+		pdfTextString(title), createdAt.UTC().Format("20060102150405Z")))
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return nil, fmt.Errorf("failed to compress PDF page: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress PDF page: %w", err)
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(pdfPageWidth), pdfNumber(pdfPageHeight), firstPageObject+2*i+1))
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes(), nil
+}
+
+// Task report
+
+// taskReport is what a PDF task report shows
+type taskReport struct {
+	// subject names the user whose tasks are shown
+	subject     string
+	from, to    *time.Time
+	generatedAt time.Time
+	tasks       []*models.Task
+	// hiddenPrivate counts private tasks left out because they stayed sealed
+	hiddenPrivate int
+}
+
+// pdfReportColumn is one column of the report's task table
+type pdfReportColumn struct {
+	header string
+	width  float64
+	cell   func(task *models.Task) string
+}
+
+var pdfReportColumns = []pdfReportColumn{
+	{header: "Title", width: 223, cell: func(t *models.Task) string { return t.Title }},
+	{header: "Status", width: 70, cell: func(t *models.Task) string { return string(t.Status) }},
+	{header: "Priority", width: 50, cell: func(t *models.Task) string { return string(t.Priority) }},
+	{header: "Due (UTC)", width: 90, cell: func(t *models.Task) string {
+		if t.DueDate == nil {
+			return ""
+		}
+		return formatReportTime(*t.DueDate)
+	}},
+	{header: "Created (UTC)", width: 90, cell: func(t *models.Task) string { return formatReportTime(t.CreatedAt) }},
+}
+
+// pdfStatusRows are the bars of the status chart, with the fills of the table's
+// status cells and stronger colors for the bars
+var pdfStatusRows = []struct {
+	status models.TaskStatus
+	label  string
+	fill   pdfColor
+	bar    pdfColor
+}{
+	{models.TaskStatusPending, "Pending", pdfColor{1, 0.949, 0.8}, pdfColor{0.945, 0.761, 0.196}},
+	{models.TaskStatusInProgress, "In progress", pdfColor{0.867, 0.922, 0.969}, pdfColor{0.357, 0.608, 0.835}},
+	{models.TaskStatusCompleted, "Completed", pdfColor{0.886, 0.937, 0.855}, pdfColor{0.439, 0.678, 0.278}},
+}
+
+const (
+	pdfTableFontSize = 9
+	pdfRowHeight     = 15
+	// pdfFooterSpace is kept free at the bottom of every page for the page number
+	pdfFooterSpace = 24
+	pdfCellPadding = 4
+)
+
+// formatReportTime writes midnight UTC as a plain date, like the Markdown export
+func formatReportTime(t time.Time) string {
+	t = t.UTC()
+	if t.Equal(t.Truncate(24 * time.Hour)) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// renderTaskReportPDF lays out the report: a heading and status chart on the
+// first page, then the task table across as many pages as it needs, with the
+// table header repeated on each
+func renderTaskReportPDF(report *taskReport) ([]byte, error) {
+	var pages []*pdfPage
+	page := &pdfPage{}
+	pages = append(pages, page)
+	y := pdfPageHeight - pdfMargin
+
+	y -= 18
+	page.text(pdfBold, 18, pdfMargin, y, pdfBlack, "Task report")
+	y -= 8
+	for _, line := range []string{
+		"User: " + report.subject,
+		"Created: " + reportRange(report.from, report.to),
+		"Generated: " + report.generatedAt.UTC().Format("2006-01-02 15:04") + " UTC",
+	} {
+		y -= 14
+		page.text(pdfRegular, 10, pdfMargin, y, pdfGray, line)
+	}
+
+	// Status summary
+	counts := make(map[models.TaskStatus]int)
+	overdue := 0
+	for _, task := range report.tasks {
+		counts[task.Status]++
+		if task.Status != models.TaskStatusCompleted && task.DueDate != nil && task.DueDate.Before(report.generatedAt) {
+			overdue++
+		}
+	}
+	maxCount := 1
+	for _, row := range pdfStatusRows {
+		maxCount = max(maxCount, counts[row.status])
+	}
+
+	y -= 30
+	page.text(pdfBold, 12, pdfMargin, y, pdfBlack, "Status summary")
+	const labelWidth, barMaxWidth, barHeight = 80.0, 340.0, 14.0
+	for _, row := range pdfStatusRows {
+		y -= 22
+		count := counts[row.status]
+		page.text(pdfRegular, 10, pdfMargin, y+3.5, pdfBlack, row.label)
+		barWidth := barMaxWidth * float64(count) / float64(maxCount)
+		if barWidth > 0 {
+			page.rect(pdfMargin+labelWidth, y, barWidth, barHeight, row.bar)
+		}
+		page.text(pdfRegular, 10, pdfMargin+labelWidth+barWidth+6, y+3.5, pdfBlack, strconv.Itoa(count))
+	}
+	y -= 24
+	summary := fmt.Sprintf("%d tasks, %d overdue", len(report.tasks), overdue)
+	if report.hiddenPrivate > 0 {
+		summary += fmt.Sprintf("; %d private tasks are not shown", report.hiddenPrivate)
+	}
+	page.text(pdfRegular, 10, pdfMargin, y, pdfGray, summary)
+
+	// Task table
+	y -= 30
+	page.text(pdfBold, 12, pdfMargin, y, pdfBlack, "Tasks")
+	y -= 10
+	if len(report.tasks) == 0 {
+		y -= 14
+		page.text(pdfRegular, 10, pdfMargin, y, pdfGray, "No tasks match this report.")
+	} else {
+		y = pdfTableHeader(page, y)
+	}
+	fills := make(map[models.TaskStatus]pdfColor, len(pdfStatusRows))
+	for _, row := range pdfStatusRows {
+		fills[row.status] = row.fill
+	}
+	for _, task := range report.tasks {
+		if y-pdfRowHeight < pdfMargin+pdfFooterSpace {
+			page = &pdfPage{}
+			pages = append(pages, page)
+			y = pdfTableHeader(page, pdfPageHeight-pdfMargin)
+		}
+
+		y -= pdfRowHeight
+		x := pdfMargin
+		for _, column := range pdfReportColumns {
+			value := column.cell(task)
+			if column.header == "Status" {
+				if fill, ok := fills[task.Status]; ok {
+					page.rect(x, y, column.width, pdfRowHeight, fill)
+				}
+			}
+			if value != "" {
+				value = pdfFit(pdfRegular, pdfTableFontSize, column.width-2*pdfCellPadding, value)
+				page.text(pdfRegular, pdfTableFontSize, x+pdfCellPadding, y+4.5, pdfBlack, value)
+			}
+			x += column.width
+		}
+		page.line(pdfMargin, y, x, y, pdfRuleColor)
+	}
+
+	for i, page := range pages {
+		page.textRight(pdfRegular, 8, pdfPageWidth-pdfMargin, pdfMargin, pdfGray, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+
+	return writePDF(pages, "Task report: "+report.subject, report.generatedAt)
+}
+
+// pdfTableHeader draws the table's header row below y and returns its bottom
+func pdfTableHeader(page *pdfPage, y float64) float64 {
+	y -= pdfRowHeight + 2
+	x := pdfMargin
+	for _, column := range pdfReportColumns {
+		page.rect(x, y, column.width, pdfRowHeight+2, pdfHeaderFill)
+		page.text(pdfBold, pdfTableFontSize, x+pdfCellPadding, y+5.5, pdfBlack, column.header)
+		x += column.width
+	}
+	return y
+}
+
+// reportRange describes the report's days; to is exclusive, so the last day
+// shown is the one before it
+func reportRange(from, to *time.Time) string {
+	switch {
+	case from != nil && to != nil:
+		return from.Format("2006-01-02") + " to " + to.AddDate(0, 0, -1).Format("2006-01-02")
+	case from != nil:
+		return "from " + from.Format("2006-01-02")
+	case to != nil:
+		return "until " + to.AddDate(0, 0, -1).Format("2006-01-02")
+	default:
+		return "any date"
+	}
+}
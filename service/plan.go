@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"task-management-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Buckets beyond these sizes are truncated rather than paginated
+	maxPlanScheduledTasks   = 1000
+	maxPlanUnscheduledTasks = 200
+)
+
+const planDateLayout = "2006-01-02"
+
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// parseISOWeek returns the Monday that starts an ISO 8601 week such as "2024-W21"
+func parseISOWeek(week string) (time.Time, error) {
+	match := isoWeekPattern.FindStringSubmatch(week)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("invalid week, use YYYY-Www such as 2024-W21")
+	}
+	year, _ := strconv.Atoi(match[1])
+	number, _ := strconv.Atoi(match[2])
+
+	// January 4th always falls in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(number-1)*7)
+
+	if y, w := monday.ISOWeek(); number < 1 || y != year || w != number {
+		return time.Time{}, fmt.Errorf("invalid week, %d has no week %02d", year, number)
+	}
+	return monday, nil
+}
+
+func formatISOWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+// GetWeeklyPlan groups the user's tasks due in an ISO week by UTC day and adds
+// their open tasks without a due date. An empty week means the current one.
+func (s *TaskQueryService) GetWeeklyPlan(ctx context.Context, user *models.User, week string) (*models.WeeklyPlan, error) {
+	if week == "" {
+		week = formatISOWeek(s.clock.Now().UTC())
+	}
+	start, err := parseISOWeek(week)
+	if err != nil {
+		return nil, err
+	}
+	end := start.AddDate(0, 0, 7)
+
+	scheduled, err := s.taskRepo.FindDueBetween(ctx, user.ID, start, end, maxPlanScheduledTasks)
+	if err != nil {
+		return nil, err
+	}
+	unscheduled, err := s.taskRepo.FindUnscheduled(ctx, user.ID, maxPlanUnscheduledTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.WeeklyPlan{
+		Week:        week,
+		StartDate:   start.Format(planDateLayout),
+		EndDate:     end.AddDate(0, 0, -1).Format(planDateLayout),
+		Days:        make([]*models.PlanDay, 7),
+		Unscheduled: unscheduled,
+	}
+	for i := range plan.Days {
+		day := start.AddDate(0, 0, i)
+		plan.Days[i] = &models.PlanDay{
+			Date:    day.Format(planDateLayout),
+			Weekday: strings.ToLower(day.Weekday().String()),
+			Tasks:   []*models.Task{},
+		}
+	}
+	for _, task := range scheduled {
+		i := int(task.DueDate.UTC().Sub(start) / (24 * time.Hour))
+		plan.Days[i].Tasks = append(plan.Days[i].Tasks, task)
+	}
+
+	return plan, nil
+}
+
+// ScheduleTask moves a task's due date to another day, keeping any time of day
+// it already had, or clears the due date when date is nil.
+func (s *TaskService) ScheduleTask(ctx context.Context, taskID primitive.ObjectID, user *models.User, date *string) (*models.Task, error) {
+	req := &models.UpdateTaskRequest{}
+	req.DueDate.Set = true
+
+	if date != nil {
+		day, err := time.Parse(planDateLayout, *date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date, use YYYY-MM-DD")
+		}
+
+		task, err := s.taskRepo.FindByID(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if task.DueDate != nil {
+			current := task.DueDate.UTC()
+			day = day.Add(current.Sub(current.Truncate(24 * time.Hour)))
+		}
+		req.DueDate.Value = &day
+	}
+
+	return s.UpdateTask(ctx, taskID, user, req)
+}
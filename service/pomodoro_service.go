@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPomodoroMinutes = 25
+	maxPomodoroMinutes     = 120
+	maxFocusStatsSessions  = 5000
+)
+
+// Focus stats periods
+const (
+	FocusPeriodDay  = "day"
+	FocusPeriodWeek = "week"
+)
+
+// PomodoroService runs focus sessions against tasks. A user has at most one
+// running session; a session nobody stops counts as completed at its planned end.
+type PomodoroService struct {
+	sessionRepo *repository.FocusSessionRepository
+	taskRepo    *repository.TaskRepository
+	taskQueries *TaskQueryService
+	clock       clock.Clock
+}
+
+func NewPomodoroService(sessionRepo *repository.FocusSessionRepository, taskRepo *repository.TaskRepository, taskQueries *TaskQueryService, clk clock.Clock) *PomodoroService {
+	return &PomodoroService{
+		sessionRepo: sessionRepo,
+		taskRepo:    taskRepo,
+		taskQueries: taskQueries,
+		clock:       clk,
+	}
+}
+
+// Start begins a session on a task the user can read
+func (s *PomodoroService) Start(ctx context.Context, user *models.User, taskID primitive.ObjectID, req *models.StartPomodoroRequest) (*models.FocusSession, error) {
+	minutes := req.Minutes
+	if minutes == 0 {
+		minutes = defaultPomodoroMinutes
+	}
+	if minutes < 1 || minutes > maxPomodoroMinutes {
+		return nil, fmt.Errorf("minutes must be between 1 and %d", maxPomodoroMinutes)
+	}
+
+	if _, err := s.taskQueries.GetTask(ctx, taskID, user); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	if _, err := s.sessionRepo.FindRunning(ctx, user.ID, now); err == nil {
+		return nil, fmt.Errorf("a pomodoro session is already running")
+	} else if err.Error() != "no pomodoro session is running" {
+		return nil, err
+	}
+
+	session := &models.FocusSession{
+		UserID:         user.ID,
+		TaskID:         taskID,
+		PlannedMinutes: minutes,
+		StartedAt:      now,
+		EndsAt:         now.Add(time.Duration(minutes) * time.Minute),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	s.derive(session, now)
+	return session, nil
+}
+
+// Stop ends the user's running session on the task early
+func (s *PomodoroService) Stop(ctx context.Context, user *models.User, taskID primitive.ObjectID) (*models.FocusSession, error) {
+	now := s.clock.Now()
+	session, err := s.sessionRepo.FindRunning(ctx, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if session.TaskID != taskID {
+		return nil, fmt.Errorf("no pomodoro session is running")
+	}
+
+	if err := s.sessionRepo.Stop(ctx, session.ID, now); err != nil {
+		return nil, err
+	}
+	session.EndedAt = &now
+
+	s.derive(session, now)
+	return session, nil
+}
+
+// ListSessions returns a task's session history, newest first
+func (s *PomodoroService) ListSessions(ctx context.Context, user *models.User, taskID primitive.ObjectID, filter repository.FocusSessionFilter) (*models.FocusSessionListResponse, error) {
+	if _, err := s.taskQueries.GetTask(ctx, taskID, user); err != nil {
+		return nil, err
+	}
+
+	sessions, totalCount, err := s.sessionRepo.FindByTaskID(ctx, taskID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	for _, session := range sessions {
+		s.derive(session, now)
+	}
+	return models.NewFocusSessionListResponse(sessions, filter.Page, filter.Limit, totalCount), nil
+}
+
+// Stats sums the user's focus time for the UTC day or ISO week containing
+// date (YYYY-MM-DD, default today). Sessions count towards the day they started.
+func (s *PomodoroService) Stats(ctx context.Context, user *models.User, period, date string) (*models.FocusStats, error) {
+	now := s.clock.Now()
+	day := now.UTC().Truncate(24 * time.Hour)
+	if date != "" {
+		parsed, err := time.Parse(planDateLayout, date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date, use YYYY-MM-DD")
+		}
+		day = parsed
+	}
+
+	var start time.Time
+	var days int
+	switch period {
+	case FocusPeriodDay, "":
+		period, start, days = FocusPeriodDay, day, 1
+	case FocusPeriodWeek:
+		// Monday of the ISO week
+		start, days = day.AddDate(0, 0, -((int(day.Weekday())+6)%7)), 7
+	default:
+		return nil, fmt.Errorf("invalid period, must be one of: day, week")
+	}
+	end := start.AddDate(0, 0, days)
+
+	sessions, err := s.sessionRepo.FindStartedBetween(ctx, user.ID, start, end, maxFocusStatsSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.FocusStats{
+		Period:    period,
+		StartDate: start.Format(planDateLayout),
+		EndDate:   end.AddDate(0, 0, -1).Format(planDateLayout),
+		Days:      make([]*models.FocusDayStats, days),
+		Tasks:     []*models.FocusTaskStats{},
+	}
+	for i := range stats.Days {
+		stats.Days[i] = &models.FocusDayStats{Date: start.AddDate(0, 0, i).Format(planDateLayout)}
+	}
+
+	byTask := make(map[primitive.ObjectID]*models.FocusTaskStats)
+	for _, session := range sessions {
+		s.derive(session, now)
+
+		stats.FocusSeconds += session.FocusSeconds
+		stats.Sessions++
+		if session.Completed {
+			stats.CompletedSessions++
+		}
+
+		dayStats := stats.Days[int(session.StartedAt.UTC().Sub(start)/(24*time.Hour))]
+		dayStats.FocusSeconds += session.FocusSeconds
+		dayStats.Sessions++
+
+		taskStats, ok := byTask[session.TaskID]
+		if !ok {
+			taskStats = &models.FocusTaskStats{TaskID: session.TaskID}
+			byTask[session.TaskID] = taskStats
+			stats.Tasks = append(stats.Tasks, taskStats)
+		}
+		taskStats.FocusSeconds += session.FocusSeconds
+		taskStats.Sessions++
+	}
+
+	if err := s.addTaskTitles(ctx, byTask); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(stats.Tasks, func(i, j int) bool {
+		return stats.Tasks[i].FocusSeconds > stats.Tasks[j].FocusSeconds
+	})
+
+	return stats, nil
+}
+
+// addTaskTitles names the tasks in the stats; deleted tasks keep an empty title
+func (s *PomodoroService) addTaskTitles(ctx context.Context, byTask map[primitive.ObjectID]*models.FocusTaskStats) error {
+	if len(byTask) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(byTask))
+	for id := range byTask {
+		ids = append(ids, id)
+	}
+	tasks, err := s.taskRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		byTask[task.ID].Title = task.Title
+	}
+	return nil
+}
+
+// derive fills in the fields computed from the clock
+func (s *PomodoroService) derive(session *models.FocusSession, now time.Time) {
+	finish, completed := session.Finish()
+	if now.Before(finish) {
+		session.Running = true
+		finish = now
+		completed = false
+	}
+	session.Completed = completed
+	session.FocusSeconds = models.Int64(finish.Sub(session.StartedAt) / time.Second)
+}
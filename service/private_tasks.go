@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"task-management-api/models"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// PrivatePassphraseHeader carries the passphrase that unlocks the caller's private tasks
+const PrivatePassphraseHeader = "X-Private-Passphrase"
+
+const (
+	privatePassphraseContextKey contextKey = "private_passphrase"
+	minPrivatePassphraseLength             = 12
+	privateKeySaltBytes                    = 16
+)
+
+// privateKeyCheck is sealed with a new key so a passphrase can be verified
+// without storing anything derived from it in the clear
+var privateKeyCheck = []byte("task-management-api private tasks")
+
+// privatePassphrase holds the request's passphrase and the key derived from
+// it, so deriving happens at most once per request
+type privatePassphrase struct {
+	value   string
+	derived bool
+	key     []byte
+	err     error
+}
+
+// PrivatePassphraseMiddleware makes the X-Private-Passphrase header available
+// to the task services. The passphrase is never stored or logged.
+func PrivatePassphraseMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if value := r.Header.Get(PrivatePassphraseHeader); value != "" {
+			ctx := context.WithValue(r.Context(), privatePassphraseContextKey, &privatePassphrase{value: value})
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// derivePrivateKey stretches a passphrase into an AES-256 key with Argon2id,
+// using the parameters OWASP recommends for interactive logins
+func derivePrivateKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, 2, 19*1024, 1, 32)
+}
+
+// newPrivateKey derives a key from a new passphrase and returns the salt and
+// sealed check value to store on the user
+func newPrivateKey(passphrase string) (salt, check []byte, err error) {
+	if len(passphrase) < minPrivatePassphraseLength {
+		return nil, nil, fmt.Errorf("passphrase must be at least %d characters", minPrivatePassphraseLength)
+	}
+
+	salt = make([]byte, privateKeySaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	check, err = seal(derivePrivateKey(passphrase, salt), privateKeyCheck)
+	if err != nil {
+		return nil, nil, err
+	}
+	return salt, check, nil
+}
+
+// privateKey returns the user's key when the request carries a passphrase,
+// and nil without an error when it carries none
+func privateKey(ctx context.Context, user *models.User) ([]byte, error) {
+	passphrase, ok := ctx.Value(privatePassphraseContextKey).(*privatePassphrase)
+	if !ok {
+		return nil, nil
+	}
+
+	if !passphrase.derived {
+		passphrase.derived = true
+		if len(user.PrivateKeySalt) == 0 {
+			passphrase.err = fmt.Errorf("private passphrase is not set up")
+		} else {
+			key := derivePrivateKey(passphrase.value, user.PrivateKeySalt)
+			if _, err := open(key, user.PrivateKeyCheck); err != nil {
+				passphrase.err = fmt.Errorf("invalid private passphrase")
+			} else {
+				passphrase.key = key
+			}
+		}
+	}
+	return passphrase.key, passphrase.err
+}
+
+// requirePrivateKey is privateKey for writes that cannot go ahead without the key
+func requirePrivateKey(ctx context.Context, user *models.User) ([]byte, error) {
+	key, err := privateKey(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("private passphrase is required")
+	}
+	return key, nil
+}
+
+// privateContent is what a private task keeps sealed
+type privateContent struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// sealTask moves the task's title and description into its sealed content
+func sealTask(key []byte, task *models.Task) error {
+	plaintext, err := json.Marshal(privateContent{Title: task.Title, Description: task.Description})
+	if err != nil {
+		return fmt.Errorf("failed to encode private task: %w", err)
+	}
+	sealed, err := seal(key, plaintext)
+	if err != nil {
+		return err
+	}
+
+	task.Sealed = sealed
+	task.Title = ""
+	task.Description = ""
+	return nil
+}
+
+// openTask restores the title and description of a sealed task. The sealed
+// content is dropped, which marks the task as revealed.
+func openTask(key []byte, task *models.Task) error {
+	plaintext, err := open(key, task.Sealed)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt private task: %w", err)
+	}
+	var content privateContent
+	if err := json.Unmarshal(plaintext, &content); err != nil {
+		return fmt.Errorf("failed to decode private task: %w", err)
+	}
+
+	task.Title = content.Title
+	task.Description = content.Description
+	task.Sealed = nil
+	return nil
+}
+
+// seal encrypts with AES-256-GCM and prepends the random nonce
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed content is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"log"
+	"task-management-api/clock"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProjectionService keeps denormalized summaries that dashboards read as a
+// single document. A summary is recomputed for its user after every task write
+// the task service reports, and an hourly consistency check rebuilds them all
+// to pick up writes it does not report, such as retention purges.
+type ProjectionService struct {
+	taskRepo       *repository.TaskRepository
+	projectionRepo *repository.ProjectionRepository
+	clock          clock.Clock
+}
+
+func NewProjectionService(taskRepo *repository.TaskRepository, projectionRepo *repository.ProjectionRepository, clk clock.Clock) *ProjectionService {
+	return &ProjectionService{
+		taskRepo:       taskRepo,
+		projectionRepo: projectionRepo,
+		clock:          clk,
+	}
+}
+
+// Subscribe keeps summaries current as tasks change. Call during startup.
+func (s *ProjectionService) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.TasksChanged) { s.taskChanged(ctx, e.UserID) })
+}
+
+// taskChanged refreshes the user's summary. Failures are logged; the hourly
+// check repairs the summary.
+func (s *ProjectionService) taskChanged(ctx context.Context, userID primitive.ObjectID) {
+	// Unassigned tasks have no dashboard
+	if userID.IsZero() {
+		return
+	}
+	if _, err := s.refreshTaskSummary(ctx, userID); err != nil {
+		logf(ctx, "Failed to refresh task summary of user %s: %v", userID.Hex(), err)
+	}
+}
+
+// TaskSummary returns the user's summary, computing it on first use
+func (s *ProjectionService) TaskSummary(ctx context.Context, user *models.User) (*models.TaskSummary, error) {
+	summary, err := s.projectionRepo.FindTaskSummary(ctx, user.ID)
+	if err == nil {
+		return summary, nil
+	}
+	if err.Error() != "task summary not found" {
+		return nil, err
+	}
+	return s.refreshTaskSummary(ctx, user.ID)
+}
+
+func (s *ProjectionService) refreshTaskSummary(ctx context.Context, userID primitive.ObjectID) (*models.TaskSummary, error) {
+	now := s.clock.Now()
+	summaries, err := s.taskRepo.SummarizeByUser(ctx, &userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// A user without tasks has no group in the aggregation
+	summary := &models.TaskSummary{UserID: userID, UpdatedAt: now}
+	if len(summaries) > 0 {
+		summary = summaries[0]
+	}
+	if err := s.projectionRepo.SaveTaskSummary(ctx, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// Check compares every stored summary with one computed from the tasks and,
+// with repair, rewrites them all and drops those of users left without tasks.
+func (s *ProjectionService) Check(ctx context.Context, repair bool) (*models.ProjectionCheckReport, error) {
+	startedAt := s.clock.Now()
+	fresh, err := s.taskRepo.SummarizeByUser(ctx, nil, startedAt)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.projectionRepo.FindAllTaskSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ProjectionCheckReport{CheckedAt: startedAt, Checked: len(fresh)}
+	storedByUser := make(map[primitive.ObjectID]*models.TaskSummary, len(stored))
+	for _, summary := range stored {
+		storedByUser[summary.UserID] = summary
+	}
+	for _, summary := range fresh {
+		current, ok := storedByUser[summary.UserID]
+		delete(storedByUser, summary.UserID)
+		switch {
+		case !ok:
+			report.Missing++
+		case !current.UpdatedAt.Before(startedAt):
+			// Refreshed by a write during the check
+		case !sameCounts(current, summary):
+			report.Drifted++
+		}
+	}
+	for _, leftover := range storedByUser {
+		if leftover.UpdatedAt.Before(startedAt) && leftover.Total > 0 {
+			report.Orphaned++
+		}
+	}
+
+	if !repair {
+		return report, nil
+	}
+	for _, summary := range fresh {
+		if err := s.projectionRepo.SaveTaskSummary(ctx, summary); err != nil {
+			return report, err
+		}
+	}
+	if _, err := s.projectionRepo.DeleteTaskSummariesBefore(ctx, startedAt); err != nil {
+		return report, err
+	}
+	report.Repaired = true
+
+	return report, nil
+}
+
+func sameCounts(a, b *models.TaskSummary) bool {
+	return a.Total == b.Total && a.Pending == b.Pending && a.InProgress == b.InProgress &&
+		a.Completed == b.Completed && a.Archived == b.Archived
+}
+
+// RunScheduled is the scheduled projection check; it repairs what it finds.
+// Drift means a write bypassed the task service's notifications.
+func (s *ProjectionService) RunScheduled(ctx context.Context) error {
+	report, err := s.Check(ctx, true)
+	if err != nil {
+		return err
+	}
+	if report.Drifted > 0 || report.Missing > 0 || report.Orphaned > 0 {
+		log.Printf("Projection check repaired task summaries: %d drifted, %d missing, %d orphaned", report.Drifted, report.Missing, report.Orphaned)
+	}
+	return nil
+}
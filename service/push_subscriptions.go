@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"task-management-api/models"
+	"task-management-api/notifications"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxPushSubscriptionsPerUser = 10
+	maxPushEndpointChars        = 2048
+)
+
+// PushPublicKey is the applicationServerKey browsers subscribe with
+func (s *NotificationService) PushPublicKey() (*models.PushPublicKeyResponse, error) {
+	if s.vapid == nil {
+		return nil, fmt.Errorf("push notifications are not configured")
+	}
+	return &models.PushPublicKeyResponse{PublicKey: s.vapid.PublicKey()}, nil
+}
+
+// SubscribePush saves the browser's subscription. Subscribing again with the
+// same endpoint updates the existing subscription instead of adding one.
+func (s *NotificationService) SubscribePush(ctx context.Context, user *models.User, req *models.CreatePushSubscriptionRequest, userAgent string) (*models.PushSubscription, error) {
+	if s.vapid == nil {
+		return nil, fmt.Errorf("push notifications are not configured")
+	}
+	endpoint := strings.TrimSpace(req.Endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	if len(endpoint) > maxPushEndpointChars {
+		return nil, fmt.Errorf("invalid endpoint, must be an https URL")
+	}
+	target := notifications.PushSubscription{Endpoint: endpoint, P256DH: strings.TrimSpace(req.Keys.P256DH), Auth: strings.TrimSpace(req.Keys.Auth)}
+	if err := notifications.ValidatePushSubscription(target); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	var expiresAt *time.Time
+	if req.ExpirationTime != nil {
+		expires := time.UnixMilli(*req.ExpirationTime).UTC()
+		if !expires.After(now) {
+			return nil, fmt.Errorf("subscription has already expired")
+		}
+		expiresAt = &expires
+	}
+
+	count, err := s.pushRepo.CountByUserID(ctx, user.ID, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxPushSubscriptionsPerUser {
+		return nil, fmt.Errorf("at most %d push subscriptions are allowed", maxPushSubscriptionsPerUser)
+	}
+
+	subscription := models.NewPushSubscription(user.ID, endpoint, target.P256DH, target.Auth, describeDevice(userAgent), expiresAt, now)
+	if err := s.pushRepo.Upsert(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *NotificationService) ListPushSubscriptions(ctx context.Context, user *models.User) ([]*models.PushSubscription, error) {
+	return s.pushRepo.FindByUserID(ctx, user.ID)
+}
+
+func (s *NotificationService) DeletePushSubscription(ctx context.Context, user *models.User, subscriptionID primitive.ObjectID) error {
+	return s.pushRepo.Delete(ctx, subscriptionID, user.ID)
+}
+
+// pushNotifiers returns a notifier for each of the user's browsers, or none
+// while push is not configured. A subscription the push service reports gone
+// is deleted.
+func (s *NotificationService) pushNotifiers(ctx context.Context, userID primitive.ObjectID) []notifications.Notifier {
+	if s.vapid == nil {
+		return nil
+	}
+	subscriptions, err := s.pushRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		logf(ctx, "Failed to find push subscriptions of user %s: %v", userID.Hex(), err)
+		return nil
+	}
+
+	notifiers := make([]notifications.Notifier, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		id := subscription.ID
+		target := notifications.PushSubscription{Endpoint: subscription.Endpoint, P256DH: subscription.P256DH, Auth: subscription.Auth}
+		notifiers = append(notifiers, notifications.NewWebPushNotifier(s.client, s.vapid, target, func(ctx context.Context) {
+			if err := s.pushRepo.DeleteByID(ctx, id); err != nil {
+				logf(ctx, "Failed to delete expired push subscription %s: %v", id.Hex(), err)
+				return
+			}
+			logf(ctx, "Deleted push subscription %s, which its push service reported gone", id.Hex())
+		}, s.clock))
+	}
+	return notifiers
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type recurrenceRule struct {
+	freq     string
+	interval int
+}
+
+// parseRecurrence accepts the shorthands daily/weekly/monthly or an RRULE
+// limited to FREQ (DAILY, WEEKLY, MONTHLY) and INTERVAL.
+func parseRecurrence(value string) (*recurrenceRule, error) {
+	switch strings.ToLower(value) {
+	case "daily":
+		return &recurrenceRule{freq: "DAILY", interval: 1}, nil
+	case "weekly":
+		return &recurrenceRule{freq: "WEEKLY", interval: 1}, nil
+	case "monthly":
+		return &recurrenceRule{freq: "MONTHLY", interval: 1}, nil
+	}
+
+	rule := &recurrenceRule{interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(value), "RRULE:"), ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid recurrence rule")
+		}
+		switch key {
+		case "FREQ":
+			if val != "DAILY" && val != "WEEKLY" && val != "MONTHLY" {
+				return nil, fmt.Errorf("unsupported recurrence frequency %q, must be DAILY, WEEKLY or MONTHLY", val)
+			}
+			rule.freq = val
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid recurrence interval")
+			}
+			rule.interval = interval
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part %q", key)
+		}
+	}
+
+	if rule.freq == "" {
+		return nil, fmt.Errorf("recurrence rule requires FREQ")
+	}
+
+	return rule, nil
+}
+
+func (r *recurrenceRule) next(t time.Time) time.Time {
+	switch r.freq {
+	case "WEEKLY":
+		return t.AddDate(0, 0, 7*r.interval)
+	case "MONTHLY":
+		return t.AddDate(0, r.interval, 0)
+	default:
+		return t.AddDate(0, 0, r.interval)
+	}
+}
+
+// nextOccurrence returns the first occurrence after now, skipping any that were missed.
+func (r *recurrenceRule) nextOccurrence(from, now time.Time) time.Time {
+	next := r.next(from)
+	for !next.After(now) {
+		next = r.next(next)
+	}
+	return next
+}
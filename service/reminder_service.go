@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"log"
+	"task-management-api/clock"
+	"task-management-api/events"
+	"task-management-api/repository"
+	"time"
+)
+
+// reminderMaxTasks bounds the tasks one reminder run handles; the rest are
+// reminded on the next run
+const reminderMaxTasks = 1000
+
+// ReminderService reminds owners of tasks about to fall due by publishing
+// task.due_soon, which is emailed to them and sent to their webhooks and
+// notification channels. Each due date is reminded once.
+type ReminderService struct {
+	taskRepo    *repository.TaskRepository
+	taskService *TaskService
+	lead        time.Duration
+	clock       clock.Clock
+}
+
+// NewReminderService reminds of tasks due within leadMinutes
+func NewReminderService(taskRepo *repository.TaskRepository, taskService *TaskService, leadMinutes int, clk clock.Clock) *ReminderService {
+	return &ReminderService{
+		taskRepo:    taskRepo,
+		taskService: taskService,
+		lead:        time.Duration(leadMinutes) * time.Minute,
+		clock:       clk,
+	}
+}
+
+// SendReminders is the scheduled reminder job. A task is marked reminded
+// before its notifications go out, so a failed one is not sent again.
+func (s *ReminderService) SendReminders(ctx context.Context) error {
+	now := s.clock.Now()
+	tasks, err := s.taskRepo.FindUnreminded(ctx, now, now.Add(s.lead), reminderMaxTasks)
+	if err != nil {
+		return err
+	}
+
+	reminded := 0
+	for _, task := range tasks {
+		marked, err := s.taskRepo.MarkReminded(ctx, task.ID, now)
+		if err != nil {
+			log.Printf("Failed to mark task %s reminded: %v", task.ID.Hex(), err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		reminded++
+
+		s.taskService.bus.Publish(ctx, events.TaskDueSoon{Task: task})
+	}
+	if reminded > 0 {
+		log.Printf("Reminder run: reminded owners of %d tasks", reminded)
+	}
+	return nil
+}
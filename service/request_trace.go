@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"task-management-api/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	requestTraceContextKey contextKey = "request_trace"
+	// maxTracedBody is how much of a failed response's body is kept to read its error
+	maxTracedBody = 4096
+	maxTracedLogs = 100
+)
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestTrace collects what happens during one request. Services add log
+// lines to it from goroutines of their own, hence the lock.
+type requestTrace struct {
+	mu    sync.Mutex
+	trace models.RequestTrace
+}
+
+// RequestTracer gives every request an ID, returned in the X-Request-ID
+// header and in 5xx error bodies, and keeps a trace of each request that
+// fails with a 5xx status so admins can look it up by that ID.
+type RequestTracer struct {
+	traceRepo *repository.RequestTraceRepository
+	version   string
+	clock     clock.Clock
+}
+
+func NewRequestTracer(traceRepo *repository.RequestTraceRepository, version string, clk clock.Clock) *RequestTracer {
+	return &RequestTracer{
+		traceRepo: traceRepo,
+		version:   version,
+		clock:     clk,
+	}
+}
+
+func (t *RequestTracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(utils.RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(utils.RequestIDHeader, id)
+
+		start := time.Now()
+		trace := &requestTrace{trace: models.RequestTrace{
+			ID:        id,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Version:   t.version,
+			StartedAt: t.clock.Now(),
+		}}
+		recorder := &traceWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), requestTraceContextKey, trace)))
+
+		if recorder.status < http.StatusInternalServerError {
+			return
+		}
+
+		trace.mu.Lock()
+		saved := trace.trace
+		saved.Logs = append([]string(nil), trace.trace.Logs...)
+		trace.mu.Unlock()
+		saved.Status = recorder.status
+		saved.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+		var body models.ErrorResponse
+		if json.Unmarshal(recorder.body.Bytes(), &body) == nil {
+			saved.Code = body.Code
+			saved.Message = body.Message
+		}
+
+		// Saved after the response, so a failing database does not slow it down further
+		go func() {
+			if err := t.traceRepo.Create(context.Background(), &saved); err != nil {
+				log.Printf("[%s] Failed to save request trace: %v", saved.ID, err)
+			}
+		}()
+	})
+}
+
+// FindTrace returns the trace of a failed request
+func (t *RequestTracer) FindTrace(ctx context.Context, id string) (*models.RequestTrace, error) {
+	if !validRequestID.MatchString(id) {
+		return nil, fmt.Errorf("request trace not found")
+	}
+	return t.traceRepo.FindByID(ctx, id)
+}
+
+// traceWriter records the status and keeps the start of 5xx bodies
+type traceWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// Unwrap returns the writer the trace records
+func (w *traceWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *traceWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *traceWriter) Write(b []byte) (int, error) {
+	if w.status >= http.StatusInternalServerError && w.body.Len() < maxTracedBody {
+		w.body.Write(b[:min(len(b), maxTracedBody-w.body.Len())])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Unique enough for correlating logs when the system's randomness fails
+		return primitive.NewObjectID().Hex()
+	}
+	return hex.EncodeToString(b)
+}
+
+// logf logs a line prefixed with the request ID and adds it to the request's
+// trace. Outside a request it is log.Printf.
+func logf(ctx context.Context, format string, args ...interface{}) {
+	trace, ok := ctx.Value(requestTraceContextKey).(*requestTrace)
+	if !ok {
+		log.Printf(format, args...)
+		return
+	}
+
+	line := fmt.Sprintf(format, args...)
+	log.Printf("[%s] %s", trace.trace.ID, line)
+	trace.mu.Lock()
+	if len(trace.trace.Logs) < maxTracedLogs {
+		trace.trace.Logs = append(trace.trace.Logs, line)
+	}
+	trace.mu.Unlock()
+}
+
+// withUser stores the authenticated user in ctx, on the request's trace and
+// on its usage
+func withUser(ctx context.Context, user *models.User) context.Context {
+	if usage, ok := ctx.Value(usageContextKey).(*usageRequest); ok {
+		usage.userID = &user.ID
+	}
+	if trace, ok := ctx.Value(requestTraceContextKey).(*requestTrace); ok {
+		trace.mu.Lock()
+		trace.trace.UserID = &user.ID
+		trace.mu.Unlock()
+	}
+	return context.WithValue(ctx, userContextKey, user)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultRetentionGraceDays = 7
+
+type RetentionService struct {
+	retentionRepo *repository.RetentionRepository
+	taskRepo      *repository.TaskRepository
+	userRepo      *repository.UserRepository
+	auditRepo     *repository.AuditRepository
+	clock         clock.Clock
+}
+
+func NewRetentionService(retentionRepo *repository.RetentionRepository, taskRepo *repository.TaskRepository, userRepo *repository.UserRepository, auditRepo *repository.AuditRepository, clk clock.Clock) *RetentionService {
+	return &RetentionService{
+		retentionRepo: retentionRepo,
+		taskRepo:      taskRepo,
+		userRepo:      userRepo,
+		auditRepo:     auditRepo,
+		clock:         clk,
+	}
+}
+
+// GetPolicy returns the stored policy, or the default one that retains everything.
+func (s *RetentionService) GetPolicy(ctx context.Context) (*models.RetentionPolicy, error) {
+	policy, err := s.retentionRepo.GetPolicy(ctx)
+	if err != nil {
+		if err.Error() == "retention policy not found" {
+			return &models.RetentionPolicy{GraceDays: defaultRetentionGraceDays}, nil
+		}
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *RetentionService) Status(ctx context.Context) (*models.RetentionStatusResponse, error) {
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.taskRepo.CountPendingPurge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auditLogs, err := s.auditRepo.CountPendingPurge(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RetentionStatusResponse{
+		Policy: policy,
+		PendingPurge: models.RetentionPending{
+			Tasks:     models.Int64(tasks),
+			AuditLogs: models.Int64(auditLogs),
+		},
+	}, nil
+}
+
+func (s *RetentionService) UpdatePolicy(ctx context.Context, actor *models.User, req *models.UpdateRetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zero days disables a rule
+	if req.CompletedTaskDays != nil {
+		if *req.CompletedTaskDays < 0 {
+			return nil, fmt.Errorf("completed_task_days must not be negative")
+		}
+		policy.CompletedTaskDays = *req.CompletedTaskDays
+	}
+	if req.AuditLogDays != nil {
+		if *req.AuditLogDays < 0 {
+			return nil, fmt.Errorf("audit_log_days must not be negative")
+		}
+		policy.AuditLogDays = *req.AuditLogDays
+	}
+	if req.GraceDays != nil {
+		if *req.GraceDays < 0 {
+			return nil, fmt.Errorf("grace_days must not be negative")
+		}
+		policy.GraceDays = *req.GraceDays
+	}
+
+	now := s.clock.Now()
+	policy.UpdatedBy = &actor.ID
+	policy.UpdatedAt = &now
+	if err := s.retentionRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	entry := models.NewAuditLog(actor.ID, "retention.update_policy", "retention_policy", actor.ID, map[string]interface{}{
+		"completed_task_days": policy.CompletedTaskDays,
+		"audit_log_days":      policy.AuditLogDays,
+		"grace_days":          policy.GraceDays,
+	}, now)
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		logf(ctx, "Failed to record audit log %s: %v", entry.Action, err)
+	}
+
+	return policy, nil
+}
+
+// Run deletes everything whose grace period has ended, then marks newly expired
+// data as pending purge. Marked items stay visible until the next run past their purge_at.
+// Users and tasks under legal hold, and audit logs about them, are skipped.
+func (s *RetentionService) Run(ctx context.Context) (*models.RetentionReport, error) {
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	heldUserIDs, err := s.userRepo.FindLegalHoldIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heldTaskIDs, err := s.taskRepo.FindLegalHoldIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heldTargetIDs := append(append([]primitive.ObjectID{}, heldUserIDs...), heldTaskIDs...)
+
+	now := s.clock.Now()
+	report := &models.RetentionReport{
+		HeldUsers: len(heldUserIDs),
+		HeldTasks: len(heldTaskIDs),
+		RanAt:     now,
+	}
+
+	tasksPurged, err := s.taskRepo.PurgeDue(ctx, now, heldUserIDs)
+	if err != nil {
+		return nil, err
+	}
+	report.TasksPurged = models.Int64(tasksPurged)
+	auditLogsPurged, err := s.auditRepo.DeletePurgeDue(ctx, now, heldTargetIDs)
+	if err != nil {
+		return nil, err
+	}
+	report.AuditLogsPurged = models.Int64(auditLogsPurged)
+
+	purgeAt := now.AddDate(0, 0, policy.GraceDays)
+	if policy.CompletedTaskDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.CompletedTaskDays)
+		marked, err := s.taskRepo.MarkPurge(ctx, cutoff, purgeAt, heldUserIDs)
+		if err != nil {
+			return nil, err
+		}
+		report.TasksMarked = models.Int64(marked)
+	}
+	if policy.AuditLogDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.AuditLogDays)
+		marked, err := s.auditRepo.MarkPurge(ctx, cutoff, purgeAt, heldTargetIDs)
+		if err != nil {
+			return nil, err
+		}
+		report.AuditLogsMarked = models.Int64(marked)
+	}
+
+	if err := s.retentionRepo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *RetentionService) ListReports(ctx context.Context, filter repository.RetentionReportFilter) (*models.RetentionReportListResponse, error) {
+	reports, totalCount, err := s.retentionRepo.FindReports(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewRetentionReportListResponse(reports, filter.Page, filter.Limit, totalCount), nil
+}
+
+// RunScheduled is the scheduled retention job; it enforces the policy and logs what it did
+func (s *RetentionService) RunScheduled(ctx context.Context) error {
+	report, err := s.Run(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Retention run: purged %d tasks and %d audit logs, marked %d tasks and %d audit logs",
+		report.TasksPurged, report.AuditLogsPurged, report.TasksMarked, report.AuditLogsMarked)
+	return nil
+}
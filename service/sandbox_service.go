@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const sandboxPassword = "password123"
+
+// Fixed identifiers and instants so contract tests can hard-code them
+var (
+	sandboxAdminID = mustObjectID("650000000000000000000001")
+	sandboxUserID  = mustObjectID("650000000000000000000002")
+	sandboxEpoch   = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	// Fixture tasks are younger than the default auto-complete threshold at this instant
+	sandboxNow = sandboxEpoch.Add(5 * time.Minute)
+)
+
+type SandboxService struct {
+	sandboxRepo *repository.SandboxRepository
+	clock       *clock.Controllable
+}
+
+func NewSandboxService(sandboxRepo *repository.SandboxRepository, clk *clock.Controllable) *SandboxService {
+	return &SandboxService{
+		sandboxRepo: sandboxRepo,
+		clock:       clk,
+	}
+}
+
+func (s *SandboxService) Reset(ctx context.Context) (*models.SandboxResetResponse, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(sandboxPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	admin := models.NewUser("admin@example.com", "admin", string(hashedPassword), models.UserRoleAdmin, sandboxEpoch)
+	admin.ID = sandboxAdminID
+
+	user := models.NewUser("user@example.com", "johndoe", string(hashedPassword), models.UserRoleUser, sandboxEpoch)
+	user.ID = sandboxUserID
+
+	tasks := []*models.Task{
+		sandboxTask("650000000000000000000101", user.ID, "Write project proposal", "Draft and circulate the proposal", models.TaskStatusPending, 0),
+		sandboxTask("650000000000000000000102", user.ID, "Review pull requests", "", models.TaskStatusInProgress, 1),
+		sandboxTask("650000000000000000000103", user.ID, "Set up CI pipeline", "Lint, test and build on every push", models.TaskStatusCompleted, 2),
+		sandboxTask("650000000000000000000104", admin.ID, "Rotate JWT secret", "", models.TaskStatusPending, 3),
+	}
+
+	if err := s.sandboxRepo.Reset(ctx, []*models.User{admin, user}, tasks); err != nil {
+		return nil, err
+	}
+
+	s.clock.SetOffset(0)
+	s.clock.Freeze(sandboxNow)
+
+	return &models.SandboxResetResponse{
+		Users:    []*models.User{admin, user},
+		Password: sandboxPassword,
+		Tasks:    tasks,
+		Time:     s.TimeState(),
+	}, nil
+}
+
+func (s *SandboxService) SetTime(req *models.SandboxTimeRequest) (*models.SandboxTimeResponse, error) {
+	if req.FreezeAt != nil && req.Unfreeze {
+		return nil, fmt.Errorf("freeze_at and unfreeze cannot be combined")
+	}
+
+	if req.OffsetSeconds != nil {
+		s.clock.SetOffset(time.Duration(*req.OffsetSeconds) * time.Second)
+	}
+	if req.FreezeAt != nil {
+		s.clock.Freeze(*req.FreezeAt)
+	}
+	if req.Unfreeze {
+		s.clock.Unfreeze()
+	}
+	if req.AdvanceSeconds != 0 {
+		s.clock.Advance(time.Duration(req.AdvanceSeconds) * time.Second)
+	}
+
+	return s.TimeState(), nil
+}
+
+func (s *SandboxService) TimeState() *models.SandboxTimeResponse {
+	frozenAt, offset := s.clock.State()
+	return &models.SandboxTimeResponse{
+		Now:           s.clock.Now(),
+		Frozen:        frozenAt != nil,
+		OffsetSeconds: models.Int64(offset / time.Second),
+	}
+}
+
+func sandboxTask(id string, userID primitive.ObjectID, title, description string, status models.TaskStatus, offsetMinutes int) *models.Task {
+	task := models.NewTask(userID, title, description, status, sandboxEpoch.Add(time.Duration(offsetMinutes)*time.Minute))
+	task.ID = mustObjectID(id)
+	return task
+}
+
+func mustObjectID(hex string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
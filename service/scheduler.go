@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"task-management-api/clock"
+	"task-management-api/cron"
+	"task-management-api/repository"
+	"time"
+)
+
+// schedulerMaxWait bounds how long the scheduler sleeps, so it notices when
+// the clock is moved, as the sandbox clock can be
+const schedulerMaxWait = time.Minute
+
+type scheduledJob struct {
+	name     string
+	spec     string
+	schedule *cron.Schedule
+	run      func(ctx context.Context) error
+	next     time.Time
+	running  atomic.Bool
+}
+
+// Scheduler runs named background jobs on cron schedules and keeps the
+// outcome of each job's last run in the database. Only the leader among the
+// instances runs jobs. A run still going when the job is next due is not
+// overlapped; that run is skipped.
+type Scheduler struct {
+	jobRepo *repository.ScheduledJobRepository
+	leader  *LeaderElector
+	clock   clock.Clock
+	jobs    []*scheduledJob
+}
+
+func NewScheduler(jobRepo *repository.ScheduledJobRepository, leader *LeaderElector, clk clock.Clock) *Scheduler {
+	return &Scheduler{
+		jobRepo: jobRepo,
+		leader:  leader,
+		clock:   clk,
+	}
+}
+
+// Add schedules run under name. Add jobs during startup, before Start.
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context) error) error {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+	if schedule.Next(s.clock.Now()).IsZero() {
+		return fmt.Errorf("schedule %q of job %s never runs", spec, name)
+	}
+	s.jobs = append(s.jobs, &scheduledJob{name: name, spec: spec, schedule: schedule, run: run})
+	return nil
+}
+
+// Start runs the jobs when they are due until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	names := make([]string, len(s.jobs))
+	now := s.clock.Now()
+	for i, job := range s.jobs {
+		names[i] = job.name
+		job.next = job.schedule.Next(now)
+	}
+	log.Printf("Starting scheduler - %d jobs: %s", len(s.jobs), strings.Join(names, ", "))
+
+	timer := time.NewTimer(s.wait(now))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Scheduler stopped")
+			return
+		case <-timer.C:
+			now = s.clock.Now()
+			leading := s.leader.IsLeader()
+			for _, job := range s.jobs {
+				if !now.Before(job.next) {
+					job.next = job.schedule.Next(now)
+					if leading {
+						go s.run(ctx, job)
+					}
+				}
+			}
+			timer.Reset(s.wait(now))
+		}
+	}
+}
+
+// wait returns how long to sleep until the next job is due
+func (s *Scheduler) wait(now time.Time) time.Duration {
+	wait := schedulerMaxWait
+	for _, job := range s.jobs {
+		if until := job.next.Sub(now); until < wait {
+			wait = max(until, 0)
+		}
+	}
+	return wait
+}
+
+func (s *Scheduler) run(ctx context.Context, job *scheduledJob) {
+	if !job.running.CompareAndSwap(false, true) {
+		log.Printf("Skipping scheduled job %s, its previous run is still going", job.name)
+		return
+	}
+	defer job.running.Store(false)
+
+	started := s.clock.Now()
+	if err := s.jobRepo.RecordStart(ctx, job.name, job.spec, started, job.next); err != nil {
+		log.Printf("Failed to record start of job %s: %v", job.name, err)
+	}
+
+	err := job.run(ctx)
+	if err != nil {
+		log.Printf("Scheduled job %s failed: %v", job.name, err)
+	}
+
+	// Record the outcome even when shutdown interrupted the run
+	finished := s.clock.Now()
+	if err := s.jobRepo.RecordFinish(context.WithoutCancel(ctx), job.name, finished, finished.Sub(started), err); err != nil {
+		log.Printf("Failed to record finish of job %s: %v", job.name, err)
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+)
+
+// Bump SchemaVersion whenever a change needs a data migration or changes the
+// meaning of stored fields; raise MinSchemaVersion when this build can no longer
+// read data written under an older schema.
+const (
+	SchemaVersion    = 1
+	MinSchemaVersion = 1
+)
+
+type SchemaService struct {
+	schemaRepo *repository.SchemaRepository
+	clock      clock.Clock
+}
+
+func NewSchemaService(schemaRepo *repository.SchemaRepository, clk clock.Clock) *SchemaService {
+	return &SchemaService{
+		schemaRepo: schemaRepo,
+		clock:      clk,
+	}
+}
+
+// EnsureCompatible records this build's schema version when it is newer and
+// returns an error when the database is outside the range this build supports.
+func (s *SchemaService) EnsureCompatible(ctx context.Context) (*models.SchemaStatus, error) {
+	status, err := s.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Compatible {
+		return status, fmt.Errorf("database schema version %d is outside the supported range %d-%d", status.DatabaseVersion, MinSchemaVersion, SchemaVersion)
+	}
+
+	if status.DatabaseVersion < SchemaVersion {
+		if err := s.schemaRepo.Upgrade(ctx, SchemaVersion, s.clock.Now()); err != nil {
+			return nil, err
+		}
+		return s.Status(ctx)
+	}
+
+	return status, nil
+}
+
+func (s *SchemaService) Status(ctx context.Context) (*models.SchemaStatus, error) {
+	status := &models.SchemaStatus{
+		CodeVersion:     SchemaVersion,
+		MinCompatible:   MinSchemaVersion,
+		DatabaseVersion: 0,
+		Compatible:      true,
+	}
+
+	record, err := s.schemaRepo.Get(ctx)
+	if err != nil && err.Error() != "schema version not found" {
+		return nil, err
+	}
+
+	// A database without a record is fresh and adopts this build's version
+	if record != nil {
+		status.DatabaseVersion = record.Version
+		status.UpdatedAt = &record.UpdatedAt
+		status.Compatible = record.Version >= MinSchemaVersion && record.Version <= SchemaVersion
+	}
+
+	return status, nil
+}
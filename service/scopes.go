@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"task-management-api/utils"
+)
+
+// Scopes limit what an API key or scoped access token may do. Requests made
+// with a regular login token carry no scopes and are not restricted by them.
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+var validScopes = map[string]bool{
+	ScopeTasksRead:  true,
+	ScopeTasksWrite: true,
+}
+
+const scopesContextKey contextKey = "scopes"
+
+// scopeGrant is the set of scopes a request was authenticated with and the
+// kind of credential that carried them, for error messages.
+type scopeGrant struct {
+	credential string
+	scopes     []string
+}
+
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !validScopes[scope] {
+			return fmt.Errorf("invalid scope, must be one of: tasks:read, tasks:write")
+		}
+	}
+	return nil
+}
+
+func withScopes(ctx context.Context, credential string, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesContextKey, &scopeGrant{credential: credential, scopes: scopes})
+}
+
+// GetScopesFromContext returns the request's scopes and whether it is limited to them
+func GetScopesFromContext(ctx context.Context) ([]string, bool) {
+	grant, ok := ctx.Value(scopesContextKey).(*scopeGrant)
+	if !ok {
+		return nil, false
+	}
+	return grant.scopes, true
+}
+
+// RequireScope rejects requests limited to scopes that do not include scope;
+// unrestricted requests pass.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := CheckScope(r.Context(), scope); err != nil {
+				utils.RespondError(w, http.StatusForbidden, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CheckScope is RequireScope for code that serves several scopes on one
+// route, such as the GraphQL resolvers
+func CheckScope(ctx context.Context, scope string) error {
+	grant, ok := ctx.Value(scopesContextKey).(*scopeGrant)
+	if ok && !containsScope(grant.scopes, scope) {
+		return fmt.Errorf("%s lacks the %s scope", grant.credential, scope)
+	}
+	return nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"task-management-api/models"
+	"task-management-api/repository"
+)
+
+// maxAutoCompleteMinutes is the longest auto-complete threshold users may choose
+const maxAutoCompleteMinutes = 24 * 60
+
+// SettingsService reads and changes the settings users control themselves
+type SettingsService struct {
+	userRepo   *repository.UserRepository
+	taskWorker *TaskWorker
+}
+
+func NewSettingsService(userRepo *repository.UserRepository, taskWorker *TaskWorker) *SettingsService {
+	return &SettingsService{
+		userRepo:   userRepo,
+		taskWorker: taskWorker,
+	}
+}
+
+func (s *SettingsService) Get(user *models.User) *models.UserSettingsResponse {
+	return models.NewUserSettingsResponse(user.Settings, s.taskWorker.autoCompleteMinutes)
+}
+
+// Update changes the settings that are set. The worker on every instance
+// follows within a few seconds; tasks already queued are checked again
+// before they are completed.
+func (s *SettingsService) Update(ctx context.Context, user *models.User, req *models.UpdateUserSettingsRequest) (*models.UserSettingsResponse, error) {
+	if req.AutoComplete == nil && req.AutoCompleteMinutes == nil {
+		return nil, fmt.Errorf("auto_complete or auto_complete_minutes is required")
+	}
+
+	settings := user.Settings
+	if req.AutoComplete != nil {
+		settings.AutoCompleteDisabled = !*req.AutoComplete
+	}
+	if req.AutoCompleteMinutes != nil {
+		if *req.AutoCompleteMinutes < 0 || *req.AutoCompleteMinutes > maxAutoCompleteMinutes {
+			return nil, fmt.Errorf("auto_complete_minutes must be between 0 and %d", maxAutoCompleteMinutes)
+		}
+		settings.AutoCompleteMinutes = *req.AutoCompleteMinutes
+	}
+
+	if err := s.userRepo.SetSettings(ctx, user.ID, settings); err != nil {
+		return nil, err
+	}
+	s.taskWorker.settingsChanged()
+
+	return models.NewUserSettingsResponse(settings, s.taskWorker.autoCompleteMinutes), nil
+}
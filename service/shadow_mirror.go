@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxShadowBodyBytes = 1 << 20
+	maxShadowInFlight  = 20
+	maxShadowDiffPaths = 10
+)
+
+type ShadowConfig struct {
+	BaseURL string
+	Percent float64
+	Client  *http.Client
+	// ForwardCredentials sends the caller's Authorization and X-API-Key to the
+	// secondary, for one that trusts the same tokens and keys. Without it only
+	// anonymous requests are mirrored.
+	ForwardCredentials bool
+}
+
+// shadowRoutes are the route templates, relative to the API version prefix,
+// whose GET requests may be mirrored: plain reads of resources with no side
+// effects. Sign-in flows, email links, downloads and admin routes never are.
+var shadowRoutes = map[string]bool{
+	"/me":                   true,
+	"/me/settings":          true,
+	"/me/achievements":      true,
+	"/webhooks/schemas":     true,
+	"/tasks":                true,
+	"/tasks/summary":        true,
+	"/tasks/my-day":         true,
+	"/tasks/focus-stats":    true,
+	"/tasks/plan":           true,
+	"/tasks/{id}":           true,
+	"/tasks/{id}/subtasks":  true,
+	"/tasks/{id}/pomodoros": true,
+	"/tasks/{id}/history":   true,
+	"/tasks/{id}/comments":  true,
+}
+
+// credentialHeaders identify the caller and are only mirrored with ForwardCredentials
+var credentialHeaders = []string{"Authorization", "X-API-Key"}
+
+// secretHeaders are never mirrored
+var secretHeaders = []string{"Cookie", "Proxy-Authorization", PrivatePassphraseHeader}
+
+// ShadowMirror replays a sample of read requests against a secondary deployment
+// and logs where its responses differ from the primary's.
+type ShadowMirror struct {
+	config   ShadowConfig
+	client   *http.Client
+	inFlight chan struct{}
+}
+
+func NewShadowMirror(config ShadowConfig) *ShadowMirror {
+	return &ShadowMirror{
+		config:   config,
+		client:   config.Client,
+		inFlight: make(chan struct{}, maxShadowInFlight),
+	}
+}
+
+type captureWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.body.Len()+len(p) <= maxShadowBodyBytes {
+		w.body.Write(p)
+	} else {
+		w.truncated = true
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (m *ShadowMirror) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !m.mirrored(r) || rand.Float64()*100 >= m.config.Percent {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		capture := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(capture, r)
+		if capture.truncated {
+			return
+		}
+
+		// Shed shadow traffic instead of queueing when the secondary is slow
+		select {
+		case m.inFlight <- struct{}{}:
+		default:
+			return
+		}
+
+		header := r.Header.Clone()
+		for _, name := range secretHeaders {
+			header.Del(name)
+		}
+		target := strings.TrimSuffix(m.config.BaseURL, "/") + r.URL.RequestURI()
+		primaryBody := capture.body.Bytes()
+		go func() {
+			defer func() { <-m.inFlight }()
+			m.mirror(target, header, capture.status, primaryBody)
+		}()
+	})
+}
+
+// mirrored reports whether r is a read of an allowed route that can be sent on
+func (m *ShadowMirror) mirrored(r *http.Request) bool {
+	current := mux.CurrentRoute(r)
+	if current == nil {
+		return false
+	}
+	template, err := current.GetPathTemplate()
+	if err != nil || !shadowRoutes[strings.TrimPrefix(template, "/api/v1")] {
+		return false
+	}
+
+	if m.config.ForwardCredentials {
+		return true
+	}
+	// The secondary would only answer 401, which says nothing about the candidate
+	for _, name := range credentialHeaders {
+		if r.Header.Get(name) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *ShadowMirror) mirror(target string, header http.Header, primaryStatus int, primaryBody []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		log.Printf("Shadow request to %s failed: %v", target, err)
+		return
+	}
+	req.Header = header
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		log.Printf("Shadow request to %s failed: %v", target, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	shadowBody, err := io.ReadAll(io.LimitReader(resp.Body, maxShadowBodyBytes))
+	if err != nil {
+		log.Printf("Shadow response from %s unreadable: %v", target, err)
+		return
+	}
+
+	if resp.StatusCode != primaryStatus {
+		log.Printf("Shadow diff %s: status %d (primary) vs %d (shadow)", target, primaryStatus, resp.StatusCode)
+		return
+	}
+
+	if diffs := diffJSONBodies(primaryBody, shadowBody); len(diffs) > 0 {
+		log.Printf("Shadow diff %s: %s", target, strings.Join(diffs, "; "))
+	}
+}
+
+// diffJSONBodies returns the JSON paths whose values differ, or a single note when a body is not JSON.
+func diffJSONBodies(primary, shadow []byte) []string {
+	var a, b interface{}
+	if json.Unmarshal(primary, &a) != nil || json.Unmarshal(shadow, &b) != nil {
+		if !bytes.Equal(primary, shadow) {
+			return []string{"non-JSON bodies differ"}
+		}
+		return nil
+	}
+
+	var diffs []string
+	diffJSON("$", a, b, &diffs)
+	return diffs
+}
+
+func diffJSON(path string, a, b interface{}, diffs *[]string) {
+	if len(*diffs) >= maxShadowDiffPaths {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			*diffs = append(*diffs, path+" type differs")
+			return
+		}
+		keys := make(map[string]bool)
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffJSON(path+"."+k, av[k], bv[k], diffs)
+		}
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			*diffs = append(*diffs, path+" type differs")
+			return
+		}
+		if len(av) != len(bv) {
+			*diffs = append(*diffs, fmt.Sprintf("%s length %d vs %d", path, len(av), len(bv)))
+			return
+		}
+		for i := range av {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], diffs)
+		}
+	default:
+		if !reflect.DeepEqual(a, b) {
+			*diffs = append(*diffs, fmt.Sprintf("%s %v vs %v", path, a, b))
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"task-management-api/models"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	latencySampleSize = 1000
+	burnWindowMinutes = 5
+	alertCooldown     = 5 * time.Minute
+)
+
+// Histogram buckets in seconds for the Prometheus request duration metric
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type SLOConfig struct {
+	AvailabilityTarget float64
+	LatencyTarget      time.Duration
+	AlertBurnRate      float64
+	AlertsEnabled      bool
+}
+
+type minuteBucket struct {
+	minute   int64
+	requests int64
+	errors   int64
+}
+
+type routeStats struct {
+	method      string
+	route       string
+	requests    int64
+	errors      int64
+	codes       map[string]int64
+	buckets     []int64
+	durationSum float64
+	latencies   []time.Duration
+	nextSample  int
+	window      [burnWindowMinutes]minuteBucket
+	lastAlert   time.Time
+}
+
+// SLOTracker records per-route availability and latency against the configured objectives.
+type SLOTracker struct {
+	config SLOConfig
+	mu     sync.Mutex
+	routes map[string]*routeStats
+}
+
+func NewSLOTracker(config SLOConfig) *SLOTracker {
+	return &SLOTracker{
+		config: config,
+		routes: make(map[string]*routeStats),
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// Unwrap exposes the wrapped writer, as http.ResponseController expects
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (t *SLOTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Label by route template so IDs don't explode cardinality
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		t.record(r.Method, route, recorder.status, time.Since(start))
+	})
+}
+
+func (t *SLOTracker) record(method, route string, status int, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := method + " " + route
+	stats, ok := t.routes[key]
+	if !ok {
+		stats = &routeStats{
+			method:  method,
+			route:   route,
+			codes:   make(map[string]int64),
+			buckets: make([]int64, len(durationBuckets)),
+		}
+		t.routes[key] = stats
+	}
+
+	// Only server errors burn the error budget; 4xx responses are client mistakes
+	failed := status >= http.StatusInternalServerError
+
+	stats.requests++
+	if failed {
+		stats.errors++
+	}
+	stats.codes[fmt.Sprintf("%dxx", status/100)]++
+
+	seconds := duration.Seconds()
+	stats.durationSum += seconds
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+
+	if len(stats.latencies) < latencySampleSize {
+		stats.latencies = append(stats.latencies, duration)
+	} else {
+		stats.latencies[stats.nextSample] = duration
+		stats.nextSample = (stats.nextSample + 1) % latencySampleSize
+	}
+
+	minute := time.Now().Unix() / 60
+	bucket := &stats.window[minute%burnWindowMinutes]
+	if bucket.minute != minute {
+		*bucket = minuteBucket{minute: minute}
+	}
+	bucket.requests++
+	if failed {
+		bucket.errors++
+	}
+
+	if failed && t.config.AlertsEnabled {
+		t.checkBurnRate(stats)
+	}
+}
+
+func (t *SLOTracker) checkBurnRate(stats *routeStats) {
+	burnRate := t.burnRate(stats)
+	if burnRate < t.config.AlertBurnRate || time.Since(stats.lastAlert) < alertCooldown {
+		return
+	}
+	stats.lastAlert = time.Now()
+	log.Printf("ALERT: error budget burn rate %.1fx for %s %s over the last %d minutes (threshold %.1fx)",
+		burnRate, stats.method, stats.route, burnWindowMinutes, t.config.AlertBurnRate)
+}
+
+// burnRate is the recent error ratio relative to the ratio the SLO allows.
+func (t *SLOTracker) burnRate(stats *routeStats) float64 {
+	oldest := time.Now().Unix()/60 - burnWindowMinutes + 1
+	var requests, errors int64
+	for _, bucket := range stats.window {
+		if bucket.minute >= oldest {
+			requests += bucket.requests
+			errors += bucket.errors
+		}
+	}
+
+	allowed := 1 - t.config.AvailabilityTarget
+	if requests == 0 || allowed <= 0 {
+		return 0
+	}
+	return (float64(errors) / float64(requests)) / allowed
+}
+
+func (t *SLOTracker) Report() *models.SLOReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := &models.SLOReport{
+		AvailabilityTarget: t.config.AvailabilityTarget,
+		LatencyTargetMS:    models.Int64(t.config.LatencyTarget.Milliseconds()),
+		Routes:             []*models.SLORouteReport{},
+	}
+
+	for _, stats := range t.routes {
+		availability := 1 - float64(stats.errors)/float64(stats.requests)
+		p95 := percentile(stats.latencies, 0.95)
+
+		// Share of the allowed failures not yet spent; negative once the budget is blown
+		budgetRemaining := 1.0
+		if allowed := 1 - t.config.AvailabilityTarget; allowed > 0 {
+			budgetRemaining = 1 - (1-availability)/allowed
+		}
+
+		report.Routes = append(report.Routes, &models.SLORouteReport{
+			Method:               stats.method,
+			Route:                stats.route,
+			Requests:             models.Int64(stats.requests),
+			Errors:               models.Int64(stats.errors),
+			Availability:         availability,
+			AvailabilityMet:      availability >= t.config.AvailabilityTarget,
+			ErrorBudgetRemaining: budgetRemaining,
+			BurnRate:             t.burnRate(stats),
+			LatencyP50MS:         milliseconds(percentile(stats.latencies, 0.50)),
+			LatencyP95MS:         milliseconds(p95),
+			LatencyP99MS:         milliseconds(percentile(stats.latencies, 0.99)),
+			LatencyMet:           p95 <= t.config.LatencyTarget,
+		})
+	}
+
+	sort.Slice(report.Routes, func(i, j int) bool {
+		if report.Routes[i].Route != report.Routes[j].Route {
+			return report.Routes[i].Route < report.Routes[j].Route
+		}
+		return report.Routes[i].Method < report.Routes[j].Method
+	})
+
+	return report
+}
+
+// PrometheusMetrics renders counters and histograms in the Prometheus text exposition format.
+func (t *SLOTracker) PrometheusMetrics() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]string, 0, len(t.routes))
+	for key := range t.routes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("# HELP http_requests_total Total HTTP requests by route, method and status class.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, key := range keys {
+		stats := t.routes[key]
+		classes := make([]string, 0, len(stats.codes))
+		for class := range stats.codes {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(&b, "http_requests_total{method=%q,route=%q,code=%q} %d\n", stats.method, stats.route, class, stats.codes[class])
+		}
+	}
+
+	b.WriteString("# HELP http_request_duration_seconds HTTP request latency by route and method.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		stats := t.routes[key]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{method=%q,route=%q,le=\"%g\"} %d\n", stats.method, stats.route, bound, stats.buckets[i])
+		}
+		fmt.Fprintf(&b, "http_request_duration_seconds_bucket{method=%q,route=%q,le=\"+Inf\"} %d\n", stats.method, stats.route, stats.requests)
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum{method=%q,route=%q} %g\n", stats.method, stats.route, stats.durationSum)
+		fmt.Fprintf(&b, "http_request_duration_seconds_count{method=%q,route=%q} %d\n", stats.method, stats.route, stats.requests)
+	}
+
+	b.WriteString("# HELP slo_availability_target Configured availability objective.\n")
+	b.WriteString("# TYPE slo_availability_target gauge\n")
+	fmt.Fprintf(&b, "slo_availability_target %g\n", t.config.AvailabilityTarget)
+	b.WriteString("# HELP slo_latency_target_seconds Configured p95 latency objective.\n")
+	b.WriteString("# TYPE slo_latency_target_seconds gauge\n")
+	fmt.Fprintf(&b, "slo_latency_target_seconds %g\n", t.config.LatencyTarget.Seconds())
+
+	return b.String()
+}
+
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
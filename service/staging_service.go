@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// stagingRule says how one collection reaches staging
+type stagingRule struct {
+	collection string
+	// anonymize rewrites personal data in place; nil copies documents unchanged
+	anonymize func(doc bson.M) error
+	// clear empties the staging collection instead of copying it, for
+	// credentials and client addresses that must not leave production
+	clear bool
+}
+
+// stagingRules lists every collection a refresh touches. A collection that is
+// not listed is neither copied nor cleared, so new collections stay out of
+// staging until someone decides what in them is personal.
+func stagingRules(passwordHash string) []stagingRule {
+	return []stagingRule{
+		{collection: "users", anonymize: anonymizeUser(passwordHash)},
+		{collection: "tasks", anonymize: anonymizeTask},
+		{collection: "comments", anonymize: anonymizeComment},
+		{collection: "task_history", anonymize: anonymizeTaskHistory},
+		// Audit details hold counts, roles, scopes and times, never names
+		{collection: "audit_logs"},
+		{collection: "my_day_items"},
+		{collection: "focus_sessions"},
+		{collection: "user_achievements"},
+		{collection: "task_summaries"},
+		{collection: "retention_policies"},
+		{collection: "field_policies"},
+		{collection: "notification_templates"},
+		{collection: "retention_reports"},
+		{collection: "schema_meta"},
+		{collection: "refresh_tokens", clear: true},
+		{collection: "sessions", clear: true},
+		{collection: "api_keys", clear: true},
+		{collection: "login_attempts", clear: true},
+		// Staging must never call production endpoints or sign with their secrets
+		{collection: "webhooks", clear: true},
+		{collection: "webhook_deliveries", clear: true},
+		{collection: "notification_channels", clear: true},
+		{collection: "push_subscriptions", clear: true},
+		{collection: "notification_preferences", clear: true},
+		// Archive objects stay in the production store, out of staging's reach
+		{collection: "audit_archives", clear: true},
+		// Logged lines may hold emails and client addresses
+		{collection: "request_traces", clear: true},
+		{collection: "import_jobs", clear: true},
+		{collection: "usage_buckets", clear: true},
+		{collection: "subscriptions", clear: true},
+		{collection: "export_jobs", clear: true},
+		{collection: "export_chunks", clear: true},
+		{collection: "deprecation_usage", clear: true},
+		{collection: "queue_jobs", clear: true},
+		{collection: "scheduled_jobs", clear: true},
+		{collection: "dead_letter", clear: true},
+		{collection: "leases", clear: true},
+		{collection: "worker_state", clear: true},
+	}
+}
+
+// StagingService refreshes a staging database with production data in which
+// emails, usernames, titles, descriptions and comments are replaced by fakes.
+// Fakes are derived from record IDs, so every refresh produces the same fake
+// for the same record.
+type StagingService struct {
+	stagingRepo *repository.StagingRepository
+	password    string
+}
+
+// NewStagingService sets password as the password of every staging user, so
+// engineers can sign in as any of them.
+func NewStagingService(stagingRepo *repository.StagingRepository, password string) *StagingService {
+	return &StagingService{
+		stagingRepo: stagingRepo,
+		password:    password,
+	}
+}
+
+// Refresh replaces the staging copy of each collection in stagingRules,
+// stopping at the first collection that fails.
+func (s *StagingService) Refresh(ctx context.Context) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(s.password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	for _, rule := range stagingRules(string(passwordHash)) {
+		if rule.clear {
+			cleared, err := s.stagingRepo.ClearCollection(ctx, rule.collection)
+			if err != nil {
+				return err
+			}
+			log.Printf("Cleared %d documents from staging %s", cleared, rule.collection)
+			continue
+		}
+
+		copied, err := s.stagingRepo.CopyCollection(ctx, rule.collection, rule.anonymize)
+		if err != nil {
+			return err
+		}
+		log.Printf("Copied %d documents to staging %s", copied, rule.collection)
+	}
+
+	return nil
+}
+
+func anonymizeUser(passwordHash string) func(doc bson.M) error {
+	return func(doc bson.M) error {
+		userID, err := documentID(doc)
+		if err != nil {
+			return err
+		}
+
+		person := newFakePerson(userID)
+		doc["email"] = person.email
+		doc["username"] = person.username
+		doc["password"] = passwordHash
+		for _, key := range []string{"password_reset_token_hash", "password_reset_expires_at", "email_verification_token_hash", "email_verification_expires_at"} {
+			delete(doc, key)
+		}
+
+		identities, _ := doc["identities"].(bson.A)
+		for _, identity := range identities {
+			identity, ok := identity.(bson.M)
+			if !ok {
+				return fmt.Errorf("identity is not a document")
+			}
+			provider, _ := identity["provider"].(string)
+			identity["subject"] = fakeSubject(userID, provider)
+			identity["email"] = person.email
+		}
+
+		return nil
+	}
+}
+
+func anonymizeTask(doc bson.M) error {
+	taskID, err := documentID(doc)
+	if err != nil {
+		return err
+	}
+
+	doc["title"] = fakeTitle(taskID, "title")
+	if description, _ := doc["description"].(string); description != "" {
+		doc["description"] = fakeText(taskID, "description", 3)
+	}
+
+	return nil
+}
+
+func anonymizeComment(doc bson.M) error {
+	commentID, err := documentID(doc)
+	if err != nil {
+		return err
+	}
+
+	doc["body"] = fakeText(commentID, "body", 2)
+	return nil
+}
+
+// anonymizeTaskHistory replaces the values of title and description changes.
+// Status and owner changes hold no personal data and are kept.
+func anonymizeTaskHistory(doc bson.M) error {
+	entryID, err := documentID(doc)
+	if err != nil {
+		return err
+	}
+
+	field, _ := doc["field"].(string)
+	for _, key := range []string{"old_value", "new_value"} {
+		if value, _ := doc[key].(string); value == "" {
+			continue
+		}
+		switch field {
+		case "title":
+			doc[key] = fakeTitle(entryID, key)
+		case "description":
+			doc[key] = fakeText(entryID, key, 3)
+		}
+	}
+
+	return nil
+}
+
+func documentID(doc bson.M) (primitive.ObjectID, error) {
+	id, ok := doc["_id"].(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, fmt.Errorf("_id is not an ObjectID")
+	}
+	return id, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskQueryService is the read side for tasks. Lists read through the list
+// read preference and dashboards read projections, so reads can be tuned
+// without touching the write path in TaskService.
+type TaskQueryService struct {
+	taskRepo    *repository.TaskRepository
+	historyRepo *repository.TaskHistoryRepository
+	userRepo    *repository.UserRepository
+	projections *ProjectionService
+	clock       clock.Clock
+}
+
+func NewTaskQueryService(taskRepo *repository.TaskRepository, historyRepo *repository.TaskHistoryRepository, userRepo *repository.UserRepository, projections *ProjectionService, clk clock.Clock) *TaskQueryService {
+	return &TaskQueryService{
+		taskRepo:    taskRepo,
+		historyRepo: historyRepo,
+		userRepo:    userRepo,
+		projections: projections,
+		clock:       clk,
+	}
+}
+
+func (s *TaskQueryService) GetTask(ctx context.Context, taskID primitive.ObjectID, user *models.User) (*models.Task, error) {
+	task, err := s.taskRepo.FindByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorization check: users can only access their own tasks unless they can read all
+	if !user.HasPermission(models.PermissionTasksReadAll) && task.UserID != user.ID {
+		return nil, fmt.Errorf("unauthorized access to task")
+	}
+
+	return task, nil
+}
+
+func (s *TaskQueryService) ListTasks(ctx context.Context, user *models.User, filter repository.TaskFilter) (*models.TaskListResponse, error) {
+	var tasks []*models.Task
+	var totalCount int64
+	var err error
+
+	// Users with tasks:read_all see all tasks, others only their own
+	if user.HasPermission(models.PermissionTasksReadAll) {
+		tasks, totalCount, err = s.taskRepo.FindAll(ctx, filter)
+	} else {
+		tasks, totalCount, err = s.taskRepo.FindByUserID(ctx, user.ID, filter)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newTaskListResponse(tasks, totalCount, filter), nil
+}
+
+// RevealPrivate decrypts the user's own private tasks among tasks when the
+// request carries their private passphrase. Other private tasks stay sealed,
+// with an empty title and description.
+func (s *TaskQueryService) RevealPrivate(ctx context.Context, user *models.User, tasks ...*models.Task) error {
+	for _, task := range tasks {
+		if !task.Private || task.Sealed == nil || task.UserID != user.ID {
+			continue
+		}
+		key, err := privateKey(ctx, user)
+		if err != nil || key == nil {
+			return err
+		}
+		if err := openTask(key, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportTasks renders the user's own unarchived tasks as a Markdown checklist
+func (s *TaskQueryService) ExportTasks(ctx context.Context, user *models.User) (string, error) {
+	tasks, err := s.exportTasks(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	return renderMarkdown(tasks), nil
+}
+
+// ExportTasksXLSX renders the same tasks as ExportTasks as an Excel workbook
+func (s *TaskQueryService) ExportTasksXLSX(ctx context.Context, user *models.User) ([]byte, error) {
+	tasks, err := s.exportTasks(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderXLSX(tasks)
+}
+
+// exportTasks returns the tasks to export; private tasks are included only
+// when the request carries the passphrase to reveal them
+func (s *TaskQueryService) exportTasks(ctx context.Context, user *models.User) ([]*models.Task, error) {
+	tasks, err := s.taskRepo.FindExportByUserID(ctx, user.ID, maxExportTasks)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.RevealPrivate(ctx, user, tasks...); err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(tasks, func(task *models.Task) bool {
+		return task.Private && task.Sealed != nil
+	}), nil
+}
+
+// TaskReportPDF renders a PDF report of one user's unarchived tasks in the
+// filter's range. Reporting on another user takes tasks:read_all; their
+// private tasks stay sealed and are only counted.
+func (s *TaskQueryService) TaskReportPDF(ctx context.Context, user *models.User, filter repository.TaskReportFilter) ([]byte, error) {
+	subject := user
+	if filter.UserID != user.ID {
+		if !user.HasPermission(models.PermissionTasksReadAll) {
+			return nil, fmt.Errorf("permission %s required", models.PermissionTasksReadAll)
+		}
+		var err error
+		if subject, err = s.userRepo.FindByID(ctx, filter.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks, err := s.taskRepo.FindForReport(ctx, filter, maxExportTasks)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.RevealPrivate(ctx, user, tasks...); err != nil {
+		return nil, err
+	}
+
+	report := &taskReport{
+		subject:     subject.Username,
+		from:        filter.From,
+		to:          filter.To,
+		generatedAt: s.clock.Now(),
+	}
+	for _, task := range tasks {
+		if task.Private && task.Sealed != nil {
+			report.hiddenPrivate++
+			continue
+		}
+		report.tasks = append(report.tasks, task)
+	}
+
+	return renderTaskReportPDF(report)
+}
+
+func (s *TaskQueryService) ListSubtasks(ctx context.Context, parentID primitive.ObjectID, user *models.User, filter repository.TaskFilter) (*models.TaskListResponse, error) {
+	// Subtasks share the parent's owner, so access to the parent grants access to its children
+	if _, err := s.GetTask(ctx, parentID, user); err != nil {
+		return nil, err
+	}
+
+	tasks, totalCount, err := s.taskRepo.FindByParentID(ctx, parentID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTaskListResponse(tasks, totalCount, filter), nil
+}
+
+func (s *TaskQueryService) ListHistory(ctx context.Context, taskID primitive.ObjectID, user *models.User, filter repository.HistoryFilter) (*models.TaskHistoryListResponse, error) {
+	if _, err := s.GetTask(ctx, taskID, user); err != nil {
+		return nil, err
+	}
+
+	entries, totalCount, err := s.historyRepo.FindByTaskID(ctx, taskID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewTaskHistoryListResponse(entries, filter.Page, filter.Limit, totalCount), nil
+}
+
+func newTaskListResponse(tasks []*models.Task, totalCount int64, filter repository.TaskFilter) *models.TaskListResponse {
+	return models.NewTaskListResponse(tasks, filter.Page, filter.Limit, totalCount)
+}
+
+// Summary returns the user's task counts from the task summary projection
+func (s *TaskQueryService) Summary(ctx context.Context, user *models.User) (*models.TaskSummary, error) {
+	return s.projections.TaskSummary(ctx, user)
+}
@@ -3,23 +3,102 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"task-management-api/clock"
+	"task-management-api/events"
 	"task-management-api/models"
 	"task-management-api/repository"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	maxBulkDeleteIDs = 1000
+	maxBlockers      = 50
+	reassignBatch    = 500
+	maxExportTasks   = 10000
+	maxImportTasks   = 500
+
+	maxJSONImportTasks = 5000
+	importBatchSize    = 100
+)
+
+// TaskService is the command side for tasks: every create, update and delete
+// goes through it so invariants and history hold. Reads go through TaskQueryService.
 type TaskService struct {
-	taskRepo *repository.TaskRepository
+	taskRepo                 *repository.TaskRepository
+	historyRepo              *repository.TaskHistoryRepository
+	userRepo                 *repository.UserRepository
+	auditRepo                *repository.AuditRepository
+	fieldPolicies            *FieldPolicyService
+	requireSubtasksCompleted bool
+	clock                    clock.Clock
+	bus                      *events.Bus
+	quota                    QuotaCheck
 }
 
-func NewTaskService(taskRepo *repository.TaskRepository) *TaskService {
+// NewTaskService publishes task events to bus once each change is saved
+func NewTaskService(taskRepo *repository.TaskRepository, historyRepo *repository.TaskHistoryRepository, userRepo *repository.UserRepository, auditRepo *repository.AuditRepository, fieldPolicies *FieldPolicyService, requireSubtasksCompleted bool, bus *events.Bus, clk clock.Clock) *TaskService {
 	return &TaskService{
-		taskRepo: taskRepo,
+		taskRepo:                 taskRepo,
+		historyRepo:              historyRepo,
+		userRepo:                 userRepo,
+		auditRepo:                auditRepo,
+		fieldPolicies:            fieldPolicies,
+		requireSubtasksCompleted: requireSubtasksCompleted,
+		bus:                      bus,
+		clock:                    clk,
+	}
+}
+
+// changed publishes whose tasks were written
+func (s *TaskService) changed(ctx context.Context, userIDs ...primitive.ObjectID) {
+	for _, userID := range userIDs {
+		s.bus.Publish(ctx, events.TasksChanged{UserID: userID})
+	}
+}
+
+// created publishes the tasks that were added
+func (s *TaskService) created(ctx context.Context, tasks ...*models.Task) {
+	for _, task := range tasks {
+		s.bus.Publish(ctx, events.TaskCreated{Task: task})
+	}
+}
+
+// EnforceQuota makes creates and imports ask check before adding tasks.
+// Follow-up occurrences of recurring tasks are never refused. Set during startup.
+func (s *TaskService) EnforceQuota(check QuotaCheck) {
+	s.quota = check
+}
+
+func (s *TaskService) checkQuota(ctx context.Context, userID primitive.ObjectID, adding int) error {
+	if s.quota == nil {
+		return nil
 	}
+	return s.quota(ctx, userID, adding)
 }
 
-func (s *TaskService) CreateTask(ctx context.Context, userID primitive.ObjectID, req *models.CreateTaskRequest) (*models.Task, error) {
+func (s *TaskService) CreateTask(ctx context.Context, user *models.User, req *models.CreateTaskRequest) (*models.Task, error) {
+	task, err := s.newTask(ctx, user, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(ctx, task.UserID, 1); err != nil {
+		return nil, err
+	}
+
+	if err := s.taskRepo.Create(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+
+	s.changed(ctx, task.UserID)
+	s.created(ctx, task)
+	return task, nil
+}
+
+// newTask validates a create request and builds the task it describes, without saving it
+func (s *TaskService) newTask(ctx context.Context, user *models.User, req *models.CreateTaskRequest) (*models.Task, error) {
 	// Validate input
 	if req.Title == "" {
 		return nil, fmt.Errorf("title is required")
@@ -36,75 +115,805 @@ func (s *TaskService) CreateTask(ctx context.Context, userID primitive.ObjectID,
 		return nil, fmt.Errorf("invalid status, must be one of: pending, in_progress, completed")
 	}
 
+	if req.Priority != "" && !IsValidPriority(req.Priority) {
+		return nil, fmt.Errorf("invalid priority, must be one of: low, medium, high")
+	}
+
+	if req.Recurrence != "" {
+		if _, err := parseRecurrence(req.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create task
-	task := models.NewTask(userID, req.Title, req.Description, status)
-	if err := s.taskRepo.Create(ctx, task); err != nil {
-		return nil, fmt.Errorf("failed to create task: %w", err)
+	task := models.NewTask(user.ID, req.Title, req.Description, status, s.clock.Now())
+	task.Priority = req.Priority
+	task.DueDate = req.DueDate
+	task.Recurrence = req.Recurrence
+
+	// Subtasks must reference an existing parent and always belong to the parent's owner
+	if req.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent_id")
+		}
+		parent, err := s.taskRepo.FindByID(ctx, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("parent task not found")
+		}
+		if !user.HasPermission(models.PermissionTasksUpdateAny) && parent.UserID != user.ID {
+			return nil, fmt.Errorf("parent task not found")
+		}
+		task.ParentID = &parent.ID
+		task.UserID = parent.UserID
+	}
+
+	if len(req.BlockedBy) > 0 {
+		blockedBy, err := s.resolveBlockers(ctx, task, user, req.BlockedBy)
+		if err != nil {
+			return nil, err
+		}
+		task.BlockedBy = blockedBy
+		if err := s.checkBlockersResolved(ctx, task, status); err != nil {
+			return nil, err
+		}
+	}
+
+	// Private tasks are sealed with the creator's key, so only the creator may own them
+	if req.Private {
+		if task.UserID != user.ID {
+			return nil, fmt.Errorf("only the owner can change a private task")
+		}
+		key, err := requirePrivateKey(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		task.Private = true
+		if err := sealTask(key, task); err != nil {
+			return nil, err
+		}
 	}
 
 	return task, nil
 }
 
-func (s *TaskService) GetTask(ctx context.Context, taskID primitive.ObjectID, user *models.User) (*models.Task, error) {
+// ImportTasks creates a task for every checklist item in text. The whole
+// document is validated before anything is created, and a failed save removes
+// the tasks saved before it, so a document is imported whole or not at all.
+// Importing the same checklist twice creates the tasks twice.
+func (s *TaskService) ImportTasks(ctx context.Context, user *models.User, text string) ([]*models.Task, error) {
+	requests, err := parseMarkdown(text, maxImportTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*models.Task, 0, len(requests))
+	for _, req := range requests {
+		task, err := s.newTask(ctx, user, req)
+		if err != nil {
+			return nil, err
+		}
+		// IDs are chosen up front, so a failed save can remove exactly what it inserted
+		task.ID = primitive.NewObjectID()
+		tasks = append(tasks, task)
+	}
+	if err := s.checkQuota(ctx, user.ID, len(tasks)); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(tasks); start += importBatchSize {
+		batch := tasks[start:min(start+importBatchSize, len(tasks))]
+		if err := s.taskRepo.CreateMany(ctx, batch); err != nil {
+			s.discardImported(ctx, tasks)
+			return nil, fmt.Errorf("failed to import tasks: %w", err)
+		}
+	}
+
+	s.importedChanged(ctx, tasks)
+	return tasks, nil
+}
+
+// discardImported removes whichever of an import's tasks were saved before
+// the import failed. They were never published, so nothing else is told.
+func (s *TaskService) discardImported(ctx context.Context, tasks []*models.Task) {
+	ids := make([]primitive.ObjectID, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	if _, err := s.taskRepo.DeleteMany(context.WithoutCancel(ctx), repository.TaskBulkFilter{IDs: ids}); err != nil {
+		logf(ctx, "Failed to remove %d tasks of a failed import: %v", len(tasks), err)
+	}
+}
+
+// ImportTasksJSON creates tasks from a JSON array, or NDJSON when ndjson is set.
+// Unlike ImportTasks, every record is validated on its own: valid ones are
+// created in batches and the rest are reported by line with the reason.
+func (s *TaskService) ImportTasksJSON(ctx context.Context, user *models.User, body []byte, ndjson bool) (*models.ImportReport, error) {
+	records, err := parseJSONImport(body, ndjson, maxJSONImportTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*models.Task
+	var failures []models.ImportFailure
+	for _, record := range records {
+		if record.err != nil {
+			failures = append(failures, models.ImportFailure{Line: record.line, Error: record.err.Error()})
+			continue
+		}
+		task, err := s.newTask(ctx, user, record.req)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "failed to") {
+				return nil, fmt.Errorf("failed to import tasks: %w", err)
+			}
+			failures = append(failures, models.ImportFailure{Line: record.line, Error: err.Error()})
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	if _, err := s.saveImported(ctx, tasks); err != nil {
+		return nil, err
+	}
+
+	return models.NewImportReport(tasks, failures), nil
+}
+
+// saveImported inserts imported tasks in batches and returns how many were
+// saved. A batch that fails to save stops the import; earlier batches stay
+// imported.
+func (s *TaskService) saveImported(ctx context.Context, tasks []*models.Task) (int, error) {
+	perOwner := map[primitive.ObjectID]int{}
+	for _, task := range tasks {
+		perOwner[task.UserID]++
+	}
+	for owner, adding := range perOwner {
+		if err := s.checkQuota(ctx, owner, adding); err != nil {
+			return 0, err
+		}
+	}
+
+	saved := 0
+	for start := 0; start < len(tasks); start += importBatchSize {
+		batch := tasks[start:min(start+importBatchSize, len(tasks))]
+		if err := s.taskRepo.CreateMany(ctx, batch); err != nil {
+			tasks = tasks[:saved]
+			s.importedChanged(ctx, tasks)
+			return saved, fmt.Errorf("failed to import tasks: %w", err)
+		}
+		saved += len(batch)
+	}
+
+	s.importedChanged(ctx, tasks)
+	return saved, nil
+}
+
+// importedChanged publishes newly imported tasks
+func (s *TaskService) importedChanged(ctx context.Context, tasks []*models.Task) {
+
+	owners := map[primitive.ObjectID]bool{}
+	for _, task := range tasks {
+		if !owners[task.UserID] {
+			owners[task.UserID] = true
+			s.changed(ctx, task.UserID)
+		}
+	}
+	s.created(ctx, tasks...)
+}
+
+func (s *TaskService) UpdateTask(ctx context.Context, taskID primitive.ObjectID, user *models.User, req *models.UpdateTaskRequest) (*models.Task, error) {
 	task, err := s.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Authorization check: users can only access their own tasks, admins can access all
-	if user.Role != models.UserRoleAdmin && task.UserID != user.ID {
-		return nil, fmt.Errorf("unauthorized access to task")
+	// Authorization check: users can only update their own tasks unless they can update any
+	if !user.HasPermission(models.PermissionTasksUpdateAny) && task.UserID != user.ID {
+		return nil, fmt.Errorf("unauthorized to update this task")
+	}
+	if req.IfMatch != nil && !slices.Contains(req.IfMatch, task.Version) {
+		return nil, fmt.Errorf("task has been modified")
+	}
+
+	// Changing a private task's content, or whether it is private, takes the owner's key
+	var key []byte
+	togglesPrivate := req.Private != nil && *req.Private != task.Private
+	if togglesPrivate || (task.Private && (req.Title != nil || req.Description != nil)) {
+		if task.UserID != user.ID {
+			return nil, fmt.Errorf("only the owner can change a private task")
+		}
+		if key, err = requirePrivateKey(ctx, user); err != nil {
+			return nil, err
+		}
+		if task.Private {
+			if err := openTask(key, task); err != nil {
+				return nil, err
+			}
+		}
+	}
+	before := *task
+
+	// Validate and apply changes
+	if req.Title != nil {
+		if *req.Title == "" {
+			return nil, fmt.Errorf("title cannot be empty")
+		}
+		task.Title = *req.Title
+	}
+
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+
+	// An empty priority clears it
+	if req.Priority != nil {
+		if *req.Priority != "" && !IsValidPriority(*req.Priority) {
+			return nil, fmt.Errorf("invalid priority, must be one of: low, medium, high")
+		}
+		task.Priority = *req.Priority
+	}
+
+	if req.DueDate.Set {
+		// A new due date gets a reminder of its own
+		if (task.DueDate == nil) != (req.DueDate.Value == nil) || (task.DueDate != nil && !task.DueDate.Equal(*req.DueDate.Value)) {
+			task.RemindedAt = nil
+		}
+		task.DueDate = req.DueDate.Value
+	}
+
+	if req.Recurrence != nil {
+		if *req.Recurrence != "" {
+			if _, err := parseRecurrence(*req.Recurrence); err != nil {
+				return nil, err
+			}
+		}
+		task.Recurrence = *req.Recurrence
+	}
+
+	if req.BlockedBy != nil {
+		blockedBy, err := s.resolveBlockers(ctx, task, user, *req.BlockedBy)
+		if err != nil {
+			return nil, err
+		}
+		task.BlockedBy = blockedBy
+	}
+
+	completed := false
+	if req.Status != nil {
+		if !IsValidStatus(*req.Status) {
+			return nil, fmt.Errorf("invalid status, must be one of: pending, in_progress, completed")
+		}
+		if err := s.checkTransition(ctx, task, *req.Status); err != nil {
+			return nil, err
+		}
+		completed = *req.Status == models.TaskStatusCompleted && task.Status != models.TaskStatusCompleted
+		task.Status = *req.Status
+	}
+
+	if err := s.fieldPolicies.CheckTaskUpdate(ctx, user, &before, task); err != nil {
+		return nil, err
+	}
+
+	if togglesPrivate {
+		task.Private = *req.Private
+	}
+	if task.Private && key != nil {
+		if err := sealTask(key, task); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		return nil, err
+	}
+
+	s.recordChanges(ctx, &before, task, &user.ID)
+	s.changed(ctx, task.UserID)
+
+	if completed {
+		s.bus.Publish(ctx, events.TaskCompleted{Task: task, By: user})
+		if err := s.scheduleNextOccurrence(ctx, task); err != nil {
+			logf(ctx, "Failed to schedule next occurrence of task %s: %v", task.ID.Hex(), err)
+		}
 	}
 
 	return task, nil
 }
 
-func (s *TaskService) ListTasks(ctx context.Context, user *models.User, filter repository.TaskFilter) (*models.TaskListResponse, error) {
-	var tasks []*models.Task
-	var totalCount int64
-	var err error
+func (s *TaskService) SetArchived(ctx context.Context, taskID primitive.ObjectID, user *models.User, archived bool) (*models.Task, error) {
+	task, err := s.taskRepo.FindByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorization check: users can only update their own tasks unless they can update any
+	if !user.HasPermission(models.PermissionTasksUpdateAny) && task.UserID != user.ID {
+		return nil, fmt.Errorf("unauthorized to update this task")
+	}
+
+	if task.Archived == archived {
+		return task, nil
+	}
+
+	if err := s.taskRepo.SetArchived(ctx, taskID, archived); err != nil {
+		return nil, err
+	}
+	s.changed(ctx, task.UserID)
+
+	return s.taskRepo.FindByID(ctx, taskID)
+}
+
+// recordChanges stores one history entry per changed field; a nil actorID attributes it to the system.
+func (s *TaskService) recordChanges(ctx context.Context, before, after *models.Task, actorID *primitive.ObjectID) {
+	now := s.clock.Now()
+	var entries []*models.TaskHistory
+	if before.Status != after.Status {
+		entries = append(entries, models.NewTaskHistory(after.ID, "status", string(before.Status), string(after.Status), actorID, now))
+	}
+	// The content of private tasks stays out of history, which anyone who can see the task may read
+	if !before.Private && !after.Private {
+		if before.Title != after.Title {
+			entries = append(entries, models.NewTaskHistory(after.ID, "title", before.Title, after.Title, actorID, now))
+		}
+		if before.Description != after.Description {
+			entries = append(entries, models.NewTaskHistory(after.ID, "description", before.Description, after.Description, actorID, now))
+		}
+	}
+
+	// History is best-effort; the change itself has already been persisted
+	if err := s.historyRepo.CreateMany(ctx, entries); err != nil {
+		logf(ctx, "Failed to record history for task %s: %v", after.ID.Hex(), err)
+	}
+}
 
-	// Admins can see all tasks, regular users can only see their own
-	if user.Role == models.UserRoleAdmin {
-		tasks, totalCount, err = s.taskRepo.FindAll(ctx, filter)
-	} else {
-		tasks, totalCount, err = s.taskRepo.FindByUserID(ctx, user.ID, filter)
+// scheduleNextOccurrence creates the follow-up task for a completed recurring task, at most once per task.
+func (s *TaskService) scheduleNextOccurrence(ctx context.Context, task *models.Task) error {
+	if task.Recurrence == "" || task.NextOccurrenceID != nil {
+		return nil
 	}
 
+	rule, err := parseRecurrence(task.Recurrence)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	from := now
+	if task.DueDate != nil {
+		from = *task.DueDate
+	}
+	dueDate := rule.nextOccurrence(from, now)
+
+	next := models.NewTask(task.UserID, task.Title, task.Description, models.TaskStatusPending, now)
+	next.ParentID = task.ParentID
+	next.Priority = task.Priority
+	next.DueDate = &dueDate
+	next.Recurrence = task.Recurrence
+	next.Private = task.Private
+	next.Sealed = task.Sealed
+	if err := s.taskRepo.Create(ctx, next); err != nil {
+		return err
+	}
+
+	// Another completion may have won the race; keep only the linked occurrence
+	linked, err := s.taskRepo.SetNextOccurrence(ctx, task.ID, next.ID)
+	if err != nil || !linked {
+		if deleteErr := s.taskRepo.Delete(ctx, next.ID); deleteErr != nil {
+			logf(ctx, "Failed to remove duplicate occurrence %s: %v", next.ID.Hex(), deleteErr)
+		}
+		return err
+	}
+
+	// The link is a change to the task, so its version moved on with it
+	task.NextOccurrenceID = &next.ID
+	task.Version++
+	s.changed(ctx, next.UserID)
+	s.created(ctx, next)
+	return nil
+}
+
+// checkTransition enforces the subtask and dependency rules for moving a task to a new status.
+func (s *TaskService) checkTransition(ctx context.Context, task *models.Task, status models.TaskStatus) error {
+	if status == task.Status {
+		return nil
+	}
+
+	if status == models.TaskStatusCompleted && s.requireSubtasksCompleted {
+		openCount, err := s.taskRepo.CountOpenSubtasks(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if openCount > 0 {
+			return fmt.Errorf("task has open subtasks")
+		}
+	}
+
+	return s.checkBlockersResolved(ctx, task, status)
+}
+
+func (s *TaskService) checkBlockersResolved(ctx context.Context, task *models.Task, status models.TaskStatus) error {
+	if status == models.TaskStatusPending || len(task.BlockedBy) == 0 {
+		return nil
+	}
+
+	// Deleted blockers no longer block
+	blockers, err := s.taskRepo.FindByIDs(ctx, task.BlockedBy)
+	if err != nil {
+		return err
+	}
+	for _, blocker := range blockers {
+		if blocker.Status != models.TaskStatusCompleted {
+			return fmt.Errorf("task is blocked by open tasks")
+		}
+	}
+
+	return nil
+}
+
+// resolveBlockers validates blocked_by references and rejects any that would create a dependency cycle.
+func (s *TaskService) resolveBlockers(ctx context.Context, task *models.Task, user *models.User, rawIDs []string) ([]primitive.ObjectID, error) {
+	if len(rawIDs) > maxBlockers {
+		return nil, fmt.Errorf("a task can be blocked by at most %d tasks", maxBlockers)
+	}
+
+	seen := make(map[primitive.ObjectID]bool)
+	var ids []primitive.ObjectID
+	for _, rawID := range rawIDs {
+		id, err := primitive.ObjectIDFromHex(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocked_by task ID: %s", rawID)
+		}
+		if id == task.ID {
+			return nil, fmt.Errorf("a task cannot block itself")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	blockers, err := s.taskRepo.FindByIDs(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
+	if len(blockers) != len(ids) {
+		return nil, fmt.Errorf("blocked_by task not found")
+	}
+	for _, blocker := range blockers {
+		if !user.HasPermission(models.PermissionTasksReadAll) && blocker.UserID != user.ID {
+			return nil, fmt.Errorf("blocked_by task not found")
+		}
+	}
+
+	// A brand-new task cannot be part of a cycle yet
+	if task.ID.IsZero() {
+		return ids, nil
+	}
 
-	// Calculate total pages
-	totalPages := int(totalCount) / filter.Limit
-	if int(totalCount)%filter.Limit > 0 {
-		totalPages++
+	// Walk the dependency graph breadth-first; reaching the task again means a cycle
+	visited := make(map[primitive.ObjectID]bool)
+	frontier := blockers
+	for len(frontier) > 0 {
+		var next []primitive.ObjectID
+		for _, t := range frontier {
+			for _, dep := range t.BlockedBy {
+				if dep == task.ID {
+					return nil, fmt.Errorf("blocked_by would create a dependency cycle")
+				}
+				if !visited[dep] {
+					visited[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		frontier, err = s.taskRepo.FindByIDs(ctx, next)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return &models.TaskListResponse{
-		Tasks:      tasks,
-		Page:       filter.Page,
-		Limit:      filter.Limit,
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-	}, nil
+	return ids, nil
 }
 
-func (s *TaskService) DeleteTask(ctx context.Context, taskID primitive.ObjectID, user *models.User) error {
+func (s *TaskService) DeleteTask(ctx context.Context, taskID primitive.ObjectID, user *models.User, subtaskMode models.SubtaskDeleteMode) (err error) {
 	// Check if task exists and user has permission
 	task, err := s.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
 		return err
 	}
 
-	// Authorization check: users can only delete their own tasks, admins can delete any task
-	if user.Role != models.UserRoleAdmin && task.UserID != user.ID {
+	// Authorization check: users can only delete their own tasks unless they can delete any
+	if !user.HasPermission(models.PermissionTasksDeleteAny) && task.UserID != user.ID {
 		return fmt.Errorf("unauthorized to delete this task")
 	}
 
-	return s.taskRepo.Delete(ctx, taskID)
+	deleted := []*models.Task{task}
+	defer func() {
+		if err == nil {
+			s.changed(ctx, task.UserID)
+			for _, task := range deleted {
+				s.bus.Publish(ctx, events.TaskDeleted{Task: task})
+			}
+		}
+	}()
+
+	if err := s.checkNotHeld(ctx, []*models.Task{task}); err != nil {
+		return err
+	}
+
+	subtaskIDs, err := s.taskRepo.FindSubtaskIDs(ctx, []primitive.ObjectID{taskID})
+	if err != nil {
+		return err
+	}
+	if len(subtaskIDs) == 0 {
+		return s.taskRepo.Delete(ctx, taskID)
+	}
+
+	// Parents with subtasks require an explicit decision about their children
+	switch subtaskMode {
+	case models.SubtaskDeleteOrphan:
+		if err := s.taskRepo.OrphanSubtasks(ctx, taskID); err != nil {
+			return err
+		}
+		return s.taskRepo.Delete(ctx, taskID)
+	case models.SubtaskDeleteCascade:
+		descendantIDs, err := s.cascadeIDs(ctx, subtaskIDs)
+		if err != nil {
+			return err
+		}
+
+		// Held descendants further down are skipped by the delete
+		deleted, err = s.taskRepo.DeleteMany(ctx, repository.TaskBulkFilter{IDs: append([]primitive.ObjectID{taskID}, descendantIDs...)})
+		return err
+	default:
+		return fmt.Errorf("task has subtasks, specify subtasks=cascade or subtasks=orphan")
+	}
+}
+
+// cascadeIDs returns the subtasks and all of their descendants. Deleting part
+// of a tree would leave held subtasks pointing at a missing parent, so it fails
+// when one of the subtasks is held.
+func (s *TaskService) cascadeIDs(ctx context.Context, subtaskIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	subtasks, err := s.taskRepo.FindByIDs(ctx, subtaskIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotHeld(ctx, subtasks); err != nil {
+		return nil, err
+	}
+
+	ids := append([]primitive.ObjectID{}, subtaskIDs...)
+	for level := subtaskIDs; len(level) > 0; {
+		level, err = s.taskRepo.FindSubtaskIDs(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, level...)
+	}
+	return ids, nil
+}
+
+// ReassignOpenTasks moves every open task owned by from to to, in batches so a
+// large backlog never holds a single long-running update. A zero to leaves the
+// tasks unassigned, visible to admins only.
+func (s *TaskService) ReassignOpenTasks(ctx context.Context, from, to primitive.ObjectID, actorID primitive.ObjectID) (int64, error) {
+	var total int64
+	for {
+		ids, err := s.taskRepo.FindOpenIDsByUserID(ctx, from, reassignBatch)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			if !to.IsZero() && total > 0 {
+				s.bus.Publish(ctx, events.TasksAssigned{UserID: to, Count: total})
+			}
+			return total, nil
+		}
+
+		moved, err := s.taskRepo.Reassign(ctx, ids, from, to)
+		if err != nil {
+			return total, err
+		}
+		total += moved
+		s.changed(ctx, from, to)
+
+		now := s.clock.Now()
+		entries := make([]*models.TaskHistory, len(ids))
+		for i, id := range ids {
+			entries[i] = models.NewTaskHistory(id, "user_id", ownerValue(from), ownerValue(to), &actorID, now)
+		}
+		if err := s.historyRepo.CreateMany(ctx, entries); err != nil {
+			logf(ctx, "Failed to record reassignment history for %d tasks: %v", len(entries), err)
+		}
+	}
+}
+
+func ownerValue(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+// checkNotHeld fails when any of the tasks, or its owner, is under legal hold.
+func (s *TaskService) checkNotHeld(ctx context.Context, tasks []*models.Task) error {
+	heldUserIDs, err := s.userRepo.FindLegalHoldIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if task.LegalHold {
+			return fmt.Errorf("task is under legal hold")
+		}
+		for _, id := range heldUserIDs {
+			if task.UserID == id {
+				return fmt.Errorf("task is under legal hold")
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetLegalHold places or releases a hold on a single task.
+func (s *TaskService) SetLegalHold(ctx context.Context, taskID primitive.ObjectID, hold bool) error {
+	return s.taskRepo.SetLegalHold(ctx, taskID, hold)
+}
+
+// CancelUserPurges clears pending purges on a user's tasks once the user is placed on hold.
+func (s *TaskService) CancelUserPurges(ctx context.Context, userID primitive.ObjectID) error {
+	return s.taskRepo.CancelPurgeByUserID(ctx, userID)
+}
+
+// BulkDeleteTasks deletes the caller's tasks matching req, or those of
+// req.UserID for callers that can delete any task. At most maxBulkDeleteIDs
+// tasks go per request; More tells the caller to repeat it.
+func (s *TaskService) BulkDeleteTasks(ctx context.Context, user *models.User, req *models.BulkDeleteTasksRequest) (*models.BulkDeleteTasksResponse, error) {
+	// Validate input
+	if len(req.IDs) == 0 && req.Status == "" {
+		return nil, fmt.Errorf("ids or status is required")
+	}
+
+	if len(req.IDs) > maxBulkDeleteIDs {
+		return nil, fmt.Errorf("at most %d ids can be deleted at once", maxBulkDeleteIDs)
+	}
+
+	if req.Subtasks != "" && req.Subtasks != models.SubtaskDeleteCascade && req.Subtasks != models.SubtaskDeleteOrphan {
+		return nil, fmt.Errorf("invalid subtasks, must be one of: cascade, orphan")
+	}
+
+	// Only the caller's tasks are deleted unless another user is named
+	ownerID := user.ID
+	if req.UserID != "" {
+		id, err := primitive.ObjectIDFromHex(req.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user_id")
+		}
+		if id != user.ID {
+			if !user.HasPermission(models.PermissionTasksDeleteAny) {
+				return nil, fmt.Errorf("you don't have permission to delete other users' tasks")
+			}
+			if _, err := s.userRepo.FindByID(ctx, id); err != nil {
+				if err.Error() == "user not found" {
+					return nil, fmt.Errorf("invalid user_id")
+				}
+				return nil, err
+			}
+		}
+		ownerID = id
+	}
+	filter := repository.TaskBulkFilter{UserID: &ownerID}
+	for _, idStr := range req.IDs {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task ID: %s", idStr)
+		}
+		filter.IDs = append(filter.IDs, id)
+	}
+
+	if req.Status != "" {
+		if !IsValidStatus(req.Status) {
+			return nil, fmt.Errorf("invalid status, must be one of: pending, in_progress, completed")
+		}
+		status := req.Status
+		filter.Status = &status
+	}
+
+	// Held tasks are skipped by the repository; held users' tasks are skipped here
+	heldUserIDs, err := s.userRepo.FindLegalHoldIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter.ExcludeUserIDs = heldUserIDs
+
+	// Parents whose subtasks are not deleted with them need the same explicit
+	// decision about their children as a single delete. One more ID than
+	// deleted is read to tell whether more match.
+	ids, err := s.taskRepo.FindBulkIDs(ctx, filter, maxBulkDeleteIDs+1)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.BulkDeleteTasksResponse{}
+	if len(ids) > maxBulkDeleteIDs {
+		ids = ids[:maxBulkDeleteIDs]
+		response.More = true
+	}
+	if len(ids) == 0 {
+		return response, nil
+	}
+	subtaskIDs, err := s.taskRepo.FindSubtaskIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var remaining []primitive.ObjectID
+	for _, id := range subtaskIDs {
+		if !selected[id] {
+			remaining = append(remaining, id)
+		}
+	}
+	// Only the tasks checked above are deleted, even if more match meanwhile
+	filter = repository.TaskBulkFilter{IDs: ids, ExcludeUserIDs: heldUserIDs}
+	if len(remaining) > 0 {
+		switch req.Subtasks {
+		case models.SubtaskDeleteOrphan:
+			if err := s.taskRepo.OrphanSubtasks(ctx, ids...); err != nil {
+				return nil, err
+			}
+		case models.SubtaskDeleteCascade:
+			descendantIDs, err := s.cascadeIDs(ctx, remaining)
+			if err != nil {
+				return nil, err
+			}
+			filter.IDs = append(filter.IDs, descendantIDs...)
+		default:
+			return nil, fmt.Errorf("task has subtasks, specify subtasks=cascade or subtasks=orphan")
+		}
+	}
+
+	deleted, err := s.taskRepo.DeleteMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	response.DeletedCount = models.Int64(len(deleted))
+
+	if ownerID != user.ID {
+		entry := models.NewAuditLog(user.ID, "task.bulk_delete", "user", ownerID, map[string]interface{}{
+			"ids":           len(req.IDs),
+			"status":        req.Status,
+			"subtasks":      req.Subtasks,
+			"deleted_count": len(deleted),
+		}, s.clock.Now())
+		if err := s.auditRepo.Create(ctx, entry); err != nil {
+			logf(ctx, "Failed to record audit log %s for %s: %v", entry.Action, ownerID.Hex(), err)
+		}
+	}
+
+	owners := make(map[primitive.ObjectID]bool)
+	for _, task := range deleted {
+		s.bus.Publish(ctx, events.TaskDeleted{Task: task})
+		if !owners[task.UserID] {
+			owners[task.UserID] = true
+			s.changed(ctx, task.UserID)
+		}
+	}
+	return response, nil
 }
 
 func IsValidStatus(status models.TaskStatus) bool {
 	return status == models.TaskStatusPending || status == models.TaskStatusInProgress || status == models.TaskStatusCompleted
 }
+
+func IsValidPriority(priority models.TaskPriority) bool {
+	return priority == models.TaskPriorityLow || priority == models.TaskPriorityMedium || priority == models.TaskPriorityHigh
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"task-management-api/models"
+	"time"
+)
+
+// DefaultTodoistAPIURL is Todoist's unified API
+const DefaultTodoistAPIURL = "https://api.todoist.com/api/v1"
+
+// todoistPageSize is the largest page the API serves
+const todoistPageSize = 200
+
+// TodoistClient reads a user's projects and active tasks with their API token
+type TodoistClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewTodoistClient(baseURL string, client *http.Client) *TodoistClient {
+	return &TodoistClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}
+}
+
+type todoistProject struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type todoistTask struct {
+	ID          string      `json:"id"`
+	ProjectID   string      `json:"project_id"`
+	ParentID    *string     `json:"parent_id"`
+	Content     string      `json:"content"`
+	Description string      `json:"description"`
+	Priority    int         `json:"priority"`
+	Due         *todoistDue `json:"due"`
+}
+
+type todoistDue struct {
+	// Date is a day ("2026-10-16"), a floating time ("2026-10-16T09:00:00")
+	// or a UTC time ("2026-10-16T07:00:00Z")
+	Date     string  `json:"date"`
+	Timezone *string `json:"timezone"`
+}
+
+// Projects returns every project of the token's user
+func (c *TodoistClient) Projects(ctx context.Context, token string) ([]todoistProject, error) {
+	var projects []todoistProject
+	err := c.list(ctx, token, "/projects", func(raw json.RawMessage) error {
+		var page []todoistProject
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return fmt.Errorf("failed to read Todoist projects: %w", err)
+		}
+		projects = append(projects, page...)
+		return nil
+	})
+	return projects, err
+}
+
+// Tasks returns the user's active tasks, and fails once there are more than max
+func (c *TodoistClient) Tasks(ctx context.Context, token string, max int) ([]todoistTask, error) {
+	var tasks []todoistTask
+	err := c.list(ctx, token, "/tasks", func(raw json.RawMessage) error {
+		var page []todoistTask
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return fmt.Errorf("failed to read Todoist tasks: %w", err)
+		}
+		tasks = append(tasks, page...)
+		if max > 0 && len(tasks) > max {
+			return fmt.Errorf("at most %d tasks can be imported at once", max)
+		}
+		return nil
+	})
+	return tasks, err
+}
+
+// list follows the cursor of a paginated endpoint, passing each page's results to add
+func (c *TodoistClient) list(ctx context.Context, token, path string, add func(json.RawMessage) error) error {
+	cursor := ""
+	for {
+		query := url.Values{"limit": {fmt.Sprint(todoistPageSize)}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to build Todoist request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json")
+
+		var page struct {
+			Results    json.RawMessage `json:"results"`
+			NextCursor *string         `json:"next_cursor"`
+		}
+		if err := c.do(req, &page); err != nil {
+			return err
+		}
+		if err := add(page.Results); err != nil {
+			return err
+		}
+		if page.NextCursor == nil || *page.NextCursor == "" {
+			return nil
+		}
+		cursor = *page.NextCursor
+	}
+}
+
+func (c *TodoistClient) do(req *http.Request, out interface{}) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Todoist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("failed to reach Todoist: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("invalid Todoist API token")
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("failed to reach Todoist: unexpected status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to reach Todoist: invalid response: %w", err)
+	}
+	return nil
+}
+
+// todoistPriorities maps Todoist's priority, 1 (normal) to 4 (urgent, shown
+// as p1 in the apps), to ours; normal tasks get no priority
+var todoistPriorities = map[int]models.TaskPriority{
+	2: models.TaskPriorityLow,
+	3: models.TaskPriorityMedium,
+	4: models.TaskPriorityHigh,
+}
+
+// todoistTaskRequest maps a Todoist task to a create request. Tasks here have
+// no projects, so the project's name is kept at the end of the description.
+func todoistTaskRequest(task todoistTask, projectName string) (*models.CreateTaskRequest, error) {
+	req := &models.CreateTaskRequest{
+		Title:       task.Content,
+		Description: task.Description,
+		Priority:    todoistPriorities[task.Priority],
+	}
+	if projectName != "" {
+		if req.Description != "" {
+			req.Description += "\n\n"
+		}
+		req.Description += "Todoist project: " + projectName
+	}
+
+	if task.Due != nil && task.Due.Date != "" {
+		due, err := parseTodoistDue(task.Due)
+		if err != nil {
+			return nil, err
+		}
+		req.DueDate = &due
+	}
+	return req, nil
+}
+
+// parseTodoistDue reads a due date; a floating time is read in the due's
+// time zone when it has one and in UTC otherwise, and a day is midnight UTC
+func parseTodoistDue(due *todoistDue) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, due.Date); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.Parse("2006-01-02", due.Date); err == nil {
+		return t, nil
+	}
+
+	location := time.UTC
+	if due.Timezone != nil && *due.Timezone != "" {
+		if loc, err := time.LoadLocation(*due.Timezone); err == nil {
+			location = loc
+		}
+	}
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", due.Date, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date %q", due.Date)
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokenIssuedAtKeepsMilliseconds(t *testing.T) {
+	revokedAt := time.Date(2026, 1, 2, 3, 4, 5, 700_000_000, time.UTC)
+
+	tests := []struct {
+		name        string
+		issuedAt    time.Time
+		wantRevoked bool
+	}{
+		{name: "earlier second", issuedAt: revokedAt.Add(-time.Second), wantRevoked: true},
+		{name: "same second, before", issuedAt: revokedAt.Add(-300 * time.Millisecond), wantRevoked: true},
+		{name: "same second, after", issuedAt: revokedAt.Add(200 * time.Millisecond)},
+		{name: "same millisecond", issuedAt: revokedAt.Add(400 * time.Microsecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Round trip the claims through JSON as a signed token would
+			encoded, err := json.Marshal(jwt.MapClaims{"iat": issuedAtClaim(tt.issuedAt)})
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var claims jwt.MapClaims
+			if err := json.Unmarshal(encoded, &claims); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			issuedAt, ok := tokenIssuedAt(claims)
+			if !ok {
+				t.Fatalf("tokenIssuedAt(%s) found no iat", encoded)
+			}
+			if want := tt.issuedAt.Truncate(time.Millisecond); !issuedAt.Equal(want) {
+				t.Errorf("tokenIssuedAt = %v, want %v", issuedAt, want)
+			}
+			if revoked := issuedAt.Before(revokedAt); revoked != tt.wantRevoked {
+				t.Errorf("revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+		})
+	}
+
+	if _, ok := tokenIssuedAt(jwt.MapClaims{}); ok {
+		t.Error("tokenIssuedAt found an iat in claims without one")
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	usageContextKey contextKey = "usage"
+	// usageFlushInterval is how long counts stay in memory before they are
+	// added to the buckets, and so how far reports lag behind
+	usageFlushInterval = time.Minute
+	usageTopEndpoints  = 10
+	maxUsageReportDays = 93
+)
+
+// usageRequest is filled in by authentication as a request passes through it
+type usageRequest struct {
+	userID   *primitive.ObjectID
+	apiKeyID *primitive.ObjectID
+}
+
+type usageKey struct {
+	userID   primitive.ObjectID
+	apiKeyID primitive.ObjectID
+	route    string
+	hour     time.Time
+}
+
+// UsageTracker counts authenticated API requests per user, API key, route and
+// hour for quota planning. Counts are summed in memory and added to the
+// stored buckets every minute.
+type UsageTracker struct {
+	usageRepo  *repository.UsageRepository
+	apiKeyRepo *repository.APIKeyRepository
+	clock      clock.Clock
+
+	mu      sync.Mutex
+	pending map[usageKey]*models.UsageCounts
+}
+
+func NewUsageTracker(usageRepo *repository.UsageRepository, apiKeyRepo *repository.APIKeyRepository, clk clock.Clock) *UsageTracker {
+	return &UsageTracker{
+		usageRepo:  usageRepo,
+		apiKeyRepo: apiKeyRepo,
+		clock:      clk,
+		pending:    make(map[usageKey]*models.UsageCounts),
+	}
+}
+
+func (t *UsageTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usage := &usageRequest{}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), usageContextKey, usage)))
+
+		// Anonymous requests, such as failed logins, belong to no account
+		if usage.userID == nil {
+			return
+		}
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		t.record(*usage, r.Method+" "+route, recorder.status)
+	})
+}
+
+// withAPIKey marks the request as made with the API key
+func withAPIKey(ctx context.Context, key *models.APIKey) {
+	if usage, ok := ctx.Value(usageContextKey).(*usageRequest); ok {
+		usage.apiKeyID = &key.ID
+	}
+}
+
+func (t *UsageTracker) record(usage usageRequest, route string, status int) {
+	key := usageKey{
+		userID: *usage.userID,
+		route:  route,
+		hour:   t.clock.Now().UTC().Truncate(time.Hour),
+	}
+	if usage.apiKeyID != nil {
+		key.apiKeyID = *usage.apiKeyID
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts, ok := t.pending[key]
+	if !ok {
+		counts = &models.UsageCounts{}
+		t.pending[key] = counts
+	}
+	counts.Requests++
+	switch {
+	case status >= http.StatusInternalServerError:
+		counts.ServerErrors++
+	case status >= http.StatusBadRequest:
+		counts.ClientErrors++
+	}
+}
+
+// Start adds the counts to the stored buckets every minute, and once more on shutdown
+func (t *UsageTracker) Start(ctx context.Context) {
+	log.Printf("Starting usage tracker - flushing every %v", usageFlushInterval)
+
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.flush(context.WithoutCancel(ctx))
+			log.Println("Usage tracker stopped")
+			return
+		case <-ticker.C:
+			t.flush(ctx)
+		}
+	}
+}
+
+// flush stores the pending counts; when that fails they are kept for the next flush
+func (t *UsageTracker) flush(ctx context.Context) {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[usageKey]*models.UsageCounts)
+	t.mu.Unlock()
+
+	increments := make([]repository.UsageIncrement, 0, len(pending))
+	for key, counts := range pending {
+		inc := repository.UsageIncrement{UserID: key.userID, Route: key.route, Hour: key.hour, Counts: *counts}
+		if !key.apiKeyID.IsZero() {
+			apiKeyID := key.apiKeyID
+			inc.APIKeyID = &apiKeyID
+		}
+		increments = append(increments, inc)
+	}
+
+	if err := t.usageRepo.Increment(ctx, increments); err != nil {
+		log.Printf("Failed to flush usage, keeping %d buckets for the next flush: %v", len(pending), err)
+		t.mu.Lock()
+		for key, counts := range pending {
+			if current, ok := t.pending[key]; ok {
+				current.Requests += counts.Requests
+				current.ClientErrors += counts.ClientErrors
+				current.ServerErrors += counts.ServerErrors
+			} else {
+				t.pending[key] = counts
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Report sums the user's usage over whole UTC days, from and to included. To
+// defaults to today and from to six days before to.
+func (t *UsageTracker) Report(ctx context.Context, userID primitive.ObjectID, fromDay, toDay *time.Time) (*models.UsageReport, error) {
+	to := startOfUTCDay(t.clock.Now())
+	if toDay != nil {
+		to = *toDay
+	}
+	from := to.AddDate(0, 0, -6)
+	if fromDay != nil {
+		from = *fromDay
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= maxUsageReportDays*24*time.Hour {
+		return nil, fmt.Errorf("usage reports cover at most %d days", maxUsageReportDays)
+	}
+
+	report, err := t.usageRepo.Summarize(ctx, userID, from, to.AddDate(0, 0, 1), usageTopEndpoints)
+	if err != nil {
+		return nil, err
+	}
+	report.StartDate = from.Format("2006-01-02")
+	report.EndDate = to.Format("2006-01-02")
+	if report.Requests > 0 {
+		report.ErrorRate = float64(report.ClientErrors+report.ServerErrors) / float64(report.Requests)
+	}
+
+	// Name the keys, including revoked ones, which keep their usage
+	keys, err := t.apiKeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[primitive.ObjectID]string, len(keys))
+	for _, key := range keys {
+		names[key.ID] = key.Name
+	}
+	for _, usage := range report.APIKeys {
+		if usage.APIKeyID != nil {
+			usage.Name = names[*usage.APIKeyID]
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"task-management-api/models"
+	"time"
+)
+
+// webhookSchema is one published version of the webhook payloads. A released
+// version never changes: a change to what receivers get, even a new field,
+// is a new version, so webhooks pinned to an older one keep working.
+type webhookSchema struct {
+	version int
+	// data builds the payload's data object for a task
+	data func(task *models.Task) interface{}
+	// taskSchema is the JSON Schema of the task in data
+	taskSchema map[string]interface{}
+}
+
+// webhookSchemas lists every version, oldest first; the last one is the latest
+var webhookSchemas = []webhookSchema{
+	{
+		version: 1,
+		data: func(task *models.Task) interface{} {
+			return webhookDataV1{Task: newWebhookTaskV1(task)}
+		},
+		taskSchema: webhookTaskSchemaV1,
+	},
+}
+
+func latestWebhookSchemaVersion() int {
+	return webhookSchemas[len(webhookSchemas)-1].version
+}
+
+func findWebhookSchema(version int) (*webhookSchema, bool) {
+	for i := range webhookSchemas {
+		if webhookSchemas[i].version == version {
+			return &webhookSchemas[i], true
+		}
+	}
+	return nil, false
+}
+
+// webhookSchemaVersion validates a requested version; zero means the latest
+func webhookSchemaVersion(requested int) (int, error) {
+	if requested == 0 {
+		return latestWebhookSchemaVersion(), nil
+	}
+	if _, ok := findWebhookSchema(requested); !ok {
+		versions := make([]string, len(webhookSchemas))
+		for i, schema := range webhookSchemas {
+			versions[i] = strconv.Itoa(schema.version)
+		}
+		return 0, fmt.Errorf("invalid schema_version, must be one of: %s", strings.Join(versions, ", "))
+	}
+	return requested, nil
+}
+
+// newWebhookPayload builds the payload of an event in the given schema version
+func newWebhookPayload(version int, id, event string, occurredAt time.Time, task *models.Task) (models.WebhookPayload, error) {
+	schema, ok := findWebhookSchema(version)
+	if !ok {
+		return models.WebhookPayload{}, fmt.Errorf("unknown webhook schema version %d", version)
+	}
+
+	return models.WebhookPayload{
+		ID:            id,
+		SchemaVersion: schema.version,
+		Event:         event,
+		OccurredAt:    occurredAt,
+		Data:          schema.data(task),
+	}, nil
+}
+
+// WebhookSchemas returns the JSON Schema of every event payload in every version
+func WebhookSchemas() *models.WebhookSchemasResponse {
+	response := &models.WebhookSchemasResponse{LatestVersion: latestWebhookSchemaVersion()}
+	for _, schema := range webhookSchemas {
+		version := &models.WebhookSchemaVersion{
+			Version: schema.version,
+			Events:  make(map[string]map[string]interface{}, len(models.TaskEvents)),
+		}
+		for _, event := range models.TaskEvents {
+			version.Events[event] = webhookPayloadSchema(schema, event)
+		}
+		response.Versions = append(response.Versions, version)
+	}
+	return response
+}
+
+// webhookPayloadSchema is the JSON Schema (draft 2020-12) of one event's payload
+func webhookPayloadSchema(schema webhookSchema, event string) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"$id":      fmt.Sprintf("urn:task-management-api:webhooks:v%d:%s", schema.version, event),
+		"title":    event,
+		"type":     "object",
+		"required": []string{"id", "schema_version", "event", "occurred_at", "data"},
+		"properties": map[string]interface{}{
+			"id":             map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"},
+			"schema_version": map[string]interface{}{"const": schema.version},
+			"event":          map[string]interface{}{"const": event},
+			"occurred_at":    map[string]interface{}{"type": "string", "format": "date-time"},
+			"data": map[string]interface{}{
+				"type":       "object",
+				"required":   []string{"task"},
+				"properties": map[string]interface{}{"task": schema.taskSchema},
+			},
+		},
+	}
+}
+
+// Version 1
+
+type webhookDataV1 struct {
+	Task webhookTaskV1 `json:"task"`
+}
+
+// webhookTaskV1 freezes the task as version 1 describes it, so later changes
+// to models.Task do not reach receivers pinned to it
+type webhookTaskV1 struct {
+	ID               string   `json:"id"`
+	UserID           string   `json:"user_id"`
+	ParentID         *string  `json:"parent_id"`
+	BlockedBy        []string `json:"blocked_by,omitempty"`
+	Title            string   `json:"title"`
+	Description      string   `json:"description,omitempty"`
+	Status           string   `json:"status"`
+	DueDate          *string  `json:"due_date"`
+	Recurrence       string   `json:"recurrence,omitempty"`
+	NextOccurrenceID *string  `json:"next_occurrence_id,omitempty"`
+	Archived         bool     `json:"archived"`
+	PurgeAt          *string  `json:"purge_at"`
+	Private          bool     `json:"private"`
+	CreatedAt        string   `json:"created_at"`
+	UpdatedAt        string   `json:"updated_at"`
+}
+
+func newWebhookTaskV1(task *models.Task) webhookTaskV1 {
+	v1 := webhookTaskV1{
+		ID:          task.ID.Hex(),
+		UserID:      task.UserID.Hex(),
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      string(task.Status),
+		Recurrence:  task.Recurrence,
+		Archived:    task.Archived,
+		Private:     task.Private,
+		CreatedAt:   models.FormatTime(task.CreatedAt),
+		UpdatedAt:   models.FormatTime(task.UpdatedAt),
+	}
+	if task.ParentID != nil {
+		parentID := task.ParentID.Hex()
+		v1.ParentID = &parentID
+	}
+	for _, id := range task.BlockedBy {
+		v1.BlockedBy = append(v1.BlockedBy, id.Hex())
+	}
+	if task.DueDate != nil {
+		dueDate := models.FormatTime(*task.DueDate)
+		v1.DueDate = &dueDate
+	}
+	if task.NextOccurrenceID != nil {
+		nextID := task.NextOccurrenceID.Hex()
+		v1.NextOccurrenceID = &nextID
+	}
+	if task.PurgeAt != nil {
+		purgeAt := models.FormatTime(*task.PurgeAt)
+		v1.PurgeAt = &purgeAt
+	}
+	return v1
+}
+
+var (
+	webhookObjectIDSchema         = map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	webhookNullableObjectIDSchema = map[string]interface{}{"type": []string{"string", "null"}, "pattern": "^[0-9a-f]{24}$"}
+	webhookNullableTimeSchema     = map[string]interface{}{"type": []string{"string", "null"}, "format": "date-time"}
+)
+
+var webhookTaskSchemaV1 = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"id", "user_id", "parent_id", "title", "status", "due_date", "archived", "purge_at", "private", "created_at", "updated_at"},
+	"properties": map[string]interface{}{
+		"id":                 webhookObjectIDSchema,
+		"user_id":            webhookObjectIDSchema,
+		"parent_id":          webhookNullableObjectIDSchema,
+		"blocked_by":         map[string]interface{}{"type": "array", "items": webhookObjectIDSchema},
+		"title":              map[string]interface{}{"type": "string", "description": "Empty for private tasks"},
+		"description":        map[string]interface{}{"type": "string"},
+		"status":             map[string]interface{}{"enum": []string{"pending", "in_progress", "completed"}},
+		"due_date":           webhookNullableTimeSchema,
+		"recurrence":         map[string]interface{}{"type": "string"},
+		"next_occurrence_id": webhookObjectIDSchema,
+		"archived":           map[string]interface{}{"type": "boolean"},
+		"purge_at":           webhookNullableTimeSchema,
+		"private":            map[string]interface{}{"type": "boolean"},
+		"created_at":         map[string]interface{}{"type": "string", "format": "date-time"},
+		"updated_at":         map[string]interface{}{"type": "string", "format": "date-time"},
+	},
+}
@@ -0,0 +1,462 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"task-management-api/clock"
+	"task-management-api/events"
+	"task-management-api/models"
+	"task-management-api/outbound"
+	"task-management-api/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxWebhooksPerUser     = 10
+	maxWebhookURLChars     = 2048
+	minWebhookSecretChars  = 16
+	webhookSecretPrefix    = "whsec_"
+	webhookMaxAttempts     = 8
+	webhookBaseBackoff     = 30 * time.Second
+	webhookMaxBackoff      = time.Hour
+	webhookPollInterval    = 5 * time.Second
+	webhookSenders         = 4
+	maxWebhookErrorChars   = 500
+	maxWebhookResponseRead = 64 << 10
+	// maxWebhookResponseBody is how much of each response the delivery log keeps
+	maxWebhookResponseBody = 2 << 10
+	// webhookDisableAfter is how long an endpoint may fail every attempt
+	// before its webhook is disabled
+	webhookDisableAfter = 72 * time.Hour
+	// A claimed delivery is retried after this long if its attempt never finishes;
+	// it must outlast the outbound timeout
+	webhookLease = 2 * time.Minute
+)
+
+// WebhookService registers webhooks and delivers task lifecycle events to
+// them. Events are stored as deliveries first and sent by a background worker,
+// so a slow or failing endpoint never delays a request. Delivery is at least
+// once: each delivery is retried with exponential backoff until the endpoint
+// answers 2xx or webhookMaxAttempts is reached. A webhook whose endpoint has
+// failed every attempt for webhookDisableAfter is disabled.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	client      *http.Client
+	clock       clock.Clock
+	wake        chan struct{}
+	quota       QuotaCheck
+}
+
+// NewWebhookService sends deliveries through client, which refuses endpoints
+// on private networks. Redirects are not followed; an endpoint that moved
+// must be registered again.
+func NewWebhookService(webhookRepo *repository.WebhookRepository, client *outbound.Client, clk clock.Clock) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      client.HTTPClientNoRedirects(0),
+		clock:       clk,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// EnforceQuota makes CreateWebhook ask check before adding a webhook. Set during startup.
+func (s *WebhookService) EnforceQuota(check QuotaCheck) {
+	s.quota = check
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, user *models.User, req *models.CreateWebhookRequest) (*models.CreateWebhookResponse, error) {
+	// Validate input
+	if req.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	endpoint, err := url.Parse(req.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" || len(req.URL) > maxWebhookURLChars {
+		return nil, fmt.Errorf("invalid url, must be an absolute http or https URL")
+	}
+
+	events, err := webhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaVersion, err := webhookSchemaVersion(req.SchemaVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		raw, _, err := newSecureToken()
+		if err != nil {
+			return nil, err
+		}
+		secret = webhookSecretPrefix + raw
+	} else if len(secret) < minWebhookSecretChars {
+		return nil, fmt.Errorf("secret must be at least %d characters", minWebhookSecretChars)
+	}
+
+	count, err := s.webhookRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, fmt.Errorf("at most %d webhooks are allowed", maxWebhooksPerUser)
+	}
+	if s.quota != nil {
+		if err := s.quota(ctx, user.ID, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	webhook := models.NewWebhook(user.ID, req.URL, events, secret, s.clock.Now())
+	webhook.SchemaVersion = schemaVersion
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return &models.CreateWebhookResponse{Webhook: webhook, Secret: secret}, nil
+}
+
+// webhookEvents validates requested events, defaulting to all of them
+func webhookEvents(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return models.TaskEvents, nil
+	}
+
+	var events []string
+	seen := make(map[string]bool, len(requested))
+	for _, event := range requested {
+		valid := false
+		for _, known := range models.TaskEvents {
+			valid = valid || event == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid event, must be one of: %s", strings.Join(models.TaskEvents, ", "))
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context, user *models.User) ([]*models.Webhook, error) {
+	return s.webhookRepo.FindByUserID(ctx, user.ID)
+}
+
+// UpdateWebhook re-pins the webhook to another schema version, or turns it
+// off or back on. Deliveries already queued keep the payload they were
+// created with; those pending when a webhook is turned off fail.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, user *models.User, webhookID primitive.ObjectID, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	if req.SchemaVersion == nil && req.Enabled == nil {
+		return nil, fmt.Errorf("schema_version or enabled is required")
+	}
+	var schemaVersion int
+	if req.SchemaVersion != nil {
+		var err error
+		if schemaVersion, err = webhookSchemaVersion(*req.SchemaVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	webhook, err := s.webhookRepo.FindByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != user.ID {
+		return nil, fmt.Errorf("webhook not found")
+	}
+
+	if req.SchemaVersion != nil {
+		if err := s.webhookRepo.SetSchemaVersion(ctx, webhookID, user.ID, schemaVersion); err != nil {
+			return nil, err
+		}
+	}
+	if req.Enabled != nil && *req.Enabled != (webhook.DisabledAt == nil) {
+		if err := s.webhookRepo.SetEnabled(ctx, webhookID, user.ID, *req.Enabled, s.clock.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return s.webhookRepo.FindByID(ctx, webhookID)
+}
+
+// DeleteWebhook removes the webhook; deliveries still pending are dropped with its log
+func (s *WebhookService) DeleteWebhook(ctx context.Context, user *models.User, webhookID primitive.ObjectID) error {
+	return s.webhookRepo.Delete(ctx, webhookID, user.ID)
+}
+
+// ListDeliveries returns the delivery log of one of the user's webhooks, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, user *models.User, webhookID primitive.ObjectID, filter repository.WebhookDeliveryFilter) (*models.WebhookDeliveryListResponse, error) {
+	webhook, err := s.webhookRepo.FindByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != user.ID {
+		return nil, fmt.Errorf("webhook not found")
+	}
+
+	deliveries, totalCount, err := s.webhookRepo.FindDeliveries(ctx, webhookID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewWebhookDeliveryListResponse(deliveries, filter.Page, filter.Limit, totalCount), nil
+}
+
+// Subscribe queues deliveries for the task events webhooks can subscribe to.
+// Call during startup.
+func (s *WebhookService) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.TaskCreated) { s.taskEvent(ctx, e.Name(), e.Task) })
+	events.Subscribe(bus, func(ctx context.Context, e events.TaskCompleted) { s.taskEvent(ctx, e.Name(), e.Task) })
+	events.Subscribe(bus, func(ctx context.Context, e events.TaskDeleted) { s.taskEvent(ctx, e.Name(), e.Task) })
+	events.Subscribe(bus, func(ctx context.Context, e events.TaskDueSoon) { s.taskEvent(ctx, e.Name(), e.Task) })
+}
+
+// taskEvent queues a delivery to every webhook of the task's owner that
+// subscribes to event. Failures are logged rather than returned.
+func (s *WebhookService) taskEvent(ctx context.Context, event string, task *models.Task) {
+	if task.UserID.IsZero() {
+		return
+	}
+
+	webhooks, err := s.webhookRepo.FindSubscribed(ctx, task.UserID, event)
+	if err != nil {
+		logf(ctx, "Failed to find webhooks for %s of task %s: %v", event, task.ID.Hex(), err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	now := s.clock.Now()
+	deliveries := make([]*models.WebhookDelivery, len(webhooks))
+	for i, webhook := range webhooks {
+		delivery := models.NewWebhookDelivery(webhook, event, "", now)
+		body, err := newWebhookPayload(webhook.PinnedSchemaVersion(), delivery.ID.Hex(), event, now, task)
+		if err != nil {
+			logf(ctx, "Failed to build %s of task %s for webhook %s: %v", event, task.ID.Hex(), webhook.ID.Hex(), err)
+			return
+		}
+		payload, err := json.Marshal(body)
+		if err != nil {
+			logf(ctx, "Failed to encode %s of task %s: %v", event, task.ID.Hex(), err)
+			return
+		}
+		delivery.Payload = string(payload)
+		deliveries[i] = delivery
+	}
+
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		logf(ctx, "Failed to queue %s of task %s for %d webhooks: %v", event, task.ID.Hex(), len(deliveries), err)
+		return
+	}
+
+	// Send right away instead of at the next poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the delivery worker until ctx is cancelled. It is safe to run on
+// every replica; each delivery is claimed by one worker at a time.
+func (s *WebhookService) Start(ctx context.Context) {
+	log.Printf("Starting webhook delivery worker - %d senders, up to %d attempts", webhookSenders, webhookMaxAttempts)
+
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook delivery worker stopped")
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		s.deliverDue(ctx)
+	}
+}
+
+// deliverDue sends every delivery that is due, webhookSenders at a time
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < webhookSenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				now := s.clock.Now()
+				delivery, err := s.webhookRepo.ClaimDueDelivery(ctx, now, now.Add(webhookLease))
+				if err != nil {
+					log.Printf("Failed to claim webhook delivery: %v", err)
+					return
+				}
+				if delivery == nil {
+					return
+				}
+				s.attempt(ctx, delivery)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *WebhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := s.webhookRepo.FindByID(ctx, delivery.WebhookID)
+	if err != nil {
+		// A deleted webhook takes its deliveries with it; anything else is retried after the lease
+		if err.Error() != "webhook not found" {
+			log.Printf("Failed to load webhook for delivery %s: %v", delivery.ID.Hex(), err)
+		}
+		return
+	}
+
+	if webhook.DisabledAt != nil {
+		if _, err := s.webhookRepo.FailPendingDeliveries(ctx, webhook.ID, "webhook is disabled"); err != nil {
+			log.Printf("Failed to mark deliveries of disabled webhook %s failed: %v", webhook.ID.Hex(), err)
+		}
+		return
+	}
+
+	responseStatus, responseBody, sendErr := s.send(ctx, webhook, delivery)
+	if ctx.Err() != nil {
+		// Shutting down; the delivery is retried after the lease
+		return
+	}
+
+	now := s.clock.Now()
+	attempts := delivery.Attempts + 1
+	result := repository.WebhookAttempt{ResponseStatus: responseStatus, ResponseBody: responseBody, At: now}
+	switch {
+	case sendErr == nil:
+		result.Status = models.WebhookDeliveryDelivered
+	case attempts >= webhookMaxAttempts:
+		result.Status = models.WebhookDeliveryFailed
+		result.Error = truncateWebhookError(sendErr)
+		log.Printf("Giving up on webhook delivery %s to %s after %d attempts: %v", delivery.ID.Hex(), webhook.ID.Hex(), attempts, sendErr)
+	default:
+		result.Status = models.WebhookDeliveryPending
+		result.Error = truncateWebhookError(sendErr)
+		result.NextAttemptAt = now.Add(webhookBackoff(attempts))
+	}
+
+	if err := s.webhookRepo.RecordAttempt(ctx, delivery.ID, result); err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID.Hex(), err)
+	}
+	s.recordHealth(ctx, webhook, sendErr == nil, now)
+}
+
+// recordHealth tracks the webhook's failure streak and disables the webhook
+// once every attempt has failed for webhookDisableAfter. Its pending
+// deliveries fail with it.
+func (s *WebhookService) recordHealth(ctx context.Context, webhook *models.Webhook, delivered bool, now time.Time) {
+	if delivered {
+		if webhook.ConsecutiveFailures > 0 {
+			if err := s.webhookRepo.RecordSuccess(ctx, webhook.ID); err != nil {
+				log.Printf("Failed to reset failures of webhook %s: %v", webhook.ID.Hex(), err)
+			}
+		}
+		return
+	}
+
+	updated, err := s.webhookRepo.RecordFailure(ctx, webhook.ID, now)
+	if err != nil {
+		if err.Error() != "webhook not found" {
+			log.Printf("Failed to record failure of webhook %s: %v", webhook.ID.Hex(), err)
+		}
+		return
+	}
+	if updated.FailingSince == nil || now.Sub(*updated.FailingSince) < webhookDisableAfter {
+		return
+	}
+
+	disabled, err := s.webhookRepo.Disable(ctx, webhook.ID, now)
+	if err != nil {
+		log.Printf("Failed to disable webhook %s: %v", webhook.ID.Hex(), err)
+		return
+	}
+	if !disabled {
+		return
+	}
+	failed, err := s.webhookRepo.FailPendingDeliveries(ctx, webhook.ID, "webhook is disabled")
+	if err != nil {
+		log.Printf("Failed to mark deliveries of disabled webhook %s failed: %v", webhook.ID.Hex(), err)
+	}
+	log.Printf("Disabled webhook %s after %d failed attempts since %s; %d pending deliveries failed", webhook.ID.Hex(), updated.ConsecutiveFailures, updated.FailingSince.Format(time.RFC3339), failed)
+}
+
+// send POSTs the payload, signed as described in signWebhook, and returns the
+// response status and the start of the body. Anything but 2xx is an error.
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(s.clock.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", delivery.ID.Hex())
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body := make([]byte, maxWebhookResponseBody)
+	n, _ := io.ReadFull(resp.Body, body)
+	// Drain a bounded amount more so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseRead))
+	responseBody := strings.ToValidUTF8(string(body[:n]), "\uFFFD")
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, responseBody, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, responseBody, nil
+}
+
+// signWebhook is the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// webhook's secret. Covering the timestamp lets receivers reject replays.
+func signWebhook(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given number of failed attempts: 30s,
+// 1m, 2m and so on, capped at webhookMaxBackoff
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+func truncateWebhookError(err error) string {
+	message := err.Error()
+	if len(message) > maxWebhookErrorChars {
+		message = message[:maxWebhookErrorChars]
+	}
+	return message
+}
@@ -2,106 +2,657 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"task-management-api/clock"
+	"task-management-api/events"
 	"task-management-api/models"
+	"task-management-api/queue"
 	"task-management-api/repository"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// WorkerConfig sets how many tasks the worker auto-completes at once, how
+// many it queues per run of the auto_complete job and how often it attempts
+// each before giving up
+type WorkerConfig struct {
+	Concurrency int
+	BatchSize   int
+	MaxAttempts int
+}
+
+// AutoCompleteQueue names the queue of tasks waiting to be auto-completed
+const AutoCompleteQueue = "auto_complete"
+
+const (
+	// workerLease is how long a job stays with one goroutine; a job whose
+	// instance stopped mid-way is picked up again after it
+	workerLease = 5 * time.Minute
+	// workerIdleWait is how long goroutines wait for new jobs before asking
+	// the queue again, for retries and jobs queued by other instances
+	workerIdleWait = 10 * time.Second
+	// workerScanInterval is how often the auto_complete job still scans for
+	// due tasks while the change stream is followed, to catch what it missed
+	workerScanInterval = time.Hour
+	// workerWatchRetry is how long the worker waits before reopening a
+	// failed change stream
+	workerWatchRetry = 5 * time.Second
+	// workerSeedLimit bounds the tasks not yet due read when watching starts
+	workerSeedLimit = 10000
+)
+
 type TaskWorker struct {
 	taskRepo            *repository.TaskRepository
+	userRepo            *repository.UserRepository
+	taskService         *TaskService
 	autoCompleteMinutes int
-	taskChannel         chan primitive.ObjectID
+	config              WorkerConfig
+	queue               queue.Queue
+	deadLetters         *repository.DeadLetterRepository
+	stateRepo           *repository.WorkerStateRepository
+	clock               clock.Clock
+	// wake tells idle goroutines that jobs were queued
+	wake chan struct{}
+	// watching is set while the task change stream is followed
+	watching atomic.Bool
+	// lastScan is when QueueDue last scanned the tasks, in Unix nanoseconds;
+	// Scan sets it while the scheduler and Watch read it
+	lastScan atomic.Int64
+
+	// paused caches the shared pause flag, read again after workerIdleWait
+	pauseMu        sync.Mutex
+	paused         bool
+	pauseCheckedAt time.Time
+
+	// autoComplete caches the users' settings, read again after workerIdleWait
+	policyMu       sync.Mutex
+	autoComplete   *autoCompletePolicy
+	policyLoadedAt time.Time
+}
+
+// autoCompletePolicy is every user's auto-complete threshold: the default,
+// and the users who disabled auto-completion or chose their own
+type autoCompletePolicy struct {
+	threshold time.Duration
+	overrides map[primitive.ObjectID]models.UserSettings
+}
+
+// thresholdFor returns how old the user's tasks must be to be auto-completed,
+// or false when the user disabled auto-completion
+func (p *autoCompletePolicy) thresholdFor(userID primitive.ObjectID) (time.Duration, bool) {
+	settings := p.overrides[userID]
+	switch {
+	case settings.AutoCompleteDisabled:
+		return 0, false
+	case settings.AutoCompleteMinutes > 0:
+		return time.Duration(settings.AutoCompleteMinutes) * time.Minute, true
+	default:
+		return p.threshold, true
+	}
+}
+
+// longest is the longest threshold of any user
+func (p *autoCompletePolicy) longest() time.Duration {
+	longest := p.threshold
+	for userID := range p.overrides {
+		if threshold, ok := p.thresholdFor(userID); ok {
+			longest = max(longest, threshold)
+		}
+	}
+	return longest
 }
 
-func NewTaskWorker(taskRepo *repository.TaskRepository, autoCompleteMinutes int) *TaskWorker {
+// exceptions gives the creation cutoff at now of every user who changed the
+// default, nil for those who disabled auto-completion
+func (p *autoCompletePolicy) exceptions(now time.Time) map[primitive.ObjectID]*time.Time {
+	exceptions := make(map[primitive.ObjectID]*time.Time, len(p.overrides))
+	for userID := range p.overrides {
+		if threshold, ok := p.thresholdFor(userID); ok {
+			cutoff := now.Add(-threshold)
+			exceptions[userID] = &cutoff
+		} else {
+			exceptions[userID] = nil
+		}
+	}
+	return exceptions
+}
+
+func NewTaskWorker(taskRepo *repository.TaskRepository, userRepo *repository.UserRepository, taskService *TaskService, autoCompleteMinutes int, config WorkerConfig, jobs queue.Queue, deadLetters *repository.DeadLetterRepository, stateRepo *repository.WorkerStateRepository, clk clock.Clock) *TaskWorker {
 	return &TaskWorker{
 		taskRepo:            taskRepo,
+		userRepo:            userRepo,
+		taskService:         taskService,
 		autoCompleteMinutes: autoCompleteMinutes,
-		taskChannel:         make(chan primitive.ObjectID, 100),
+		config:              config,
+		queue:               jobs,
+		deadLetters:         deadLetters,
+		stateRepo:           stateRepo,
+		clock:               clk,
+		wake:                make(chan struct{}, config.Concurrency),
 	}
 }
 
+// Start runs the goroutines that auto-complete queued tasks until ctx is
+// cancelled. Tasks are queued by QueueDue, the scheduled auto_complete job.
 func (w *TaskWorker) Start(ctx context.Context) {
-	log.Printf("Starting background worker - auto-complete after %d minutes", w.autoCompleteMinutes)
+	log.Printf("Starting background worker - auto-complete after %d minutes with %d goroutines, batches of %d and up to %d attempts",
+		w.autoCompleteMinutes, w.config.Concurrency, w.config.BatchSize, w.config.MaxAttempts)
+
+	for i := 0; i < w.config.Concurrency; i++ {
+		go w.processQueuedTasks(ctx)
+	}
+
+	<-ctx.Done()
+	log.Println("Background worker stopped")
+}
+
+// QueueDue queues a batch of tasks old enough to be auto-completed. While
+// Watch follows the task changes, it only scans once per workerScanInterval.
+func (w *TaskWorker) QueueDue(ctx context.Context) error {
+	if w.watching.Load() && w.clock.Now().Sub(time.Unix(0, w.lastScan.Load())) < workerScanInterval {
+		return nil
+	}
+	_, err := w.Scan(ctx)
+	return err
+}
+
+// Scan queues a batch of tasks old enough to be auto-completed now and
+// returns how many were queued; tasks already queued are not counted
+func (w *TaskWorker) Scan(ctx context.Context) (int, error) {
+	policy, err := w.policy(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Find tasks that are older than their owners' auto-complete thresholds
+	now := w.clock.Now()
+	tasks, err := w.taskRepo.FindPendingTasks(ctx, now.Add(-policy.threshold), policy.exceptions(now), int64(w.config.BatchSize))
+	if err != nil {
+		return 0, err
+	}
+	w.lastScan.Store(now.UnixNano())
+	if err := w.stateRepo.RecordScan(ctx, AutoCompleteQueue, now); err != nil {
+		log.Printf("Failed to record worker scan: %v", err)
+	}
+
+	// Queue tasks for auto-completion; tasks already queued stay queued once
+	queued := 0
+	for _, task := range tasks {
+		if w.enqueue(ctx, task.ID, now) {
+			queued++
+		}
+	}
+	w.wakeConsumers(queued)
+	return queued, nil
+}
+
+// Paused reports whether operators paused auto-completion, on any instance
+func (w *TaskWorker) Paused(ctx context.Context) bool {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	if time.Since(w.pauseCheckedAt) >= workerIdleWait {
+		state, err := w.stateRepo.Get(ctx, AutoCompleteQueue)
+		if err != nil {
+			// Keep the last known flag until the state can be read again
+			log.Printf("Failed to read worker state: %v", err)
+		} else {
+			w.paused = state.Paused
+		}
+		w.pauseCheckedAt = time.Now()
+	}
+	return w.paused
+}
+
+// policy returns the users' auto-complete settings. Until they could be read
+// once, it fails and nothing is queued.
+func (w *TaskWorker) policy(ctx context.Context) (*autoCompletePolicy, error) {
+	w.policyMu.Lock()
+	defer w.policyMu.Unlock()
+
+	if w.autoComplete != nil && time.Since(w.policyLoadedAt) < workerIdleWait {
+		return w.autoComplete, nil
+	}
+
+	users, err := w.userRepo.FindAutoCompleteOverrides(ctx)
+	if err != nil {
+		if w.autoComplete == nil {
+			return nil, err
+		}
+		// Keep the last known settings until they can be read again
+		log.Printf("Failed to read auto-complete settings: %v", err)
+	} else {
+		overrides := make(map[primitive.ObjectID]models.UserSettings, len(users))
+		for _, user := range users {
+			overrides[user.ID] = user.Settings
+		}
+		// An unchanged policy stays the same value, so Watch knows when to reorder
+		if w.autoComplete == nil || !maps.Equal(w.autoComplete.overrides, overrides) {
+			w.autoComplete = &autoCompletePolicy{threshold: time.Duration(w.autoCompleteMinutes) * time.Minute, overrides: overrides}
+		}
+	}
+	w.policyLoadedAt = time.Now()
+	return w.autoComplete, nil
+}
 
-	// Start worker goroutines to process tasks from the channel
-	for i := 0; i < 3; i++ {
-		go w.processTasksFromChannel(ctx)
+// settingsChanged makes this instance read the users' settings again on
+// next use instead of after workerIdleWait
+func (w *TaskWorker) settingsChanged() {
+	w.policyMu.Lock()
+	defer w.policyMu.Unlock()
+
+	w.policyLoadedAt = time.Time{}
+}
+
+// setPaused applies a pause or resume on this instance without waiting for
+// the next read of the shared flag
+func (w *TaskWorker) setPaused(paused bool) {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	w.paused = paused
+	w.pauseCheckedAt = time.Now()
+	if !paused {
+		w.wakeConsumers(w.config.Concurrency)
 	}
+}
+
+// Watch follows the task changes until ctx is cancelled, queueing each open
+// task the moment it becomes due rather than at the next scan. Only the
+// leader queues; the other instances follow along to take over promptly.
+func (w *TaskWorker) Watch(ctx context.Context, leader *LeaderElector) {
+	log.Println("Starting task change stream - auto-completing tasks as they become due")
 
-	// Periodically check for tasks that need auto-completion
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	changes := make(chan *models.Task, 100)
+	go w.dispatchDue(ctx, changes, leader)
 
+	var resumeToken bson.Raw
 	for {
+		// Without a token, start the stream before reading the tasks not yet
+		// due, so no change between the two is missed
+		stream, err := w.taskRepo.WatchTasks(ctx, resumeToken, time.Now())
+		if err == nil {
+			err = w.follow(ctx, stream, resumeToken == nil, changes, &resumeToken)
+		} else {
+			// A token too old to resume from is dropped; the tasks are read again
+			resumeToken = nil
+		}
+
+		if ctx.Err() != nil {
+			log.Println("Task change stream stopped")
+			return
+		}
+		if errors.Is(err, repository.ErrReplicaSetRequired) {
+			log.Printf("WARNING: %v; the auto_complete job scans for due tasks instead", err)
+			return
+		}
+		log.Printf("Task change stream failed, reopening in %v: %v", workerWatchRetry, err)
 		select {
 		case <-ctx.Done():
-			log.Println("Background worker stopped")
-			close(w.taskChannel)
 			return
-		case <-ticker.C:
-			w.checkAndQueueTasks(ctx)
+		case <-time.After(workerWatchRetry):
 		}
 	}
 }
 
-func (w *TaskWorker) checkAndQueueTasks(ctx context.Context) {
-	// Find tasks that are older than the auto-complete threshold
-	threshold := time.Now().Add(-time.Duration(w.autoCompleteMinutes) * time.Minute)
+// follow passes the stream's changes on until it fails, first reading the
+// tasks not yet due when seed is set
+func (w *TaskWorker) follow(ctx context.Context, stream *repository.TaskChangeStream, seed bool, changes chan<- *models.Task, resumeToken *bson.Raw) error {
+	defer stream.Close(context.WithoutCancel(ctx))
 
-	tasks, err := w.taskRepo.FindPendingTasks(ctx, threshold)
-	if err != nil {
-		log.Printf("Error finding pending tasks: %v", err)
-		return
+	if seed {
+		if err := w.seed(ctx, changes); err != nil {
+			return err
+		}
 	}
 
-	// Queue tasks for auto-completion
+	w.watching.Store(true)
+	defer w.watching.Store(false)
+	for {
+		task, err := stream.Next(ctx)
+		if err != nil {
+			return err
+		}
+		*resumeToken = stream.ResumeToken()
+		select {
+		case changes <- task:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// seed sends the open tasks that may not be due yet; older ones are left to
+// the next scan of the auto_complete job
+func (w *TaskWorker) seed(ctx context.Context, changes chan<- *models.Task) error {
+	policy, err := w.policy(ctx)
+	if err != nil {
+		return err
+	}
+	tasks, err := w.taskRepo.FindOpenCreatedSince(ctx, w.clock.Now().Add(-policy.longest()), workerSeedLimit)
+	if err != nil {
+		return err
+	}
 	for _, task := range tasks {
 		select {
-		case w.taskChannel <- task.ID:
-			log.Printf("Queued task %s for auto-completion", task.ID.Hex())
-		default:
-			log.Printf("Task channel full, skipping task %s", task.ID.Hex())
+		case changes <- task:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+	return nil
 }
 
-func (w *TaskWorker) processTasksFromChannel(ctx context.Context) {
+// dispatchDue keeps the open tasks in due order and queues each when it
+// becomes due, while this instance is the leader. When users change their
+// settings, their tasks move.
+func (w *TaskWorker) dispatchDue(ctx context.Context, changes <-chan *models.Task, leader *LeaderElector) {
+	due := newDueQueue()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	// The open tasks not queued yet, to order again under new settings
+	open := make(map[primitive.ObjectID]*models.Task)
+	var policy *autoCompletePolicy
+	schedule := func(task *models.Task) {
+		if threshold, ok := policy.thresholdFor(task.UserID); ok {
+			due.set(task.ID, task.CreatedAt.Add(threshold))
+		} else {
+			due.remove(task.ID)
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case taskID, ok := <-w.taskChannel:
-			if !ok {
+		case task := <-changes:
+			if (task.Status == models.TaskStatusPending || task.Status == models.TaskStatusInProgress) && !task.Archived {
+				open[task.ID] = task
+				if policy != nil {
+					schedule(task)
+				}
+			} else {
+				delete(open, task.ID)
+				due.remove(task.ID)
+			}
+		case <-timer.C:
+			now := w.clock.Now()
+			leading := leader.IsLeader()
+			queued := 0
+			for next := due.peek(); next != nil && !next.dueAt.After(now); next = due.peek() {
+				due.pop()
+				delete(open, next.id)
+				if leading && w.enqueue(ctx, next.id, now) {
+					queued++
+				}
+			}
+			w.wakeConsumers(queued)
+		}
+
+		if current, err := w.policy(ctx); err != nil {
+			log.Printf("Failed to read auto-complete settings: %v", err)
+		} else if current != policy {
+			policy = current
+			for _, task := range open {
+				schedule(task)
+			}
+		}
+
+		// Wake up when the next task is due, and at least once a minute in
+		// case the clock was moved
+		wait := time.Minute
+		if next := due.peek(); next != nil {
+			wait = min(max(next.dueAt.Sub(w.clock.Now()), 0), wait)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// enqueue queues the task for auto-completion, reporting whether it was added
+func (w *TaskWorker) enqueue(ctx context.Context, taskID primitive.ObjectID, now time.Time) bool {
+	added, err := w.queue.Enqueue(ctx, taskID.Hex(), now)
+	if err != nil {
+		log.Printf("Failed to queue task %s for auto-completion: %v", taskID.Hex(), err)
+		return false
+	}
+	if added {
+		log.Printf("Queued task %s for auto-completion", taskID.Hex())
+	}
+	return added
+}
+
+// wakeConsumers wakes up to n idle goroutines for newly queued jobs
+func (w *TaskWorker) wakeConsumers(n int) {
+	for i := 0; i < min(n, w.config.Concurrency); i++ {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// processQueuedTasks runs jobs until ctx is cancelled; while the worker is
+// paused, jobs stay queued
+func (w *TaskWorker) processQueuedTasks(ctx context.Context) {
+	for {
+		var job *queue.Job
+		if !w.Paused(ctx) {
+			var err error
+			if job, err = w.queue.Dequeue(ctx, w.clock.Now(), workerLease); err != nil {
+				log.Printf("Failed to take a job from the queue: %v", err)
+			}
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
 				return
+			case <-w.wake:
+			case <-time.After(workerIdleWait):
 			}
-			w.autoCompleteTask(ctx, taskID)
+			continue
 		}
+
+		w.runJob(ctx, job)
 	}
 }
 
-func (w *TaskWorker) autoCompleteTask(ctx context.Context, taskID primitive.ObjectID) {
+// runJob auto-completes the job's task and removes the job, or retries it
+// later when the attempt failed, waiting a minute longer after each attempt.
+// A job failing its last attempt moves to the dead letters.
+func (w *TaskWorker) runJob(ctx context.Context, job *queue.Job) {
+	// Finish the job even if shutdown starts meanwhile
+	ctx = context.WithoutCancel(ctx)
+
+	taskID, err := primitive.ObjectIDFromHex(job.Key)
+	if err == nil {
+		err = w.autoCompleteTask(ctx, taskID)
+	} else {
+		log.Printf("Dropping job %s with invalid task ID %q", job.ID, job.Key)
+		err = nil
+	}
+
+	if err != nil && job.Attempts >= w.config.MaxAttempts {
+		if w.bury(ctx, job, err) {
+			w.count(ctx, 0, 1, 1)
+			return
+		}
+	}
+	if err != nil {
+		retryAt := w.clock.Now().Add(time.Duration(job.Attempts) * time.Minute)
+		log.Printf("Auto-completion of task %s failed on attempt %d, retrying at %s: %v", job.Key, job.Attempts, retryAt.Format(time.RFC3339), err)
+		if err := w.queue.Retry(ctx, job, retryAt, err.Error()); err != nil {
+			log.Printf("Failed to requeue job %s: %v", job.ID, err)
+		}
+		w.count(ctx, 0, 1, 0)
+		return
+	}
+	if err := w.queue.Ack(ctx, job); err != nil {
+		log.Printf("Failed to remove job %s from the queue: %v", job.ID, err)
+	}
+	w.count(ctx, 1, 0, 0)
+}
+
+// count adds to the shared counters; a failed write only loses the counts
+func (w *TaskWorker) count(ctx context.Context, processed, failed, deadLettered int64) {
+	if err := w.stateRepo.Count(ctx, AutoCompleteQueue, processed, failed, deadLettered); err != nil {
+		log.Printf("Failed to count worker jobs: %v", err)
+	}
+}
+
+// bury moves a job out of the queue into the dead letters. It reports false
+// when the dead letter could not be stored, leaving the job to be retried.
+func (w *TaskWorker) bury(ctx context.Context, job *queue.Job, cause error) bool {
+	deadLetter := &models.DeadLetter{
+		Queue:      AutoCompleteQueue,
+		Key:        job.Key,
+		Attempts:   job.Attempts,
+		Error:      cause.Error(),
+		EnqueuedAt: job.EnqueuedAt,
+		FailedAt:   w.clock.Now(),
+	}
+	if err := w.deadLetters.Create(ctx, deadLetter); err != nil {
+		log.Printf("Failed to dead-letter job %s: %v", job.ID, err)
+		return false
+	}
+	log.Printf("Auto-completion of task %s failed on all %d attempts, moved to dead letter %s: %v", job.Key, job.Attempts, deadLetter.ID.Hex(), cause)
+
+	if err := w.queue.Ack(ctx, job); err != nil {
+		log.Printf("Failed to remove job %s from the queue: %v", job.ID, err)
+	}
+	return true
+}
+
+// autoCompleteTask completes the task if it is still eligible. It fails only
+// when the attempt should be retried.
+func (w *TaskWorker) autoCompleteTask(ctx context.Context, taskID primitive.ObjectID) error {
 	// Verify the task still exists and is in a valid state
 	task, err := w.taskRepo.FindByID(ctx, taskID)
 	if err != nil {
+		if err.Error() != "task not found" {
+			return err
+		}
 		log.Printf("Task %s not found or already deleted, skipping auto-completion", taskID.Hex())
-		return
+		return nil
 	}
 
-	// Only auto-complete if still in pending or in_progress status
-	if task.Status == models.TaskStatusPending || task.Status == models.TaskStatusInProgress {
-		// Check if task is old enough
-		threshold := time.Now().Add(-time.Duration(w.autoCompleteMinutes) * time.Minute)
-		if task.CreatedAt.Before(threshold) {
-			err := w.taskRepo.UpdateStatus(ctx, taskID, models.TaskStatusCompleted)
-			if err != nil {
-				log.Printf("Failed to auto-complete task %s: %v", taskID.Hex(), err)
-				return
+	verdict, err := w.evaluate(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate task: %w", err)
+	}
+	if !verdict.WouldComplete {
+		for _, check := range verdict.Checks {
+			if !check.Passed {
+				log.Printf("Skipping auto-completion of task %s: %s", taskID.Hex(), check.Detail)
+				break
 			}
-			log.Printf("Auto-completed task %s", taskID.Hex())
 		}
+		return nil
+	}
+
+	// The task is completed only as it was evaluated; a task changed meanwhile
+	// is left to a later scan, which evaluates it again
+	completed := *task
+	if err := w.taskRepo.UpdateStatus(ctx, &completed, models.TaskStatusCompleted); err != nil {
+		if err.Error() == "task has been modified" {
+			log.Printf("Task %s changed during auto-completion, skipping", taskID.Hex())
+			return nil
+		}
+		if err.Error() == "task not found" {
+			log.Printf("Task %s not found or already deleted, skipping auto-completion", taskID.Hex())
+			return nil
+		}
+		return err
+	}
+	log.Printf("Auto-completed task %s", taskID.Hex())
+
+	w.taskService.recordChanges(ctx, task, &completed, nil)
+	w.taskService.changed(ctx, task.UserID)
+	w.taskService.bus.Publish(ctx, events.TaskCompleted{Task: &completed, AutoCompleteMinutes: verdict.ThresholdMinutes})
+
+	if err := w.taskService.scheduleNextOccurrence(ctx, &completed); err != nil {
+		log.Printf("Failed to schedule next occurrence of task %s: %v", taskID.Hex(), err)
+	}
+	return nil
+}
+
+// Simulate runs the auto-complete decision for one task without changing it.
+func (w *TaskWorker) Simulate(ctx context.Context, taskID primitive.ObjectID) (*models.WorkerVerdict, error) {
+	task, err := w.taskRepo.FindByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return w.evaluate(ctx, task)
+}
+
+// evaluate is the single auto-complete decision: every check must pass.
+func (w *TaskWorker) evaluate(ctx context.Context, task *models.Task) (*models.WorkerVerdict, error) {
+	policy, err := w.policy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	threshold, enabled := policy.thresholdFor(task.UserID)
+	source := "the owner's setting"
+	if !enabled || policy.overrides[task.UserID].AutoCompleteMinutes == 0 {
+		threshold, source = policy.threshold, "AUTO_COMPLETE_MINUTES"
+	}
+
+	now := w.clock.Now()
+	age := now.Sub(task.CreatedAt)
+
+	verdict := &models.WorkerVerdict{
+		TaskID:           task.ID,
+		Status:           task.Status,
+		AgeMinutes:       int(age / time.Minute),
+		ThresholdMinutes: int(threshold / time.Minute),
+		EligibleAt:       task.CreatedAt.Add(threshold),
+		EvaluatedAt:      now,
+	}
+
+	open := task.Status == models.TaskStatusPending || task.Status == models.TaskStatusInProgress
+	verdict.AddCheck("status", open, fmt.Sprintf("status is %s; only pending and in_progress tasks are auto-completed", task.Status))
+	if task.Archived {
+		verdict.AddCheck("archived", false, "task is archived; archived tasks are never auto-completed")
+	} else {
+		verdict.AddCheck("archived", true, "task is not archived")
+	}
+	if enabled {
+		verdict.AddCheck("settings", true, "the owner allows auto-completion")
+	} else {
+		verdict.AddCheck("settings", false, "the owner turned auto-completion off in their settings")
+	}
+	verdict.AddCheck("age", age > threshold, fmt.Sprintf("created %d minutes ago; the threshold is %d minutes (%s)", verdict.AgeMinutes, verdict.ThresholdMinutes, source))
+
+	// Auto-completion follows the same subtask and dependency rules as manual updates
+	if open {
+		switch err := w.taskService.checkTransition(ctx, task, models.TaskStatusCompleted); {
+		case err == nil:
+			verdict.AddCheck("dependencies", true, "no open subtasks or blockers prevent completion")
+		case err.Error() == "task has open subtasks":
+			verdict.AddCheck("dependencies", false, "task has open subtasks (REQUIRE_SUBTASKS_COMPLETED is enabled)")
+		case err.Error() == "task is blocked by open tasks":
+			verdict.AddCheck("dependencies", false, "task is blocked by open tasks")
+		default:
+			return nil, err
+		}
+	}
+
+	verdict.WouldComplete = true
+	for _, check := range verdict.Checks {
+		verdict.WouldComplete = verdict.WouldComplete && check.Passed
 	}
+	return verdict, nil
 }
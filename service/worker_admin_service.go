@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"task-management-api/repository"
+)
+
+// WorkerAdminService lets operators see and control the auto-completion
+// worker of every instance
+type WorkerAdminService struct {
+	taskWorker     *TaskWorker
+	stateRepo      *repository.WorkerStateRepository
+	deadLetterRepo *repository.DeadLetterRepository
+	auditRepo      *repository.AuditRepository
+	clock          clock.Clock
+}
+
+func NewWorkerAdminService(taskWorker *TaskWorker, stateRepo *repository.WorkerStateRepository, deadLetterRepo *repository.DeadLetterRepository, auditRepo *repository.AuditRepository, clk clock.Clock) *WorkerAdminService {
+	return &WorkerAdminService{
+		taskWorker:     taskWorker,
+		stateRepo:      stateRepo,
+		deadLetterRepo: deadLetterRepo,
+		auditRepo:      auditRepo,
+		clock:          clk,
+	}
+}
+
+func (s *WorkerAdminService) Status(ctx context.Context) (*models.WorkerStatus, error) {
+	state, err := s.stateRepo.Get(ctx, AutoCompleteQueue)
+	if err != nil {
+		return nil, err
+	}
+	depth, err := s.taskWorker.queue.Depth(ctx, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	deadLetters, err := s.deadLetterRepo.Count(ctx, AutoCompleteQueue)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WorkerStatus{
+		Paused:       state.Paused,
+		PausedBy:     state.PausedBy,
+		PausedAt:     state.PausedAt,
+		LastScanAt:   state.LastScanAt,
+		Processed:    models.Int64(state.Processed),
+		Failed:       models.Int64(state.Failed),
+		DeadLettered: models.Int64(state.DeadLettered),
+		Queue: models.WorkerQueueDepth{
+			Ready:       models.Int64(depth.Ready),
+			Leased:      models.Int64(depth.Leased),
+			Waiting:     models.Int64(depth.Waiting),
+			DeadLetters: models.Int64(deadLetters),
+		},
+		AutoCompleteMinutes: s.taskWorker.autoCompleteMinutes,
+		Concurrency:         s.taskWorker.config.Concurrency,
+		ChangeStream:        s.taskWorker.watching.Load(),
+	}, nil
+}
+
+// SetPaused pauses or resumes auto-completion on every instance. Other
+// instances notice within workerIdleWait; jobs already running finish.
+func (s *WorkerAdminService) SetPaused(ctx context.Context, actor *models.User, paused bool) (*models.WorkerStatus, error) {
+	now := s.clock.Now()
+	if err := s.stateRepo.SetPaused(ctx, AutoCompleteQueue, paused, actor.ID, now); err != nil {
+		return nil, err
+	}
+	s.taskWorker.setPaused(paused)
+
+	action := "worker.resume"
+	if paused {
+		action = "worker.pause"
+	}
+	if err := s.auditRepo.Create(ctx, models.NewAuditLog(actor.ID, action, "worker", actor.ID, map[string]interface{}{"worker": AutoCompleteQueue}, now)); err != nil {
+		logf(ctx, "Failed to record audit log %s: %v", action, err)
+	}
+
+	return s.Status(ctx)
+}
+
+// Trigger scans for due tasks now instead of at the next run of the auto_complete job
+func (s *WorkerAdminService) Trigger(ctx context.Context) (*models.WorkerTriggerResponse, error) {
+	queued, err := s.taskWorker.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.WorkerTriggerResponse{Queued: queued}, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"task-management-api/models"
+	"time"
+)
+
+// Cell styles, indexes into cellXfs in xlsxStyles
+const (
+	xlsxStyleText = iota
+	xlsxStyleHeader
+	xlsxStyleDateTime
+	xlsxStylePending
+	xlsxStyleInProgress
+	xlsxStyleCompleted
+)
+
+// xlsxMaxCellChars is the most text Excel keeps in one cell
+const xlsxMaxCellChars = 32767
+
+// xlsxEpoch is day zero of Excel's date serial numbers, in UTC
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+var xlsxStatusStyles = map[models.TaskStatus]int{
+	models.TaskStatusPending:    xlsxStylePending,
+	models.TaskStatusInProgress: xlsxStyleInProgress,
+	models.TaskStatusCompleted:  xlsxStyleCompleted,
+}
+
+// xlsxColumn is one column of the export sheet
+type xlsxColumn struct {
+	header string
+	width  int
+	// cell writes the task's value for this column
+	cell func(task *models.Task) xlsxCell
+}
+
+// xlsxCell is a typed cell value; a nil time or empty text leaves the cell blank
+type xlsxCell struct {
+	text  string
+	time  *time.Time
+	style int
+}
+
+var xlsxColumns = []xlsxColumn{
+	{header: "Title", width: 40, cell: func(t *models.Task) xlsxCell { return xlsxCell{text: t.Title} }},
+	{header: "Status", width: 14, cell: func(t *models.Task) xlsxCell {
+		return xlsxCell{text: string(t.Status), style: xlsxStatusStyles[t.Status]}
+	}},
+	{header: "Priority", width: 10, cell: func(t *models.Task) xlsxCell { return xlsxCell{text: string(t.Priority)} }},
+	{header: "Due (UTC)", width: 18, cell: func(t *models.Task) xlsxCell { return xlsxCell{time: t.DueDate, style: xlsxStyleDateTime} }},
+	{header: "Recurrence", width: 16, cell: func(t *models.Task) xlsxCell { return xlsxCell{text: t.Recurrence} }},
+	{header: "Description", width: 60, cell: func(t *models.Task) xlsxCell { return xlsxCell{text: t.Description} }},
+	{header: "Created (UTC)", width: 18, cell: func(t *models.Task) xlsxCell { return xlsxCell{time: &t.CreatedAt, style: xlsxStyleDateTime} }},
+	{header: "Updated (UTC)", width: 18, cell: func(t *models.Task) xlsxCell { return xlsxCell{time: &t.UpdatedAt, style: xlsxStyleDateTime} }},
+	{header: "ID", width: 26, cell: func(t *models.Task) xlsxCell { return xlsxCell{text: t.ID.Hex()} }},
+}
+
+// renderXLSX writes tasks as a single-sheet workbook: a bold, frozen header row
+// with filters, dates as real date cells and status cells colored by status.
+func renderXLSX(tasks []*models.Task) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(tasks)},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func xlsxSheet(tasks []*models.Task) string {
+	lastColumn := xlsxColumnName(len(xlsxColumns) - 1)
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	b.WriteString(`<cols>`)
+	for i, column := range xlsxColumns {
+		fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, column.width)
+	}
+	b.WriteString(`</cols>`)
+
+	b.WriteString(`<sheetData>`)
+	b.WriteString(`<row r="1">`)
+	for i, column := range xlsxColumns {
+		writeXLSXCell(&b, xlsxColumnName(i)+"1", xlsxCell{text: column.header, style: xlsxStyleHeader})
+	}
+	b.WriteString(`</row>`)
+	for i, task := range tasks {
+		row := strconv.Itoa(i + 2)
+		fmt.Fprintf(&b, `<row r="%s">`, row)
+		for j, column := range xlsxColumns {
+			writeXLSXCell(&b, xlsxColumnName(j)+row, column.cell(task))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+
+	fmt.Fprintf(&b, `<autoFilter ref="A1:%s%d"/>`, lastColumn, len(tasks)+1)
+	b.WriteString(`</worksheet>`)
+	return b.String()
+}
+
+func writeXLSXCell(b *strings.Builder, ref string, cell xlsxCell) {
+	switch {
+	case cell.time != nil:
+		serial := cell.time.UTC().Sub(xlsxEpoch).Hours() / 24
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, cell.style, strconv.FormatFloat(serial, 'f', -1, 64))
+	case cell.text != "":
+		text := cell.text
+		if len(text) > xlsxMaxCellChars {
+			text = strings.ToValidUTF8(text[:xlsxMaxCellChars], "")
+		}
+		fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, cell.style)
+		xml.EscapeText(b, []byte(text))
+		b.WriteString(`</t></is></c>`)
+	case cell.style != xlsxStyleText:
+		fmt.Fprintf(b, `<c r="%s" s="%d"/>`, ref, cell.style)
+	}
+}
+
+// xlsxColumnName turns a zero-based column index into A, B, ... Z, AA, AB ...
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Tasks" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the cellXfs in the order of the xlsxStyle constants.
+// Status colors: pending amber, in progress blue, completed green.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="6">` +
+	`<fill><patternFill patternType="none"/></fill>` +
+	`<fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9D9D9"/></patternFill></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFFFF2CC"/></patternFill></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFDDEBF7"/></patternFill></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFE2EFDA"/></patternFill></fill>` +
+	`</fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="6">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="3" borderId="0" xfId="0" applyFill="1"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="4" borderId="0" xfId="0" applyFill="1"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="5" borderId="0" xfId="0" applyFill="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
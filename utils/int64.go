@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"task-management-api/models"
+)
+
+var int64Type = reflect.TypeOf(models.Int64(0))
+
+// marshalInt64AsString encodes data as JSON with its models.Int64 values as
+// decimal strings. Data is encoded as usual and the encoding copied token by
+// token, next to data itself, quoting the numbers that came from an Int64.
+// Members keep their order.
+func marshalInt64AsString(data interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var out bytes.Buffer
+	if err := copyQuotingInt64(decoder, &out, reflect.ValueOf(data)); err != nil {
+		return nil, fmt.Errorf("failed to read response JSON: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// copyQuotingInt64 copies the next JSON value from decoder to out. v is the Go
+// value it was encoded from, or invalid when that is not known.
+func copyQuotingInt64(decoder *json.Decoder, out *bytes.Buffer, v reflect.Value) error {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			v = reflect.Value{}
+			break
+		}
+		v = v.Elem()
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		switch token {
+		case '{':
+			members := jsonMembers(v)
+			out.WriteByte('{')
+			for i := 0; decoder.More(); i++ {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				writeJSON(out, key)
+				out.WriteByte(':')
+				if err := copyQuotingInt64(decoder, out, members[key.(string)]); err != nil {
+					return err
+				}
+			}
+			out.WriteByte('}')
+		case '[':
+			out.WriteByte('[')
+			for i := 0; decoder.More(); i++ {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				var item reflect.Value
+				if v.IsValid() && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && i < v.Len() {
+					item = v.Index(i)
+				}
+				if err := copyQuotingInt64(decoder, out, item); err != nil {
+					return err
+				}
+			}
+			out.WriteByte(']')
+		}
+		// The closing delimiter
+		_, err := decoder.Token()
+		return err
+	case json.Number:
+		if v.IsValid() && v.Type() == int64Type {
+			writeJSON(out, string(token))
+			return nil
+		}
+		out.WriteString(string(token))
+	default:
+		writeJSON(out, token)
+	}
+	return nil
+}
+
+// jsonMembers maps the member names of v's JSON object to the values they
+// were encoded from: a struct's fields, by the rules of encoding/json, or a
+// map's entries. Members it cannot tell are left out.
+func jsonMembers(v reflect.Value) map[string]reflect.Value {
+	members := make(map[string]reflect.Value)
+	if !v.IsValid() {
+		return members
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		addStructMembers(members, v)
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			if !key.CanInterface() {
+				continue
+			}
+			if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+				if text, err := marshaler.MarshalText(); err == nil {
+					members[string(text)] = iter.Value()
+				}
+				continue
+			}
+			members[fmt.Sprint(key.Interface())] = iter.Value()
+		}
+	}
+	return members
+}
+
+// addStructMembers adds the fields of struct v. Fields of embedded structs are
+// promoted unless a field of v has the same name.
+func addStructMembers(members map[string]reflect.Value, v reflect.Value) {
+	t := v.Type()
+	var embedded []reflect.Value
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			for value.Kind() == reflect.Ptr && !value.IsNil() {
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				embedded = append(embedded, value)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		members[name] = value
+	}
+
+	for _, value := range embedded {
+		promoted := make(map[string]reflect.Value)
+		addStructMembers(promoted, value)
+		for name, member := range promoted {
+			if _, ok := members[name]; !ok {
+				members[name] = member
+			}
+		}
+	}
+}
+
+func writeJSON(out *bytes.Buffer, value interface{}) {
+	encoded, _ := json.Marshal(value)
+	out.Write(encoded)
+}
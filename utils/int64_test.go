@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"task-management-api/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type counters struct {
+	Total   models.Int64  `json:"total"`
+	Page    int           `json:"page"`
+	Skipped *models.Int64 `json:"skipped"`
+}
+
+type embeddingCounters struct {
+	counters
+	Page   models.Int64            `json:"page"`
+	ByUser map[string]models.Int64 `json:"by_user"`
+	Hidden models.Int64            `json:"-"`
+	Nested []counters              `json:"nested,omitempty"`
+}
+
+func TestMarshalInt64AsString(t *testing.T) {
+	skipped := models.Int64(3)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	task := models.NewTask(primitive.NilObjectID, "task", "", models.TaskStatusPending, now)
+	task.ID = primitive.NilObjectID
+
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{
+			name: "struct fields keep their order",
+			data: counters{Total: 42, Page: 2, Skipped: &skipped},
+			want: `{"total":"42","page":2,"skipped":"3"}`,
+		},
+		{
+			name: "nil pointer",
+			data: counters{Total: 1},
+			want: `{"total":"1","page":0,"skipped":null}`,
+		},
+		{
+			name: "embedded fields, shadowing, maps and slices",
+			data: embeddingCounters{
+				counters: counters{Total: 5, Page: 1},
+				Page:     9,
+				ByUser:   map[string]models.Int64{"a": 1},
+				Hidden:   7,
+				Nested:   []counters{{Total: 6}},
+			},
+			want: `{"total":"5","skipped":null,"page":"9","by_user":{"a":"1"},"nested":[{"total":"6","page":0,"skipped":null}]}`,
+		},
+		{
+			name: "models with their own MarshalJSON",
+			data: models.NewTaskListResponse([]*models.Task{task}, 1, 10, 1),
+			want: `{"tasks":[{"id":"000000000000000000000000","user_id":"000000000000000000000000","parent_id":null,"title":"task","status":"pending","archived":false,"private":false,"due_date":null,"purge_at":null,"created_at":"2026-01-02T03:04:05Z","updated_at":"2026-01-02T03:04:05Z"}],"page":1,"limit":10,"total_count":"1","total_pages":1}`,
+		},
+		{
+			name: "untyped values stay numbers",
+			data: map[string]interface{}{"count": int64(4), "html": "<b>"},
+			want: `{"count":4,"html":"\u003cb\u003e"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshalInt64AsString(tt.data)
+			if err != nil {
+				t.Fatalf("marshalInt64AsString: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateInt64(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		fallback    bool
+		wantStrings bool
+	}{
+		{name: "default numbers", accept: ""},
+		{name: "default strings", accept: "*/*", fallback: true, wantStrings: true},
+		{name: "asked for strings", accept: "application/json; int64=string", wantStrings: true},
+		{name: "asked for numbers", accept: "application/json;int64=number", fallback: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext bool
+			handler := Negotiate(tt.fallback)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = Int64AsString(r.Context())
+				RespondJSON(w, http.StatusOK, counters{Total: 42})
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			want := `{"total":42,"page":0,"skipped":null}` + "\n"
+			if tt.wantStrings {
+				want = `{"total":"42","page":0,"skipped":null}` + "\n"
+			}
+			if got := recorder.Body.String(); got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+			if fromContext != tt.wantStrings {
+				t.Errorf("Int64AsString = %v, want %v", fromContext, tt.wantStrings)
+			}
+		})
+	}
+}
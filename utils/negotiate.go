@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Encoder writes response bodies in a format other than JSON. RespondJSON
+// uses one when the request's Accept header prefers it.
+type Encoder interface {
+	// ContentType is sent as the response's Content-Type
+	ContentType() string
+	Encode(w io.Writer, data interface{}) error
+}
+
+// encoders maps media types to their encoders; JSON is built in
+var encoders = map[string]Encoder{}
+
+// RegisterEncoder makes RespondJSON answer in the encoder's format to
+// requests that accept one of mediaTypes. Encoders are registered at startup,
+// before the server handles requests.
+func RegisterEncoder(encoder Encoder, mediaTypes ...string) {
+	for _, mediaType := range mediaTypes {
+		encoders[mediaType] = encoder
+	}
+}
+
+// responseFormat is what Negotiate chose for a request's responses
+type responseFormat struct {
+	// encoder is nil for JSON
+	encoder Encoder
+	// int64AsString renders models.Int64 counters as JSON strings
+	int64AsString bool
+}
+
+type contextKey string
+
+const formatContextKey contextKey = "response_format"
+
+// negotiatedWriter carries the request's format to RespondJSON, which is
+// given the writer rather than the request
+type negotiatedWriter struct {
+	http.ResponseWriter
+	format *responseFormat
+}
+
+func (w *negotiatedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Negotiate chooses the format of the request's RespondJSON responses from its
+// Accept header. JSON stays the default: it is chosen whenever it is accepted
+// as much as any other format, and when nothing the header names is available.
+//
+// JSON responses render 64-bit counters as numbers, or as decimal strings when
+// int64AsString is set. JavaScript reads every JSON number as a float64 and
+// loses precision past 2^53, so a client can ask for strings, or numbers, with
+// the int64 parameter of application/json: "Accept: application/json; int64=string".
+// The choice is kept in the request context for handlers that describe the
+// response, see Int64AsString.
+func Negotiate(int64AsString bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+			format := &responseFormat{
+				encoder:       negotiateEncoder(r.Header.Get("Accept")),
+				int64AsString: negotiateInt64(r.Header.Get("Accept"), int64AsString),
+			}
+			r = r.WithContext(context.WithValue(r.Context(), formatContextKey, format))
+			next.ServeHTTP(&negotiatedWriter{ResponseWriter: w, format: format}, r)
+		})
+	}
+}
+
+// Int64AsString reports whether the request's JSON responses render 64-bit
+// counters as strings
+func Int64AsString(ctx context.Context) bool {
+	format, ok := ctx.Value(formatContextKey).(*responseFormat)
+	return ok && format.int64AsString
+}
+
+// negotiateInt64 reads the int64 parameter of application/json in the Accept
+// header, string or number, falling back to the default when none is given
+func negotiateInt64(accept string, fallback bool) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != "application/json" {
+			continue
+		}
+		switch params["int64"] {
+		case "string":
+			return true
+		case "number":
+			return false
+		}
+	}
+	return fallback
+}
+
+// negotiateEncoder returns the registered encoder the Accept header prefers
+// over JSON, or nil for JSON. Of equally preferred types, one named exactly
+// beats a wildcard.
+func negotiateEncoder(accept string) Encoder {
+	var best Encoder
+	bestQ, bestExact := -1.0, false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		var candidate Encoder
+		exact := true
+		switch mediaType {
+		case "application/json":
+		case "*/*", "application/*":
+			exact = false
+		default:
+			var ok bool
+			if candidate, ok = encoders[mediaType]; !ok {
+				continue
+			}
+		}
+		// Ranked by q, then exactness; JSON (a nil candidate) wins full ties
+		if q > bestQ || q == bestQ && exact && !bestExact || q == bestQ && exact == bestExact && candidate == nil {
+			best, bestQ, bestExact = candidate, q, exact
+		}
+	}
+	return best
+}
+
+// negotiatedFormat finds the format Negotiate chose, looking through the
+// writers middleware wrapped around it; nil when Negotiate did not run
+func negotiatedFormat(w http.ResponseWriter) *responseFormat {
+	for {
+		switch writer := w.(type) {
+		case *negotiatedWriter:
+			return writer.format
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = writer.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
@@ -3,11 +3,37 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+	"task-management-api/clock"
 	"task-management-api/models"
 )
 
+// RequestIDHeader carries the request's ID on every response. A valid ID sent
+// by the client or a proxy is kept, so it matches their logs.
+const RequestIDHeader = "X-Request-ID"
+
+// RespondJSON writes data as JSON, or in the format Negotiate chose for the request
 func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
+	format := negotiatedFormat(w)
+	if format != nil && format.encoder != nil {
+		w.Header().Set("Content-Type", format.encoder.ContentType())
+		w.WriteHeader(status)
+		if err := format.encoder.Encode(w, data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	if format != nil && format.int64AsString {
+		encoded, err := marshalInt64AsString(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write(append(encoded, '\n'))
+		return
+	}
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -15,8 +41,57 @@ func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
 }
 
 func RespondError(w http.ResponseWriter, status int, message string) {
-	RespondJSON(w, status, models.ErrorResponse{
+	RespondErrorDetails(w, status, message, nil)
+}
+
+// RespondErrorDetails is RespondError with machine-readable details, such as the
+// fields that made a request fail
+func RespondErrorDetails(w http.ResponseWriter, status int, message string, details map[string]interface{}) {
+	response := models.ErrorResponse{
 		Error:   http.StatusText(status),
+		Code:    models.ErrorCode(status, message),
 		Message: message,
-	})
+		Details: details,
+	}
+	if status >= http.StatusInternalServerError {
+		response.RequestID = w.Header().Get(RequestIDHeader)
+		response.Timestamp = models.FormatTime(responseClock(w).Now())
+	}
+	RespondJSON(w, status, response)
+}
+
+// clockWriter carries the server's clock to RespondErrorDetails, which is
+// given the writer rather than the request
+type clockWriter struct {
+	http.ResponseWriter
+	clock clock.Clock
+}
+
+func (w *clockWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithClock makes error responses take their timestamps from clk, so they
+// agree with every other timestamp when the sandbox moves time
+func WithClock(clk clock.Clock) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&clockWriter{ResponseWriter: w, clock: clk}, r)
+		})
+	}
+}
+
+// responseClock finds the clock WithClock set, looking through the writers
+// middleware wrapped around it; the wall clock when WithClock did not run
+func responseClock(w http.ResponseWriter) clock.Clock {
+	for {
+		switch writer := w.(type) {
+		case *clockWriter:
+			return writer.clock
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = writer.Unwrap()
+		default:
+			return clock.New()
+		}
+	}
 }
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"task-management-api/clock"
+	"task-management-api/models"
+	"testing"
+	"time"
+)
+
+func TestRespondErrorTimestampUsesClock(t *testing.T) {
+	clk := clock.NewControllable()
+	clk.Freeze(time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600)))
+
+	// Negotiate wraps the writer again, as it does in the server
+	handler := WithClock(clk)(Negotiate(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RespondError(w, http.StatusInternalServerError, "failed to load tasks")
+	})))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+
+	var response models.ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if want := "2026-01-02T02:04:05Z"; response.Timestamp != want {
+		t.Errorf("timestamp = %q, want %q", response.Timestamp, want)
+	}
+}
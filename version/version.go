@@ -0,0 +1,46 @@
+package version
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// Set at build time, e.g.
+// go build -ldflags "-X task-management-api/version.Version=1.4.0 -X task-management-api/version.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// HeaderName carries the version on every response
+const HeaderName = "X-App-Version"
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// String is the short form used in the startup banner and response header
+func String() string {
+	return Version + " (" + Commit + ")"
+}
+
+// Middleware adds the version header to every response, including errors and unmatched routes.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderName, String())
+		next.ServeHTTP(w, r)
+	})
+}